@@ -19,3 +19,360 @@
   run bin/kubeconform -schema-location 'fixtures/{{ .ResourceKind }}.json' -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' fixtures/test_crd.yaml
   [ "$status" -eq 0 ]
 }
+
+@test "Pass when parsing a Custom Resource and using its CustomResourceDefinition as schema location" {
+  run bin/kubeconform -schema-location 'crd+fixtures/registry/sagemaker.aws.amazon.com_trainingjobs.yaml' fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+}
+
+@test "Pass when checking the syntax of a valid file without network access" {
+  run bin/kubeconform -syntax-only -summary fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found in 1 file - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Fail when checking the syntax of a resource missing its kind" {
+  run bin/kubeconform -syntax-only fixtures/missing_kind.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Pass when printing a status line to stderr" {
+  run bash -c "bin/kubeconform -syntax-only -status-line fixtures/folder 2>&1 >/dev/null"
+  [ "$status" -eq 0 ]
+  [ "$output" = "valid=7 invalid=0 error=0 skipped=0" ]
+}
+
+@test "Pass when skipping files that do not exist with -skip-unreadable" {
+  run bin/kubeconform -syntax-only -skip-unreadable -summary fixtures/valid.yaml fixtures/does-not-exist.yaml
+  [ "$status" -eq 0 ]
+  [ "${lines[1]}" = "Summary: 2 resources found in 2 files - Valid: 1, Invalid: 0, Errors: 0, Skipped: 1" ]
+}
+
+@test "Fail when a file does not exist without -skip-unreadable" {
+  run bin/kubeconform -syntax-only fixtures/valid.yaml fixtures/does-not-exist.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Pass when parsing a multi-document UTF-16 file" {
+  run bin/kubeconform -syntax-only -summary fixtures/multi_valid_utf16.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 6 resources found in 1 file - Valid: 6, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Fail when a file exceeds the maximum file size, but validate the other files" {
+  run bin/kubeconform -syntax-only -summary -max-file-size 1000 fixtures/valid_large.yaml fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+  [ "${lines[1]}" = "Summary: 2 resources found in 2 files - Valid: 1, Invalid: 0, Errors: 1, Skipped: 0" ]
+}
+
+@test "Pass when validating a single document of a file by index" {
+  run bin/kubeconform -syntax-only -summary -only-doc-index 1 fixtures/multi_valid.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 6 resources found in 1 file - Valid: 3, Invalid: 0, Errors: 0, Skipped: 3" ]
+}
+
+@test "Pass results to the -exec-on-complete command" {
+  run bash -c "bin/kubeconform -syntax-only -exec-on-complete 'echo \$KUBECONFORM_VALID \$KUBECONFORM_ERRORS \$KUBECONFORM_SUCCESS' fixtures/multi_valid.yaml 2>&1"
+  [ "$status" -eq 0 ]
+  [ "$output" = "6 0 true" ]
+}
+
+@test "Fail when the -exec-on-complete command fails with -fail-on-exec-error" {
+  run bin/kubeconform -syntax-only -fail-on-exec-error -exec-on-complete 'exit 3' fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Write the schemas used to -schema-provenance-file" {
+  run bin/kubeconform -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' -schema-provenance-file "$BATS_TMPDIR/provenance.json" fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  run grep -c '"sha256": "f6ee7fd4e9a2f3c807b083026dd04ec49f54143bb2e2d8d10fa4d0402dd8680b"' "$BATS_TMPDIR/provenance.json"
+  [ "$output" = "1" ]
+}
+
+@test "Fail when a field selected with -only-fields does not match its schema" {
+  echo '{"type": "array", "items": {"type": "object", "required": ["resources"]}}' > "$BATS_TMPDIR/containers.json"
+  run bin/kubeconform -only-fields "ReplicationController:spec.template.spec.containers=$BATS_TMPDIR/containers.json" fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "fixtures/valid.yaml - ReplicationController bob is invalid: For field spec.template.spec.containers.0: resources is required" ]
+}
+
+@test "Fail when a field selected with -only-fields is missing with -fail-on-missing-fields" {
+  echo '{"type": "object"}' > "$BATS_TMPDIR/object.json"
+  run bin/kubeconform -only-fields "ReplicationController:spec.foo=$BATS_TMPDIR/object.json" fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  run bin/kubeconform -only-fields "ReplicationController:spec.foo=$BATS_TMPDIR/object.json" -fail-on-missing-fields fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Format results with -output-template" {
+  run bin/kubeconform -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' -verbose -output-template '{{ .Status }}: {{ .File }} {{ .Kind }}/{{ .Name }}' fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "valid: fixtures/test_crd.yaml TrainingJob/xgboost-mnist-debugger" ]
+}
+
+@test "Skip resources whose schema can not be downloaded with -tolerate-download-errors" {
+  run bin/kubeconform -schema-location 'http://127.0.0.1:1/{{ .ResourceKind }}.json' fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+  run bin/kubeconform -tolerate-download-errors -schema-location 'http://127.0.0.1:1/{{ .ResourceKind }}.json' -summary fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  [[ "$output" == *"Skipped: 1"* ]]
+}
+
+@test "Pass when using a glob pattern as schema location" {
+  run bin/kubeconform -schema-location './fixtures/**' -summary fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found in 1 file - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Fail when a resource is skipped with -fail-on-skipped" {
+  run bin/kubeconform -skip ReplicationController fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  run bin/kubeconform -fail-on-skipped -skip ReplicationController fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Use the exit codes set with -exit-codes" {
+  run bin/kubeconform -exit-codes invalid=2,error=3 -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  run bin/kubeconform -exit-codes invalid=2,error=3 -schema-location 'fixtures/registry/*.json' fixtures/valid.yaml
+  [ "$status" -eq 3 ]
+  run bin/kubeconform -exit-codes skipped=4 -skip TrainingJob fixtures/test_crd.yaml
+  [ "$status" -eq 4 ]
+}
+
+@test "Skip resources of aggregated APIs without a schema, unless -strict-aggregated is set" {
+  run bin/kubeconform -verbose -schema-location 'fixtures/registry/*.json' fixtures/podmetrics.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "fixtures/podmetrics.yaml - foo PodMetrics skipped: no schema found for metrics.k8s.io/v1beta1, served by an aggregated API server" ]
+  run bin/kubeconform -strict-aggregated -schema-location 'fixtures/registry/*.json' fixtures/podmetrics.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Fail when the same resource is declared twice in a file" {
+  run bin/kubeconform -ignore-missing-schemas -schema-location 'fixtures/registry/*.json' fixtures/duplicates.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "fixtures/duplicates.yaml - failed validation: duplicate resource v1 ReplicationController bob in documents 0 and 1" ]
+  run bin/kubeconform -ignore-missing-schemas -schema-location 'fixtures/registry/*.json' fixtures/same-object-different-namespace.yaml
+  [ "$status" -eq 0 ]
+}
+
+@test "Write failures to -output-file and the summary to stdout" {
+  run bin/kubeconform -output csv -output-file "$BATS_TMPDIR/failures.csv" -summary -schema-location 'fixtures/registry/*.json' fixtures/valid.yaml fixtures/test_crd.yaml
+  [ "$status" -eq 1 ]
+  [ "${lines[0]}" = "Summary: 2 resources found in 2 files - Valid: 1, Invalid: 0, Errors: 1, Skipped: 0" ]
+  run cat "$BATS_TMPDIR/failures.csv"
+  [ "${lines[0]}" = "file,kind,name,namespace,status,message" ]
+  [ "${lines[1]}" = "fixtures/valid.yaml,ReplicationController,bob,,error,could not find schema for ReplicationController" ]
+  [ "${#lines[@]}" -eq 2 ]
+}
+
+@test "Write the kinds without schema to -unknown-kinds-file" {
+  run bin/kubeconform -ignore-missing-schemas -schema-location 'fixtures/registry/*.json' -unknown-kinds-file "$BATS_TMPDIR/unknown.json" fixtures/valid.yaml fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  run cat "$BATS_TMPDIR/unknown.json"
+  [ "${lines[1]}" = '    "apiVersion": "v1",' ]
+  [ "${lines[2]}" = '    "kind": "ReplicationController"' ]
+}
+
+@test "Pass when using schema locations from -schema-catalog" {
+  run bin/kubeconform -summary -schema-catalog fixtures/schema-catalog.yaml fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found in 1 file - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Fail when the -schema-catalog can not be read" {
+  run bin/kubeconform -schema-catalog fixtures/missing-catalog.yaml fixtures/test_crd.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Fail when a schema does not match its checksum in -schema-lock" {
+  run bin/kubeconform -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' -schema-lock "$BATS_TMPDIR/schemas.lock" -update-schema-lock fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  run bin/kubeconform -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' -schema-lock "$BATS_TMPDIR/schemas.lock" fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  echo '{"sagemaker.aws.amazon.com/v1/TrainingJob": "0000"}' > "$BATS_TMPDIR/schemas.lock"
+  run bin/kubeconform -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' -schema-lock "$BATS_TMPDIR/schemas.lock" fixtures/test_crd.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Pass when a file sets its schema location with a directive" {
+  run bin/kubeconform -summary -schema-location '/nonexistent/{{ .ResourceKind }}.json' fixtures/schema_location_directive.yaml fixtures/test_crd.yaml
+  [ "$status" -eq 1 ]
+  [ "${lines[0]}" = "fixtures/test_crd.yaml - TrainingJob xgboost-mnist-debugger failed validation: could not find schema for TrainingJob" ]
+  [ "${lines[1]}" = "Summary: 2 resources found in 2 files - Valid: 1, Invalid: 0, Errors: 1, Skipped: 0" ]
+}
+
+@test "Use the schema locations and kinds to skip of a -profile" {
+  run bin/kubeconform -summary -profiles-file fixtures/profiles.yaml -profile sagemaker fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found in 1 file - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0" ]
+  run bin/kubeconform -summary -profiles-file fixtures/profiles.yaml -profile core fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found in 1 file - Valid: 0, Invalid: 0, Errors: 0, Skipped: 1" ]
+}
+
+@test "Fail with an unknown -profile" {
+  run bin/kubeconform -profiles-file fixtures/profiles.yaml -profile production fixtures/test_crd.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "no profile production in fixtures/profiles.yaml, available profiles: core, sagemaker" ]
+}
+
+@test "Fail resources using a prerelease apiVersion with -no-prerelease-apis" {
+  run bin/kubeconform -syntax-only -no-prerelease-apis fixtures/podmetrics.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "fixtures/podmetrics.yaml - PodMetrics foo failed validation: prohibited prerelease apiVersion metrics.k8s.io/v1beta1" ]
+  run bin/kubeconform -syntax-only -no-prerelease-apis -allow-prerelease-apis metrics.k8s.io/v1beta1/PodMetrics fixtures/podmetrics.yaml
+  [ "$status" -eq 0 ]
+}
+
+@test "Validate the files referenced by a kustomization with -kustomization" {
+  run bin/kubeconform -kustomization -syntax-only -summary fixtures/kustomize/overlay
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 3 resources found in 3 files - Valid: 3, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Fail on cyclic kustomization references with -kustomization" {
+  run bin/kubeconform -kustomization fixtures/kustomize/cyclic/a
+  [ "$status" -eq 1 ]
+  [ "$output" = "cyclic kustomization reference: fixtures/kustomize/cyclic/a/kustomization.yaml -> fixtures/kustomize/cyclic/b/kustomization.yaml -> fixtures/kustomize/cyclic/a/kustomization.yaml" ]
+}
+
+@test "Validate the manifests applied by Flux Kustomizations with -flux" {
+  run bin/kubeconform -flux -syntax-only -summary -log-level warning fixtures/flux/clusters/prod
+  [ "$status" -eq 0 ]
+  [ "${lines[1]}" = "Summary: 5 resources found in 4 files - Valid: 5, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Only report resources that started or stopped failing with -dry-run-diff" {
+  bin/kubeconform -syntax-only -output json fixtures/missing_kind.yaml > "$BATS_TMPDIR/previous.json" || true
+  run bin/kubeconform -syntax-only -dry-run-diff "$BATS_TMPDIR/previous.json" fixtures/missing_kind.yaml fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Diff: 0 newly failing, 0 fixed, 1 still failing" ]
+  run bin/kubeconform -syntax-only -dry-run-diff "$BATS_TMPDIR/previous.json" fixtures/missing_apiversion.yaml
+  [ "$status" -eq 1 ]
+  [ "${lines[0]}" = "newly failing: fixtures/missing_apiversion.yaml - ReplicationController bob: error while parsing: missing 'apiVersion' key" ]
+  [ "${lines[1]}" = "fixed: fixtures/missing_kind.yaml" ]
+}
+
+@test "Substitute environment variables with -envsubst" {
+  NAME=bob run bin/kubeconform -syntax-only -verbose -envsubst fixtures/envsubst.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "fixtures/envsubst.yaml - ReplicationController bob is valid" ]
+  run bin/kubeconform -syntax-only -envsubst -envsubst-undefined error fixtures/envsubst.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "fixtures/envsubst.yaml - failed validation: variable NAME is not set, line 4" ]
+}
+
+@test "Print the number of files, resources and kinds found with -preflight" {
+  run bin/kubeconform -preflight -syntax-only -summary fixtures/multi_valid.yaml fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  [ "${lines[0]}" = "found 2 files, 7 resources across 2 distinct kinds" ]
+  [ "${lines[1]}" = "Summary: 7 resources found in 2 files - Valid: 7, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Add missing labels to files with -fix" {
+  cp fixtures/valid.yaml "$BATS_TMPDIR/fix.yaml"
+  run bin/kubeconform -syntax-only -require-labels app.kubernetes.io/managed-by "$BATS_TMPDIR/fix.yaml"
+  [ "$status" -eq 1 ]
+  run bin/kubeconform -syntax-only -require-labels app.kubernetes.io/managed-by -fix -fix-labels app.kubernetes.io/managed-by=platform "$BATS_TMPDIR/fix.yaml"
+  [ "$status" -eq 0 ]
+  [ "$output" = "$BATS_TMPDIR/fix.yaml - ReplicationController bob: added labels app.kubernetes.io/managed-by" ]
+  run bin/kubeconform -syntax-only -require-labels app.kubernetes.io/managed-by "$BATS_TMPDIR/fix.yaml"
+  [ "$status" -eq 0 ]
+}
+
+@test "Fail resources using an apiVersion not allowed with -allowed-apiversions" {
+  run bin/kubeconform -syntax-only -allowed-apiversions PodMetrics=metrics.k8s.io/v1 fixtures/podmetrics.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "fixtures/podmetrics.yaml - PodMetrics foo failed validation: apiVersion metrics.k8s.io/v1beta1 is not allowed for PodMetrics, allowed: metrics.k8s.io/v1" ]
+  run bin/kubeconform -syntax-only -allowed-apiversions PodMetrics=metrics.k8s.io/v1beta1 fixtures/podmetrics.yaml
+  [ "$status" -eq 0 ]
+}
+
+@test "Validate the documents of a file after documents that fail to parse" {
+  run bin/kubeconform -syntax-only -summary fixtures/multi_parse_errors.yaml
+  [ "$status" -eq 1 ]
+  [[ "$output" == *"fixtures/multi_parse_errors.yaml - document 2 failed validation: error unmarshalling resource"* ]]
+  [[ "$output" == *"fixtures/multi_parse_errors.yaml - document 3 failed validation: error while parsing: failed reading List items"* ]]
+  [[ "$output" == *"fixtures/multi_parse_errors.yaml - document 4 failed validation: error unmarshalling resource"* ]]
+  [[ "$output" == *"Summary: 6 resources found in 1 file - Valid: 2, Invalid: 0, Errors: 4, Skipped: 0"* ]]
+}
+
+@test "Skip resources of kinds not given with -only-kinds" {
+  run bin/kubeconform -syntax-only -summary -only-kinds ReplicationController fixtures/multi_valid.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 6 resources found in 1 file - Valid: 3, Invalid: 0, Errors: 0, Skipped: 3" ]
+}
+
+@test "Fail resources referencing missing resources with -cross-resource-rules" {
+  run bin/kubeconform -syntax-only -cross-resource-rules service-selector,ingress-backend,hpa-target fixtures/cross_resource.yaml
+  [ "$status" -eq 1 ]
+  [ "${lines[0]}" = "fixtures/cross_resource.yaml - Service api failed validation: selector app=api matches the pods of no workload among the resources validated" ]
+  [ "${lines[1]}" = "fixtures/cross_resource.yaml - HorizontalPodAutoscaler api failed validation: scaleTargetRef Deployment api not found among the resources validated" ]
+  run bin/kubeconform -syntax-only -cross-resource-rules service-selector fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+}
+
+@test "Fail PodDisruptionBudgets selecting no pods with -cross-resource-rules pdb-selector" {
+  run bin/kubeconform -syntax-only -cross-resource-rules pdb-selector fixtures/cross_resource.yaml
+  [ "$status" -eq 1 ]
+  [ "$output" = "fixtures/cross_resource.yaml - PodDisruptionBudget api failed validation: selector app=api matches the pods of no workload among the resources validated" ]
+}
+
+@test "Print a character per resource with -output dots" {
+  run bin/kubeconform -syntax-only -output dots fixtures/valid.yaml fixtures/multi_valid.yaml fixtures/missing_apiversion.yaml
+  [ "$status" -eq 1 ]
+  [ "${lines[0]}" = ".......F" ]
+  [ "${lines[1]}" = "fixtures/missing_apiversion.yaml - ReplicationController bob failed validation: error while parsing: missing 'apiVersion' key" ]
+  [ "${lines[2]}" = "Summary: 8 resources found in 3 files - Valid: 7, Invalid: 0, Errors: 1, Skipped: 0" ]
+}
+
+@test "Skip Lists with -list-mode skip" {
+  run bin/kubeconform -syntax-only -summary -list-mode skip fixtures/list_valid.yaml
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found in 1 file - Valid: 0, Invalid: 0, Errors: 0, Skipped: 1" ]
+}
+
+@test "Log in JSON with -log-format json" {
+  run bin/kubeconform -log-format json -log-level debug -schema-location './fixtures/registry/{{ .ResourceKind }}{{ .KindSuffix }}.json' fixtures/test_crd.yaml
+  [ "$status" -eq 0 ]
+  [[ "${lines[0]}" == '{"time":"'*'","level":"debug","msg":"fixtures/test_crd.yaml - document 0: found TrainingJob xgboost-mnist-debugger, apiVersion sagemaker.aws.amazon.com/v1"}' ]]
+}
+
+@test "Read custom short names as their kind with -kind-shortnames" {
+  run bash -c "printf 'apiVersion: argoproj.io/v1alpha1\nkind: wf\nmetadata:\n  name: hello\n' | bin/kubeconform -verbose -kind-shortnames wf=Workflow -skip Workflow"
+  [ "$status" -eq 0 ]
+  [ "$output" = "stdin - hello Workflow skipped" ]
+}
+
+@test "Validate short names of kinds against the schema of the kind" {
+  run bash -c "sed 's/^kind: ReplicationController/kind: rc/' fixtures/valid.yaml | bin/kubeconform -verbose -cache fixtures/cache/"
+  [ "$status" -eq 0 ]
+  [[ "$output" == "stdin - ReplicationController bob is valid (Kubernetes master, download "*", validation "*")" ]]
+}
+
+@test "Write the results of each file with -results-dir" {
+  run bin/kubeconform -syntax-only -results-dir "$BATS_TEST_TMPDIR/results" fixtures/valid.yaml
+  [ "$status" -eq 0 ]
+  run grep -c '"status": "statusValid"' "$BATS_TEST_TMPDIR/results/fixtures/valid.yaml.json"
+  [ "$output" = "1" ]
+}
+
+@test "Print the reason for a non-zero exit code with -summary" {
+  run bin/kubeconform -syntax-only -summary -exit-codes error=4 fixtures/valid.yaml fixtures/missing_apiversion.yaml
+  [ "$status" -eq 4 ]
+  [ "${lines[2]}" = "Exit code 4: 1 error" ]
+}
+
+@test "Pass when enough resources are valid with -pass-threshold" {
+  run bin/kubeconform -syntax-only -pass-threshold 0.5 fixtures/valid.yaml fixtures/missing_apiversion.yaml
+  [ "$status" -eq 0 ]
+  run bin/kubeconform -syntax-only -pass-threshold 0.6 fixtures/valid.yaml fixtures/missing_apiversion.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Read gzip-compressed manifests with -decompress" {
+  run bash -c "gzip -c fixtures/valid.yaml | bin/kubeconform -syntax-only -summary -decompress"
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found parsing stdin - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
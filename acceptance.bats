@@ -26,7 +26,7 @@ resetCacheFolder() {
 @test "Pass when parsing a valid Kubernetes config file with int_to_string vars" {
   run bin/kubeconform -verbose fixtures/int_or_string.yaml
   [ "$status" -eq 0 ]
-  [ "$output" = "fixtures/int_or_string.yaml - Service heapster is valid" ]
+  [[ "$output" == "fixtures/int_or_string.yaml - Service heapster is valid (Kubernetes master, download "*", validation "*")" ]]
 }
 
 @test "Pass when parsing a valid Kubernetes config JSON file" {
@@ -38,19 +38,19 @@ resetCacheFolder() {
 @test "Pass when parsing a valid Kubernetes config YAML file with generate name" {
   run bin/kubeconform -verbose fixtures/generate_name.yaml
   [ "$status" -eq 0 ]
-  [ "$output" = "fixtures/generate_name.yaml - Job pi-{{ generateName }} is valid" ]
+  [[ "$output" == "fixtures/generate_name.yaml - Job pi-{{ generateName }} is valid (Kubernetes master, download "*", validation "*")" ]]
 }
 
 @test "Pass when parsing a Kubernetes file with string and integer quantities" {
   run bin/kubeconform -verbose fixtures/quantity.yaml
   [ "$status" -eq 0 ]
-  [ "$output" = "fixtures/quantity.yaml - LimitRange mem-limit-range is valid" ]
+  [[ "$output" == "fixtures/quantity.yaml - LimitRange mem-limit-range is valid (Kubernetes master, download "*", validation "*")" ]]
 }
 
 @test "Pass when parsing a valid Kubernetes config file with null arrays" {
   run bin/kubeconform -verbose fixtures/null_string.yaml
   [ "$status" -eq 0 ]
-  [ "$output" = "fixtures/null_string.yaml - Service frontend is valid" ]
+  [[ "$output" == "fixtures/null_string.yaml - Service frontend is valid (Kubernetes master, download "*", validation "*")" ]]
 }
 
 @test "Pass when parsing a valid Kubernetes config file with null strings" {
@@ -300,3 +300,31 @@ resetCacheFolder() {
   [ "$status" -eq 0 ]
   [ "$output" = 'Summary: 100000 resources found parsing stdin - Valid: 100000, Invalid: 0, Errors: 0, Skipped: 0' ]
 }
+
+@test "Pass when reading a list of files from stdin with -stdin-format filelist" {
+  run bash -c "printf 'fixtures/valid.yaml\nfixtures/multi_valid.yaml\n' | bin/kubeconform -summary -stdin-format filelist"
+  [ "$status" -eq 0 ]
+  [ "$output" = 'Summary: 7 resources found in 2 files - Valid: 7, Invalid: 0, Errors: 0, Skipped: 0' ]
+}
+
+@test "Pass when parsing a valid Kubernetes config YAML file on stdin with -stdin-format yaml" {
+  run bash -c "cat fixtures/valid.yaml | bin/kubeconform -summary -stdin-format yaml"
+  [ "$status" -eq 0 ]
+  [ "$output" = "Summary: 1 resource found parsing stdin - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0" ]
+}
+
+@test "Fail when passing an invalid value to -stdin-format" {
+  run bin/kubeconform -stdin-format xml fixtures/valid.yaml
+  [ "$status" -eq 1 ]
+}
+
+@test "Fail when no files are found and -fail-on-no-files is set" {
+  run bin/kubeconform -fail-on-no-files -ignore-filename-pattern '.*' fixtures/folder
+  [ "$status" -eq 1 ]
+  [ "$output" = "no files found to validate" ]
+}
+
+@test "Pass when no files are found and -fail-on-no-files is not set" {
+  run bin/kubeconform -ignore-filename-pattern '.*' fixtures/folder
+  [ "$status" -eq 0 ]
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yannh/kubeconform/pkg/config"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+// fixLabels adds the labels of -fix-labels to the resources of the YAML files to validate that are
+// missing them. Files that can not be fixed are reported, and left for the validation to report.
+func fixLabels(ctx context.Context, cfg config.Config) {
+	files, err := resource.FindFiles(ctx, cfg.Files, filesOpts(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed fixing labels: %s\n", err)
+		return
+	}
+
+	for _, f := range files {
+		if ext := strings.ToLower(f); !strings.HasSuffix(ext, ".yaml") && !strings.HasSuffix(ext, ".yml") {
+			continue
+		}
+
+		fixes, err := resource.FixLabels(f, cfg.FixLabels, cfg.SkipKinds)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed fixing labels in %s: %s\n", f, err)
+			continue
+		}
+		for _, fix := range fixes {
+			fmt.Fprintf(os.Stderr, "%s - %s %s: added labels %s\n", fix.Path, fix.Kind, fix.Name, strings.Join(fix.Labels, ", "))
+		}
+	}
+}
@@ -1,37 +1,88 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/yannh/kubeconform/pkg/config"
+	"github.com/yannh/kubeconform/pkg/logging"
 	"github.com/yannh/kubeconform/pkg/output"
+	"github.com/yannh/kubeconform/pkg/registry"
 	"github.com/yannh/kubeconform/pkg/resource"
 	"github.com/yannh/kubeconform/pkg/validator"
 )
 
 var version = "development"
 
-func processResults(cancel context.CancelFunc, o output.Output, validationResults <-chan validator.Result, exitOnError bool) <-chan bool {
-	success := true
-	result := make(chan bool)
+// exitInterrupted is returned when validation is interrupted with Ctrl-C, 128 + SIGINT as reported by shells
+const exitInterrupted = 130
+
+// runStats aggregates the results of a run
+type runStats struct {
+	success                             bool
+	failures                            map[validator.Status]bool // statuses that failed the run
+	nValid, nInvalid, nErrors, nSkipped int
+}
+
+// validRatio returns the ratio of valid resources among the resources validated, 1 if there are
+// none. Skipped resources are counted as not valid if includeSkipped, and left out otherwise.
+func (s runStats) validRatio(includeSkipped bool) float64 {
+	total := s.nValid + s.nInvalid + s.nErrors
+	if includeSkipped {
+		total += s.nSkipped
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(s.nValid) / float64(total)
+}
+
+// percent formats a ratio as a percentage, e.g. 95% or 97.5%. It is rounded down, so that ratios
+// just below a threshold are not shown as reaching it.
+func percent(ratio float64) string {
+	return strconv.FormatFloat(math.Floor(ratio*1000+1e-9)/10, 'f', -1, 64) + "%"
+}
+
+func processResults(cancel context.CancelFunc, o output.Output, validationResults <-chan validator.Result, exitOnError, failOnInvalid, failOnSkipped bool) <-chan runStats {
+	stats := runStats{success: true, failures: map[validator.Status]bool{}}
+	result := make(chan runStats)
 
 	go func() {
 		for res := range validationResults {
-			if res.Status == validator.Error || res.Status == validator.Invalid {
-				success = false
+			switch res.Status {
+			case validator.Valid:
+				stats.nValid++
+			case validator.Invalid:
+				stats.nInvalid++
+			case validator.Error:
+				stats.nErrors++
+			case validator.Skipped:
+				stats.nSkipped++
+			}
+			if res.Status == validator.Error || (res.Status == validator.Invalid && failOnInvalid) || (res.Status == validator.Skipped && failOnSkipped) {
+				stats.success = false
+				stats.failures[res.Status] = true
 			}
 			if o != nil {
 				if err := o.Write(res); err != nil {
-					fmt.Fprint(os.Stderr, "failed writing log\n")
+					log.Printf("error: failed writing result: %s", err)
 				}
 			}
-			if !success && exitOnError {
+			if !stats.success && exitOnError {
 				cancel() // early exit - signal to stop searching for resources
 				break
 			}
@@ -40,12 +91,42 @@ func processResults(cancel context.CancelFunc, o output.Output, validationResult
 		for range validationResults { // allow resource finders to exit
 		}
 
-		result <- success
+		result <- stats
 	}()
 
 	return result
 }
 
+// runCompletionHook runs command with sh once validation is complete, passing the results of the run
+// as environment variables. Its output is sent to stderr, to not interfere with kubeconform's output.
+func runCompletionHook(command string, stats runStats) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("KUBECONFORM_SUCCESS=%t", stats.success),
+		fmt.Sprintf("KUBECONFORM_VALID=%d", stats.nValid),
+		fmt.Sprintf("KUBECONFORM_INVALID=%d", stats.nInvalid),
+		fmt.Sprintf("KUBECONFORM_ERRORS=%d", stats.nErrors),
+		fmt.Sprintf("KUBECONFORM_SKIPPED=%d", stats.nSkipped),
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// readFileList reads a list of paths from r, one per line. Empty lines are ignored.
+func readFileList(r io.Reader) ([]string, error) {
+	files := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+
+	return files, scanner.Err()
+}
+
 func realMain() int {
 	cfg, out, err := config.FromFlags(os.Args[0], os.Args[1:])
 	if out != "" {
@@ -69,71 +150,433 @@ func realMain() int {
 		return 1
 	}
 
+	// Messages about the operation of kubeconform are logged with the standard logger
+	logWriter, err := logging.NewWriter(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	log.SetFlags(0)
+	log.SetOutput(logWriter)
+
+	if cfg.Profile != "" {
+		profile, err := config.LoadProfile(cfg.ProfilesFile, cfg.Profile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		cfg.ApplyProfile(profile)
+	}
+
+	if cfg.PrintConfig {
+		b, err := cfg.YAML()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed printing configuration: %s\n", err)
+			return 1
+		}
+		fmt.Print(string(b))
+		return 0
+	}
+
 	if cfg.CPUProfileFile != "" {
 		f, err := os.Create(cfg.CPUProfileFile)
 		if err != nil {
-			log.Fatal("could not create CPU profile: ", err)
+			log.Fatal("error: could not create CPU profile: ", err)
 		}
 		defer f.Close()
 		if err := pprof.StartCPUProfile(f); err != nil {
-			log.Fatal("could not start CPU profile: ", err)
+			log.Fatal("error: could not start CPU profile: ", err)
 		}
 		runtime.SetBlockProfileRate(1)
 
 		defer pprof.StopCPUProfile()
 	}
 
+	if cfg.GitDiff != "" {
+		if cfg.Files, err = resource.GitDiffFiles(context.Background(), cfg.GitDiff, cfg.Files, filesOpts(cfg)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
 	useStdin := false
-	if len(cfg.Files) == 0 || (len(cfg.Files) == 1 && cfg.Files[0] == "-") {
-		stat, _ := os.Stdin.Stat()
-		if (stat.Mode() & os.ModeCharDevice) != 0 {
-			log.Fatalf("failing to read data from stdin")
+	if cfg.GitDiff == "" && (len(cfg.Files) == 0 || (len(cfg.Files) == 1 && cfg.Files[0] == "-")) {
+		switch cfg.StdinFormat {
+		case "yaml":
+			useStdin = true
+		case "filelist":
+			if cfg.Files, err = readFileList(os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "failed reading file list from stdin: %s\n", err)
+				return 1
+			}
+		default:
+			stat, _ := os.Stdin.Stat()
+			if (stat.Mode() & os.ModeCharDevice) != 0 {
+				log.Fatalf("error: failing to read data from stdin")
+			}
+			useStdin = true
+		}
+	}
+
+	if cfg.Kustomization {
+		if cfg.Files, err = kustomizationFiles(cfg.Files); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if cfg.Flux {
+		var kustomizations []resource.FluxKustomization
+		if cfg.Files, kustomizations, err = resource.FluxKustomizationFiles(context.Background(), cfg.Files, filesOpts(cfg)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, k := range kustomizations {
+			if k.Skipped != "" {
+				log.Printf("warning: Flux Kustomization %s in %s is not expanded: %s", k.Name, k.Path, k.Skipped)
+				continue
+			}
+			log.Printf("info: Flux Kustomization %s in %s applies %d files from %s", k.Name, k.Path, len(k.Files), k.Source)
+			for _, f := range k.Files {
+				log.Printf("debug: Flux Kustomization %s applies %s", k.Name, f)
+			}
 		}
-		useStdin = true
 	}
 
-	var o output.Output
-	if o, err = output.New(cfg.OutputFormat, cfg.Summary, useStdin, cfg.Verbose); err != nil {
+	var diff *output.Diff
+	if cfg.DryRunDiff != "" {
+		if diff, err = readDiff(cfg.DryRunDiff, cfg.OutputFormat); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	o, outputFile, err := newOutput(cfg, useStdin)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
+	if diff != nil {
+		o = diff
+	}
+	if cfg.OTelTraces {
+		traces, err := output.NewTraces(os.Getenv)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		o = output.NewMulti(o, traces)
+	}
+	if cfg.ResultsDir != "" {
+		o = output.NewMulti(o, output.NewResultsDir(cfg.ResultsDir))
+	}
+	if outputFile != nil {
+		defer outputFile.Close()
+	}
+
+	var provenance *validator.Provenance
+	if cfg.SchemaProvenanceFile != "" {
+		provenance = validator.NewProvenance()
+	}
+
+	var unknownKinds *validator.UnknownKinds
+	if cfg.UnknownKindsFile != "" {
+		unknownKinds = validator.NewUnknownKinds()
+	}
+
+	var crossResourceChecks *validator.CrossResourceChecks
+	if len(cfg.CrossResourceRules) > 0 {
+		if crossResourceChecks, err = validator.NewCrossResourceChecks(cfg.CrossResourceRules); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	var serverDryRun validator.DryRunner
+	if cfg.ServerDryRun {
+		dryRunner, err := registry.NewDryRunner(cfg.DryRunContext, cfg.SkipTLS, cfg.NoProxy, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		serverDryRun = dryRunner
+	}
+
+	var schemaLock *validator.SchemaLock
+	if cfg.UpdateSchemaLock {
+		schemaLock = validator.NewSchemaLock()
+	} else if cfg.SchemaLock != "" {
+		if schemaLock, err = readSchemaLock(cfg.SchemaLock); err != nil {
+			fmt.Fprintf(os.Stderr, "failed reading schema lock: %s\n", err)
+			return 1
+		}
+	}
 
-	v, err := validator.New(cfg.SchemaLocations, validator.Opts{
-		Cache:                cfg.Cache,
-		SkipTLS:              cfg.SkipTLS,
-		SkipKinds:            cfg.SkipKinds,
-		RejectKinds:          cfg.RejectKinds,
-		KubernetesVersion:    cfg.KubernetesVersion,
-		Strict:               cfg.Strict,
-		IgnoreMissingSchemas: cfg.IgnoreMissingSchemas,
+	schemaLocations := cfg.SchemaLocations
+	if cfg.SchemaCatalog != "" {
+		catalogLocations, err := registry.LoadSchemaCatalog(context.Background(), cfg.SchemaCatalog, cfg.SkipTLS, cfg.NoProxy)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		schemaLocations = append(catalogLocations, schemaLocations...)
+	}
+
+	v, err := validator.New(schemaLocations, validator.Opts{
+		Cache:                  cfg.Cache,
+		SchemaCacheURL:         cfg.SchemaCacheURL,
+		WarnSchemaOverrides:    cfg.WarnSchemaOverrides,
+		WarnDeprecatedFields:   cfg.WarnDeprecatedFields,
+		DefaultNamespace:       cfg.DefaultNamespace,
+		Provenance:             provenance,
+		UnknownKinds:           unknownKinds,
+		CrossResourceChecks:    crossResourceChecks,
+		ServerDryRun:           serverDryRun,
+		SchemaLock:             schemaLock,
+		OnlyFields:             cfg.OnlyFields,
+		FailOnMissingFields:    cfg.FailOnMissingFields,
+		Aliases:                cfg.Aliases,
+		KindShortnames:         cfg.KindShortnames,
+		ApplyDefaults:          cfg.ApplyDefaults,
+		SkipTLS:                cfg.SkipTLS,
+		NoProxy:                cfg.NoProxy,
+		SkipKinds:              cfg.SkipKinds,
+		OnlyKinds:              cfg.OnlyKinds,
+		ListMode:               cfg.ListMode,
+		RevisionAnnotation:     cfg.RevisionAnnotation,
+		RejectKinds:            cfg.RejectKinds,
+		NoPrereleaseAPIs:       cfg.NoPrereleaseAPIs,
+		AllowedPrereleaseAPIs:  cfg.AllowPrereleaseAPIs,
+		AllowedAPIVersions:     cfg.AllowedAPIVersions,
+		SkipOwned:              cfg.SkipOwned,
+		TolerateDownloadErrors: cfg.TolerateDownloadErrors,
+		Debug:                  cfg.LogLevel == "debug",
+		KubernetesVersion:      cfg.KubernetesVersion,
+		VersionResolution:      cfg.VersionResolution,
+		FallbackVersion:        cfg.FallbackVersion,
+		Strict:                 cfg.Strict,
+		IgnoreMissingSchemas:   cfg.IgnoreMissingSchemas,
+		ValidateLabelSyntax:    cfg.ValidateLabelSyntax,
+		RequiredLabels:         cfg.RequireLabels,
+		ValidateImages:         cfg.ValidateImages,
+		RequireImageRegistry:   cfg.RequireImageRegistry,
+		ValidateSecrets:        cfg.ValidateSecrets,
+		AggregatedAPIs:         cfg.AggregatedAPIs,
+		SSABase:                cfg.SSABase,
+		StrictAggregated:       cfg.StrictAggregated,
+		IgnoreKeys:             cfg.IgnoreKeys,
+		CheckSizeLimit:         cfg.CheckSizeLimit,
+		SizeLimit:              cfg.SizeLimit,
+		SyntaxOnly:             cfg.SyntaxOnly,
+		StrictDiff:             cfg.StrictDiff,
+		Patches:                cfg.Patches,
+		IgnoreErrorPatterns:    cfg.IgnoreErrorPatterns,
+		RegistryMaxFailures:    cfg.RegistryMaxFailures,
+		RegistryCooldown:       cfg.RegistryCooldown,
+		RegistryTimeouts:       cfg.SchemaLocationTimeouts,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
 
+	// Ctrl-C stops the discovery of resources and aborts schema downloads in progress
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if cfg.Fix {
+		fixLabels(interrupted, cfg)
+	}
+
+	if cfg.Preflight {
+		writePreflight(os.Stderr, countResources(interrupted, cfg, cfg.Files))
+	}
+
+	stats, nResources := validate(interrupted, cfg, v, o, useStdin, cfg.Files, crossResourceChecks)
+
+	if cfg.StatusLine {
+		fmt.Fprintf(os.Stderr, "valid=%d invalid=%d error=%d skipped=%d\n", stats.nValid, stats.nInvalid, stats.nErrors, stats.nSkipped)
+	}
+
+	if interrupted.Err() != nil {
+		fmt.Fprintln(os.Stderr, "validation interrupted")
+		return exitInterrupted
+	}
+
+	if provenance != nil {
+		if err := writeProvenance(cfg.SchemaProvenanceFile, provenance); err != nil {
+			fmt.Fprintf(os.Stderr, "failed writing schema provenance: %s\n", err)
+			return 1
+		}
+	}
+
+	if cfg.UpdateSchemaLock {
+		if err := writeSchemaLock(cfg.SchemaLock, schemaLock); err != nil {
+			fmt.Fprintf(os.Stderr, "failed writing schema lock: %s\n", err)
+			return 1
+		}
+	}
+
+	if unknownKinds != nil {
+		if err := writeUnknownKinds(cfg.UnknownKindsFile, unknownKinds); err != nil {
+			fmt.Fprintf(os.Stderr, "failed writing unknown kinds: %s\n", err)
+			return 1
+		}
+	}
+
+	if cfg.ExecOnComplete != "" {
+		if err := runCompletionHook(cfg.ExecOnComplete, stats); err != nil {
+			fmt.Fprintf(os.Stderr, "failed running -exec-on-complete command: %s\n", err)
+			if cfg.FailOnExecError {
+				return 1
+			}
+		}
+	}
+
+	if cfg.FailOnNoFiles && nResources == 0 {
+		fmt.Fprintln(os.Stderr, "no files found to validate")
+		return 1
+	}
+
+	if cfg.Watch {
+		return watch(interrupted, cfg, v, stats)
+	}
+
+	code, reason := exitCode(stats, cfg.ExitCodes)
+	if cfg.PassThreshold > 0 {
+		ratio := stats.validRatio(cfg.ThresholdSkipped)
+		code, reason = 0, ""
+		if ratio < cfg.PassThreshold {
+			code, reason = 1, fmt.Sprintf("%s of resources valid, below the pass threshold of %s", percent(ratio), percent(cfg.PassThreshold))
+		}
+		if cfg.Summary {
+			fmt.Fprintf(os.Stderr, "Valid: %s, pass threshold: %s\n", percent(ratio), percent(cfg.PassThreshold))
+		}
+	}
+	if diff != nil {
+		code, reason = 0, ""
+		if n := diff.NewlyFailing(); n > 0 {
+			code, reason = 1, plural(n, "newly failing resource")
+		}
+	}
+
+	// The reason is written to stderr, so that it does not mix with results written to stdout
+	if cfg.Summary && code != 0 {
+		fmt.Fprintf(os.Stderr, "Exit code %d: %s\n", code, reason)
+	}
+
+	return code
+}
+
+// newOutput returns the output results are written to. With -output-file, the file is returned so
+// that it can be closed once results have been written.
+func newOutput(cfg config.Config, useStdin bool) (output.Output, *os.File, error) {
+	switch {
+	case cfg.OutputFile != "":
+		f, err := os.Create(cfg.OutputFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		o, err := output.NewFailures(f, cfg.OutputFormat, useStdin)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		if cfg.Summary {
+			o = output.NewMulti(o, output.NewSummary(useStdin))
+		}
+		return o, f, nil
+	case cfg.OutputTemplate != "":
+		o, err := output.NewTemplate(cfg.OutputTemplate, cfg.Verbose)
+		return o, nil, err
+	case cfg.PrintCanonical:
+		return output.NewCanonical(cfg.Summary, useStdin, cfg.Verbose), nil, nil
+	case cfg.OutputFormat == "syslog":
+		o, err := output.NewSyslog(cfg.SyslogAddress, cfg.SyslogFacility, cfg.Summary, cfg.Verbose)
+		return o, nil, err
+	default:
+		o, err := output.New(cfg.OutputFormat, cfg.Summary, useStdin, cfg.Verbose)
+		return o, nil, err
+	}
+}
+
+// filesOpts returns how resources are read from files
+func filesOpts(cfg config.Config) resource.FilesOpts {
+	return resource.FilesOpts{
+		IgnoreFilePatterns: cfg.IgnoreFilenamePatterns,
+		MaxFileSize:        cfg.MaxFileSize,
+		NormalizeNewlines:  cfg.NormalizeNewlines,
+		Envsubst:           cfg.Envsubst,
+		EnvsubstUndefined:  cfg.EnvsubstUndefined,
+		ListMode:           cfg.ListMode,
+		Decompress:         cfg.Decompress,
+		OnlyDocIndexes:     cfg.OnlyDocIndexes,
+	}
+}
+
+// validate validates the resources in files, or read from stdin, and writes the results to o. It
+// returns the statistics of the run and the number of resources found. If crossResourceChecks is set,
+// the resources are checked against each other once all have been validated.
+func validate(ctx context.Context, cfg config.Config, v validator.Validator, o output.Output, useStdin bool, files []string, crossResourceChecks *validator.CrossResourceChecks) (runStats, int64) {
 	validationResults := make(chan validator.Result)
-	ctx, cancel := context.WithCancel(context.Background())
-	successChan := processResults(cancel, o, validationResults, cfg.ExitOnError)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// With -strict-diff, invalid resources are reported for information only, unless given an exit code
+	_, invalidCode := cfg.ExitCodes["invalid"]
+	_, skippedCode := cfg.ExitCodes["skipped"]
+	statsChan := processResults(cancel, o, validationResults, cfg.ExitOnError, !cfg.StrictDiff || invalidCode, cfg.FailOnSkipped || skippedCode)
 
 	var resourcesChan <-chan resource.Resource
 	var errors <-chan error
 	if useStdin {
-		resourcesChan, errors = resource.FromStream(ctx, "stdin", os.Stdin)
+		var stdin io.Reader = os.Stdin
+		if cfg.Decompress {
+			stdin = resource.Decompress(stdin)
+		}
+		if cfg.NormalizeNewlines {
+			stdin = resource.NormalizeNewlines(stdin)
+		}
+		if cfg.Envsubst {
+			stdin = resource.Envsubst(stdin, os.LookupEnv, cfg.EnvsubstUndefined)
+		}
+		resourcesChan, errors = resource.FromStreamWithOpts(ctx, "stdin", stdin, resource.StreamOpts{ListMode: cfg.ListMode, OnlyDocIndexes: cfg.OnlyDocIndexes})
+	} else {
+		resourcesChan, errors = resource.FromFilesWithOpts(ctx, files, filesOpts(cfg))
+	}
+
+	// Process discovered resources across multiple workers - with -shard-by-kind, each kind is
+	// processed by a single worker
+	shards := make([]<-chan resource.Resource, cfg.NumberOfWorkers)
+	if cfg.ShardByKind {
+		shards = resource.ShardByKind(resourcesChan, cfg.NumberOfWorkers)
 	} else {
-		resourcesChan, errors = resource.FromFiles(ctx, cfg.Files, cfg.IgnoreFilenamePatterns)
+		for i := range shards {
+			shards[i] = resourcesChan
+		}
 	}
 
-	// Process discovered resources across multiple workers
+	// Schema downloads are aborted on cancellation by validators supporting it
+	validateResource := func(v validator.Validator, res resource.Resource) validator.Result {
+		if cv, ok := v.(validator.ContextValidator); ok {
+			return cv.ValidateResourceWithContext(ctx, res)
+		}
+		return v.ValidateResource(res)
+	}
+
+	var nResources int64
 	wg := sync.WaitGroup{}
 	for i := 0; i < cfg.NumberOfWorkers; i++ {
 		wg.Add(1)
 		go func(resources <-chan resource.Resource, validationResults chan<- validator.Result, v validator.Validator) {
 			for res := range resources {
-				validationResults <- v.ValidateResource(res)
+				if ctx.Err() != nil {
+					continue // drain the resources found before the cancellation
+				}
+				atomic.AddInt64(&nResources, 1)
+				validationResults <- validateResource(v, res)
 			}
 			wg.Done()
-		}(resourcesChan, validationResults, v)
+		}(shards[i], validationResults, v)
 	}
 
 	wg.Add(1)
@@ -145,11 +588,23 @@ func realMain() int {
 			}
 
 			if err, ok := err.(resource.DiscoveryError); ok {
+				if cfg.SkipUnreadable && err.Unreadable() {
+					log.Printf("skipping %s: %s", err.Path, err.Err)
+					validationResults <- validator.Result{
+						Resource: resource.Resource{Path: err.Path},
+						Err:      err.Err,
+						Status:   validator.Skipped,
+					}
+					continue
+				}
 				validationResults <- validator.Result{
 					Resource: resource.Resource{Path: err.Path},
 					Err:      err.Err,
 					Status:   validator.Error,
 				}
+				if err.TooLarge() || err.Duplicate() {
+					continue // the file was not read or is read to the end, there is no reason to stop processing the others
+				}
 			} else {
 				validationResults <- validator.Result{
 					Resource: resource.Resource{},
@@ -164,15 +619,147 @@ func realMain() int {
 
 	wg.Wait()
 
+	if crossResourceChecks != nil && ctx.Err() == nil {
+		for _, res := range crossResourceChecks.Check() {
+			validationResults <- res
+		}
+	}
+
 	close(validationResults)
-	success := <-successChan
-	o.Flush()
+	stats := <-statsChan
+	if err := o.Flush(); err != nil {
+		log.Printf("error: %s", err)
+	}
 
-	if !success {
-		return 1
+	return stats, atomic.LoadInt64(&nResources)
+}
+
+// exitCode returns the exit code for the most severe status that failed the run - error, invalid then
+// skipped - as set with -exit-codes, or 1, and the reason for it: the number of resources of each
+// status that failed the run, e.g. "3 invalid resources". The reason is empty if the run succeeded.
+func exitCode(stats runStats, exitCodes map[string]int) (int, string) {
+	code := 0
+	reasons := []string{}
+	for _, failure := range []struct {
+		status validator.Status
+		n      int
+		word   string
+	}{
+		{validator.Error, stats.nErrors, "error"},
+		{validator.Invalid, stats.nInvalid, "invalid resource"},
+		{validator.Skipped, stats.nSkipped, "skipped resource"},
+	} {
+		if !stats.failures[failure.status] {
+			continue
+		}
+		if code == 0 {
+			code = 1
+			if c, ok := exitCodes[failure.status.String()]; ok {
+				code = c
+			}
+		}
+		reasons = append(reasons, plural(failure.n, failure.word))
+	}
+
+	if code == 0 && !stats.success {
+		return 1, "validation failed"
+	}
+
+	return code, strings.Join(reasons, ", ")
+}
+
+// writeProvenance writes the schemas used during validation to the file at p
+func writeProvenance(p string, provenance *validator.Provenance) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	if err := provenance.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readSchemaLock reads the schema checksums to validate with from the file at p
+func readSchemaLock(p string) (*validator.SchemaLock, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return validator.ReadSchemaLock(f)
+}
+
+// writeSchemaLock writes the checksums of the schemas used to the file at p
+func writeSchemaLock(p string, schemaLock *validator.SchemaLock) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	if err := schemaLock.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeUnknownKinds writes the kinds without schema to the file at p, as YAML or JSON depending on its extension
+func writeUnknownKinds(p string, unknownKinds *validator.UnknownKinds) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".yaml", ".yml":
+		err = unknownKinds.WriteYAML(f)
+	default:
+		err = unknownKinds.WriteJSON(f)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readDiff returns an output comparing results against the results of a previous run, read from path
+func readDiff(path, format string) (*output.Diff, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return output.NewDiff(os.Stdout, f, format)
+}
+
+// kustomizationFiles replaces the kustomization files and the folders holding one in paths
+// with the files they reference
+func kustomizationFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, p := range paths {
+		kfile, ok := resource.KustomizationFile(p)
+		if !ok {
+			files = append(files, p)
+			continue
+		}
+
+		kfiles, err := resource.KustomizationFiles(kfile)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, kfiles...)
 	}
 
-	return 0
+	return files, nil
 }
 
 func main() {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/yannh/kubeconform/pkg/config"
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// preflightCounts is the scope of a run, found by reading files before validating them
+type preflightCounts struct {
+	nFiles, nResources int
+	kinds              map[string]bool
+}
+
+// countResources reads the resources in files without validating them. Files that can not be read
+// are left for the validation to report.
+func countResources(ctx context.Context, cfg config.Config, files []string) preflightCounts {
+	counts := preflightCounts{kinds: map[string]bool{}}
+	seenFiles := map[string]bool{}
+
+	resources, errors := resource.FromFilesWithOpts(ctx, files, filesOpts(cfg))
+	go func() {
+		for range errors {
+		}
+	}()
+
+	for res := range resources {
+		if !seenFiles[res.Path] {
+			seenFiles[res.Path] = true
+			counts.nFiles++
+		}
+		// Documents with only comments are not resources, as in the summary
+		var r map[string]interface{}
+		if len(res.Bytes) == 0 || (yaml.Unmarshal(res.Bytes, &r) == nil && r == nil) {
+			continue
+		}
+		counts.nResources++
+		if sig, err := res.Signature(); err == nil {
+			counts.kinds[sig.Kind] = true
+		}
+	}
+
+	return counts
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// writePreflight writes the number of files, resources and distinct kinds found
+func writePreflight(w io.Writer, counts preflightCounts) {
+	fmt.Fprintf(w, "found %s, %s across %s\n", plural(counts.nFiles, "file"), plural(counts.nResources, "resource"), plural(len(counts.kinds), "distinct kind"))
+}
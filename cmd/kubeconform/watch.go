@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/config"
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// watchInterval is how often files are checked for changes with -watch
+const watchInterval = time.Second
+
+// fileState identifies a version of a file, to detect changes
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileStates returns the state of each file, skipping files that can not be stat'ed
+func fileStates(files []string) map[string]fileState {
+	states := map[string]fileState{}
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			states[f] = fileState{fi.ModTime(), fi.Size()}
+		}
+	}
+	return states
+}
+
+// changedFiles returns the files that were added or modified between two states, in lexical order
+func changedFiles(before, after map[string]fileState) []string {
+	changed := []string{}
+	for f, state := range after {
+		if previous, ok := before[f]; !ok || previous != state {
+			changed = append(changed, f)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// watch validates files again when they are added or modified, until ctx is cancelled. It returns the
+// exit code of the last validation.
+func watch(ctx context.Context, cfg config.Config, v validator.Validator, stats runStats) int {
+	opts := filesOpts(cfg)
+	files, err := resource.FindFiles(ctx, cfg.Files, opts)
+	if err != nil {
+		log.Printf("error: failed watching files: %s", err)
+		return 1
+	}
+	states := fileStates(files)
+	log.Printf("watching %s for changes", strings.Join(cfg.Files, ", "))
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			code, _ := exitCode(stats, cfg.ExitCodes)
+			return code
+		case <-ticker.C:
+		}
+
+		files, err := resource.FindFiles(ctx, cfg.Files, opts)
+		if err != nil {
+			log.Printf("warning: failed listing files: %s", err)
+			continue
+		}
+		current := fileStates(files)
+		changed := changedFiles(states, current)
+		states = current
+		if len(changed) == 0 || ctx.Err() != nil {
+			continue
+		}
+
+		log.Printf("validating changed files: %s", strings.Join(changed, ", "))
+		o, _, err := newOutput(cfg, false)
+		if err != nil {
+			log.Printf("error: %s", err)
+			return 1
+		}
+		stats, _ = validate(ctx, cfg, v, o, false, changed, nil)
+	}
+}
@@ -4,28 +4,153 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
 )
 
 type Config struct {
-	Cache                  string
-	CPUProfileFile         string
-	ExitOnError            bool
-	Files                  []string
-	SchemaLocations        []string
-	SkipTLS                bool
-	SkipKinds              map[string]struct{}
-	RejectKinds            map[string]struct{}
-	OutputFormat           string
-	KubernetesVersion      string
-	NumberOfWorkers        int
-	Summary                bool
-	Strict                 bool
-	Verbose                bool
-	IgnoreMissingSchemas   bool
-	IgnoreFilenamePatterns []string
-	Help                   bool
-	Version                bool
+	AggregatedAPIs         []string                     `json:"aggregated-apis"`
+	AllowPrereleaseAPIs    []string                     `json:"allow-prerelease-apis"`
+	AllowedAPIVersions     map[string][]string          `json:"allowed-apiversions"`
+	Aliases                map[string]string            `json:"alias"`
+	ApplyDefaults          bool                         `json:"apply-defaults"`
+	Cache                  string                       `json:"cache"`
+	CheckSizeLimit         bool                         `json:"check-size-limit"`
+	CPUProfileFile         string                       `json:"cpu-prof"`
+	CrossResourceRules     []string                     `json:"cross-resource-rules"`
+	DefaultNamespace       string                       `json:"default-namespace"`
+	DryRunDiff             string                       `json:"dry-run-diff"`
+	Envsubst               bool                         `json:"envsubst"`
+	EnvsubstUndefined      string                       `json:"envsubst-undefined"`
+	ExecOnComplete         string                       `json:"exec-on-complete"`
+	ExitCodes              map[string]int               `json:"exit-codes"`
+	ExitOnError            bool                         `json:"exit-on-error"`
+	Fix                    bool                         `json:"fix"`
+	FixLabels              map[string]string            `json:"fix-labels"`
+	FailOnExecError        bool                         `json:"fail-on-exec-error"`
+	FailOnMissingFields    bool                         `json:"fail-on-missing-fields"`
+	FailOnNoFiles          bool                         `json:"fail-on-no-files"`
+	FailOnSkipped          bool                         `json:"fail-on-skipped"`
+	PassThreshold          float64                      `json:"pass-threshold"`
+	ThresholdSkipped       bool                         `json:"pass-threshold-skipped"`
+	Files                  []string                     `json:"files"`
+	SchemaCatalog          string                       `json:"schema-catalog"`
+	SchemaLocations        []string                     `json:"schema-location"`
+	SchemaLocationTimeouts map[string]time.Duration     `json:"schema-location-timeouts"` // by schema location, without the timeout qualifier
+	SchemaLock             string                       `json:"schema-lock"`
+	SchemaCacheURL         string                       `json:"schema-cache-url"`
+	SchemaProvenanceFile   string                       `json:"schema-provenance-file"`
+	RevisionAnnotation     string                       `json:"schema-revision-annotation"`
+	SSABase                string                       `json:"ssa-base"`
+	ServerDryRun           bool                         `json:"server-dry-run"`
+	DryRunContext          string                       `json:"server-dry-run-context"`
+	SkipTLS                bool                         `json:"insecure-skip-tls-verify"`
+	SkipOwned              bool                         `json:"skip-owned"`
+	SkipUnreadable         bool                         `json:"skip-unreadable"`
+	StatusLine             bool                         `json:"status-line"`
+	ShardByKind            bool                         `json:"shard-by-kind"`
+	SizeLimit              int64                        `json:"size-limit"`
+	SkipKinds              map[string]struct{}          `json:"skip"`
+	RejectKinds            map[string]struct{}          `json:"reject"`
+	RequireLabels          []string                     `json:"require-labels"`
+	OutputFormat           string                       `json:"output"`
+	OutputFile             string                       `json:"output-file"`
+	ResultsDir             string                       `json:"results-dir"`
+	OutputTemplate         string                       `json:"output-template"`
+	OnlyFields             map[string]map[string]string `json:"only-fields"`
+	Patches                map[string][]string          `json:"patch"`
+	RegistryMaxFailures    int                          `json:"registry-max-failures"`
+	RegistryCooldown       time.Duration                `json:"registry-cooldown"`
+	KubernetesVersion      string                       `json:"kubernetes-version"`
+	FallbackVersion        string                       `json:"fallback-kubernetes-version"`
+	VersionResolution      string                       `json:"version-resolution"`
+	KindShortnames         map[string]string            `json:"kind-shortnames"`
+	GitDiff                string                       `json:"git-diff"`
+	Kustomization          bool                         `json:"kustomization"`
+	Flux                   bool                         `json:"flux"`
+	ListMode               string                       `json:"list-mode"`
+	LogFormat              string                       `json:"log-format"`
+	LogLevel               string                       `json:"log-level"`
+	MaxFileSize            int64                        `json:"max-file-size"`
+	NumberOfWorkers        int                          `json:"n"`
+	NormalizeNewlines      bool                         `json:"normalize-newlines"`
+	NoPrereleaseAPIs       bool                         `json:"no-prerelease-apis"`
+	NoProxy                string                       `json:"no-proxy"`
+	OnlyDocIndexes         []int                        `json:"only-doc-index"`
+	OnlyKinds              map[string]struct{}          `json:"only-kinds"`
+	OTelTraces             bool                         `json:"otel-traces"`
+	Summary                bool                         `json:"summary"`
+	SyslogAddress          string                       `json:"syslog-address"`
+	SyslogFacility         string                       `json:"syslog-facility"`
+	Strict                 bool                         `json:"strict"`
+	StrictAggregated       bool                         `json:"strict-aggregated"`
+	StrictDiff             bool                         `json:"strict-diff"`
+	TolerateDownloadErrors bool                         `json:"tolerate-download-errors"`
+	UnknownKindsFile       string                       `json:"unknown-kinds-file"`
+	UpdateSchemaLock       bool                         `json:"update-schema-lock"`
+	Verbose                bool                         `json:"verbose"`
+	WarnDeprecatedFields   bool                         `json:"warn-deprecated-fields"`
+	Watch                  bool                         `json:"watch"`
+	WarnSchemaOverrides    bool                         `json:"warn-schema-overrides"`
+	IgnoreMissingSchemas   bool                         `json:"ignore-missing-schemas"`
+	IgnoreFilenamePatterns []string                     `json:"ignore-filename-pattern"`
+	IgnoreKeys             []string                     `json:"ignore-keys"`
+	IgnoreErrorPatterns    []string                     `json:"ignore-error-pattern"`
+	Decompress             bool                         `json:"decompress"`
+	StdinFormat            string                       `json:"stdin-format"`
+	SyntaxOnly             bool                         `json:"syntax-only"`
+	ValidateImages         bool                         `json:"validate-images"`
+	RequireImageRegistry   bool                         `json:"require-image-registry"`
+	ValidateLabelSyntax    bool                         `json:"validate-label-syntax"`
+	ValidateSecrets        bool                         `json:"validate-secrets"`
+	Preflight              bool                         `json:"preflight"`
+	PrintCanonical         bool                         `json:"print-canonical"`
+	Profile                string                       `json:"profile"`
+	ProfilesFile           string                       `json:"profiles-file"`
+	PrintConfig            bool                         `json:"-"`
+	Help                   bool                         `json:"-"`
+	Version                bool                         `json:"-"`
+}
+
+// YAML returns the configuration as YAML, keyed by command-line parameter name
+func (c Config) YAML() ([]byte, error) {
+	sortedKeys := func(m map[string]struct{}) []string {
+		keys := []string{}
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	var timeouts map[string]string
+	for location, timeout := range c.SchemaLocationTimeouts {
+		if timeouts == nil {
+			timeouts = map[string]string{}
+		}
+		timeouts[location] = timeout.String()
+	}
+
+	// Fields of the outer struct take precedence over the embedded ones with the same name
+	return yaml.Marshal(struct {
+		Config
+		SkipKinds        []string          `json:"skip"`
+		RejectKinds      []string          `json:"reject"`
+		OnlyKinds        []string          `json:"only-kinds"`
+		RegistryCooldown string            `json:"registry-cooldown"`
+		Timeouts         map[string]string `json:"schema-location-timeouts"`
+	}{
+		Config:           c,
+		SkipKinds:        sortedKeys(c.SkipKinds),
+		RejectKinds:      sortedKeys(c.RejectKinds),
+		OnlyKinds:        sortedKeys(c.OnlyKinds),
+		RegistryCooldown: c.RegistryCooldown.String(),
+		Timeouts:         timeouts,
+	})
 }
 
 type arrayParam []string
@@ -52,10 +177,206 @@ func splitCSV(csvStr string) map[string]struct{} {
 	return valuesMap
 }
 
+func splitList(csvStr string) []string {
+	var values []string
+	for _, value := range strings.Split(csvStr, ",") {
+		if len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// parsePatches parses a list of kind=path pairs into lists of patch files by kind
+func parsePatches(patches []string) (map[string][]string, error) {
+	var patchesByKind map[string][]string
+	for _, p := range patches {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid value for -patch: %s, must be kind=path", p)
+		}
+		if patchesByKind == nil {
+			patchesByKind = map[string][]string{}
+		}
+		patchesByKind[parts[0]] = append(patchesByKind[parts[0]], parts[1])
+	}
+
+	return patchesByKind, nil
+}
+
+// parseOnlyFields parses a list of kind:path=schema values into schema files by kind and path
+func parseOnlyFields(values []string) (map[string]map[string]string, error) {
+	var fields map[string]map[string]string
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		selector := strings.SplitN(parts[0], ":", 2)
+		if len(parts) != 2 || parts[1] == "" || len(selector) != 2 || selector[0] == "" || selector[1] == "" {
+			return nil, fmt.Errorf("invalid value for -only-fields: %s, must be kind:path=schema", v)
+		}
+		if fields == nil {
+			fields = map[string]map[string]string{}
+		}
+		if fields[selector[0]] == nil {
+			fields[selector[0]] = map[string]string{}
+		}
+		fields[selector[0]][selector[1]] = parts[1]
+	}
+
+	return fields, nil
+}
+
+// parseAliases parses a list of apiVersion/Kind=apiVersion/Kind pairs into aliases by apiVersion/Kind
+func parseAliases(values []string) (map[string]string, error) {
+	isGVK := func(s string) bool {
+		i := strings.LastIndex(s, "/")
+		return i > 0 && i < len(s)-1
+	}
+
+	var aliases map[string]string
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || !isGVK(parts[0]) || !isGVK(parts[1]) {
+			return nil, fmt.Errorf("invalid value for -alias: %s, must be apiVersion/Kind=apiVersion/Kind", v)
+		}
+		if aliases == nil {
+			aliases = map[string]string{}
+		}
+		aliases[parts[0]] = parts[1]
+	}
+
+	return aliases, nil
+}
+
+// parseAllowedAPIVersions parses a comma-separated list of Kind=apiVersion pairs into the apiVersions
+// allowed for each kind
+func parseAllowedAPIVersions(csvStr string) (map[string][]string, error) {
+	var allowed map[string][]string
+	for _, v := range splitList(csvStr) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid value for -allowed-apiversions: %s, must be Kind=apiVersion", v)
+		}
+		if allowed == nil {
+			allowed = map[string][]string{}
+		}
+		allowed[parts[0]] = append(allowed[parts[0]], parts[1])
+	}
+
+	return allowed, nil
+}
+
+// parseKindShortnames parses a comma-separated list of shortname=Kind pairs into kinds by short name
+func parseKindShortnames(csvStr string) (map[string]string, error) {
+	var shortnames map[string]string
+	for _, v := range splitList(csvStr) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid value for -kind-shortnames: %s, must be shortname=Kind", v)
+		}
+		if shortnames == nil {
+			shortnames = map[string]string{}
+		}
+		shortnames[parts[0]] = parts[1]
+	}
+
+	return shortnames, nil
+}
+
+// parseLabels parses a comma-separated list of key=value pairs into labels
+func parseLabels(csvStr string) (map[string]string, error) {
+	var labels map[string]string
+	for _, v := range splitList(csvStr) {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label %s in -fix-labels, must be key=value", v)
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[parts[0]] = parts[1]
+	}
+
+	return labels, nil
+}
+
+// timeoutPrefix qualifies a schema location with the timeout of its requests, e.g.
+// timeout=5s+https://example.com/schemas/{{ .ResourceKind }}.json
+const timeoutPrefix = "timeout="
+
+// parseSchemaLocations removes the timeout qualifiers from schema locations, returning the schema
+// locations without them and the timeouts, by schema location
+func parseSchemaLocations(values []string) ([]string, map[string]time.Duration, error) {
+	var timeouts map[string]time.Duration
+	var locations []string
+	for _, v := range values {
+		if !strings.HasPrefix(v, timeoutPrefix) {
+			locations = append(locations, v)
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(v, timeoutPrefix), "+", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, nil, fmt.Errorf("invalid value for -schema-location: %s, must be timeout=duration+location", v)
+		}
+		timeout, err := time.ParseDuration(parts[0])
+		if err != nil || timeout <= 0 {
+			return nil, nil, fmt.Errorf("invalid timeout %s for schema location %s, must be a positive duration, e.g. 5s", parts[0], parts[1])
+		}
+		if timeouts == nil {
+			timeouts = map[string]time.Duration{}
+		}
+		timeouts[parts[1]] = timeout
+		locations = append(locations, parts[1])
+	}
+
+	return locations, timeouts, nil
+}
+
+// parseIndexes parses a comma-separated list of document indexes
+func parseIndexes(csvStr string) ([]int, error) {
+	var indexes []int
+	for _, value := range splitList(csvStr) {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("invalid value for -only-doc-index: %s, must be a non-negative integer", value)
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes, nil
+}
+
+// parseExitCodes parses a comma-separated list of status=code pairs, e.g. invalid=2,error=3
+func parseExitCodes(csvStr string) (map[string]int, error) {
+	var exitCodes map[string]int
+	for _, value := range splitList(csvStr) {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid value for -exit-codes: %s, must be status=code", value)
+		}
+		switch parts[0] {
+		case "invalid", "error", "skipped":
+		default:
+			return nil, fmt.Errorf("invalid value for -exit-codes: %s, status must be invalid, error or skipped", value)
+		}
+		code, err := strconv.Atoi(parts[1])
+		if err != nil || code < 1 || code > 255 {
+			return nil, fmt.Errorf("invalid value for -exit-codes: %s, code must be between 1 and 255", value)
+		}
+		if exitCodes == nil {
+			exitCodes = map[string]int{}
+		}
+		exitCodes[parts[0]] = code
+	}
+
+	return exitCodes, nil
+}
+
 // FromFlags retrieves kubeconform's runtime configuration from the command-line parameters
 func FromFlags(progName string, args []string) (Config, string, error) {
-	var schemaLocationsParam, ignoreFilenamePatterns arrayParam
-	var skipKindsCSV, rejectKindsCSV string
+	var schemaLocationsParam, ignoreFilenamePatterns, ignoreErrorPatterns, patchesParam, onlyFieldsParam, aliasesParam arrayParam
+	var skipKindsCSV, rejectKindsCSV, onlyKindsCSV, ignoreKeysCSV, onlyDocIndexesCSV, exitCodesCSV, aggregatedAPIsCSV, allowPrereleaseAPIsCSV, allowedAPIVersionsCSV, requireLabelsCSV, fixLabelsCSV, crossResourceRulesCSV, kindShortnamesCSV string
 	flags := flag.NewFlagSet(progName, flag.ExitOnError)
 	var buf bytes.Buffer
 	flags.SetOutput(&buf)
@@ -63,21 +384,103 @@ func FromFlags(progName string, args []string) (Config, string, error) {
 	c := Config{}
 	c.Files = []string{}
 
+	flags.StringVar(&aggregatedAPIsCSV, "aggregated-apis", "", "comma-separated list of apiVersions or apiVersion/Kinds served by aggregated API servers, whose resources are skipped when no schema is found. Replaces the built-in list of metrics APIs, include default to extend it, e.g. default,example.com/v1alpha1")
 	flags.StringVar(&c.KubernetesVersion, "kubernetes-version", "master", "version of Kubernetes to validate against, e.g.: 1.18.0")
+	flags.StringVar(&c.VersionResolution, "version-resolution", "", "how schemas are looked up when there is none for -kubernetes-version: exact, or nearest to also look up the minor then major version, e.g. 1.28 then 1 for 1.28.3 (default exact)")
+	flags.StringVar(&c.FallbackVersion, "fallback-kubernetes-version", "", "version of Kubernetes schemas are looked up for last with -version-resolution nearest, e.g. master")
+	flags.BoolVar(&c.ServerDryRun, "server-dry-run", false, "also submit resources that pass schema validation to the cluster of the current kubeconfig context with a server-side dry-run, failing the resources it rejects, e.g. denied by an admission webhook or a quota. Requires credentials for the cluster, nothing is persisted")
+	flags.StringVar(&c.DryRunContext, "server-dry-run-context", "", "kubeconfig context of the cluster resources are submitted to with -server-dry-run, instead of the current context")
+	flags.StringVar(&c.GitDiff, "git-diff", "", "only validate the files changed since this git revision, as listed by git diff --name-only, e.g. origin/main... for the changes since the merge base of origin/main. Deleted files are skipped. Files and folders given restrict the changes to those below them")
+	flags.BoolVar(&c.Kustomization, "kustomization", false, "validate the files referenced by kustomizations given as arguments, or in folders given as arguments, following nested kustomizations, instead of the kustomizations themselves")
+	flags.BoolVar(&c.Flux, "flux", false, "also validate the manifests applied by the Flux Kustomizations found in the files and folders given, following the Kustomizations found in those. spec.path is read from the git repository holding each Kustomization, sources are not fetched")
+	flags.Var(&aliasesParam, "alias", "apiVersion and kind to look up the schema of resources under when none is found for their own, e.g. old.example.com/v1/Widget=new.example.com/v1/Widget (can be specified multiple times)")
+	flags.BoolVar(&c.ApplyDefaults, "apply-defaults", false, "set the default values declared in schemas on missing fields before validation, as the API server would")
 	flags.Var(&schemaLocationsParam, "schema-location", "override schemas location search path (can be specified multiple times)")
+	flags.StringVar(&c.SchemaCatalog, "schema-catalog", "", "URL or path of a YAML file listing schema locations to search, before those given with -schema-location")
+	flags.StringVar(&c.SchemaLock, "schema-lock", "", "JSON file of the sha256 checksum of the schema of each apiVersion/Kind, and of the documents schemas reference. Resources whose schema does not match, or is not listed, fail validation")
+	flags.BoolVar(&c.UpdateSchemaLock, "update-schema-lock", false, "write the checksums of the schemas used to the -schema-lock file, instead of checking them")
+	flags.StringVar(&c.Profile, "profile", "", "name of the profile of -profiles-file to validate with, adding its schema locations and kinds to skip or reject to the ones given on the command line")
+	flags.StringVar(&c.ProfilesFile, "profiles-file", "", "YAML file of profiles, each with its own schema locations and kinds to skip or reject, e.g. for each cluster manifests are deployed to")
 	flags.StringVar(&skipKindsCSV, "skip", "", "comma-separated list of kinds to ignore")
+	flags.StringVar(&onlyKindsCSV, "only-kinds", "", "comma-separated list of kinds to validate, resources of other kinds are skipped - before the kinds given with -skip")
 	flags.StringVar(&rejectKindsCSV, "reject", "", "comma-separated list of kinds to reject")
+	flags.BoolVar(&c.NoPrereleaseAPIs, "no-prerelease-apis", false, "fail resources using an alpha or beta apiVersion, such as batch/v1beta1, even if they match their schema")
+	flags.StringVar(&kindShortnamesCSV, "kind-shortnames", "", "comma-separated list of shortname=Kind pairs, read as the kind in resources, e.g. the short names of custom resources: wf=Workflow. The short names, plurals and lower-case names of Kubernetes kinds, e.g. deploy, are always read as the kind")
+	flags.StringVar(&allowedAPIVersionsCSV, "allowed-apiversions", "", "comma-separated list of Kind=apiVersion pairs: resources of these kinds fail unless they use one of their listed apiVersions, e.g. Deployment=apps/v1,HorizontalPodAutoscaler=autoscaling/v2")
+	flags.StringVar(&allowPrereleaseAPIsCSV, "allow-prerelease-apis", "", "comma-separated list of apiVersions or apiVersion/Kinds allowed with -no-prerelease-apis, e.g. autoscaling/v2beta2,batch/v1beta1/CronJob")
+	flags.StringVar(&c.DefaultNamespace, "default-namespace", "", "namespace to set on namespaced resources that do not have one before validation, as kubectl apply -n would")
+	flags.StringVar(&exitCodesCSV, "exit-codes", "", "comma-separated list of exit codes to use when resources are invalid, in error or skipped, e.g. invalid=2,error=3,skipped=4. The code of the most severe status found is used - error, invalid then skipped. Skipped resources fail the run when given a code")
 	flags.BoolVar(&c.ExitOnError, "exit-on-error", false, "immediately stop execution when the first error is encountered")
+	flags.StringVar(&c.ExecOnComplete, "exec-on-complete", "", "shell command to run once validation is complete, with the results in the environment variables KUBECONFORM_SUCCESS, KUBECONFORM_VALID, KUBECONFORM_INVALID, KUBECONFORM_ERRORS and KUBECONFORM_SKIPPED")
+	flags.BoolVar(&c.FailOnExecError, "fail-on-exec-error", false, "fail if the -exec-on-complete command fails")
+	flags.BoolVar(&c.FailOnMissingFields, "fail-on-missing-fields", false, "fail validation of resources missing a field selected with -only-fields instead of passing it")
+	flags.BoolVar(&c.FailOnNoFiles, "fail-on-no-files", false, "fail if no files or resources were found to validate")
+	flags.BoolVar(&c.FailOnSkipped, "fail-on-skipped", false, "fail if any resource is skipped, e.g. because of a missing schema or -skip")
+	flags.Float64Var(&c.PassThreshold, "pass-threshold", 0, "pass if at least this ratio of the resources validated are valid, e.g. 0.95, even if others are invalid or in error - 0 to fail on any invalid resource or error. Skipped resources are not counted, unless with -pass-threshold-skipped")
+	flags.BoolVar(&c.ThresholdSkipped, "pass-threshold-skipped", false, "count skipped resources in the ratio of -pass-threshold, as not valid")
+	flags.StringVar(&ignoreKeysCSV, "ignore-keys", "", "comma-separated list of paths to remove from resources before validation, e.g. status,metadata.managedFields,spec.containers.*.image")
 	flags.BoolVar(&c.IgnoreMissingSchemas, "ignore-missing-schemas", false, "skip files with missing schemas instead of failing")
+	flags.Var(&ignoreErrorPatterns, "ignore-error-pattern", "regular expression matching schema validation errors to ignore (can be specified multiple times)")
 	flags.Var(&ignoreFilenamePatterns, "ignore-filename-pattern", "regular expression specifying paths to ignore (can be specified multiple times)")
-	flags.BoolVar(&c.Summary, "summary", false, "print a summary at the end (ignored for junit output)")
+	flags.BoolVar(&c.Decompress, "decompress", false, "read gzip-compressed manifests, and validate the .yaml.gz, .yml.gz and .json.gz files found in folders")
+	flags.BoolVar(&c.SkipOwned, "skip-owned", false, "skip resources with ownerReferences, such as ReplicaSets and Pods created by controllers")
+	flags.BoolVar(&c.SkipUnreadable, "skip-unreadable", false, "report files and folders that can not be opened as skipped instead of failing")
+	flags.BoolVar(&c.StatusLine, "status-line", false, "print a machine-readable count of results to stderr at the end, e.g. valid=1 invalid=0 error=0 skipped=0")
+	flags.BoolVar(&c.Summary, "summary", false, "print a summary at the end (ignored for csv and junit output, and with -output-template), and the reason for a non-zero exit code to stderr")
+	flags.StringVar(&c.ListMode, "list-mode", "", "how resources of kind List are handled - empty or expand to validate their items as separate resources (default), validate to validate Lists as they are against the schema of List, or skip to skip them")
+	flags.StringVar(&c.LogFormat, "log-format", "", "format of the messages logged to stderr about the operation of kubeconform, separate from the results - text (default), json or logfmt")
+	flags.StringVar(&c.LogLevel, "log-level", "info", "log level - debug, info, warning, error. debug logs each step of the validation of every resource to stderr: signature, schema lookups and outcome")
+	flags.Int64Var(&c.MaxFileSize, "max-file-size", 0, "maximum size in bytes of files to validate, bigger files fail validation without being read, and of their decompressed content with -decompress (0 for no limit)")
+	flags.BoolVar(&c.CheckSizeLimit, "check-size-limit", false, "fail validation of resources bigger than -size-limit, serialised as JSON, as the API server would reject them")
+	flags.Int64Var(&c.SizeLimit, "size-limit", 0, "maximum size in bytes of resources with -check-size-limit (0 for 1572864, the etcd request size limit)")
+	flags.BoolVar(&c.NormalizeNewlines, "normalize-newlines", false, "replace Windows line endings (CRLF) with LF before parsing")
+	flags.BoolVar(&c.Envsubst, "envsubst", false, "substitute environment variables written $VAR or ${VAR} in files and stdin before parsing, as envsubst does")
+	flags.StringVar(&c.EnvsubstUndefined, "envsubst-undefined", "", "what to do with variables that are not set with -envsubst - empty to replace them with an empty string (default), keep to leave them as they are, or error to fail the file")
 	flags.IntVar(&c.NumberOfWorkers, "n", 4, "number of goroutines to run concurrently")
+	flags.StringVar(&c.ResultsDir, "results-dir", "", "also write the results of each file to a JSON file in this directory, mirroring the tree of the files validated, e.g. results-dir/manifests/app.yaml.json")
+	flags.BoolVar(&c.OTelTraces, "otel-traces", false, "export an OpenTelemetry trace of the run, with a span per resource, to the OTLP/HTTP endpoint set with the OTEL_EXPORTER_OTLP_* environment variables")
+	flags.BoolVar(&c.ShardByKind, "shard-by-kind", false, "have each kind of resource validated by a single worker, instead of by any available worker")
+	flags.IntVar(&c.RegistryMaxFailures, "registry-max-failures", 0, "stop querying a schema location after this many consecutive failures, falling through to the next one (0 to disable)")
+	flags.DurationVar(&c.RegistryCooldown, "registry-cooldown", 30*time.Second, "time after which a schema location disabled by -registry-max-failures is queried again")
+	flags.StringVar(&c.SSABase, "ssa-base", "", "file or folder of live objects, e.g. from kubectl get -o yaml. Resources are merged into the live object with the same apiVersion, kind, namespace and name before validation, as server-side apply would")
 	flags.BoolVar(&c.Strict, "strict", false, "disallow additional properties not in schema")
-	flags.StringVar(&c.OutputFormat, "output", "text", "output format - json, junit, tap, text")
-	flags.BoolVar(&c.Verbose, "verbose", false, "print results for all resources (ignored for tap and junit output)")
+	flags.BoolVar(&c.StrictAggregated, "strict-aggregated", false, "fail resources of aggregated APIs, such as metrics.k8s.io, when no schema is found instead of skipping them")
+	flags.BoolVar(&c.StrictDiff, "strict-diff", false, "validate in strict and non-strict mode, and only report errors specific to strict mode. Invalid resources do not cause a failure")
+	flags.Var(&onlyFieldsParam, "only-fields", "validate only the field at a dot-separated path of resources of a kind against a schema file, instead of the schema of the resource, e.g. Deployment:spec.template.spec.containers=./containers.json (can be specified multiple times)")
+	flags.StringVar(&onlyDocIndexesCSV, "only-doc-index", "", "comma-separated list of indexes of the documents to validate in each file, starting at 0. Other documents are not read, nor counted in the summary")
+	flags.StringVar(&c.OutputFormat, "output", "text", "output format - csv, dots, gitlab, json, junit, syslog, tap, text. dots prints a character per resource, then the failures and the summary")
+	flags.StringVar(&c.DryRunDiff, "dry-run-diff", "", "JSON results of a previous run, written with -output json, to compare the results against. Only resources that started or stopped failing are reported, in the text or json output format, and only newly failing resources fail the run")
+	flags.StringVar(&c.OutputFile, "output-file", "", "write invalid resources and errors to this file in the output format, instead of all results to stdout. Only the summary is printed to stdout, with -summary")
+	flags.StringVar(&c.SyslogAddress, "syslog-address", "", "address of the syslog daemon results are sent to with -output syslog, as network://host:port, e.g. udp://logs.example.com:514 - the local syslog daemon if empty")
+	flags.StringVar(&c.SyslogFacility, "syslog-facility", "", "syslog facility of the messages sent with -output syslog, e.g. daemon or local0 (default user)")
+	flags.StringVar(&c.OutputTemplate, "output-template", "", "Go template for a line of text output per resource, with the fields .File, .Kind, .Name, .Namespace, .APIVersion, .Status and .Msg, e.g. '{{ .Status }} {{ .File }} {{ .Kind }}/{{ .Name }}'")
+	flags.BoolVar(&c.SyntaxOnly, "syntax-only", false, "only check that resources can be parsed and have a kind and apiVersion, without downloading schemas")
+	flags.BoolVar(&c.TolerateDownloadErrors, "tolerate-download-errors", false, "skip resources whose schema could not be downloaded because of a network or server error instead of failing, with a warning. Missing schemas are handled by -ignore-missing-schemas")
+	flags.BoolVar(&c.ValidateImages, "validate-images", false, "check that container images in pod specs are valid image references")
+	flags.BoolVar(&c.RequireImageRegistry, "require-image-registry", false, "with -validate-images, fail container images that do not name their registry, e.g. nginx:1.25 instead of docker.io/library/nginx:1.25")
+	flags.StringVar(&requireLabelsCSV, "require-labels", "", "comma-separated list of label keys every resource must set, e.g. app.kubernetes.io/managed-by")
+	flags.BoolVar(&c.Fix, "fix", false, "add the labels of -fix-labels to the resources of files missing them, and write the files back before validating them")
+	flags.StringVar(&fixLabelsCSV, "fix-labels", "", "comma-separated list of key=value labels added to resources missing them with -fix, e.g. app.kubernetes.io/managed-by=platform")
+	flags.StringVar(&crossResourceRulesCSV, "cross-resource-rules", "", "comma-separated list of rules checking references between the resources validated, once all are validated - service-selector, ingress-backend, hpa-target, pdb-selector. Resources are kept in memory until then")
+	flags.BoolVar(&c.ValidateLabelSyntax, "validate-label-syntax", false, "check metadata labels and annotations against Kubernetes' key and value syntax rules")
+	flags.BoolVar(&c.ValidateSecrets, "validate-secrets", false, "check that the data of Secrets is base64-encoded, and that the keys of their data and stringData are valid")
+	flags.BoolVar(&c.Verbose, "verbose", false, "print results for all resources, with the Kubernetes version of the schema used and the time spent downloading it and validating (ignored for csv, tap and junit output)")
+	flags.BoolVar(&c.Watch, "watch", false, "after validating, keep watching the files and folders given and validate files again when they are added or modified, until interrupted")
+	flags.BoolVar(&c.WarnDeprecatedFields, "warn-deprecated-fields", false, "log a warning for every field set in a resource whose schema marks it as deprecated")
+	flags.BoolVar(&c.WarnSchemaOverrides, "warn-schema-overrides", false, "warn when a schema can be found in more than one schema location, and print which one is used. Queries all schema locations once per kind")
+	flags.StringVar(&c.NoProxy, "no-proxy", "", "comma-separated list of hosts, domains and IP ranges to connect to without the proxy set with HTTPS_PROXY or HTTP_PROXY, overriding NO_PROXY. localhost and loopback addresses are never proxied")
 	flags.BoolVar(&c.SkipTLS, "insecure-skip-tls-verify", false, "disable verification of the server's SSL certificate. This will make your HTTPS connections insecure")
+	flags.StringVar(&c.RevisionAnnotation, "schema-revision-annotation", "", "annotation of resources selecting the revision of their schema, e.g. schema-revision. Its value is set as {{ .SchemaRevision }} in schema locations, and is empty for resources without the annotation")
+	flags.StringVar(&c.SchemaProvenanceFile, "schema-provenance-file", "", "write the URL, API version, kind and sha256 checksum of every schema used, and of the documents they reference, to this file as JSON")
+	flags.StringVar(&c.UnknownKindsFile, "unknown-kinds-file", "", "write the API version and kind of every resource for which no schema was found to this file, as YAML if its extension is .yaml or .yml, as JSON otherwise")
+	flags.StringVar(&c.SchemaCacheURL, "schema-cache-url", "", "URL of an HTTP cache for schemas shared between runs, queried with GET and filled with PUT")
 	flags.StringVar(&c.Cache, "cache", "", "cache schemas downloaded via HTTP to this folder")
 	flags.StringVar(&c.CPUProfileFile, "cpu-prof", "", "debug - log CPU profiling to file")
+	flags.StringVar(&c.StdinFormat, "stdin-format", "", "how to interpret data piped to stdin - yaml (manifests), filelist (one path per line). Autodetected if unset")
+	flags.Var(&patchesParam, "patch", "JSON Patch file to apply to resources of a kind before validation, e.g. Deployment=./patch.json (can be specified multiple times)")
+	flags.BoolVar(&c.Preflight, "preflight", false, "read files once before validating them, and print the number of files, resources and distinct kinds found to stderr")
+	flags.BoolVar(&c.PrintCanonical, "print-canonical", false, "print each valid resource as YAML with sorted keys and consistent indentation (text output only)")
+	flags.BoolVar(&c.PrintConfig, "print-config", false, "print the effective configuration as YAML and exit")
 	flags.BoolVar(&c.Help, "h", false, "show help information")
 	flags.BoolVar(&c.Version, "v", false, "show version information")
 	flags.Usage = func() {
@@ -88,14 +491,229 @@ func FromFlags(progName string, args []string) (Config, string, error) {
 	err := flags.Parse(args)
 
 	c.SkipKinds = splitCSV(skipKindsCSV)
+	if onlyKindsCSV != "" {
+		c.OnlyKinds = splitCSV(onlyKindsCSV)
+	}
 	c.RejectKinds = splitCSV(rejectKindsCSV)
 	c.IgnoreFilenamePatterns = ignoreFilenamePatterns
-	c.SchemaLocations = schemaLocationsParam
+	c.IgnoreErrorPatterns = ignoreErrorPatterns
+	c.IgnoreKeys = splitList(ignoreKeysCSV)
+	c.AggregatedAPIs = splitList(aggregatedAPIsCSV)
+	c.AllowPrereleaseAPIs = splitList(allowPrereleaseAPIsCSV)
+	c.RequireLabels = splitList(requireLabelsCSV)
+	c.CrossResourceRules = splitList(crossResourceRulesCSV)
 	c.Files = flags.Args()
 
+	var locationsErr error
+	if c.SchemaLocations, c.SchemaLocationTimeouts, locationsErr = parseSchemaLocations(schemaLocationsParam); locationsErr != nil && err == nil {
+		err = locationsErr
+	}
+
+	var apiVersionsErr error
+	if c.AllowedAPIVersions, apiVersionsErr = parseAllowedAPIVersions(allowedAPIVersionsCSV); apiVersionsErr != nil && err == nil {
+		err = apiVersionsErr
+	}
+
+	var shortnamesErr error
+	if c.KindShortnames, shortnamesErr = parseKindShortnames(kindShortnamesCSV); shortnamesErr != nil && err == nil {
+		err = shortnamesErr
+	}
+
+	var patchErr error
+	if c.Patches, patchErr = parsePatches(patchesParam); patchErr != nil && err == nil {
+		err = patchErr
+	}
+
+	var aliasErr error
+	if c.Aliases, aliasErr = parseAliases(aliasesParam); aliasErr != nil && err == nil {
+		err = aliasErr
+	}
+
+	var fieldsErr error
+	if c.OnlyFields, fieldsErr = parseOnlyFields(onlyFieldsParam); fieldsErr != nil && err == nil {
+		err = fieldsErr
+	}
+
+	var exitCodesErr error
+	if c.ExitCodes, exitCodesErr = parseExitCodes(exitCodesCSV); exitCodesErr != nil && err == nil {
+		err = exitCodesErr
+	}
+
+	var indexErr error
+	if c.OnlyDocIndexes, indexErr = parseIndexes(onlyDocIndexesCSV); indexErr != nil && err == nil {
+		err = indexErr
+	}
+
+	var fixLabelsErr error
+	if c.FixLabels, fixLabelsErr = parseLabels(fixLabelsCSV); fixLabelsErr != nil && err == nil {
+		err = fixLabelsErr
+	}
+
 	if c.Help {
 		flags.Usage()
 	}
 
+	if err == nil && c.OutputTemplate != "" && c.OutputFormat != "text" {
+		err = fmt.Errorf("-output-template can only be used with the text output format")
+	}
+
+	if err == nil && c.PrintCanonical && (c.OutputFormat != "text" || c.OutputTemplate != "") {
+		err = fmt.Errorf("-print-canonical can only be used with the text output format")
+	}
+
+	if err == nil && c.OutputFile != "" && (c.OutputTemplate != "" || c.PrintCanonical) {
+		err = fmt.Errorf("-output-file can not be used with -output-template or -print-canonical")
+	}
+
+	if err == nil && c.DryRunDiff != "" && (c.OutputFormat != "text" && c.OutputFormat != "json") {
+		err = fmt.Errorf("-dry-run-diff can only be used with the text or json output formats")
+	}
+
+	if err == nil && c.DryRunDiff != "" && (c.OutputFile != "" || c.OutputTemplate != "" || c.PrintCanonical || c.Watch) {
+		err = fmt.Errorf("-dry-run-diff can not be used with -output-file, -output-template, -print-canonical or -watch")
+	}
+
+	if err == nil && c.OutputFile != "" && c.OutputFormat == "syslog" {
+		err = fmt.Errorf("-output-file can not be used with the syslog output format")
+	}
+
+	if err == nil && (c.SyslogAddress != "" || c.SyslogFacility != "") && c.OutputFormat != "syslog" {
+		err = fmt.Errorf("-syslog-address and -syslog-facility require -output syslog")
+	}
+
+	if err == nil && c.Watch && (len(c.Files) == 0 || (len(c.Files) == 1 && c.Files[0] == "-")) {
+		err = fmt.Errorf("-watch requires files or folders to validate")
+	}
+
+	if err == nil && c.Kustomization && (len(c.Files) == 0 || (len(c.Files) == 1 && c.Files[0] == "-")) {
+		err = fmt.Errorf("-kustomization requires files or folders to validate")
+	}
+
+	if err == nil && c.Flux && (len(c.Files) == 0 || (len(c.Files) == 1 && c.Files[0] == "-")) {
+		err = fmt.Errorf("-flux requires files or folders to validate")
+	}
+
+	if err == nil && c.GitDiff != "" && len(c.Files) == 1 && c.Files[0] == "-" {
+		err = fmt.Errorf("-git-diff can not be used with stdin")
+	}
+
+	if err == nil && (c.PassThreshold < 0 || c.PassThreshold > 1) {
+		err = fmt.Errorf("invalid value for -pass-threshold: %g, must be between 0 and 1", c.PassThreshold)
+	}
+
+	if err == nil && c.ThresholdSkipped && c.PassThreshold == 0 {
+		err = fmt.Errorf("-pass-threshold-skipped requires -pass-threshold")
+	}
+
+	if err == nil && c.PassThreshold > 0 && (c.DryRunDiff != "" || c.Watch) {
+		err = fmt.Errorf("-pass-threshold can not be used with -dry-run-diff or -watch")
+	}
+
+	if err == nil && c.DryRunContext != "" && !c.ServerDryRun {
+		err = fmt.Errorf("-server-dry-run-context requires -server-dry-run")
+	}
+
+	if err == nil && c.GitDiff != "" && c.Kustomization {
+		err = fmt.Errorf("-git-diff can not be used with -kustomization")
+	}
+
+	if err == nil && c.Flux && (c.GitDiff != "" || c.Kustomization) {
+		err = fmt.Errorf("-flux can not be used with -git-diff or -kustomization")
+	}
+
+	if err == nil && c.Preflight && (len(c.Files) == 0 || (len(c.Files) == 1 && c.Files[0] == "-")) {
+		err = fmt.Errorf("-preflight requires files or folders to validate")
+	}
+
+	if err == nil && c.Fix && len(c.FixLabels) == 0 {
+		err = fmt.Errorf("-fix requires -fix-labels")
+	}
+
+	if err == nil && len(c.FixLabels) > 0 && !c.Fix {
+		err = fmt.Errorf("-fix-labels requires -fix")
+	}
+
+	if err == nil && c.Fix && (len(c.Files) == 0 || (len(c.Files) == 1 && c.Files[0] == "-")) {
+		err = fmt.Errorf("-fix requires files or folders to validate")
+	}
+
+	if err == nil && c.Profile != "" && c.ProfilesFile == "" {
+		err = fmt.Errorf("-profile requires -profiles-file")
+	}
+
+	if err == nil && len(c.AllowPrereleaseAPIs) > 0 && !c.NoPrereleaseAPIs {
+		err = fmt.Errorf("-allow-prerelease-apis requires -no-prerelease-apis")
+	}
+
+	if err == nil && c.EnvsubstUndefined != "" && c.EnvsubstUndefined != "empty" && c.EnvsubstUndefined != "keep" && c.EnvsubstUndefined != "error" {
+		err = fmt.Errorf("-envsubst-undefined must be empty, keep or error")
+	}
+
+	if err == nil && c.EnvsubstUndefined != "" && !c.Envsubst {
+		err = fmt.Errorf("-envsubst-undefined requires -envsubst")
+	}
+
+	if err == nil && c.SizeLimit < 0 {
+		err = fmt.Errorf("-size-limit must not be negative")
+	}
+
+	if err == nil && c.UpdateSchemaLock && c.SchemaLock == "" {
+		err = fmt.Errorf("-update-schema-lock requires -schema-lock")
+	}
+
+	if err == nil && c.RequireImageRegistry && !c.ValidateImages {
+		err = fmt.Errorf("-require-image-registry requires -validate-images")
+	}
+
+	if err == nil && c.Watch && c.OutputFile != "" {
+		err = fmt.Errorf("-watch can not be used with -output-file")
+	}
+
+	if err == nil && c.Watch && c.GitDiff != "" {
+		err = fmt.Errorf("-watch can not be used with -git-diff")
+	}
+
+	if err == nil && c.Watch && c.ResultsDir != "" {
+		err = fmt.Errorf("-watch can not be used with -results-dir")
+	}
+
+	if err == nil && c.Watch && c.OTelTraces {
+		err = fmt.Errorf("-watch can not be used with -otel-traces")
+	}
+
+	if err == nil && c.Watch && len(c.CrossResourceRules) > 0 {
+		err = fmt.Errorf("-watch can not be used with -cross-resource-rules")
+	}
+
+	for _, rule := range c.CrossResourceRules {
+		if err == nil && rule != "service-selector" && rule != "ingress-backend" && rule != "hpa-target" && rule != "pdb-selector" {
+			err = fmt.Errorf("invalid value for -cross-resource-rules: %s, must be service-selector, ingress-backend, hpa-target or pdb-selector", rule)
+		}
+	}
+
+	if err == nil && c.VersionResolution != "" && c.VersionResolution != "exact" && c.VersionResolution != "nearest" {
+		err = fmt.Errorf("invalid value for -version-resolution: %s, must be exact or nearest", c.VersionResolution)
+	}
+
+	if err == nil && c.FallbackVersion != "" && c.VersionResolution != "nearest" {
+		err = fmt.Errorf("-fallback-kubernetes-version requires -version-resolution nearest")
+	}
+
+	if err == nil && c.ListMode != "" && c.ListMode != "expand" && c.ListMode != "validate" && c.ListMode != "skip" {
+		err = fmt.Errorf("invalid value for -list-mode: %s, must be expand, validate or skip", c.ListMode)
+	}
+
+	if err == nil && c.LogLevel != "debug" && c.LogLevel != "info" && c.LogLevel != "warning" && c.LogLevel != "error" {
+		err = fmt.Errorf("invalid value for -log-level: %s, must be debug, info, warning or error", c.LogLevel)
+	}
+
+	if err == nil && c.LogFormat != "" && c.LogFormat != "text" && c.LogFormat != "json" && c.LogFormat != "logfmt" {
+		err = fmt.Errorf("invalid value for -log-format: %s, must be text, json or logfmt", c.LogFormat)
+	}
+
+	if err == nil && c.StdinFormat != "" && c.StdinFormat != "yaml" && c.StdinFormat != "filelist" {
+		err = fmt.Errorf("invalid value for -stdin-format: %s, must be yaml or filelist", c.StdinFormat)
+	}
+
 	return c, buf.String(), err
 }
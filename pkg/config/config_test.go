@@ -2,7 +2,9 @@ package config
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSkipKindMaps(t *testing.T) {
@@ -51,6 +53,8 @@ func TestFromFlags(t *testing.T) {
 				Files:             []string{},
 				KubernetesVersion: "master",
 				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
 				OutputFormat:      "text",
 				SchemaLocations:   nil,
 				SkipKinds:         map[string]struct{}{},
@@ -64,6 +68,8 @@ func TestFromFlags(t *testing.T) {
 				Help:              true,
 				KubernetesVersion: "master",
 				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
 				OutputFormat:      "text",
 				SchemaLocations:   nil,
 				SkipKinds:         map[string]struct{}{},
@@ -77,6 +83,8 @@ func TestFromFlags(t *testing.T) {
 				Version:           true,
 				KubernetesVersion: "master",
 				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
 				OutputFormat:      "text",
 				SchemaLocations:   nil,
 				SkipKinds:         map[string]struct{}{},
@@ -89,18 +97,37 @@ func TestFromFlags(t *testing.T) {
 				Files:             []string{},
 				KubernetesVersion: "master",
 				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
 				OutputFormat:      "text",
 				SchemaLocations:   nil,
 				SkipKinds:         map[string]struct{}{"a": {}, "b": {}, "c": {}},
 				RejectKinds:       map[string]struct{}{},
 			},
 		},
+		{
+			[]string{"-stdin-format", "filelist"},
+			Config{
+				Files:             []string{},
+				KubernetesVersion: "master",
+				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
+				OutputFormat:      "text",
+				SchemaLocations:   nil,
+				SkipKinds:         map[string]struct{}{},
+				RejectKinds:       map[string]struct{}{},
+				StdinFormat:       "filelist",
+			},
+		},
 		{
 			[]string{"-summary", "-verbose", "file1", "file2"},
 			Config{
 				Files:             []string{"file1", "file2"},
 				KubernetesVersion: "master",
 				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
 				OutputFormat:      "text",
 				SchemaLocations:   nil,
 				SkipKinds:         map[string]struct{}{},
@@ -110,16 +137,22 @@ func TestFromFlags(t *testing.T) {
 			},
 		},
 		{
-			[]string{"-cache", "cache", "-ignore-missing-schemas", "-kubernetes-version", "1.16.0", "-n", "2", "-output", "json",
+			[]string{"-cache", "cache", "-fail-on-no-files", "-ignore-keys", "status,metadata.managedFields", "-ignore-missing-schemas", "-kubernetes-version", "1.16.0", "-n", "2", "-output", "json",
+				"-patch", "Deployment=a.json", "-patch", "Deployment=b.json", "-patch", "Service=c.json",
 				"-schema-location", "folder", "-schema-location", "anotherfolder", "-skip", "kinda,kindb", "-strict",
 				"-reject", "kindc,kindd", "-summary", "-verbose", "file1", "file2"},
 			Config{
 				Cache:                "cache",
+				FailOnNoFiles:        true,
 				Files:                []string{"file1", "file2"},
+				IgnoreKeys:           []string{"status", "metadata.managedFields"},
 				IgnoreMissingSchemas: true,
 				KubernetesVersion:    "1.16.0",
 				NumberOfWorkers:      2,
+				RegistryCooldown:     30 * time.Second,
+				LogLevel:             "info",
 				OutputFormat:         "json",
+				Patches:              map[string][]string{"Deployment": {"a.json", "b.json"}, "Service": {"c.json"}},
 				SchemaLocations:      []string{"folder", "anotherfolder"},
 				SkipKinds:            map[string]struct{}{"kinda": {}, "kindb": {}},
 				RejectKinds:          map[string]struct{}{"kindc": {}, "kindd": {}},
@@ -128,6 +161,105 @@ func TestFromFlags(t *testing.T) {
 				Verbose:              true,
 			},
 		},
+		{
+			[]string{"-only-fields", "Deployment:spec.template.spec.containers=containers.json", "-only-fields", "Deployment:metadata.labels=labels.json", "-fail-on-missing-fields", "file1"},
+			Config{
+				FailOnMissingFields: true,
+				Files:               []string{"file1"},
+				KubernetesVersion:   "master",
+				NumberOfWorkers:     4,
+				OnlyFields:          map[string]map[string]string{"Deployment": {"spec.template.spec.containers": "containers.json", "metadata.labels": "labels.json"}},
+				RegistryCooldown:    30 * time.Second,
+				LogLevel:            "info",
+				OutputFormat:        "text",
+				SkipKinds:           map[string]struct{}{},
+				RejectKinds:         map[string]struct{}{},
+			},
+		},
+		{
+			[]string{"-fail-on-skipped", "file1"},
+			Config{
+				FailOnSkipped:     true,
+				Files:             []string{"file1"},
+				KubernetesVersion: "master",
+				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
+				OutputFormat:      "text",
+				SkipKinds:         map[string]struct{}{},
+				RejectKinds:       map[string]struct{}{},
+			},
+		},
+		{
+			[]string{"-log-level", "debug", "file1"},
+			Config{
+				Files:             []string{"file1"},
+				KubernetesVersion: "master",
+				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "debug",
+				OutputFormat:      "text",
+				SkipKinds:         map[string]struct{}{},
+				RejectKinds:       map[string]struct{}{},
+			},
+		},
+		{
+			[]string{"-aggregated-apis", "default,example.com/v1alpha1", "-strict-aggregated", "file1"},
+			Config{
+				AggregatedAPIs:    []string{"default", "example.com/v1alpha1"},
+				Files:             []string{"file1"},
+				KubernetesVersion: "master",
+				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
+				OutputFormat:      "text",
+				SkipKinds:         map[string]struct{}{},
+				RejectKinds:       map[string]struct{}{},
+				StrictAggregated:  true,
+			},
+		},
+		{
+			[]string{"-ssa-base", "live.yaml", "file1"},
+			Config{
+				Files:             []string{"file1"},
+				KubernetesVersion: "master",
+				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
+				OutputFormat:      "text",
+				SkipKinds:         map[string]struct{}{},
+				RejectKinds:       map[string]struct{}{},
+				SSABase:           "live.yaml",
+			},
+		},
+		{
+			[]string{"-skip-owned", "file1"},
+			Config{
+				Files:             []string{"file1"},
+				KubernetesVersion: "master",
+				NumberOfWorkers:   4,
+				RegistryCooldown:  30 * time.Second,
+				LogLevel:          "info",
+				OutputFormat:      "text",
+				SkipKinds:         map[string]struct{}{},
+				SkipOwned:         true,
+				RejectKinds:       map[string]struct{}{},
+			},
+		},
+		{
+			[]string{"-tolerate-download-errors", "file1"},
+			Config{
+				Files:                  []string{"file1"},
+				KubernetesVersion:      "master",
+				NumberOfWorkers:        4,
+				RegistryCooldown:       30 * time.Second,
+				LogLevel:               "info",
+				OutputFormat:           "text",
+				SkipKinds:              map[string]struct{}{},
+				RejectKinds:            map[string]struct{}{},
+				TolerateDownloadErrors: true,
+			},
+		},
 	}
 
 	for i, testCase := range testCases {
@@ -137,3 +269,473 @@ func TestFromFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestFromFlagsInvalidStdinFormat(t *testing.T) {
+	if _, _, err := FromFlags("kubeconform", []string{"-stdin-format", "xml"}); err == nil {
+		t.Errorf("expected an error for an invalid -stdin-format value")
+	}
+}
+
+func TestFromFlagsInvalidLogLevel(t *testing.T) {
+	if _, _, err := FromFlags("kubeconform", []string{"-log-level", "trace"}); err == nil {
+		t.Errorf("expected an error for an invalid -log-level value")
+	}
+}
+
+func TestYAML(t *testing.T) {
+	cfg, _, _ := FromFlags("kubeconform", []string{"-skip", "b,a", "-registry-cooldown", "1m", "-print-config", "file1"})
+	got, err := cfg.YAML()
+	if err != nil {
+		t.Fatalf("failed marshalling configuration: %s", err)
+	}
+
+	for _, expected := range []string{"skip:\n- a\n- b\n", "reject: []\n", "registry-cooldown: 1m0s\n", "files:\n- file1\n"} {
+		if !strings.Contains(string(got), expected) {
+			t.Errorf("expected configuration to contain %q, got:\n%s", expected, got)
+		}
+	}
+
+	if strings.Contains(string(got), "print-config") {
+		t.Errorf("expected print-config to be omitted, got:\n%s", got)
+	}
+}
+
+func TestFromFlagsOutputTemplate(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-output-template", "{{ .File }}"})
+	if err != nil || cfg.OutputTemplate != "{{ .File }}" {
+		t.Errorf("expected output template to be set, got %q, %v", cfg.OutputTemplate, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-output", "json", "-output-template", "{{ .File }}"}); err == nil {
+		t.Errorf("expected an error when using -output-template with -output json")
+	}
+}
+
+func TestFromFlagsPrintCanonical(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-print-canonical"})
+	if err != nil || !cfg.PrintCanonical {
+		t.Errorf("expected -print-canonical to be set, got %v, %v", cfg.PrintCanonical, err)
+	}
+
+	for _, args := range [][]string{
+		{"-output", "json", "-print-canonical"},
+		{"-output-template", "{{ .File }}", "-print-canonical"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsOutputFile(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-output", "json", "-output-file", "failures.json", "-summary"})
+	if err != nil || cfg.OutputFile != "failures.json" {
+		t.Errorf("expected output file to be set, got %q, %v", cfg.OutputFile, err)
+	}
+
+	for _, args := range [][]string{
+		{"-output-file", "failures.txt", "-print-canonical"},
+		{"-output-file", "failures.txt", "-output-template", "{{ .File }}"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsSizeLimit(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-check-size-limit", "-size-limit", "262144"})
+	if err != nil || !cfg.CheckSizeLimit || cfg.SizeLimit != 262144 {
+		t.Errorf("expected size limit of 262144, got %t, %d, %v", cfg.CheckSizeLimit, cfg.SizeLimit, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-check-size-limit", "-size-limit", "-1"}); err == nil {
+		t.Errorf("expected an error for a negative -size-limit")
+	}
+}
+
+func TestFromFlagsSchemaLock(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-schema-lock", "schemas.lock", "-update-schema-lock"})
+	if err != nil || cfg.SchemaLock != "schemas.lock" || !cfg.UpdateSchemaLock {
+		t.Errorf("expected schema lock to be updated, got %q, %v, %v", cfg.SchemaLock, cfg.UpdateSchemaLock, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-update-schema-lock"}); err == nil {
+		t.Errorf("expected an error for -update-schema-lock without -schema-lock")
+	}
+}
+
+func TestFromFlagsRequireImageRegistry(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-validate-images", "-require-image-registry"})
+	if err != nil || !cfg.ValidateImages || !cfg.RequireImageRegistry {
+		t.Errorf("expected image registries to be required, got %v, %v, %v", cfg.ValidateImages, cfg.RequireImageRegistry, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-require-image-registry"}); err == nil {
+		t.Errorf("expected an error for -require-image-registry without -validate-images")
+	}
+}
+
+func TestFromFlagsPrereleaseAPIs(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-no-prerelease-apis", "-allow-prerelease-apis", "autoscaling/v2beta2,batch/v1beta1/CronJob"})
+	if err != nil || !cfg.NoPrereleaseAPIs || !reflect.DeepEqual(cfg.AllowPrereleaseAPIs, []string{"autoscaling/v2beta2", "batch/v1beta1/CronJob"}) {
+		t.Errorf("expected prerelease APIs to be rejected with 2 exceptions, got %v, %v, %v", cfg.NoPrereleaseAPIs, cfg.AllowPrereleaseAPIs, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-allow-prerelease-apis", "batch/v1beta1"}); err == nil {
+		t.Errorf("expected an error for -allow-prerelease-apis without -no-prerelease-apis")
+	}
+}
+
+func TestFromFlagsKustomization(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-kustomization", "overlays/production"})
+	if err != nil || !cfg.Kustomization {
+		t.Errorf("expected kustomizations to be followed, got %v, %v", cfg.Kustomization, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-kustomization"}); err == nil {
+		t.Errorf("expected an error for -kustomization without files")
+	}
+}
+
+func TestFromFlagsFlux(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-flux", "clusters/production"})
+	if err != nil || !cfg.Flux {
+		t.Errorf("expected Flux Kustomizations to be followed, got %v, %v", cfg.Flux, err)
+	}
+
+	for _, args := range [][]string{
+		{"-flux"},
+		{"-flux", "-"},
+		{"-flux", "-kustomization", "clusters/production"},
+		{"-flux", "-git-diff", "origin/main", "clusters/production"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsDryRunDiff(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-dry-run-diff", "previous.json", "-output", "json"})
+	if err != nil || cfg.DryRunDiff != "previous.json" {
+		t.Errorf("expected results to be compared to previous.json, got %q, %v", cfg.DryRunDiff, err)
+	}
+
+	for _, args := range [][]string{
+		{"-dry-run-diff", "previous.json", "-output", "junit"},
+		{"-dry-run-diff", "previous.json", "-output-file", "failures.json"},
+		{"-dry-run-diff", "previous.json", "-watch", "manifests/"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsEnvsubst(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-envsubst", "-envsubst-undefined", "error"})
+	if err != nil || !cfg.Envsubst || cfg.EnvsubstUndefined != "error" {
+		t.Errorf("expected variables to be substituted, failing on undefined ones, got %v, %q, %v", cfg.Envsubst, cfg.EnvsubstUndefined, err)
+	}
+
+	for _, args := range [][]string{
+		{"-envsubst", "-envsubst-undefined", "fail"},
+		{"-envsubst-undefined", "keep"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsSyslog(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-output", "syslog", "-syslog-address", "udp://localhost:514", "-syslog-facility", "local0"})
+	if err != nil || cfg.OutputFormat != "syslog" || cfg.SyslogAddress != "udp://localhost:514" || cfg.SyslogFacility != "local0" {
+		t.Errorf("expected results to be sent to syslog, got %q, %q, %q, %v", cfg.OutputFormat, cfg.SyslogAddress, cfg.SyslogFacility, err)
+	}
+
+	for _, args := range [][]string{
+		{"-output", "syslog", "-output-file", "failures.log"},
+		{"-syslog-facility", "local0"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsPreflight(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-preflight", "manifests/"})
+	if err != nil || !cfg.Preflight {
+		t.Errorf("expected resources to be counted before validation, got %v, %v", cfg.Preflight, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-preflight", "-"}); err == nil {
+		t.Errorf("expected an error for -preflight with stdin")
+	}
+}
+
+func TestFromFlagsFixLabels(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-require-labels", "app.kubernetes.io/managed-by", "-fix", "-fix-labels", "app.kubernetes.io/managed-by=platform,tier=", "manifests/"})
+	if err != nil || !cfg.Fix || !reflect.DeepEqual(cfg.RequireLabels, []string{"app.kubernetes.io/managed-by"}) ||
+		!reflect.DeepEqual(cfg.FixLabels, map[string]string{"app.kubernetes.io/managed-by": "platform", "tier": ""}) {
+		t.Errorf("expected labels to be required and fixed, got %v, %v, %v, %v", cfg.RequireLabels, cfg.Fix, cfg.FixLabels, err)
+	}
+
+	for _, args := range [][]string{
+		{"-fix", "manifests/"},
+		{"-fix-labels", "tier=backend", "manifests/"},
+		{"-fix", "-fix-labels", "tier", "manifests/"},
+		{"-fix", "-fix-labels", "tier=backend"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsSchemaLocationTimeouts(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{
+		"-schema-location", "timeout=2s+https://mirror.example.com/{{ .ResourceKind }}.json",
+		"-schema-location", "timeout=1m+draft-07+https://schemas.example.com/{{ .ResourceKind }}.json",
+		"-schema-location", "default",
+		"file1",
+	})
+	expectLocations := []string{"https://mirror.example.com/{{ .ResourceKind }}.json", "draft-07+https://schemas.example.com/{{ .ResourceKind }}.json", "default"}
+	expectTimeouts := map[string]time.Duration{
+		"https://mirror.example.com/{{ .ResourceKind }}.json":           2 * time.Second,
+		"draft-07+https://schemas.example.com/{{ .ResourceKind }}.json": time.Minute,
+	}
+	if err != nil || !reflect.DeepEqual(cfg.SchemaLocations, expectLocations) || !reflect.DeepEqual(cfg.SchemaLocationTimeouts, expectTimeouts) {
+		t.Errorf("expected timeouts to be parsed from schema locations, got %v, %v, %v", cfg.SchemaLocations, cfg.SchemaLocationTimeouts, err)
+	}
+
+	for _, location := range []string{"timeout=2s", "timeout=2s+", "timeout=fast+default", "timeout=-1s+default", "timeout=0s+default"} {
+		if _, _, err := FromFlags("kubeconform", []string{"-schema-location", location, "file1"}); err == nil {
+			t.Errorf("expected an error for schema location %s", location)
+		}
+	}
+}
+
+func TestFromFlagsAllowedAPIVersions(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-allowed-apiversions", "Deployment=apps/v1,HorizontalPodAutoscaler=autoscaling/v2,HorizontalPodAutoscaler=autoscaling/v1", "file1"})
+	expect := map[string][]string{"Deployment": {"apps/v1"}, "HorizontalPodAutoscaler": {"autoscaling/v2", "autoscaling/v1"}}
+	if err != nil || !reflect.DeepEqual(cfg.AllowedAPIVersions, expect) {
+		t.Errorf("expected allowed apiVersions %v, got %v, %v", expect, cfg.AllowedAPIVersions, err)
+	}
+
+	for _, csv := range []string{"Deployment", "Deployment=", "=apps/v1"} {
+		if _, _, err := FromFlags("kubeconform", []string{"-allowed-apiversions", csv, "file1"}); err == nil {
+			t.Errorf("expected an error for -allowed-apiversions %s", csv)
+		}
+	}
+}
+
+func TestFromFlagsOnlyKinds(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-only-kinds", "Deployment,Service", "-skip", "Service", "file1"})
+	if expect := map[string]struct{}{"Deployment": {}, "Service": {}}; err != nil || !reflect.DeepEqual(cfg.OnlyKinds, expect) {
+		t.Errorf("expected kinds to validate %v, got %v, %v", expect, cfg.OnlyKinds, err)
+	}
+	if expect := map[string]struct{}{"Service": {}}; !reflect.DeepEqual(cfg.SkipKinds, expect) {
+		t.Errorf("expected kinds to skip %v, got %v", expect, cfg.SkipKinds)
+	}
+}
+
+func TestFromFlagsVersionResolution(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-kubernetes-version", "1.28.3", "-version-resolution", "nearest", "-fallback-kubernetes-version", "master", "file1"})
+	if err != nil || cfg.VersionResolution != "nearest" || cfg.FallbackVersion != "master" {
+		t.Errorf("expected nearest version resolution falling back to master, got %s, %s, %v", cfg.VersionResolution, cfg.FallbackVersion, err)
+	}
+
+	for _, args := range [][]string{
+		{"-version-resolution", "closest", "file1"},
+		{"-fallback-kubernetes-version", "master", "file1"},
+		{"-version-resolution", "exact", "-fallback-kubernetes-version", "master", "file1"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsCrossResourceRules(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-cross-resource-rules", "service-selector,hpa-target", "file1"})
+	if expect := []string{"service-selector", "hpa-target"}; err != nil || !reflect.DeepEqual(cfg.CrossResourceRules, expect) {
+		t.Errorf("expected cross-resource rules %v, got %v, %v", expect, cfg.CrossResourceRules, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-cross-resource-rules", "service-selector,pvc-claim", "file1"}); err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+}
+
+func TestFromFlagsListMode(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-list-mode", "skip", "file1"})
+	if err != nil || cfg.ListMode != "skip" {
+		t.Errorf("expected list mode skip, got %s, %v", cfg.ListMode, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-list-mode", "flatten", "file1"}); err == nil {
+		t.Errorf("expected an error for an invalid list mode")
+	}
+}
+
+func TestFromFlagsLogFormat(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-log-format", "json", "-log-level", "warning", "file1"})
+	if err != nil || cfg.LogFormat != "json" || cfg.LogLevel != "warning" {
+		t.Errorf("expected json logs at the warning level, got %s, %s, %v", cfg.LogFormat, cfg.LogLevel, err)
+	}
+
+	for _, args := range [][]string{
+		{"-log-format", "xml", "file1"},
+		{"-log-level", "trace", "file1"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsKindShortnames(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-kind-shortnames", "wf=Workflow,cwf=ClusterWorkflowTemplate", "file1"})
+	expect := map[string]string{"wf": "Workflow", "cwf": "ClusterWorkflowTemplate"}
+	if err != nil || !reflect.DeepEqual(cfg.KindShortnames, expect) {
+		t.Errorf("expected %+v, got %+v, %v", expect, cfg.KindShortnames, err)
+	}
+
+	for _, v := range []string{"wf", "=Workflow", "wf="} {
+		if _, _, err := FromFlags("kubeconform", []string{"-kind-shortnames", v, "file1"}); err == nil {
+			t.Errorf("expected an error for -kind-shortnames %s", v)
+		}
+	}
+}
+
+func TestFromFlagsGitDiff(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-git-diff", "origin/main...", "manifests/"})
+	if err != nil || cfg.GitDiff != "origin/main..." {
+		t.Errorf("expected -git-diff origin/main..., got %s, %v", cfg.GitDiff, err)
+	}
+
+	for _, args := range [][]string{
+		{"-git-diff", "origin/main", "-"},
+		{"-git-diff", "origin/main", "-kustomization", "manifests/"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsServerDryRun(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-server-dry-run", "-server-dry-run-context", "staging", "file1"})
+	if err != nil || !cfg.ServerDryRun || cfg.DryRunContext != "staging" {
+		t.Errorf("expected a server dry-run in the staging context, got %t, %s, %v", cfg.ServerDryRun, cfg.DryRunContext, err)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-server-dry-run-context", "staging", "file1"}); err == nil {
+		t.Errorf("expected an error for -server-dry-run-context without -server-dry-run")
+	}
+}
+
+func TestFromFlagsPassThreshold(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-pass-threshold", "0.95", "-pass-threshold-skipped", "file1"})
+	if err != nil || cfg.PassThreshold != 0.95 || !cfg.ThresholdSkipped {
+		t.Errorf("expected a pass threshold of 0.95 counting skipped resources, got %g, %t, %v", cfg.PassThreshold, cfg.ThresholdSkipped, err)
+	}
+
+	for _, args := range [][]string{
+		{"-pass-threshold", "95", "file1"},
+		{"-pass-threshold", "-0.5", "file1"},
+		{"-pass-threshold-skipped", "file1"},
+		{"-pass-threshold", "0.95", "-watch", "file1"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsDecompress(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-decompress", "archive/"})
+	if err != nil || !cfg.Decompress {
+		t.Errorf("expected decompression to be enabled, got %t, %v", cfg.Decompress, err)
+	}
+}
+
+func TestFromFlagsWatch(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-watch", "manifests/"})
+	if err != nil || !cfg.Watch {
+		t.Errorf("expected watch to be enabled, got %v, %v", cfg.Watch, err)
+	}
+
+	for _, args := range [][]string{
+		{"-watch"},
+		{"-watch", "-"},
+		{"-watch", "-output-file", "failures.txt", "manifests/"},
+		{"-watch", "-otel-traces", "manifests/"},
+		{"-watch", "-results-dir", "results/", "manifests/"},
+		{"-watch", "-git-diff", "origin/main", "manifests/"},
+		{"-watch", "-cross-resource-rules", "hpa-target", "manifests/"},
+	} {
+		if _, _, err := FromFlags("kubeconform", args); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestFromFlagsInvalidPatch(t *testing.T) {
+	for _, patch := range []string{"Deployment", "=patch.json", "Deployment="} {
+		if _, _, err := FromFlags("kubeconform", []string{"-patch", patch}); err == nil {
+			t.Errorf("expected an error for -patch %s", patch)
+		}
+	}
+}
+
+func TestFromFlagsAliases(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-alias", "old.example.com/v1/Widget=new.example.com/v1/Widget", "-alias", "v1/Foo=v1/Bar"})
+	expect := map[string]string{"old.example.com/v1/Widget": "new.example.com/v1/Widget", "v1/Foo": "v1/Bar"}
+	if err != nil || !reflect.DeepEqual(cfg.Aliases, expect) {
+		t.Errorf("expected aliases %v, got %v, %v", expect, cfg.Aliases, err)
+	}
+
+	for _, alias := range []string{"v1/Foo", "v1/Foo=", "Foo=v1/Bar", "v1/=v1/Bar", "v1/Foo=/Bar"} {
+		if _, _, err := FromFlags("kubeconform", []string{"-alias", alias}); err == nil {
+			t.Errorf("expected an error for -alias %s", alias)
+		}
+	}
+}
+
+func TestFromFlagsInvalidOnlyFields(t *testing.T) {
+	for _, fields := range []string{"Deployment", "Deployment:spec", "spec=schema.json", ":spec=schema.json", "Deployment:=schema.json", "Deployment:spec="} {
+		if _, _, err := FromFlags("kubeconform", []string{"-only-fields", fields}); err == nil {
+			t.Errorf("expected an error for -only-fields %s", fields)
+		}
+	}
+}
+
+func TestFromFlagsOnlyDocIndexes(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-only-doc-index", "0,42"})
+	if err != nil || !reflect.DeepEqual(cfg.OnlyDocIndexes, []int{0, 42}) {
+		t.Errorf("expected document indexes [0 42], got %v, %v", cfg.OnlyDocIndexes, err)
+	}
+
+	for _, indexes := range []string{"a", "1,-1"} {
+		if _, _, err := FromFlags("kubeconform", []string{"-only-doc-index", indexes}); err == nil {
+			t.Errorf("expected an error for -only-doc-index %s", indexes)
+		}
+	}
+}
+
+func TestFromFlagsExitCodes(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-exit-codes", "invalid=2,error=3,skipped=4"})
+	if err != nil || !reflect.DeepEqual(cfg.ExitCodes, map[string]int{"invalid": 2, "error": 3, "skipped": 4}) {
+		t.Errorf("expected exit codes to be parsed, got %v, %v", cfg.ExitCodes, err)
+	}
+
+	for _, exitCodes := range []string{"invalid", "valid=2", "error=0", "error=256", "error=x"} {
+		if _, _, err := FromFlags("kubeconform", []string{"-exit-codes", exitCodes}); err == nil {
+			t.Errorf("expected an error for -exit-codes %s", exitCodes)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Profile holds the schema locations, kinds to skip or reject and apiVersions allowed for a set of
+// manifests, e.g. the manifests deployed to a cluster with its own CRDs installed
+type Profile struct {
+	SchemaLocations    []string            `json:"schema-location"`
+	SkipKinds          []string            `json:"skip"`
+	RejectKinds        []string            `json:"reject"`
+	AllowedAPIVersions map[string][]string `json:"allowed-apiversions"` // by kind
+}
+
+// LoadProfile reads the profile called name from the YAML file at p, listing profiles by name under
+// a profiles key
+func LoadProfile(p, name string) (Profile, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed reading profiles: %s", err)
+	}
+
+	var profiles struct {
+		Profiles map[string]Profile `json:"profiles"`
+	}
+	if err := yaml.UnmarshalStrict(b, &profiles); err != nil {
+		return Profile{}, fmt.Errorf("failed parsing profiles in %s: %s", p, err)
+	}
+
+	profile, ok := profiles.Profiles[name]
+	if !ok {
+		names := []string{}
+		for n := range profiles.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("no profile %s in %s, available profiles: %s", name, p, strings.Join(names, ", "))
+	}
+
+	return profile, nil
+}
+
+// ApplyProfile adds the schema locations, kinds and allowed apiVersions of a profile to the configuration.
+// Schema locations given on the command line are searched before the ones of the profile.
+func (c *Config) ApplyProfile(profile Profile) {
+	c.SchemaLocations = append(append([]string{}, c.SchemaLocations...), profile.SchemaLocations...)
+	if c.SkipKinds == nil {
+		c.SkipKinds = map[string]struct{}{}
+	}
+	if c.RejectKinds == nil {
+		c.RejectKinds = map[string]struct{}{}
+	}
+	for _, kind := range profile.SkipKinds {
+		c.SkipKinds[kind] = struct{}{}
+	}
+	for _, kind := range profile.RejectKinds {
+		c.RejectKinds[kind] = struct{}{}
+	}
+	for kind, versions := range profile.AllowedAPIVersions {
+		if c.AllowedAPIVersions == nil {
+			c.AllowedAPIVersions = map[string][]string{}
+		}
+		c.AllowedAPIVersions[kind] = append(c.AllowedAPIVersions[kind], versions...)
+	}
+}
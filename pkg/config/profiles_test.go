@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "profiles.yaml")
+	os.WriteFile(p, []byte(`profiles:
+  production:
+    schema-location: [default, 'crds/{{ .ResourceKind }}.json']
+    skip: [SealedSecret]
+    allowed-apiversions:
+      Deployment: [apps/v1]
+  staging:
+    reject: [Secret]
+`), 0644)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte("profiles:\n  production:\n    skip-kinds: [SealedSecret]\n"), 0644)
+
+	for _, testCase := range []struct {
+		path, name string
+		expect     Profile
+		expectErr  bool
+	}{
+		{p, "production", Profile{SchemaLocations: []string{"default", "crds/{{ .ResourceKind }}.json"}, SkipKinds: []string{"SealedSecret"}, AllowedAPIVersions: map[string][]string{"Deployment": {"apps/v1"}}}, false},
+		{p, "staging", Profile{RejectKinds: []string{"Secret"}}, false},
+		{p, "development", Profile{}, true},
+		{invalid, "production", Profile{}, true},
+		{filepath.Join(dir, "missing.yaml"), "production", Profile{}, true},
+	} {
+		got, err := LoadProfile(testCase.path, testCase.name)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("%s: expected error %t, got %v", testCase.name, testCase.expectErr, err)
+		}
+		if !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("%s: expected %+v, got %+v", testCase.name, testCase.expect, got)
+		}
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	cfg, _, err := FromFlags("kubeconform", []string{"-profile", "production", "-profiles-file", "profiles.yaml", "-schema-location", "local", "-skip", "ConfigMap", "-allowed-apiversions", "Deployment=apps/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.ApplyProfile(Profile{SchemaLocations: []string{"default"}, SkipKinds: []string{"SealedSecret"}, RejectKinds: []string{"Secret"}, AllowedAPIVersions: map[string][]string{"Deployment": {"extensions/v1beta1"}, "Ingress": {"networking.k8s.io/v1"}}})
+	if expect := []string{"local", "default"}; !reflect.DeepEqual(cfg.SchemaLocations, expect) {
+		t.Errorf("expected schema locations %v, got %v", expect, cfg.SchemaLocations)
+	}
+	if expect := map[string]struct{}{"ConfigMap": {}, "SealedSecret": {}}; !reflect.DeepEqual(cfg.SkipKinds, expect) {
+		t.Errorf("expected kinds to skip %v, got %v", expect, cfg.SkipKinds)
+	}
+	if expect := map[string]struct{}{"Secret": {}}; !reflect.DeepEqual(cfg.RejectKinds, expect) {
+		t.Errorf("expected kinds to reject %v, got %v", expect, cfg.RejectKinds)
+	}
+	if expect := map[string][]string{"Deployment": {"apps/v1", "extensions/v1beta1"}, "Ingress": {"networking.k8s.io/v1"}}; !reflect.DeepEqual(cfg.AllowedAPIVersions, expect) {
+		t.Errorf("expected allowed apiVersions %v, got %v", expect, cfg.AllowedAPIVersions)
+	}
+
+	if _, _, err := FromFlags("kubeconform", []string{"-profile", "production"}); err == nil {
+		t.Errorf("expected an error for -profile without -profiles-file")
+	}
+}
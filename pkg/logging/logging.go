@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats of the messages logged
+const (
+	FormatText   = "text"   // the message, after the date and time, as the log package writes it
+	FormatJSON   = "json"   // a JSON object per message, with the time, level and msg keys
+	FormatLogfmt = "logfmt" // a line of time=, level= and msg= pairs per message
+)
+
+// Levels of the messages logged, from the least to the most severe. The level of a message is
+// given by its prefix, e.g. "warning: ", messages without a prefix are at the info level.
+const (
+	LevelDebug   = "debug"
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+var levels = []string{LevelDebug, LevelInfo, LevelWarning, LevelError}
+
+// severity returns the rank of level in levels, or -1 if it is not a level
+func severity(level string) int {
+	for i, l := range levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Writer formats the messages of a log.Logger without flags, e.g. the standard logger set up with
+// log.SetFlags(0) and log.SetOutput. Messages below the minimum level are dropped.
+// It is safe to use from multiple Go routines.
+type Writer struct {
+	sync.Mutex
+	w        io.Writer
+	format   string
+	minLevel int
+	now      func() time.Time
+}
+
+// NewWriter returns a Writer writing messages at level or above to w, in format. format is one of
+// FormatText (if empty), FormatJSON or FormatLogfmt, level one of LevelDebug, LevelInfo (if empty),
+// LevelWarning or LevelError.
+func NewWriter(w io.Writer, format, level string) (*Writer, error) {
+	if format == "" {
+		format = FormatText
+	}
+	if format != FormatText && format != FormatJSON && format != FormatLogfmt {
+		return nil, fmt.Errorf("invalid log format %s, must be %s, %s or %s", format, FormatText, FormatJSON, FormatLogfmt)
+	}
+	if level == "" {
+		level = LevelInfo
+	}
+	if severity(level) == -1 {
+		return nil, fmt.Errorf("invalid log level %s, must be %s", level, strings.Join(levels, ", "))
+	}
+
+	return &Writer{w: w, format: format, minLevel: severity(level), now: time.Now}, nil
+}
+
+// parseLevel splits a message into its level and the message without the level prefix
+func parseLevel(msg string) (string, string) {
+	for _, level := range levels {
+		if strings.HasPrefix(msg, level+": ") {
+			return level, strings.TrimPrefix(msg, level+": ")
+		}
+	}
+	return LevelInfo, msg
+}
+
+// Write writes the message in p, a single message as the log package writes them
+func (lw *Writer) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	level, msg := parseLevel(line)
+	if severity(level) < lw.minLevel {
+		return len(p), nil
+	}
+
+	t := lw.now()
+	var out string
+	switch lw.format {
+	case FormatJSON:
+		b, err := json.Marshal(struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{t.Format(time.RFC3339), level, msg})
+		if err != nil {
+			return 0, err
+		}
+		out = string(b) + "\n"
+	case FormatLogfmt:
+		out = fmt.Sprintf("time=%s level=%s msg=%s\n", t.Format(time.RFC3339), level, logfmtValue(msg))
+	default:
+		out = t.Format("2006/01/02 15:04:05") + " " + line + "\n"
+	}
+
+	lw.Lock()
+	defer lw.Unlock()
+	if _, err := io.WriteString(lw.w, out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logfmtValue quotes values that are empty, or contain spaces, quotes, equal signs or control characters
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " =\"\\") || strings.IndexFunc(v, func(r rune) bool { return r < ' ' }) != -1 {
+		return strconv.Quote(v)
+	}
+	return v
+}
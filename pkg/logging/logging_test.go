@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	now := func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	for _, testCase := range []struct {
+		format, level string
+		expect        string
+	}{
+		{
+			"", "",
+			"2026/01/02 03:04:05 watching manifests for changes\n" +
+				"2026/01/02 03:04:05 warning: schema registry disabled\n" +
+				"2026/01/02 03:04:05 error: failed writing result: broken pipe\n",
+		},
+		{
+			FormatJSON, LevelDebug,
+			`{"time":"2026-01-02T03:04:05Z","level":"debug","msg":"v1/Service - schema found in \"cache\""}` + "\n" +
+				`{"time":"2026-01-02T03:04:05Z","level":"info","msg":"watching manifests for changes"}` + "\n" +
+				`{"time":"2026-01-02T03:04:05Z","level":"warning","msg":"schema registry disabled"}` + "\n" +
+				`{"time":"2026-01-02T03:04:05Z","level":"error","msg":"failed writing result: broken pipe"}` + "\n",
+		},
+		{
+			FormatLogfmt, LevelWarning,
+			`time=2026-01-02T03:04:05Z level=warning msg="schema registry disabled"` + "\n" +
+				`time=2026-01-02T03:04:05Z level=error msg="failed writing result: broken pipe"` + "\n",
+		},
+	} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, testCase.format, testCase.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.now = now
+
+		logger := log.New(w, "", 0)
+		logger.Printf("debug: v1/Service - schema found in %q", "cache")
+		logger.Printf("watching manifests for changes")
+		logger.Printf("warning: schema registry disabled")
+		logger.Printf("error: failed writing result: %s", "broken pipe")
+
+		if got := buf.String(); got != testCase.expect {
+			t.Errorf("format %q, level %q - expected:\n%s\ngot:\n%s", testCase.format, testCase.level, testCase.expect, got)
+		}
+	}
+}
+
+func TestNewWriterInvalid(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, "xml", ""); err == nil {
+		t.Errorf("expected an error for an invalid format")
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, "", "trace"); err == nil {
+		t.Errorf("expected an error for an invalid level")
+	}
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+type csvo struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// csvOutput writes one row per resource, with a header row. Summaries are not supported.
+func csvOutput(w io.Writer, withSummary bool, isStdin, verbose bool) Output {
+	return &csvo{
+		w: csv.NewWriter(w),
+	}
+}
+
+func (o *csvo) writeHeader() error {
+	if o.headerWritten {
+		return nil
+	}
+	o.headerWritten = true
+
+	return o.w.Write([]string{"file", "kind", "name", "namespace", "status", "message"})
+}
+
+// Write writes a row for the result, and flushes it
+func (o *csvo) Write(result validator.Result) error {
+	if err := o.writeHeader(); err != nil {
+		return err
+	}
+
+	if result.Status == validator.Empty {
+		return nil
+	}
+	status := result.Status.String()
+
+	msg := ""
+	if result.Err != nil {
+		msg = result.Err.Error()
+	}
+
+	sig, _ := result.Resource.Signature()
+	if err := o.w.Write([]string{result.Resource.Path, sig.Kind, sig.Name, sig.Namespace, status, msg}); err != nil {
+		return err
+	}
+	o.w.Flush()
+
+	return o.w.Error()
+}
+
+// Flush writes the header if no result was written
+func (o *csvo) Flush() error {
+	if err := o.writeHeader(); err != nil {
+		return err
+	}
+	o.w.Flush()
+
+	return o.w.Error()
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestCSVWrite(t *testing.T) {
+	for _, testCase := range []struct {
+		name    string
+		results []validator.Result
+		expect  string
+	}{
+		{
+			"no resources",
+			[]validator.Result{},
+			"file,kind,name,namespace,status,message\n",
+		},
+		{
+			"a valid and an invalid deployment",
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path: "deployment.yml",
+						Bytes: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "my-app"
+  namespace: "my-namespace"
+`),
+					},
+					Status: validator.Valid,
+					Err:    nil,
+				},
+				{
+					Resource: resource.Resource{
+						Path: "deployment.yml",
+						Bytes: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "my-other-app"
+`),
+					},
+					Status: validator.Invalid,
+					Err:    fmt.Errorf("For field spec: Invalid type, expected \"object\" - For field\nmetadata: required"),
+				},
+				{
+					Resource: resource.Resource{Path: "empty.yml"},
+					Status:   validator.Empty,
+				},
+				{
+					Resource: resource.Resource{Path: "missing.yml"},
+					Status:   validator.Error,
+					Err:      fmt.Errorf("open missing.yml: no such file or directory"),
+				},
+			},
+			`file,kind,name,namespace,status,message
+deployment.yml,Deployment,my-app,my-namespace,valid,
+deployment.yml,Deployment,my-other-app,,invalid,"For field spec: Invalid type, expected ""object"" - For field
+metadata: required"
+missing.yml,,,,error,open missing.yml: no such file or directory
+`,
+		},
+	} {
+		w := new(bytes.Buffer)
+		o := csvOutput(w, false, false, false)
+
+		for _, res := range testCase.results {
+			o.Write(res)
+		}
+		o.Flush()
+
+		if w.String() != testCase.expect {
+			t.Errorf("%s - expected:\n%s\ngot:\n%s", testCase.name, testCase.expect, w)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// Diff compares the results of a run against the results of a previous run, written with the json
+// output format, and reports the resources that started or stopped failing. Resources are
+// identified by file, apiVersion, kind, namespace and name.
+type Diff struct {
+	w            io.Writer
+	format       string
+	previous     map[string]oresult // resources failing in the previous run, by identity
+	failing      map[string]oresult // resources failing in this run, by identity
+	newlyFailing []oresult
+	fixed        []oresult
+}
+
+// NewDiff returns an output writing to w, in the text or json format, how the results differ from
+// the previous results read from previous
+func NewDiff(w io.Writer, previous io.Reader, format string) (*Diff, error) {
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("results can only be compared in the text or json output formats")
+	}
+
+	var results struct {
+		Resources []oresult `json:"resources"`
+	}
+	if err := json.NewDecoder(previous).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed parsing previous results: %s", err)
+	}
+
+	d := &Diff{
+		w:        w,
+		format:   format,
+		previous: map[string]oresult{},
+		failing:  map[string]oresult{},
+	}
+	for _, res := range results.Resources {
+		if res.Status == "statusInvalid" || res.Status == "statusError" {
+			d.previous[resultKey(res)] = res
+		}
+	}
+
+	return d, nil
+}
+
+func resultKey(res oresult) string {
+	return res.Filename + "\x00" + res.Version + "\x00" + res.Kind + "\x00" + res.Namespace + "\x00" + res.Name
+}
+
+// Write records the result of a resource, which is compared to the previous results on Flush
+func (d *Diff) Write(result validator.Result) error {
+	st := ""
+	switch result.Status {
+	case validator.Invalid:
+		st = "statusInvalid"
+	case validator.Error:
+		st = "statusError"
+	default:
+		return nil
+	}
+
+	sig, _ := result.Resource.Signature()
+	res := oresult{
+		Filename:  result.Resource.Path,
+		Kind:      sig.Kind,
+		Name:      sig.Name,
+		Namespace: sig.Namespace,
+		Version:   sig.Version,
+		Status:    st,
+		Msg:       result.Err.Error(),
+		Code:      string(result.Code),
+	}
+	d.failing[resultKey(res)] = res
+
+	return nil
+}
+
+// Flush writes the resources failing in this run but not in the previous one, and the resources
+// failing in the previous run but not in this one
+func (d *Diff) Flush() error {
+	d.newlyFailing, d.fixed = []oresult{}, []oresult{}
+	nStillFailing := 0
+	for key, res := range d.failing {
+		if _, ok := d.previous[key]; ok {
+			nStillFailing++
+			continue
+		}
+		d.newlyFailing = append(d.newlyFailing, res)
+	}
+	for key, res := range d.previous {
+		if _, ok := d.failing[key]; !ok {
+			d.fixed = append(d.fixed, res)
+		}
+	}
+	sortResults(d.newlyFailing)
+	sortResults(d.fixed)
+
+	if d.format == "json" {
+		res, err := json.MarshalIndent(struct {
+			NewlyFailing []oresult `json:"newlyFailing"`
+			Fixed        []oresult `json:"fixed"`
+			StillFailing int       `json:"stillFailing"`
+		}{d.newlyFailing, d.fixed, nStillFailing}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(d.w, "%s\n", res)
+		return err
+	}
+
+	for _, res := range d.newlyFailing {
+		fmt.Fprintf(d.w, "newly failing: %s: %s\n", describeResult(res), res.Msg)
+	}
+	for _, res := range d.fixed {
+		fmt.Fprintf(d.w, "fixed: %s\n", describeResult(res))
+	}
+	_, err := fmt.Fprintf(d.w, "Diff: %d newly failing, %d fixed, %d still failing\n", len(d.newlyFailing), len(d.fixed), nStillFailing)
+	return err
+}
+
+// NewlyFailing returns the number of resources failing in this run but not in the previous one,
+// once Flush has been called
+func (d *Diff) NewlyFailing() int {
+	return len(d.newlyFailing)
+}
+
+// describeResult returns the file, kind and name of a result, as in the text output
+func describeResult(res oresult) string {
+	if res.Kind == "" {
+		return res.Filename
+	}
+	return fmt.Sprintf("%s - %s %s", res.Filename, res.Kind, res.Name)
+}
+
+func sortResults(results []oresult) {
+	sort.Slice(results, func(i, j int) bool {
+		return resultKey(results[i]) < resultKey(results[j])
+	})
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestDiff(t *testing.T) {
+	deployment := func(name string) resource.Resource {
+		return resource.Resource{Path: "deployment.yml", Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n  namespace: default\n")}
+	}
+
+	previous := `{
+  "resources": [
+    {"filename": "deployment.yml", "kind": "Deployment", "name": "still-broken", "namespace": "default", "version": "apps/v1", "status": "statusInvalid", "msg": "For field spec: Invalid type"},
+    {"filename": "deployment.yml", "kind": "Deployment", "name": "repaired", "namespace": "default", "version": "apps/v1", "status": "statusError", "msg": "could not find schema for Deployment"},
+    {"filename": "deployment.yml", "kind": "Deployment", "name": "valid", "namespace": "default", "version": "apps/v1", "status": "statusValid", "msg": ""}
+  ]
+}`
+	results := []validator.Result{
+		{Resource: deployment("still-broken"), Status: validator.Invalid, Err: fmt.Errorf("For field spec: Invalid type")},
+		{Resource: deployment("repaired"), Status: validator.Valid},
+		{Resource: deployment("valid"), Status: validator.Invalid, Err: fmt.Errorf("For field spec.replicas: Invalid type")},
+	}
+
+	for _, testCase := range []struct {
+		format string
+		expect string
+	}{
+		{
+			"text",
+			`newly failing: deployment.yml - Deployment valid: For field spec.replicas: Invalid type
+fixed: deployment.yml - Deployment repaired
+Diff: 1 newly failing, 1 fixed, 1 still failing
+`,
+		},
+		{
+			"json",
+			`{
+  "newlyFailing": [
+    {
+      "filename": "deployment.yml",
+      "kind": "Deployment",
+      "name": "valid",
+      "namespace": "default",
+      "version": "apps/v1",
+      "status": "statusInvalid",
+      "msg": "For field spec.replicas: Invalid type"
+    }
+  ],
+  "fixed": [
+    {
+      "filename": "deployment.yml",
+      "kind": "Deployment",
+      "name": "repaired",
+      "namespace": "default",
+      "version": "apps/v1",
+      "status": "statusError",
+      "msg": "could not find schema for Deployment"
+    }
+  ],
+  "stillFailing": 1
+}
+`,
+		},
+	} {
+		w := new(bytes.Buffer)
+		d, err := NewDiff(w, strings.NewReader(previous), testCase.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range results {
+			d.Write(res)
+		}
+		d.Flush()
+
+		if w.String() != testCase.expect {
+			t.Errorf("%s - expected:\n%s\ngot:\n%s", testCase.format, testCase.expect, w.String())
+		}
+		if d.NewlyFailing() != 1 {
+			t.Errorf("%s - expected 1 newly failing resource, got %d", testCase.format, d.NewlyFailing())
+		}
+	}
+}
+
+func TestNewDiffErrors(t *testing.T) {
+	if _, err := NewDiff(new(bytes.Buffer), strings.NewReader(`{"resources": []}`), "junit"); err == nil {
+		t.Errorf("expected an error for the junit output format")
+	}
+	if _, err := NewDiff(new(bytes.Buffer), strings.NewReader("file,kind\n"), "text"); err == nil {
+		t.Errorf("expected an error for previous results that are not JSON")
+	}
+}
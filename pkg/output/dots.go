@@ -0,0 +1,105 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// defaultDotsWidth is the number of characters per line of the dots output, when not writing to a
+// terminal whose width is known
+const defaultDotsWidth = 80
+
+// terminalWidth returns the number of columns of the terminal f is attached to, or 0 if f is not a
+// terminal. It is nil on platforms where the width can not be queried, see dots_unix.go
+var terminalWidth func(f *os.File) int
+
+type dotso struct {
+	sync.Mutex
+	w       io.Writer
+	width   int          // characters per line, lines are wrapped after this many characters
+	column  int          // characters written on the current line
+	text    *texto       // formats failures and the summary as the text output does
+	details bytes.Buffer // failures and summary written by text, printed on Flush
+}
+
+// dotsOutput writes a character per resource as they are validated, as test runners do: . for valid
+// resources, F for invalid resources and errors, s for skipped resources. The details of failures
+// and the summary, even without withSummary, are written on Flush.
+func dotsOutput(w io.Writer, withSummary, isStdin, verbose bool) Output {
+	o := &dotso{
+		w:     w,
+		width: dotsWidth(w),
+	}
+	o.text = textOutput(&o.details, true, isStdin, false).(*texto)
+	return o
+}
+
+// dotsWidth returns the width of the terminal w writes to, as set in $COLUMNS or queried from the
+// terminal, or defaultDotsWidth
+func dotsWidth(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if f, ok := w.(*os.File); ok && terminalWidth != nil {
+		if columns := terminalWidth(f); columns > 0 {
+			return columns
+		}
+	}
+	return defaultDotsWidth
+}
+
+func (o *dotso) Write(result validator.Result) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if err := o.text.Write(result); err != nil {
+		return err
+	}
+
+	var c string
+	switch result.Status {
+	case validator.Valid:
+		c = "."
+	case validator.Invalid, validator.Error:
+		c = "F"
+	case validator.Skipped:
+		c = "s"
+	case validator.Empty:
+		return nil
+	}
+
+	if o.column >= o.width {
+		if _, err := fmt.Fprintln(o.w); err != nil {
+			return err
+		}
+		o.column = 0
+	}
+	o.column++
+	_, err := fmt.Fprint(o.w, c)
+	return err
+}
+
+// Flush ends the line of characters, and prints the details of failures and the summary
+func (o *dotso) Flush() error {
+	o.Lock()
+	defer o.Unlock()
+
+	if o.column > 0 {
+		if _, err := fmt.Fprintln(o.w); err != nil {
+			return err
+		}
+		o.column = 0
+	}
+
+	if err := o.text.Flush(); err != nil {
+		return err
+	}
+	_, err := io.Copy(o.w, &o.details)
+	return err
+}
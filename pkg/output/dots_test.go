@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestDotsWrite(t *testing.T) {
+	deployment := func(name string) resource.Resource {
+		return resource.Resource{
+			Path:  "deployments.yml",
+			Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n"),
+		}
+	}
+
+	for _, testCase := range []struct {
+		name    string
+		width   int
+		results []validator.Result
+		expect  string
+	}{
+		{
+			"no resources",
+			80,
+			[]validator.Result{},
+			"Summary: 0 resource found in 0 file - Valid: 0, Invalid: 0, Errors: 0, Skipped: 0\n",
+		},
+		{
+			"a character per resource, failures after the characters",
+			80,
+			[]validator.Result{
+				{Resource: deployment("a"), Status: validator.Valid},
+				{Resource: deployment("b"), Status: validator.Invalid, Err: fmt.Errorf("spec.replicas: Invalid type")},
+				{Resource: resource.Resource{Path: "empty.yml"}, Status: validator.Empty},
+				{Resource: deployment("c"), Status: validator.Skipped},
+				{Resource: resource.Resource{Path: "unreadable.yml"}, Status: validator.Error, Err: fmt.Errorf("permission denied")},
+			},
+			".FsF\n" +
+				"deployments.yml - Deployment b is invalid: spec.replicas: Invalid type\n" +
+				"unreadable.yml - failed validation: permission denied\n" +
+				"Summary: 4 resources found in 3 files - Valid: 1, Invalid: 1, Errors: 1, Skipped: 1\n",
+		},
+		{
+			"lines wrapped at the width",
+			3,
+			[]validator.Result{
+				{Resource: deployment("a"), Status: validator.Valid},
+				{Resource: deployment("b"), Status: validator.Valid},
+				{Resource: deployment("c"), Status: validator.Valid},
+				{Resource: deployment("d"), Status: validator.Valid},
+				{Resource: deployment("e"), Status: validator.Skipped},
+				{Resource: deployment("f"), Status: validator.Valid},
+				{Resource: deployment("g"), Status: validator.Valid},
+			},
+			"...\n.s.\n.\n" +
+				"Summary: 7 resources found in 1 file - Valid: 6, Invalid: 0, Errors: 0, Skipped: 1\n",
+		},
+	} {
+		w := new(bytes.Buffer)
+		o := dotsOutput(w, false, false, false).(*dotso)
+		o.width = testCase.width
+
+		for _, res := range testCase.results {
+			o.Write(res)
+		}
+		o.Flush()
+
+		if w.String() != testCase.expect {
+			t.Errorf("%s - expected:\n%s\ngot:\n%s", testCase.name, testCase.expect, w)
+		}
+	}
+}
+
+func TestDotsWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "120")
+	if got := dotsWidth(new(bytes.Buffer)); got != 120 {
+		t.Errorf("expected the width set in COLUMNS, got %d", got)
+	}
+
+	t.Setenv("COLUMNS", "")
+	if got := dotsWidth(new(bytes.Buffer)); got != defaultDotsWidth {
+		t.Errorf("expected the default width when not writing to a terminal, got %d", got)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package output
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func init() {
+	terminalWidth = func(f *os.File) int {
+		var ws struct {
+			Row, Col, Xpixel, Ypixel uint16
+		}
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws))); errno != 0 {
+			return 0
+		}
+		return int(ws.Col)
+	}
+}
@@ -0,0 +1,94 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// gitlabIssue is an issue of a GitLab Code Quality report
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+type gitlabo struct {
+	w      io.Writer
+	issues []gitlabIssue
+}
+
+// gitlabOutput writes invalid resources and errors as a GitLab Code Quality report, a JSON array of
+// issues. Summaries are not supported.
+func gitlabOutput(w io.Writer, withSummary bool, isStdin, verbose bool) Output {
+	return &gitlabo{
+		w:      w,
+		issues: []gitlabIssue{},
+	}
+}
+
+func (o *gitlabo) Write(result validator.Result) error {
+	severity := ""
+	switch result.Status {
+	case validator.Invalid:
+		severity = "major"
+	case validator.Error:
+		severity = "critical"
+	default:
+		return nil
+	}
+
+	description := result.Err.Error()
+	sig, _ := result.Resource.Signature()
+	if sig.Kind != "" && sig.Name != "" {
+		description = fmt.Sprintf("%s %s: %s", sig.Kind, sig.Name, description)
+	}
+
+	checkName := "kubeconform"
+	if result.Code != "" {
+		checkName += "/" + string(result.Code)
+	}
+
+	// Fingerprints do not depend on the line of the resource, so that issues are still matched
+	// across runs when resources above them are added or removed
+	sum := sha256.Sum256([]byte(result.Resource.Path + "\x00" + sig.QualifiedName() + "\x00" + result.Err.Error()))
+
+	issue := gitlabIssue{
+		Description: description,
+		CheckName:   checkName,
+		Fingerprint: hex.EncodeToString(sum[:]),
+		Severity:    severity,
+		Location:    gitlabLocation{Path: result.Resource.Path},
+	}
+	issue.Location.Lines.Begin = result.Resource.Line
+	if issue.Location.Lines.Begin == 0 {
+		issue.Location.Lines.Begin = 1
+	}
+	o.issues = append(o.issues, issue)
+
+	return nil
+}
+
+// Flush writes the report
+func (o *gitlabo) Flush() error {
+	b, err := json.MarshalIndent(o.issues, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(o.w, "%s\n", b)
+	return err
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestGitlabWrite(t *testing.T) {
+	results := []validator.Result{
+		{
+			Resource: resource.Resource{Path: "deployment.yml", Line: 5, Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n")},
+			Status:   validator.Valid,
+		},
+		{
+			Resource: resource.Resource{Path: "deployment.yml", Line: 12, Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-other-app\n")},
+			Status:   validator.Invalid,
+			Err:      fmt.Errorf("For field spec: Invalid type"),
+			Code:     validator.ConstraintViolation,
+		},
+		{
+			Resource: resource.Resource{Path: "missing.yml"},
+			Status:   validator.Error,
+			Err:      fmt.Errorf("open missing.yml: no such file or directory"),
+		},
+	}
+
+	write := func(results []validator.Result) string {
+		w := new(bytes.Buffer)
+		o := gitlabOutput(w, true, false, true)
+		for _, res := range results {
+			o.Write(res)
+		}
+		o.Flush()
+		return w.String()
+	}
+
+	var issues []gitlabIssue
+	if err := json.Unmarshal([]byte(write(results)), &issues); err != nil {
+		t.Fatalf("failed parsing report: %s", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(issues))
+	}
+
+	for i, testCase := range []struct {
+		description, checkName, severity, path string
+		line                                   int
+	}{
+		{"Deployment my-other-app: For field spec: Invalid type", "kubeconform/constraint-violation", "major", "deployment.yml", 12},
+		{"open missing.yml: no such file or directory", "kubeconform", "critical", "missing.yml", 1},
+	} {
+		issue := issues[i]
+		if issue.Description != testCase.description || issue.CheckName != testCase.checkName || issue.Severity != testCase.severity ||
+			issue.Location.Path != testCase.path || issue.Location.Lines.Begin != testCase.line || len(issue.Fingerprint) != 64 {
+			t.Errorf("%d - unexpected issue %+v", i, issue)
+		}
+	}
+
+	// Fingerprints are stable across runs, even if the resource moved in its file
+	results[1].Resource = resource.Resource{Path: results[1].Resource.Path, Line: 20, Bytes: results[1].Resource.Bytes}
+	var moved []gitlabIssue
+	json.Unmarshal([]byte(write(results)), &moved)
+	if moved[0].Fingerprint != issues[0].Fingerprint || moved[0].Fingerprint == moved[1].Fingerprint {
+		t.Errorf("expected stable and distinct fingerprints, got %s, %s and %s", issues[0].Fingerprint, moved[0].Fingerprint, moved[1].Fingerprint)
+	}
+
+	if got := write([]validator.Result{}); got != "[]\n" {
+		t.Errorf("expected an empty report, got %s", got)
+	}
+}
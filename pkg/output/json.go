@@ -9,12 +9,17 @@ import (
 )
 
 type oresult struct {
-	Filename string `json:"filename"`
-	Kind     string `json:"kind"`
-	Name     string `json:"name"`
-	Version  string `json:"version"`
-	Status   string `json:"status"`
-	Msg      string `json:"msg"`
+	Filename       string  `json:"filename"`
+	Kind           string  `json:"kind"`
+	Name           string  `json:"name"`
+	Namespace      string  `json:"namespace,omitempty"`
+	Version        string  `json:"version"`
+	Status         string  `json:"status"`
+	Msg            string  `json:"msg"`
+	Code           string  `json:"code,omitempty"`
+	K8sVersion     string  `json:"kubernetesVersion,omitempty"`
+	DownloadTime   float64 `json:"downloadTime,omitempty"`   // in seconds
+	ValidationTime float64 `json:"validationTime,omitempty"` // in seconds
 }
 
 type jsono struct {
@@ -63,7 +68,19 @@ func (o *jsono) Write(result validator.Result) error {
 
 	if o.verbose || (result.Status != validator.Valid && result.Status != validator.Skipped && result.Status != validator.Empty) {
 		sig, _ := result.Resource.Signature()
-		o.results = append(o.results, oresult{Filename: result.Resource.Path, Kind: sig.Kind, Name: sig.Name, Version: sig.Version, Status: st, Msg: msg})
+		o.results = append(o.results, oresult{
+			Filename:       result.Resource.Path,
+			Kind:           sig.Kind,
+			Name:           sig.Name,
+			Namespace:      sig.Namespace,
+			Version:        sig.Version,
+			Status:         st,
+			Msg:            msg,
+			Code:           string(result.Code),
+			K8sVersion:     result.KubernetesVersion,
+			DownloadTime:   result.DownloadTime.Seconds(),
+			ValidationTime: result.ValidationTime.Seconds(),
+		})
 	}
 
 	return nil
@@ -2,7 +2,9 @@ package output
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/yannh/kubeconform/pkg/resource"
 	"github.com/yannh/kubeconform/pkg/validator"
@@ -93,6 +95,80 @@ metadata:
     "skipped": 0
   }
 }
+`,
+		},
+		{
+			"a single deployment, verbose, with timings and kubernetes version",
+			false,
+			false,
+			true,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path: "deployment.yml",
+						Bytes: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "my-app"
+`),
+					},
+					Status:            validator.Valid,
+					Err:               nil,
+					DownloadTime:      1500 * time.Millisecond,
+					ValidationTime:    2 * time.Millisecond,
+					KubernetesVersion: "1.18.0",
+				},
+			},
+			`{
+  "resources": [
+    {
+      "filename": "deployment.yml",
+      "kind": "Deployment",
+      "name": "my-app",
+      "version": "apps/v1",
+      "status": "statusValid",
+      "msg": "",
+      "kubernetesVersion": "1.18.0",
+      "downloadTime": 1.5,
+      "validationTime": 0.002
+    }
+  ]
+}
+`,
+		},
+		{
+			"an invalid deployment, with an error code",
+			false,
+			false,
+			false,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path: "deployment.yml",
+						Bytes: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "my-app"
+`),
+					},
+					Status: validator.Invalid,
+					Err:    fmt.Errorf("For field spec: Invalid type"),
+					Code:   validator.ConstraintViolation,
+				},
+			},
+			`{
+  "resources": [
+    {
+      "filename": "deployment.yml",
+      "kind": "Deployment",
+      "name": "my-app",
+      "version": "apps/v1",
+      "status": "statusInvalid",
+      "msg": "For field spec: Invalid type",
+      "code": "constraint-violation"
+    }
+  ]
+}
 `,
 		},
 	} {
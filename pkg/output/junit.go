@@ -11,6 +11,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"sort"
 	"time"
 
 	"github.com/yannh/kubeconform/pkg/validator"
@@ -121,14 +122,20 @@ func (o *junito) Write(result validator.Result) error {
 		o.nValid++
 	case validator.Invalid:
 		o.nInvalid++
+		suite.Failures++
 		failure := TestCaseError{Message: result.Err.Error()}
 		testCase.Failure = append(testCase.Failure, failure)
 	case validator.Error:
 		o.nErrors++
+		suite.Errors++
 		testCase.Error = &TestCaseError{Message: result.Err.Error()}
 	case validator.Skipped:
 		testCase.Skipped = &TestCaseSkipped{}
+		if result.Err != nil {
+			testCase.Skipped.Message = result.Err.Error()
+		}
 		o.nSkipped++
+		suite.Skipped++
 	case validator.Empty:
 		return nil
 	}
@@ -139,7 +146,7 @@ func (o *junito) Write(result validator.Result) error {
 	return nil
 }
 
-// Flush outputs the results as XML
+// Flush outputs the results as XML, with a test suite per file in the order files were first reported
 func (o *junito) Flush() error {
 	runtime := time.Now().Sub(o.startTime)
 
@@ -147,6 +154,7 @@ func (o *junito) Flush() error {
 	for _, suite := range o.suites {
 		suites = append(suites, *suite)
 	}
+	sort.Slice(suites, func(i, j int) bool { return suites[i].Id < suites[j].Id })
 
 	root := TestSuiteCollection{
 		Name:     "kubeconform",
@@ -2,6 +2,7 @@ package output
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -87,6 +88,55 @@ metadata:
 				"  </testsuite>\n" +
 				"</testsuites>\n",
 		},
+		{
+			"resources in several files, with counts per file",
+			true,
+			false,
+			false,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path:  "b.yml",
+						Bytes: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"),
+					},
+					Status: validator.Invalid,
+					Err:    fmt.Errorf("invalid data"),
+				},
+				{
+					Resource: resource.Resource{
+						Path:  "a.yml",
+						Bytes: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: b\n"),
+					},
+					Status: validator.Skipped,
+					Err:    fmt.Errorf("no schema found"),
+				},
+				{
+					Resource: resource.Resource{
+						Path:  "b.yml",
+						Bytes: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: c\n"),
+					},
+					Status: validator.Error,
+					Err:    fmt.Errorf("failed"),
+				},
+			},
+			"<testsuites name=\"kubeconform\" time=\"\" tests=\"3\" failures=\"1\" disabled=\"1\" errors=\"1\">\n" +
+				"  <testsuite name=\"b.yml\" id=\"1\" tests=\"2\" failures=\"1\" errors=\"1\" disabled=\"0\" skipped=\"0\">\n" +
+				"    <properties></properties>\n" +
+				"    <testcase name=\"a\" classname=\"ConfigMap@v1\">\n" +
+				"      <failure message=\"invalid data\" type=\"\"></failure>\n" +
+				"    </testcase>\n" +
+				"    <testcase name=\"c\" classname=\"Secret@v1\">\n" +
+				"      <error message=\"failed\" type=\"\"></error>\n" +
+				"    </testcase>\n" +
+				"  </testsuite>\n" +
+				"  <testsuite name=\"a.yml\" id=\"2\" tests=\"1\" failures=\"0\" errors=\"0\" disabled=\"0\" skipped=\"1\">\n" +
+				"    <properties></properties>\n" +
+				"    <testcase name=\"b\" classname=\"Service@v1\">\n" +
+				"      <skipped message=\"no schema found\"></skipped>\n" +
+				"    </testcase>\n" +
+				"  </testsuite>\n" +
+				"</testsuites>\n",
+		},
 	} {
 		w := new(bytes.Buffer)
 		o := junitOutput(w, testCase.withSummary, testCase.isStdin, testCase.verbose)
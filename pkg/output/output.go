@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/yannh/kubeconform/pkg/validator"
@@ -13,9 +14,20 @@ type Output interface {
 }
 
 func New(outputFormat string, printSummary, isStdin, verbose bool) (Output, error) {
-	w := os.Stdout
+	if outputFormat == "syslog" {
+		return NewSyslog("", "", printSummary, verbose)
+	}
+	return newOutput(os.Stdout, outputFormat, printSummary, isStdin, verbose)
+}
 
+func newOutput(w io.Writer, outputFormat string, printSummary, isStdin, verbose bool) (Output, error) {
 	switch {
+	case outputFormat == "csv":
+		return csvOutput(w, printSummary, isStdin, verbose), nil
+	case outputFormat == "dots":
+		return dotsOutput(w, printSummary, isStdin, verbose), nil
+	case outputFormat == "gitlab":
+		return gitlabOutput(w, printSummary, isStdin, verbose), nil
 	case outputFormat == "json":
 		return jsonOutput(w, printSummary, isStdin, verbose), nil
 	case outputFormat == "junit":
@@ -25,6 +37,80 @@ func New(outputFormat string, printSummary, isStdin, verbose bool) (Output, erro
 	case outputFormat == "text":
 		return textOutput(w, printSummary, isStdin, verbose), nil
 	default:
-		return nil, fmt.Errorf("`outputFormat` must be 'json', 'tap' or 'text'")
+		return nil, fmt.Errorf("`outputFormat` must be 'csv', 'dots', 'gitlab', 'json', 'junit', 'syslog', 'tap' or 'text'")
+	}
+}
+
+// NewTemplate returns an output writing a line per resource formatted with the Go text/template tpl
+func NewTemplate(tpl string, verbose bool) (Output, error) {
+	return templateOutput(os.Stdout, tpl, verbose)
+}
+
+// NewCanonical returns a text output that also prints each valid resource as YAML with sorted keys
+// and consistent indentation
+func NewCanonical(printSummary, isStdin, verbose bool) Output {
+	o := textOutput(os.Stdout, printSummary, isStdin, verbose).(*texto)
+	o.printCanonical = true
+	return o
+}
+
+// NewFailures returns an output writing only invalid resources and errors to w, in outputFormat
+func NewFailures(w io.Writer, outputFormat string, isStdin bool) (Output, error) {
+	o, err := newOutput(w, outputFormat, false, isStdin, false)
+	if err != nil {
+		return nil, err
+	}
+	return &filtered{o: o, statuses: []validator.Status{validator.Invalid, validator.Error}}, nil
+}
+
+// NewSummary returns a text output only printing the summary of the results
+func NewSummary(isStdin bool) Output {
+	o := textOutput(os.Stdout, true, isStdin, false).(*texto)
+	o.summaryOnly = true
+	return o
+}
+
+// filtered writes the results with one of statuses to o
+type filtered struct {
+	o        Output
+	statuses []validator.Status
+}
+
+func (f *filtered) Write(result validator.Result) error {
+	for _, s := range f.statuses {
+		if result.Status == s {
+			return f.o.Write(result)
+		}
+	}
+	return nil
+}
+
+func (f *filtered) Flush() error {
+	return f.o.Flush()
+}
+
+// multi writes results to several outputs
+type multi []Output
+
+// NewMulti returns an output writing results to all of outputs, in order
+func NewMulti(outputs ...Output) Output {
+	return multi(outputs)
+}
+
+func (m multi) Write(result validator.Result) error {
+	for _, o := range m {
+		if err := o.Write(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multi) Flush() error {
+	for _, o := range m {
+		if err := o.Flush(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+var testResults = []validator.Result{
+	{
+		Resource: resource.Resource{Path: "a.yml", Bytes: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")},
+		Status:   validator.Valid,
+	},
+	{
+		Resource: resource.Resource{Path: "a.yml", Bytes: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n")},
+		Status:   validator.Invalid,
+		Err:      fmt.Errorf("invalid data"),
+	},
+	{
+		Resource: resource.Resource{Path: "b.yml", Bytes: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: c\n")},
+		Status:   validator.Skipped,
+	},
+	{
+		Resource: resource.Resource{Path: "b.yml", Bytes: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: d\n")},
+		Status:   validator.Error,
+		Err:      fmt.Errorf("failed"),
+	},
+}
+
+func TestFailuresWrite(t *testing.T) {
+	w := new(bytes.Buffer)
+	o, err := NewFailures(w, "text", false)
+	if err != nil {
+		t.Fatalf("failed creating output: %s", err)
+	}
+	for _, res := range testResults {
+		o.Write(res)
+	}
+	o.Flush()
+
+	expect := "a.yml - ConfigMap b is invalid: invalid data\nb.yml - Secret d failed validation: failed\n"
+	if w.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, w)
+	}
+
+	if _, err := NewFailures(w, "xml", false); err == nil {
+		t.Errorf("expected an error for an invalid output format")
+	}
+}
+
+func TestSummaryWrite(t *testing.T) {
+	w := new(bytes.Buffer)
+	o := textOutput(w, true, false, true).(*texto)
+	o.summaryOnly = true
+
+	failures := new(bytes.Buffer)
+	f, _ := NewFailures(failures, "text", false)
+	m := NewMulti(f, o)
+	for _, res := range testResults {
+		m.Write(res)
+	}
+	m.Flush()
+
+	expect := "Summary: 4 resources found in 2 files - Valid: 1, Invalid: 1, Errors: 1, Skipped: 1\n"
+	if w.String() != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, w)
+	}
+	if failures.Len() == 0 {
+		t.Errorf("expected failures to be written to all outputs")
+	}
+}
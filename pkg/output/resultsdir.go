@@ -0,0 +1,95 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// ResultsDir writes the results of each file to a file of its own in a directory, mirroring the
+// tree of the files validated: the results of manifests/app/deployment.yaml are written to
+// <dir>/manifests/app/deployment.yaml.json, as the JSON output does with -summary and -verbose.
+// Results are grouped by file as they are written, and the files are written on Flush.
+type ResultsDir struct {
+	sync.Mutex
+	dir   string
+	wd    string
+	files map[string]*jsono // results by path of the file validated
+	getwd func() (string, error)
+}
+
+// NewResultsDir returns an output writing the results of each file to dir
+func NewResultsDir(dir string) *ResultsDir {
+	return &ResultsDir{
+		dir:   dir,
+		files: map[string]*jsono{},
+		getwd: os.Getwd,
+	}
+}
+
+func (r *ResultsDir) Write(result validator.Result) error {
+	r.Lock()
+	defer r.Unlock()
+
+	o, ok := r.files[result.Resource.Path]
+	if !ok {
+		o = jsonOutput(nil, true, false, true).(*jsono)
+		r.files[result.Resource.Path] = o
+	}
+	return o.Write(result)
+}
+
+// Flush writes the results of each file to dir
+func (r *ResultsDir) Flush() error {
+	r.Lock()
+	defer r.Unlock()
+
+	wd, err := r.getwd()
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(r.files))
+	for p := range r.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		o := r.files[p]
+		buf := new(bytes.Buffer)
+		o.w = buf
+		if err := o.Flush(); err != nil {
+			return err
+		}
+
+		resultsPath := filepath.Join(r.dir, relativePath(p, wd)+".json")
+		if err := os.MkdirAll(filepath.Dir(resultsPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(resultsPath, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	r.files = map[string]*jsono{}
+
+	return nil
+}
+
+// relativePath returns the path of p relative to the working directory wd. Paths outside of wd are
+// made relative to the root of the filesystem, so that results are never written outside of the
+// results directory.
+func relativePath(p, wd string) string {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(wd, p)
+	}
+	if rel, err := filepath.Rel(wd, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	return strings.TrimLeft(strings.TrimPrefix(filepath.Clean(p), filepath.VolumeName(p)), string(filepath.Separator))
+}
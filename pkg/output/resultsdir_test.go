@@ -0,0 +1,99 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestResultsDir(t *testing.T) {
+	dir := t.TempDir()
+	o := NewResultsDir(dir)
+	o.getwd = func() (string, error) { return "/src", nil }
+
+	deployment := func(path, name string) resource.Resource {
+		return resource.Resource{
+			Path:  path,
+			Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n"),
+		}
+	}
+
+	for _, res := range []validator.Result{
+		{Resource: deployment("manifests/app/deployment.yaml", "a"), Status: validator.Valid},
+		{Resource: deployment("/src/manifests/db.yaml", "b"), Status: validator.Invalid, Err: fmt.Errorf("spec.replicas: Invalid type")},
+		{Resource: deployment("manifests/app/deployment.yaml", "c"), Status: validator.Skipped},
+		{Resource: deployment("../shared/deployment.yaml", "d"), Status: validator.Valid},
+	} {
+		if err := o.Write(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expect := range map[string]string{
+		"manifests/app/deployment.yaml.json": `{
+  "resources": [
+    {
+      "filename": "manifests/app/deployment.yaml",
+      "kind": "Deployment",
+      "name": "a",
+      "version": "apps/v1",
+      "status": "statusValid",
+      "msg": ""
+    },
+    {
+      "filename": "manifests/app/deployment.yaml",
+      "kind": "Deployment",
+      "name": "c",
+      "version": "apps/v1",
+      "status": "statusSkipped",
+      "msg": ""
+    }
+  ],
+  "summary": {
+    "valid": 1,
+    "invalid": 0,
+    "errors": 0,
+    "skipped": 1
+  }
+}
+`,
+		"manifests/db.yaml.json": `{
+  "resources": [
+    {
+      "filename": "/src/manifests/db.yaml",
+      "kind": "Deployment",
+      "name": "b",
+      "version": "apps/v1",
+      "status": "statusInvalid",
+      "msg": "spec.replicas: Invalid type"
+    }
+  ],
+  "summary": {
+    "valid": 0,
+    "invalid": 1,
+    "errors": 0,
+    "skipped": 0
+  }
+}
+`,
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != expect {
+			t.Errorf("%s - expected:\n%s\ngot:\n%s", path, expect, got)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "shared", "deployment.yaml.json")); err != nil {
+		t.Errorf("expected the results of files outside of the working directory to be written in the results directory: %s", err)
+	}
+}
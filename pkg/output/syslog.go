@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// syslogWriter sends messages to syslog at a given priority, as log/syslog's Writer does
+type syslogWriter interface {
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+	Close() error
+}
+
+// dialSyslog connects to the syslog daemon at raddr over network, or to the local syslog daemon if
+// network is empty. It is nil on platforms without syslog support, see syslog_unix.go
+var dialSyslog func(network, raddr, facility string) (syslogWriter, error)
+
+type syslogo struct {
+	sync.Mutex
+	w    syslogWriter
+	text *texto       // formats results as the text output does
+	buf  bytes.Buffer // text written by text, sent to syslog after each result
+}
+
+// NewSyslog returns an output sending each result to syslog: invalid resources and errors at the
+// error priority, skipped resources at the warning priority, and valid resources at the info priority.
+// address is a network://host:port URL, e.g. udp://logs.example.com:514, or empty for the local
+// syslog daemon. facility is a syslog facility name, user if empty.
+func NewSyslog(address, facility string, printSummary, verbose bool) (Output, error) {
+	if dialSyslog == nil {
+		return nil, fmt.Errorf("syslog output is not supported on this platform")
+	}
+
+	network, raddr := "", ""
+	if address != "" {
+		parts := strings.SplitN(address, "://", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid syslog address %s, must be network://host:port, e.g. udp://localhost:514", address)
+		}
+		network, raddr = parts[0], parts[1]
+	}
+
+	w, err := dialSyslog(network, raddr, facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to syslog: %s", err)
+	}
+
+	return syslogOutput(w, printSummary, verbose), nil
+}
+
+func syslogOutput(w syslogWriter, printSummary, verbose bool) *syslogo {
+	o := &syslogo{w: w}
+	o.text = textOutput(&o.buf, printSummary, false, verbose).(*texto)
+	return o
+}
+
+func (o *syslogo) Write(result validator.Result) error {
+	o.Lock()
+	defer o.Unlock()
+
+	defer o.buf.Reset()
+	if err := o.text.Write(result); err != nil {
+		return err
+	}
+
+	msg := strings.TrimSuffix(o.buf.String(), "\n")
+	if msg == "" {
+		return nil
+	}
+
+	switch result.Status {
+	case validator.Invalid, validator.Error:
+		return o.w.Err(msg)
+	case validator.Skipped:
+		return o.w.Warning(msg)
+	default:
+		return o.w.Info(msg)
+	}
+}
+
+// Flush sends the summary, if enabled, and closes the connection to syslog
+func (o *syslogo) Flush() error {
+	o.Lock()
+	defer o.Unlock()
+
+	defer o.buf.Reset()
+	err := o.text.Flush()
+	if msg := strings.TrimSuffix(o.buf.String(), "\n"); err == nil && msg != "" {
+		err = o.w.Info(msg)
+	}
+
+	if closeErr := o.w.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,95 @@
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+type mockSyslogWriter struct {
+	messages []string
+	closed   bool
+}
+
+func (m *mockSyslogWriter) Info(msg string) error {
+	m.messages = append(m.messages, "info: "+msg)
+	return nil
+}
+
+func (m *mockSyslogWriter) Warning(msg string) error {
+	m.messages = append(m.messages, "warning: "+msg)
+	return nil
+}
+
+func (m *mockSyslogWriter) Err(msg string) error {
+	m.messages = append(m.messages, "err: "+msg)
+	return nil
+}
+
+func (m *mockSyslogWriter) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestSyslogWrite(t *testing.T) {
+	deployment := resource.Resource{Path: "deployment.yml", Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n")}
+	results := []validator.Result{
+		{Resource: deployment, Status: validator.Valid},
+		{Resource: deployment, Status: validator.Invalid, Err: fmt.Errorf("For field spec: Invalid type")},
+		{Resource: deployment, Status: validator.Error, Err: fmt.Errorf("could not find schema for Deployment")},
+		{Resource: deployment, Status: validator.Skipped},
+		{Resource: resource.Resource{Path: "empty.yml"}, Status: validator.Empty},
+	}
+
+	for _, testCase := range []struct {
+		name         string
+		printSummary bool
+		verbose      bool
+		expect       []string
+	}{
+		{
+			"no summary, no verbose",
+			false,
+			false,
+			[]string{
+				"err: deployment.yml - Deployment my-app is invalid: For field spec: Invalid type",
+				"err: deployment.yml - Deployment my-app failed validation: could not find schema for Deployment",
+			},
+		},
+		{
+			"summary, verbose",
+			true,
+			true,
+			[]string{
+				"info: deployment.yml - Deployment my-app is valid",
+				"err: deployment.yml - Deployment my-app is invalid: For field spec: Invalid type",
+				"err: deployment.yml - Deployment my-app failed validation: could not find schema for Deployment",
+				"warning: deployment.yml - my-app Deployment skipped",
+				"info: Summary: 4 resources found in 2 files - Valid: 1, Invalid: 1, Errors: 1, Skipped: 1",
+			},
+		},
+	} {
+		w := &mockSyslogWriter{}
+		o := syslogOutput(w, testCase.printSummary, testCase.verbose)
+		for _, res := range results {
+			o.Write(res)
+		}
+		o.Flush()
+
+		if !reflect.DeepEqual(w.messages, testCase.expect) {
+			t.Errorf("%s - expected:\n%q\ngot:\n%q", testCase.name, testCase.expect, w.messages)
+		}
+		if !w.closed {
+			t.Errorf("%s - expected the connection to syslog to be closed", testCase.name)
+		}
+	}
+}
+
+func TestNewSyslogInvalidAddress(t *testing.T) {
+	if _, err := NewSyslog("localhost:514", "", false, false); err == nil {
+		t.Errorf("expected an error for an address without network")
+	}
+}
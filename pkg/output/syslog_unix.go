@@ -0,0 +1,46 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package output
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+func init() {
+	dialSyslog = func(network, raddr, facility string) (syslogWriter, error) {
+		if facility == "" {
+			facility = "user"
+		}
+		priority, ok := syslogFacilities[facility]
+		if !ok {
+			return nil, fmt.Errorf("unknown syslog facility %s", facility)
+		}
+
+		return syslog.Dial(network, raddr, priority|syslog.LOG_INFO, "kubeconform")
+	}
+}
@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package output
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestNewSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("failed listening on UDP: %s", err)
+	}
+	defer conn.Close()
+
+	o, err := NewSyslog("udp://"+conn.LocalAddr().String(), "local0", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Write(validator.Result{
+		Resource: resource.Resource{Path: "deployment.yml", Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n")},
+		Status:   validator.Invalid,
+		Err:      fmt.Errorf("For field spec: Invalid type"),
+	})
+	o.Flush()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// local0 (16) * 8 + err (3)
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<131>") || !strings.Contains(msg, " kubeconform[") || !strings.HasSuffix(strings.TrimSpace(msg), "]: deployment.yml - Deployment my-app is invalid: For field spec: Invalid type") {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+}
+
+func TestNewSyslogUnknownFacility(t *testing.T) {
+	if _, err := NewSyslog("udp://127.0.0.1:514", "nope", false, false); err == nil {
+		t.Errorf("expected an error for an unknown facility")
+	}
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"text/template"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// templateResult is the data a result template is executed with
+type templateResult struct {
+	File, Kind, Name, Namespace, APIVersion string
+	Status                                  string // valid, invalid, error or skipped
+	Msg                                     string // the validation error, if any
+}
+
+type templateo struct {
+	sync.Mutex
+	w       io.Writer
+	tpl     *template.Template
+	verbose bool
+}
+
+// templateOutput writes a line per resource, formatted with a Go text/template. As with the text output,
+// valid and skipped resources are only written in verbose mode. Summaries are not supported.
+func templateOutput(w io.Writer, tpl string, verbose bool) (Output, error) {
+	t, err := template.New("output").Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing output template: %s", err)
+	}
+
+	// Fail early on templates using fields that do not exist
+	if err := t.Execute(ioutil.Discard, templateResult{}); err != nil {
+		return nil, fmt.Errorf("invalid output template: %s", err)
+	}
+
+	return &templateo{
+		w:       w,
+		tpl:     t,
+		verbose: verbose,
+	}, nil
+}
+
+func (o *templateo) Write(result validator.Result) error {
+	o.Lock()
+	defer o.Unlock()
+
+	switch result.Status {
+	case validator.Empty:
+		return nil
+	case validator.Valid, validator.Skipped:
+		if !o.verbose {
+			return nil
+		}
+	}
+
+	msg := ""
+	if result.Err != nil {
+		msg = result.Err.Error()
+	}
+
+	sig, _ := result.Resource.Signature()
+	if err := o.tpl.Execute(o.w, templateResult{
+		File:       result.Resource.Path,
+		Kind:       sig.Kind,
+		Name:       sig.Name,
+		Namespace:  sig.Namespace,
+		APIVersion: sig.Version,
+		Status:     result.Status.String(),
+		Msg:        msg,
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(o.w, "\n")
+	return err
+}
+
+func (o *templateo) Flush() error {
+	return nil
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestTemplateWrite(t *testing.T) {
+	deployment := resource.Resource{
+		Path:  "deployment.yml",
+		Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n  namespace: my-namespace\n"),
+	}
+
+	for _, testCase := range []struct {
+		name    string
+		tpl     string
+		verbose bool
+		results []validator.Result
+		expect  string
+	}{
+		{
+			"invalid resource",
+			"{{ .Status }} {{ .File }} {{ .APIVersion }} {{ .Kind }} {{ .Namespace }}/{{ .Name }}: {{ .Msg }}",
+			false,
+			[]validator.Result{{Resource: deployment, Status: validator.Invalid, Err: fmt.Errorf("For field spec: required")}},
+			"invalid deployment.yml apps/v1 Deployment my-namespace/my-app: For field spec: required\n",
+		},
+		{
+			"valid resources are only written in verbose mode",
+			"{{ .Status }} {{ .Name }}",
+			false,
+			[]validator.Result{{Resource: deployment, Status: validator.Valid}, {Resource: deployment, Status: validator.Skipped}},
+			"",
+		},
+		{
+			"verbose",
+			"{{ .Status }} {{ .Name }}",
+			true,
+			[]validator.Result{{Resource: deployment, Status: validator.Valid}, {Resource: deployment, Status: validator.Skipped}, {Status: validator.Empty}},
+			"valid my-app\nskipped my-app\n",
+		},
+	} {
+		w := new(bytes.Buffer)
+		o, err := templateOutput(w, testCase.tpl, testCase.verbose)
+		if err != nil {
+			t.Fatalf("%s: failed creating output: %s", testCase.name, err)
+		}
+
+		for _, res := range testCase.results {
+			o.Write(res)
+		}
+		o.Flush()
+
+		if w.String() != testCase.expect {
+			t.Errorf("%s - expected:\n%s\ngot:\n%s", testCase.name, testCase.expect, w)
+		}
+	}
+}
+
+func TestTemplateInvalid(t *testing.T) {
+	for _, tpl := range []string{"{{ .Status", "{{ .DoesNotExist }}"} {
+		if _, err := templateOutput(new(bytes.Buffer), tpl, false); err == nil {
+			t.Errorf("expected an error for template %s", tpl)
+		}
+	}
+}
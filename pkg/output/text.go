@@ -3,9 +3,13 @@ package output
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/yannh/kubeconform/pkg/validator"
+	"sigs.k8s.io/yaml"
 )
 
 type texto struct {
@@ -14,6 +18,8 @@ type texto struct {
 	withSummary                         bool
 	isStdin                             bool
 	verbose                             bool
+	printCanonical                      bool // print valid resources as YAML with sorted keys
+	summaryOnly                         bool // only print the summary, not the results
 	files                               map[string]bool
 	nValid, nInvalid, nErrors, nSkipped int
 }
@@ -39,28 +45,45 @@ func (o *texto) Write(result validator.Result) error {
 
 	var err error
 
+	w := o.w
+	if o.summaryOnly {
+		w = ioutil.Discard
+	}
+
 	sig, _ := result.Resource.Signature()
 
 	o.files[result.Resource.Path] = true
 	switch result.Status {
 	case validator.Valid:
 		if o.verbose {
-			_, err = fmt.Fprintf(o.w, "%s - %s %s is valid\n", result.Resource.Path, sig.Kind, sig.Name)
+			_, err = fmt.Fprintf(w, "%s - %s %s is valid%s\n", result.Resource.Path, sig.Kind, sig.Name, details(result))
+		}
+		if err == nil && o.printCanonical {
+			err = writeCanonical(w, result.Resource.Bytes)
 		}
 		o.nValid++
 	case validator.Invalid:
-		_, err = fmt.Fprintf(o.w, "%s - %s %s is invalid: %s\n", result.Resource.Path, sig.Kind, sig.Name, result.Err)
+		if o.verbose {
+			_, err = fmt.Fprintf(w, "%s - %s %s is invalid%s: %s\n", result.Resource.Path, sig.Kind, sig.Name, details(result), result.Err)
+		} else {
+			_, err = fmt.Fprintf(w, "%s - %s %s is invalid: %s\n", result.Resource.Path, sig.Kind, sig.Name, result.Err)
+		}
 		o.nInvalid++
 	case validator.Error:
 		if sig.Kind != "" && sig.Name != "" {
-			_, err = fmt.Fprintf(o.w, "%s - %s %s failed validation: %s\n", result.Resource.Path, sig.Kind, sig.Name, result.Err)
+			_, err = fmt.Fprintf(w, "%s - %s %s failed validation: %s\n", result.Resource.Path, sig.Kind, sig.Name, result.Err)
+		} else if result.Code == validator.ParseError {
+			// Documents that could not be parsed are identified by their index in the file
+			_, err = fmt.Fprintf(w, "%s - document %d failed validation: %s\n", result.Resource.Path, result.Resource.DocumentIndex, result.Err)
 		} else {
-			_, err = fmt.Fprintf(o.w, "%s - failed validation: %s\n", result.Resource.Path, result.Err)
+			_, err = fmt.Fprintf(w, "%s - failed validation: %s\n", result.Resource.Path, result.Err)
 		}
 		o.nErrors++
 	case validator.Skipped:
-		if o.verbose {
-			_, err = fmt.Fprintf(o.w, "%s - %s %s skipped\n", result.Resource.Path, sig.Name, sig.Kind)
+		if o.verbose && result.Err != nil {
+			_, err = fmt.Fprintf(w, "%s - %s %s skipped: %s\n", result.Resource.Path, sig.Name, sig.Kind, result.Err)
+		} else if o.verbose {
+			_, err = fmt.Fprintf(w, "%s - %s %s skipped\n", result.Resource.Path, sig.Name, sig.Kind)
 		}
 		o.nSkipped++
 	case validator.Empty: // sent to ensure we count the filename as parsed
@@ -69,6 +92,42 @@ func (o *texto) Write(result validator.Result) error {
 	return err
 }
 
+// details returns how a resource was validated, printed after its status in verbose mode: the
+// Kubernetes version of the schema it was validated against, and the time spent downloading the
+// schema and validating, e.g. " (Kubernetes 1.28, download 12.5ms, validation 210µs)"
+func details(result validator.Result) string {
+	parts := []string{}
+	if result.KubernetesVersion != "" {
+		parts = append(parts, "Kubernetes "+result.KubernetesVersion)
+	}
+	if result.DownloadTime > 0 {
+		parts = append(parts, "download "+result.DownloadTime.Round(time.Microsecond).String())
+	}
+	if result.ValidationTime > 0 {
+		parts = append(parts, "validation "+result.ValidationTime.Round(time.Microsecond).String())
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// writeCanonical writes a resource as a YAML document with sorted keys and consistent indentation
+func writeCanonical(w io.Writer, b []byte) error {
+	var obj interface{}
+	if err := yaml.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+
+	canonical, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "---\n%s", canonical)
+	return err
+}
+
 func (o *texto) Flush() error {
 	var err error
 	if o.withSummary {
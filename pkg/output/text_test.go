@@ -2,7 +2,9 @@ package output
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/yannh/kubeconform/pkg/resource"
 	"github.com/yannh/kubeconform/pkg/validator"
@@ -69,6 +71,97 @@ metadata:
 Summary: 1 resource found in 1 file - Valid: 1, Invalid: 0, Errors: 0, Skipped: 0
 `,
 		},
+		{
+			"the Kubernetes version of the schema, verbose",
+			false,
+			false,
+			true,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path:  "deployment.yml",
+						Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n"),
+					},
+					Status:            validator.Valid,
+					KubernetesVersion: "1.28",
+				},
+				{
+					Resource: resource.Resource{
+						Path:  "deployment.yml",
+						Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-other-app\n"),
+					},
+					Status:            validator.Invalid,
+					Err:               fmt.Errorf("For field spec.replicas: Invalid type"),
+					KubernetesVersion: "1.28",
+				},
+			},
+			"deployment.yml - Deployment my-app is valid (Kubernetes 1.28)\n" +
+				"deployment.yml - Deployment my-other-app is invalid (Kubernetes 1.28): For field spec.replicas: Invalid type\n",
+		},
+		{
+			"download and validation times, verbose",
+			false,
+			false,
+			true,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path:  "deployment.yml",
+						Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-app\n"),
+					},
+					Status:            validator.Valid,
+					KubernetesVersion: "master",
+					DownloadTime:      12500 * time.Microsecond,
+					ValidationTime:    210400 * time.Nanosecond,
+				},
+				{
+					Resource: resource.Resource{
+						Path:  "deployment.yml",
+						Bytes: []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: my-other-app\n"),
+					},
+					Status:         validator.Valid,
+					ValidationTime: 3 * time.Millisecond,
+				},
+			},
+			"deployment.yml - Deployment my-app is valid (Kubernetes master, download 12.5ms, validation 210µs)\n" +
+				"deployment.yml - Deployment my-other-app is valid (validation 3ms)\n",
+		},
+		{
+			"a resource skipped with a reason, verbose",
+			false,
+			false,
+			true,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path:  "podmetrics.yml",
+						Bytes: []byte("apiVersion: metrics.k8s.io/v1beta1\nkind: PodMetrics\nmetadata:\n  name: my-app\n"),
+					},
+					Status: validator.Skipped,
+					Err:    fmt.Errorf("no schema found for metrics.k8s.io/v1beta1, served by an aggregated API server"),
+				},
+			},
+			"podmetrics.yml - my-app PodMetrics skipped: no schema found for metrics.k8s.io/v1beta1, served by an aggregated API server\n",
+		},
+		{
+			"a document that could not be parsed",
+			false,
+			false,
+			false,
+			[]validator.Result{
+				{
+					Resource: resource.Resource{
+						Path:          "multi.yml",
+						Bytes:         []byte("- a\n- b\n"),
+						DocumentIndex: 2,
+					},
+					Status: validator.Error,
+					Err:    fmt.Errorf("error unmarshalling resource"),
+					Code:   validator.ParseError,
+				},
+			},
+			"multi.yml - document 2 failed validation: error unmarshalling resource\n",
+		},
 	} {
 		w := new(bytes.Buffer)
 		o := textOutput(w, testCase.withSummary, testCase.isStdin, testCase.verbose)
@@ -83,3 +176,46 @@ Summary: 1 resource found in 1 file - Valid: 1, Invalid: 0, Errors: 0, Skipped:
 		}
 	}
 }
+
+func TestTextWriteCanonical(t *testing.T) {
+	w := new(bytes.Buffer)
+	o := textOutput(w, false, false, false).(*texto)
+	o.printCanonical = true
+
+	for _, res := range []validator.Result{
+		{
+			Resource: resource.Resource{
+				Path: "deployment.yml",
+				Bytes: []byte(`kind: Deployment
+apiVersion: apps/v1
+metadata:
+    name: "my-app"
+    labels: {app: my-app}
+`),
+			},
+			Status: validator.Valid,
+		},
+		{
+			Resource: resource.Resource{
+				Path:  "service.yml",
+				Bytes: []byte("kind: Service\napiVersion: v1\nmetadata:\n  name: my-app\n"),
+			},
+			Status: validator.Skipped,
+		},
+	} {
+		o.Write(res)
+	}
+	o.Flush()
+
+	expect := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: my-app
+  name: my-app
+`
+	if got := w.String(); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
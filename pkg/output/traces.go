@@ -0,0 +1,277 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+// tracesBatchSize is the number of spans sent in a single export request
+const tracesBatchSize = 512
+
+// OTLP span kind and status codes
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusError      = 2
+)
+
+// otlpSpan is a span, in the JSON encoding of the OpenTelemetry protocol
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"` // int64 are encoded as strings
+	} `json:"value"`
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	a.Value.StringValue = &value
+	return a
+}
+
+func intAttribute(key string, value int64) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	v := strconv.FormatInt(value, 10)
+	a.Value.IntValue = &v
+	return a
+}
+
+// Traces exports an OpenTelemetry trace of the run over OTLP/HTTP, with a span for the run and a
+// child span for the validation of each resource. Spans are exported in batches, and on Flush.
+type Traces struct {
+	sync.Mutex
+	c           *http.Client
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	traceID     string
+	root        otlpSpan
+	start       time.Time
+	pending     []otlpSpan
+}
+
+// NewTraces returns an output exporting a trace of the run, configured with the standard OTLP
+// exporter environment variables read with getenv, e.g. os.Getenv:
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS or
+// OTEL_EXPORTER_OTLP_TRACES_HEADERS, OTEL_EXPORTER_OTLP_TIMEOUT or OTEL_EXPORTER_OTLP_TRACES_TIMEOUT,
+// and OTEL_SERVICE_NAME. Spans are sent JSON-encoded, the grpc protocol is not supported.
+func NewTraces(getenv func(string) string) (*Traces, error) {
+	env := func(name string) string {
+		if v := getenv("OTEL_EXPORTER_OTLP_TRACES_" + name); v != "" {
+			return v
+		}
+		return getenv("OTEL_EXPORTER_OTLP_" + name)
+	}
+
+	if protocol := env("PROTOCOL"); protocol == "grpc" {
+		return nil, fmt.Errorf("OTLP protocol grpc is not supported, use http/json")
+	}
+
+	endpoint := getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			base = "http://localhost:4318"
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP traces endpoint %s, must be an http or https URL", endpoint)
+	}
+
+	headers := map[string]string{}
+	for _, h := range strings.Split(env("HEADERS"), ",") {
+		if strings.TrimSpace(h) == "" {
+			continue
+		}
+		parts := strings.SplitN(h, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %s, must be key=value", h)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP header %s: %s", h, err)
+		}
+		headers[strings.TrimSpace(parts[0])] = value
+	}
+
+	timeout := 10 * time.Second
+	if v := env("TIMEOUT"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return nil, fmt.Errorf("invalid OTLP timeout %s, must be a number of milliseconds", v)
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	serviceName := getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "kubeconform"
+	}
+
+	t := &Traces{
+		c:           &http.Client{Timeout: timeout},
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		traceID:     randomID(16),
+		start:       time.Now(),
+	}
+	t.root = otlpSpan{TraceID: t.traceID, SpanID: randomID(8), Name: "kubeconform", Kind: otlpSpanKindInternal}
+
+	return t, nil
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Write records a span for the validation of a resource. Results do not carry the time their
+// validation started, the span ends when the result is written and lasts as long as the download and
+// validation of the resource took.
+func (t *Traces) Write(result validator.Result) error {
+	if result.Status == validator.Empty {
+		return nil
+	}
+
+	end := time.Now()
+	sig, _ := result.Resource.Signature()
+
+	span := otlpSpan{
+		TraceID:           t.traceID,
+		SpanID:            randomID(8),
+		ParentSpanID:      t.root.SpanID,
+		Name:              "validate",
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(end.Add(-result.DownloadTime - result.ValidationTime)),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []otlpAttribute{
+			stringAttribute("kubeconform.file", result.Resource.Path),
+			intAttribute("kubeconform.document_index", int64(result.Resource.DocumentIndex)),
+			stringAttribute("kubeconform.kind", sig.Kind),
+			stringAttribute("kubeconform.api_version", sig.Version),
+			stringAttribute("kubeconform.name", sig.Name),
+			stringAttribute("kubeconform.status", result.Status.String()),
+			intAttribute("kubeconform.download_time_ms", result.DownloadTime.Milliseconds()),
+			intAttribute("kubeconform.validation_time_ms", result.ValidationTime.Milliseconds()),
+		},
+	}
+	if sig.Kind != "" {
+		span.Name = "validate " + sig.Kind
+	}
+	if result.Status == validator.Invalid || result.Status == validator.Error {
+		span.Status.Code = otlpStatusError
+		if result.Err != nil {
+			span.Status.Message = result.Err.Error()
+		}
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	t.pending = append(t.pending, span)
+	if len(t.pending) < tracesBatchSize {
+		return nil
+	}
+	spans := t.pending
+	t.pending = nil
+
+	return t.export(spans)
+}
+
+// Flush ends the span of the run, and exports the spans not exported yet
+func (t *Traces) Flush() error {
+	t.Lock()
+	defer t.Unlock()
+
+	t.root.StartTimeUnixNano = unixNano(t.start)
+	t.root.EndTimeUnixNano = unixNano(time.Now())
+	spans := append(t.pending, t.root)
+	t.pending = nil
+
+	return t.export(spans)
+}
+
+// export sends spans to the OTLP endpoint, as an ExportTraceServiceRequest
+func (t *Traces) export(spans []otlpSpan) error {
+	type scopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	type resourceSpans struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+
+	rs := resourceSpans{}
+	rs.Resource.Attributes = []otlpAttribute{stringAttribute("service.name", t.serviceName)}
+	ss := scopeSpans{Spans: spans}
+	ss.Scope.Name = "github.com/yannh/kubeconform"
+	rs.ScopeSpans = []scopeSpans{ss}
+
+	body, err := json.Marshal(struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}{[]resourceSpans{rs}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed exporting traces: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed exporting traces: %s", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed exporting traces to %s: %s", t.endpoint, resp.Status)
+	}
+
+	return nil
+}
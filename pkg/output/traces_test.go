@@ -0,0 +1,131 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"github.com/yannh/kubeconform/pkg/validator"
+)
+
+func TestTraces(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	spans := []otlpSpan{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "Bearer my token" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		spans = append(spans, req.ResourceSpans[0].ScopeSpans[0].Spans...)
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": server.URL + "/",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "Authorization=Bearer%20my%20token",
+	}
+	o, err := NewTraces(func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < tracesBatchSize+1; i++ {
+		o.Write(validator.Result{
+			Resource:       resource.Resource{Path: "deployment.yml", Bytes: []byte(fmt.Sprintf("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app-%d\n", i))},
+			Status:         validator.Valid,
+			ValidationTime: time.Millisecond,
+		})
+	}
+	o.Write(validator.Result{
+		Resource: resource.Resource{Path: "service.yml", Bytes: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: svc\n")},
+		Status:   validator.Invalid,
+		Err:      fmt.Errorf("spec.ports is required"),
+	})
+	o.Write(validator.Result{Resource: resource.Resource{Path: "blank.yml"}, Status: validator.Empty})
+	if err := o.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected spans to be exported in 2 requests, got %d", requests)
+	}
+	if len(spans) != tracesBatchSize+3 {
+		t.Fatalf("expected %d spans, got %d", tracesBatchSize+3, len(spans))
+	}
+
+	root := spans[len(spans)-1]
+	if root.Name != "kubeconform" || root.ParentSpanID != "" || len(root.TraceID) != 32 || len(root.SpanID) != 16 {
+		t.Errorf("unexpected span for the run: %+v", root)
+	}
+	for _, span := range spans[:len(spans)-1] {
+		if span.TraceID != root.TraceID || span.ParentSpanID != root.SpanID {
+			t.Fatalf("expected the span %s to be a child of the span of the run", span.Name)
+		}
+	}
+
+	invalid := spans[len(spans)-2]
+	attributes := map[string]string{}
+	for _, a := range invalid.Attributes {
+		if a.Value.StringValue != nil {
+			attributes[a.Key] = *a.Value.StringValue
+		}
+	}
+	if invalid.Name != "validate Service" || invalid.Status.Code != otlpStatusError || invalid.Status.Message != "spec.ports is required" ||
+		attributes["kubeconform.kind"] != "Service" || attributes["kubeconform.file"] != "service.yml" || attributes["kubeconform.status"] != "invalid" {
+		t.Errorf("unexpected span for an invalid resource: %+v", invalid)
+	}
+}
+
+func TestNewTracesInvalidEnvironment(t *testing.T) {
+	for _, env := range []map[string]string{
+		{"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+		{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "localhost:4318"},
+		{"OTEL_EXPORTER_OTLP_HEADERS": "Authorization"},
+		{"OTEL_EXPORTER_OTLP_TIMEOUT": "10s"},
+	} {
+		if _, err := NewTraces(func(k string) string { return env[k] }); err == nil {
+			t.Errorf("expected an error for %v", env)
+		}
+	}
+}
+
+func TestTracesExportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	o, err := NewTraces(func(k string) string {
+		if k == "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT" {
+			return server.URL + "/custom/traces"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Flush(); err == nil {
+		t.Errorf("expected an error when the endpoint fails")
+	}
+}
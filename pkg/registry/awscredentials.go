@@ -0,0 +1,497 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// awsCredentials are the credentials requests to AWS are signed with
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero if the credentials do not expire
+}
+
+// awsCredentialsChain retrieves AWS credentials as the AWS SDKs and CLI do, from the first source
+// configured: the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, a web identity
+// token (IRSA) set with AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN, the profile set with
+// AWS_PROFILE in the shared credentials and config files, container credentials (ECS, EKS Pod
+// Identity), and the EC2 instance metadata service. Requests are anonymous if none is available.
+// Credentials are retrieved on first use, and again when they expire.
+type awsCredentialsChain struct {
+	c        httpDoer // client for STS
+	metadata httpDoer // client for the container and instance metadata endpoints, without proxy
+	region   string
+	now      func() time.Time
+
+	sync.Mutex
+	creds    *awsCredentials
+	resolved bool
+}
+
+func newAWSCredentialsChain(c httpDoer, region string) *awsCredentialsChain {
+	return &awsCredentialsChain{
+		c:        c,
+		metadata: &http.Client{Transport: &http.Transport{}, Timeout: 5 * time.Second},
+		region:   region,
+		now:      time.Now,
+	}
+}
+
+// get returns the credentials to sign requests with, nil for anonymous requests
+func (p *awsCredentialsChain) get(ctx context.Context) (*awsCredentials, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.resolved && (p.creds == nil || p.creds.Expires.IsZero() || p.creds.Expires.Sub(p.now()) > 5*time.Minute) {
+		return p.creds, nil
+	}
+
+	creds, err := p.resolve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving AWS credentials: %s", err)
+	}
+	p.creds, p.resolved = creds, true
+	return creds, nil
+}
+
+func (p *awsCredentialsChain) resolve(ctx context.Context) (*awsCredentials, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		secret := os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if secret == "" {
+			return nil, fmt.Errorf("AWS_ACCESS_KEY_ID is set without AWS_SECRET_ACCESS_KEY")
+		}
+		return &awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	if tokenFile, role := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && role != "" {
+		return p.webIdentity(ctx, tokenFile, role, os.Getenv("AWS_ROLE_SESSION_NAME"))
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	creds, err := p.profile(ctx, profile, 0)
+	if err != nil || creds != nil {
+		return creds, err
+	}
+	if profile != "" {
+		return nil, fmt.Errorf("profile %s not found in the shared credentials or config files", profile)
+	}
+
+	if os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return p.container(ctx)
+	}
+
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		// Outside of EC2 the instance metadata service is unreachable, requests are then anonymous
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		if creds, err := p.instanceMetadata(ctx); err == nil {
+			return creds, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// awsConfigFile returns the path of a shared AWS file, set with env or in ~/.aws
+func awsConfigFile(env, name string) string {
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// parseINI returns the keys of the sections of an AWS shared credentials or config file. Nested
+// settings, indented below a key without value, are ignored.
+func parseINI(b []byte) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, ";"):
+			continue
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if sections[name] == nil {
+				sections[name] = map[string]string{}
+			}
+			section = sections[name]
+		case section != nil && line == strings.TrimLeft(line, " \t"):
+			if kv := strings.SplitN(trimmed, "=", 2); len(kv) == 2 {
+				section[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	return sections
+}
+
+// profileSettings returns the settings of a profile, from the shared credentials file then the
+// shared config file, and false if neither declares it
+func profileSettings(profile string) (map[string]string, bool) {
+	settings, found := map[string]string{}, false
+	for _, f := range []struct{ env, name, section string }{
+		{"AWS_SHARED_CREDENTIALS_FILE", "credentials", profile},
+		{"AWS_CONFIG_FILE", "config", "profile " + profile},
+	} {
+		b, err := ioutil.ReadFile(awsConfigFile(f.env, f.name))
+		if err != nil {
+			continue
+		}
+		sections := parseINI(b)
+		section, ok := sections[f.section]
+		if !ok && f.name == "config" && profile == "default" {
+			section, ok = sections["default"]
+		}
+		if !ok {
+			continue
+		}
+		found = true
+		for k, v := range section {
+			if _, ok := settings[k]; !ok {
+				settings[k] = v
+			}
+		}
+	}
+	return settings, found
+}
+
+// profileRegion returns the region of the profile set with AWS_PROFILE, or of the default profile
+func profileRegion() string {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	settings, _ := profileSettings(profile)
+	return settings["region"]
+}
+
+// profile returns the credentials of a profile of the shared files, the default one if empty, or
+// nil if the files do not declare it. Profiles assuming a role are followed to their source profile.
+func (p *awsCredentialsChain) profile(ctx context.Context, profile string, depth int) (*awsCredentials, error) {
+	if profile == "" {
+		profile = "default"
+	}
+	if depth > 5 {
+		return nil, fmt.Errorf("too many chained source profiles for profile %s", profile)
+	}
+	settings, ok := profileSettings(profile)
+	if !ok {
+		return nil, nil
+	}
+
+	switch {
+	case settings["role_arn"] != "" && settings["web_identity_token_file"] != "":
+		return p.webIdentity(ctx, settings["web_identity_token_file"], settings["role_arn"], settings["role_session_name"])
+
+	case settings["role_arn"] != "":
+		if settings["source_profile"] == "" {
+			return nil, fmt.Errorf("profile %s assumes role %s without source_profile, only source profiles are supported", profile, settings["role_arn"])
+		}
+		var source *awsCredentials
+		var err error
+		if settings["source_profile"] == profile {
+			source, err = staticProfileCredentials(profile, settings)
+		} else {
+			source, err = p.profile(ctx, settings["source_profile"], depth+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			return nil, fmt.Errorf("source profile %s of profile %s not found", settings["source_profile"], profile)
+		}
+		return p.assumeRole(ctx, source, settings["role_arn"], settings["role_session_name"], settings["external_id"])
+
+	case settings["credential_process"] != "":
+		return credentialProcess(ctx, settings["credential_process"])
+
+	case settings["aws_access_key_id"] != "":
+		return staticProfileCredentials(profile, settings)
+
+	case settings["sso_start_url"] != "" || settings["sso_session"] != "":
+		return nil, fmt.Errorf("profile %s uses IAM Identity Center, which is not supported - export its credentials with \"aws configure export-credentials --profile %s --format env\"", profile, profile)
+	}
+
+	return nil, nil
+}
+
+func staticProfileCredentials(profile string, settings map[string]string) (*awsCredentials, error) {
+	if settings["aws_access_key_id"] == "" || settings["aws_secret_access_key"] == "" {
+		return nil, fmt.Errorf("profile %s has no aws_access_key_id and aws_secret_access_key", profile)
+	}
+	return &awsCredentials{AccessKeyID: settings["aws_access_key_id"], SecretAccessKey: settings["aws_secret_access_key"], SessionToken: settings["aws_session_token"]}, nil
+}
+
+// processCredentials are the credentials printed by a credential_process, or returned by the
+// container and instance metadata endpoints
+type processCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Token           string
+	Expiration      string
+}
+
+func (c processCredentials) credentials(source string) (*awsCredentials, error) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, fmt.Errorf("no credentials returned by %s", source)
+	}
+	creds := &awsCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken}
+	if creds.SessionToken == "" {
+		creds.SessionToken = c.Token
+	}
+	if c.Expiration != "" {
+		expires, err := time.Parse(time.RFC3339, c.Expiration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration %s returned by %s", c.Expiration, source)
+		}
+		creds.Expires = expires
+	}
+	return creds, nil
+}
+
+// credentialProcess runs the credential_process of a profile, which prints credentials as JSON
+func credentialProcess(ctx context.Context, command string) (*awsCredentials, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed running credential_process %s: %s", command, err)
+	}
+
+	var c processCredentials
+	if err := json.Unmarshal(out, &c); err != nil {
+		return nil, fmt.Errorf("failed parsing output of credential_process %s: %s", command, err)
+	}
+	return c.credentials("credential_process " + command)
+}
+
+// getJSON sends req with c and decodes its JSON response into v
+func getJSON(c httpDoer, req *http.Request, v interface{}) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %d: %s", req.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// container returns the credentials of the container credentials endpoint, used by ECS tasks and
+// EKS Pod Identity
+func (p *awsCredentialsChain) container(ctx context.Context) (*awsCredentials, error) {
+	url := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		url = "http://169.254.170.2" + uri
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		b, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading container authorization token: %s", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	var c processCredentials
+	if err := getJSON(p.metadata, req, &c); err != nil {
+		return nil, fmt.Errorf("failed retrieving container credentials: %s", err)
+	}
+	return c.credentials("the container credentials endpoint")
+}
+
+// instanceMetadata returns the credentials of the role of the EC2 instance, with IMDSv2
+func (p *awsCredentialsChain) instanceMetadata(ctx context.Context) (*awsCredentials, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	resp, err := p.metadata.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	token, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed retrieving instance metadata token: HTTP status %d", resp.StatusCode)
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		resp, err := p.metadata.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("instance metadata returned HTTP status %d", resp.StatusCode)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	roles, err := get("")
+	if err != nil {
+		return nil, err
+	}
+	role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+	if role == "" {
+		return nil, fmt.Errorf("no role attached to the instance")
+	}
+	b, err := get(role)
+	if err != nil {
+		return nil, err
+	}
+
+	var c processCredentials
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed parsing instance credentials: %s", err)
+	}
+	return c.credentials("the instance metadata service")
+}
+
+// stsEndpoint returns the URL of the STS endpoint of the region
+func (p *awsCredentialsChain) stsEndpoint() string {
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_STS"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/"
+	}
+	return "https://sts." + p.region + ".amazonaws.com/"
+}
+
+// stsCredentials is the response of the AssumeRole and AssumeRoleWithWebIdentity STS actions, their
+// result is named after the action
+type stsCredentials struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string
+			SessionToken    string
+			Expiration      time.Time
+		}
+	} `xml:",any"`
+}
+
+// sts calls an STS action with the query parameters params, signed with creds unless nil
+func (p *awsCredentialsChain) sts(ctx context.Context, params map[string]string, creds *awsCredentials) (*awsCredentials, error) {
+	params["Version"] = "2011-06-15"
+	if params["RoleSessionName"] == "" {
+		params["RoleSessionName"] = fmt.Sprintf("kubeconform-%d", p.now().Unix())
+	}
+	names := []string{}
+	for k := range params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	query := []string{}
+	for _, k := range names {
+		query = append(query, uriEncode(k, true)+"="+uriEncode(params[k], true))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.stsEndpoint()+"?"+strings.Join(query, "&"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		signV4(req, "sts", p.region, creds, p.now())
+	}
+
+	resp, err := p.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("STS %s returned HTTP status %d: %s", params["Action"], resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var c stsCredentials
+	if err := xml.Unmarshal(body, &c); err != nil {
+		return nil, fmt.Errorf("failed parsing STS %s response: %s", params["Action"], err)
+	}
+	if c.Result.Credentials.AccessKeyID == "" {
+		return nil, fmt.Errorf("no credentials returned by STS %s", params["Action"])
+	}
+	return &awsCredentials{
+		AccessKeyID:     c.Result.Credentials.AccessKeyID,
+		SecretAccessKey: c.Result.Credentials.SecretAccessKey,
+		SessionToken:    c.Result.Credentials.SessionToken,
+		Expires:         c.Result.Credentials.Expiration,
+	}, nil
+}
+
+// webIdentity exchanges the web identity token in tokenFile for the credentials of role, as IRSA does
+func (p *awsCredentialsChain) webIdentity(ctx context.Context, tokenFile, role, sessionName string) (*awsCredentials, error) {
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading web identity token: %s", err)
+	}
+	return p.sts(ctx, map[string]string{
+		"Action":           "AssumeRoleWithWebIdentity",
+		"RoleArn":          role,
+		"RoleSessionName":  sessionName,
+		"WebIdentityToken": strings.TrimSpace(string(token)),
+	}, nil)
+}
+
+// assumeRole returns the credentials of role, assumed with the credentials of a source profile
+func (p *awsCredentialsChain) assumeRole(ctx context.Context, source *awsCredentials, role, sessionName, externalID string) (*awsCredentials, error) {
+	params := map[string]string{"Action": "AssumeRole", "RoleArn": role, "RoleSessionName": sessionName}
+	if externalID != "" {
+		params["ExternalId"] = externalID
+	}
+	return p.sts(ctx, params, source)
+}
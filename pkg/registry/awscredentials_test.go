@@ -0,0 +1,250 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAWSCredentialsChain(t *testing.T) {
+	expiration := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/sts/" && r.URL.Query().Get("Action") == "AssumeRoleWithWebIdentity":
+			if r.URL.Query().Get("WebIdentityToken") != "web-token" || r.URL.Query().Get("RoleArn") != "arn:aws:iam::123456789012:role/irsa" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIAWEB</AccessKeyId><SecretAccessKey>web-secret</SecretAccessKey><SessionToken>web-session</SessionToken>
+<Expiration>%s</Expiration></Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`, expiration.Format(time.RFC3339))
+		case r.URL.Path == "/sts/" && r.URL.Query().Get("Action") == "AssumeRole":
+			if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIASOURCE/") || r.URL.Query().Get("ExternalId") != "external" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIAROLE</AccessKeyId><SecretAccessKey>role-secret</SecretAccessKey><SessionToken>role-session</SessionToken>
+<Expiration>%s</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`, expiration.Format(time.RFC3339))
+		case r.URL.Path == "/container":
+			if r.Header.Get("Authorization") != "container-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprintf(w, `{"AccessKeyId": "ASIACONTAINER", "SecretAccessKey": "container-secret", "Token": "container-session", "Expiration": "%s"}`, expiration.Format(time.RFC3339))
+		case r.URL.Path == "/latest/api/token" && r.Method == http.MethodPut:
+			w.Write([]byte("imds-token"))
+		case strings.HasPrefix(r.URL.Path, "/latest/meta-data/iam/security-credentials/"):
+			if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path == "/latest/meta-data/iam/security-credentials/" {
+				w.Write([]byte("instance-role"))
+				return
+			}
+			fmt.Fprintf(w, `{"Code": "Success", "AccessKeyId": "ASIAINSTANCE", "SecretAccessKey": "instance-secret", "Token": "instance-session", "Expiration": "%s"}`, expiration.Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	ioutil.WriteFile(tokenFile, []byte("web-token\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "credentials"), []byte(`[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[source]
+aws_access_key_id = AKIASOURCE
+aws_secret_access_key = source-secret
+`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "config"), []byte(`[default]
+region = eu-west-3
+
+[profile process]
+credential_process = echo '{"Version": 1, "AccessKeyId": "AKIAPROCESS", "SecretAccessKey": "process-secret"}'
+
+[profile role]
+role_arn = arn:aws:iam::123456789012:role/admin
+source_profile = source
+external_id = external
+
+[profile web]
+role_arn = arn:aws:iam::123456789012:role/irsa
+web_identity_token_file = `+tokenFile+`
+
+[profile sso]
+sso_session = my-sso
+sso_account_id = 123456789012
+`), 0644)
+
+	for i, testCase := range []struct {
+		env       map[string]string
+		expect    *awsCredentials
+		expectErr string
+	}{
+		{
+			map[string]string{"AWS_ACCESS_KEY_ID": "AKIAENV", "AWS_SECRET_ACCESS_KEY": "env-secret", "AWS_SESSION_TOKEN": "env-session"},
+			&awsCredentials{AccessKeyID: "AKIAENV", SecretAccessKey: "env-secret", SessionToken: "env-session"},
+			"",
+		},
+		{
+			map[string]string{"AWS_ACCESS_KEY_ID": "AKIAENV"},
+			nil,
+			"without AWS_SECRET_ACCESS_KEY",
+		},
+		{
+			map[string]string{"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile, "AWS_ROLE_ARN": "arn:aws:iam::123456789012:role/irsa"},
+			&awsCredentials{AccessKeyID: "ASIAWEB", SecretAccessKey: "web-secret", SessionToken: "web-session", Expires: expiration},
+			"",
+		},
+		{
+			map[string]string{"AWS_SHARED_CREDENTIALS_FILE": filepath.Join(dir, "credentials")},
+			&awsCredentials{AccessKeyID: "AKIADEFAULT", SecretAccessKey: "default-secret"},
+			"",
+		},
+		{
+			map[string]string{"AWS_PROFILE": "process"},
+			&awsCredentials{AccessKeyID: "AKIAPROCESS", SecretAccessKey: "process-secret"},
+			"",
+		},
+		{
+			map[string]string{"AWS_PROFILE": "role"},
+			&awsCredentials{AccessKeyID: "ASIAROLE", SecretAccessKey: "role-secret", SessionToken: "role-session", Expires: expiration},
+			"",
+		},
+		{
+			map[string]string{"AWS_PROFILE": "web"},
+			&awsCredentials{AccessKeyID: "ASIAWEB", SecretAccessKey: "web-secret", SessionToken: "web-session", Expires: expiration},
+			"",
+		},
+		{
+			map[string]string{"AWS_PROFILE": "sso"},
+			nil,
+			"IAM Identity Center, which is not supported",
+		},
+		{
+			map[string]string{"AWS_PROFILE": "missing"},
+			nil,
+			"profile missing not found",
+		},
+		{
+			map[string]string{"AWS_CONTAINER_CREDENTIALS_FULL_URI": server.URL + "/container", "AWS_CONTAINER_AUTHORIZATION_TOKEN": "container-token"},
+			&awsCredentials{AccessKeyID: "ASIACONTAINER", SecretAccessKey: "container-secret", SessionToken: "container-session", Expires: expiration},
+			"",
+		},
+		{
+			map[string]string{"AWS_CONTAINER_CREDENTIALS_FULL_URI": server.URL + "/container"},
+			nil,
+			"HTTP status 401",
+		},
+		{
+			map[string]string{"AWS_EC2_METADATA_DISABLED": "", "AWS_EC2_METADATA_SERVICE_ENDPOINT": server.URL},
+			&awsCredentials{AccessKeyID: "ASIAINSTANCE", SecretAccessKey: "instance-secret", SessionToken: "instance-session", Expires: expiration},
+			"",
+		},
+		{
+			map[string]string{"AWS_EC2_METADATA_DISABLED": "", "AWS_EC2_METADATA_SERVICE_ENDPOINT": server.URL + "/unreachable"},
+			nil,
+			"",
+		},
+		{
+			map[string]string{},
+			nil,
+			"",
+		},
+	} {
+		env := map[string]string{
+			"HOME":                                   dir,
+			"AWS_ACCESS_KEY_ID":                      "",
+			"AWS_SECRET_ACCESS_KEY":                  "",
+			"AWS_SESSION_TOKEN":                      "",
+			"AWS_WEB_IDENTITY_TOKEN_FILE":            "",
+			"AWS_ROLE_ARN":                           "",
+			"AWS_ROLE_SESSION_NAME":                  "",
+			"AWS_PROFILE":                            "",
+			"AWS_SHARED_CREDENTIALS_FILE":            filepath.Join(dir, "none"),
+			"AWS_CONFIG_FILE":                        filepath.Join(dir, "config"),
+			"AWS_ENDPOINT_URL_STS":                   server.URL + "/sts/",
+			"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "",
+			"AWS_CONTAINER_CREDENTIALS_FULL_URI":     "",
+			"AWS_CONTAINER_AUTHORIZATION_TOKEN":      "",
+			"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE": "",
+			"AWS_EC2_METADATA_DISABLED":              "true",
+			"AWS_EC2_METADATA_SERVICE_ENDPOINT":      "",
+		}
+		if _, ok := testCase.env["AWS_PROFILE"]; ok || testCase.env["AWS_ACCESS_KEY_ID"] != "" {
+			env["AWS_SHARED_CREDENTIALS_FILE"] = filepath.Join(dir, "credentials")
+		}
+		for k, v := range testCase.env {
+			env[k] = v
+		}
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+
+		p := newAWSCredentialsChain(server.Client(), "us-east-1")
+		got, err := p.get(context.Background())
+		if testCase.expectErr != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.expectErr) {
+				t.Errorf("test %d: expected error containing %s, got %v", i+1, testCase.expectErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error %s", i+1, err)
+			continue
+		}
+		if (got == nil) != (testCase.expect == nil) || (got != nil && *got != *testCase.expect) {
+			t.Errorf("test %d: expected %+v, got %+v", i+1, testCase.expect, got)
+		}
+	}
+}
+
+func TestAWSCredentialsChainRefresh(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"AccessKeyId": "ASIA%d", "SecretAccessKey": "secret", "Token": "session", "Expiration": "2030-01-01T01:00:00Z"}`, requests)
+	}))
+	defer server.Close()
+
+	for k, v := range map[string]string{
+		"AWS_ACCESS_KEY_ID":                  "",
+		"AWS_WEB_IDENTITY_TOKEN_FILE":        "",
+		"AWS_PROFILE":                        "",
+		"AWS_SHARED_CREDENTIALS_FILE":        filepath.Join(t.TempDir(), "none"),
+		"AWS_CONFIG_FILE":                    filepath.Join(t.TempDir(), "none"),
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": server.URL,
+	} {
+		t.Setenv(k, v)
+	}
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newAWSCredentialsChain(server.Client(), "us-east-1")
+	p.now = func() time.Time { return now }
+
+	for i, testCase := range []struct {
+		after  time.Duration
+		expect string
+	}{
+		{0, "ASIA1"},
+		{30 * time.Minute, "ASIA1"},
+		{56 * time.Minute, "ASIA2"},
+	} {
+		now = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Add(testCase.after)
+		got, err := p.get(context.Background())
+		if err != nil || got.AccessKeyID != testCase.expect {
+			t.Errorf("test %d: expected %s, got %+v, %v", i+1, testCase.expect, got, err)
+		}
+	}
+}
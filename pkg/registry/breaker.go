@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// UnavailableError is returned instead of querying a registry that has been failing repeatedly
+type UnavailableError struct {
+	err error
+}
+
+func newUnavailableError(err error) *UnavailableError {
+	return &UnavailableError{err}
+}
+func (e *UnavailableError) Error() string { return e.err.Error() }
+
+// CircuitBreaker wraps a Registry, and stops sending it requests for the rest of a cooldown
+// period once it has failed a number of times in a row. This lets the validator fall through
+// to the next registry, for example a mirror, instead of waiting for errors on every resource.
+// It is safe to use from multiple Go routines.
+type CircuitBreaker struct {
+	sync.Mutex
+	reg       Registry
+	threshold int           // number of consecutive failures after which the circuit opens
+	cooldown  time.Duration // time after which a request is let through again
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// NewCircuitBreaker returns a registry that stops querying reg after threshold consecutive failures,
+// and probes it again after cooldown
+func NewCircuitBreaker(reg Registry, threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		reg:       reg,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (cb *CircuitBreaker) isOpen() bool {
+	cb.Lock()
+	defer cb.Unlock()
+
+	return cb.failures >= cb.threshold && cb.now().Sub(cb.openedAt) < cb.cooldown
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.Lock()
+	defer cb.Unlock()
+
+	// A schema not being in a registry is a normal response from a healthy registry
+	if _, notFound := err.(*NotFoundError); err == nil || notFound {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		if cb.failures == cb.threshold {
+			log.Printf("warning: schema registry disabled for %s after %d consecutive failures: %s", cb.cooldown, cb.threshold, err)
+		}
+		cb.openedAt = cb.now()
+	}
+}
+
+// DownloadSchemaWithContext downloads the schema from the underlying registry, unless it is considered unavailable
+func (cb *CircuitBreaker) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	if cb.isOpen() {
+		return nil, newUnavailableError(fmt.Errorf("schema registry disabled after %d consecutive failures", cb.threshold))
+	}
+
+	b, err := DownloadSchemaWithContext(ctx, cb.reg, resourceKind, resourceAPIVersion, k8sVersion)
+	if ctx.Err() == nil { // cancelled requests say nothing about the health of the registry
+		cb.record(err)
+	}
+
+	return b, err
+}
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (cb *CircuitBreaker) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return cb.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
+
+// Unwrap returns the registry protected by the circuit breaker
+func (cb *CircuitBreaker) Unwrap() Registry {
+	return cb.reg
+}
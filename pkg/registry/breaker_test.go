@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type mockRegistry struct {
+	calls  int
+	schema []byte
+	err    error
+}
+
+func (m *mockRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	m.calls++
+	return m.schema, m.err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	reg := &mockRegistry{err: fmt.Errorf("connection refused")}
+	cb := NewCircuitBreaker(reg, 2, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		cb.DownloadSchema("Deployment", "apps/v1", "1.18.0")
+	}
+	if reg.calls != 2 {
+		t.Errorf("expected registry to be called 2 times before the circuit opens, got %d", reg.calls)
+	}
+
+	_, err := cb.DownloadSchema("Deployment", "apps/v1", "1.18.0")
+	if _, ok := err.(*UnavailableError); !ok {
+		t.Errorf("expected UnavailableError while the circuit is open, got %v", err)
+	}
+
+	// After the cooldown, the registry gets probed again
+	now = now.Add(2 * time.Minute)
+	reg.err = newNotFoundError(fmt.Errorf("no schema found"))
+	cb.DownloadSchema("Deployment", "apps/v1", "1.18.0")
+	cb.DownloadSchema("Deployment", "apps/v1", "1.18.0")
+	if reg.calls != 4 {
+		t.Errorf("expected registry to be probed after cooldown, got %d calls", reg.calls)
+	}
+}
+
+func TestCircuitBreakerIgnoresCancelledRequests(t *testing.T) {
+	reg := &mockRegistry{err: context.Canceled}
+	cb := NewCircuitBreaker(reg, 1, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cb.DownloadSchemaWithContext(ctx, "Deployment", "apps/v1", "1.18.0")
+	if _, err := cb.DownloadSchema("Deployment", "apps/v1", "1.18.0"); err != context.Canceled {
+		t.Errorf("expected cancelled requests not to open the circuit, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package registry
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/cache"
+)
+
+const (
+	s3Prefix  = "s3://"
+	gcsPrefix = "gs://"
+)
+
+// BucketRegistry retrieves schemas from an AWS S3 or Google Cloud Storage bucket.
+//
+// Credentials are found as the cloud providers' SDKs find them, see awsCredentialsChain and
+// gcpTokenSource. Without credentials, requests are anonymous, which works for public buckets.
+type BucketRegistry struct {
+	c                  httpDoer
+	endpoint           string // URL of the bucket, the object key is appended to it
+	schemaPathTemplate string // template of the object key
+	cache              cache.Cache
+	strict             bool
+	sign               func(ctx context.Context, req *http.Request) (signed bool, err error) // adds credentials to a request, if any
+	forbiddenNotFound  bool                                                                  // HTTP status 403 to anonymous requests means the object does not exist, as S3 answers without ListBucket
+}
+
+// parseBucketLocation splits a location such as s3://bucket/path/{{ .ResourceKind }}.json?region=eu-west-1
+// into the bucket, the object key template and the region, if any
+func parseBucketLocation(location string) (bucket, keyTemplate, region string, err error) {
+	if i := strings.LastIndex(location, "?region="); i >= 0 {
+		location, region = location[:i], location[i+len("?region="):]
+	}
+
+	parts := strings.SplitN(location, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("schema location must be in the form bucket/path")
+	}
+
+	return parts[0], parts[1], region, nil
+}
+
+func newBucketRegistry(schemaLocation string, cacheFolder string, strict bool, skipTLS bool, noProxy string, timeout time.Duration) (*BucketRegistry, error) {
+	filecache, err := newFileCache(cacheFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := &BucketRegistry{
+		c:      newHTTPClient(skipTLS, noProxy, timeout, ""),
+		cache:  filecache,
+		strict: strict,
+	}
+
+	var bucket, region string
+	switch {
+	case strings.HasPrefix(schemaLocation, s3Prefix):
+		if bucket, reg.schemaPathTemplate, region, err = parseBucketLocation(strings.TrimPrefix(schemaLocation, s3Prefix)); err != nil {
+			return nil, fmt.Errorf("failed initialising S3 registry: %s", err)
+		}
+		for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+			if region == "" {
+				region = os.Getenv(env)
+			}
+		}
+		if region == "" {
+			region = profileRegion()
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		reg.endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", bucket, region)
+		reg.forbiddenNotFound = true
+		reg.sign = s3Signer(region, newAWSCredentialsChain(reg.c, region).get, time.Now)
+
+	case strings.HasPrefix(schemaLocation, gcsPrefix):
+		if bucket, reg.schemaPathTemplate, _, err = parseBucketLocation(strings.TrimPrefix(schemaLocation, gcsPrefix)); err != nil {
+			return nil, fmt.Errorf("failed initialising GCS registry: %s", err)
+		}
+		reg.endpoint = fmt.Sprintf("https://storage.googleapis.com/%s/", bucket)
+		reg.sign = bearerSigner(newGCPTokenSource(reg.c).get)
+	}
+
+	if err := checkSchemaPath(reg.schemaPathTemplate); err != nil {
+		return nil, fmt.Errorf("failed initialising bucket registry: %s", err)
+	}
+
+	return reg, nil
+}
+
+// DownloadSchemaWithContext downloads the schema for a particular resource from a bucket
+func (r BucketRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	key, err := schemaPath(r.schemaPathTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		if b, err := r.cache.Get(resourceKind, resourceAPIVersion, k8sVersion); err == nil {
+			return b.([]byte), nil
+		}
+	}
+
+	url := r.endpoint + uriEncode(key, false)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading schema at %s: %s", url, err)
+	}
+	signed, err := r.sign(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading schema at %s: %s", url, err)
+	}
+
+	resp, err := r.c.Do(req)
+	if err == nil && resp.StatusCode == http.StatusForbidden && r.forbiddenNotFound && !signed {
+		resp.Body.Close()
+		return nil, newNotFoundError(fmt.Errorf("no schema found, or access to %s denied - received HTTP status 403", url))
+	}
+	body, err := readSchemaResponse(url, resp, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		if err := r.cache.Set(resourceKind, resourceAPIVersion, k8sVersion, body); err != nil {
+			return nil, fmt.Errorf("failed writing schema to cache: %s", err)
+		}
+	}
+
+	return body, nil
+}
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (r BucketRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return r.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
+
+// bearerSigner adds the OAuth2 access token returned by token to requests, unless it is empty
+func bearerSigner(token func(ctx context.Context) (string, error)) func(ctx context.Context, req *http.Request) (bool, error) {
+	return func(ctx context.Context, req *http.Request) (bool, error) {
+		t, err := token(ctx)
+		if err != nil || t == "" {
+			return false, err
+		}
+		req.Header.Set("Authorization", "Bearer "+t)
+		return true, nil
+	}
+}
+
+// uriEncode escapes s as required by AWS Signature Version 4, optionally leaving slashes untouched
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+// s3Signer signs requests to S3 with the credentials returned by creds, unless nil
+func s3Signer(region string, creds func(ctx context.Context) (*awsCredentials, error), now func() time.Time) func(ctx context.Context, req *http.Request) (bool, error) {
+	return func(ctx context.Context, req *http.Request) (bool, error) {
+		c, err := creds(ctx)
+		if err != nil || c == nil {
+			return false, err
+		}
+		signV4(req, "s3", region, c, now())
+		return true, nil
+	}
+}
+
+// signV4 signs GET requests to an AWS service with AWS Signature Version 4. All headers of the request
+// are signed, its query must be canonical, sorted and encoded with uriEncode.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signV4(req *http.Request, service, region string, creds *awsCredentials, now time.Time) {
+	t := now.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex("")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := []string{}
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/cache"
+)
+
+// catalogIndex maps "apiVersion/Kind" (e.g. apps/v1/Deployment) to a schema path,
+// relative to the location of the index
+type catalogIndex struct {
+	base    *url.URL
+	schemas map[string]string
+	err     error
+}
+
+// CatalogRegistry resolves schemas through an index file published by the schema host,
+// instead of templating the schema filenames
+type CatalogRegistry struct {
+	sync.Mutex
+	c             httpDoer
+	indexTemplate string
+	cache         cache.Cache
+	strict        bool
+	indexes       map[string]*catalogIndex // indexes already retrieved, by URL
+}
+
+func newCatalogRegistry(indexTemplate string, cacheFolder string, strict bool, skipTLS bool, noProxy string, timeout time.Duration) (*CatalogRegistry, error) {
+	filecache, err := newFileCache(cacheFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CatalogRegistry{
+		c:             newHTTPClient(skipTLS, noProxy, timeout, httpsHost(indexTemplate)),
+		indexTemplate: indexTemplate,
+		cache:         filecache,
+		strict:        strict,
+		indexes:       map[string]*catalogIndex{},
+	}, nil
+}
+
+// index retrieves the index at indexURL. Indexes are only retrieved once per run,
+// failures included - unless the request was cancelled.
+func (r *CatalogRegistry) index(ctx context.Context, indexURL string) (*catalogIndex, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if idx, ok := r.indexes[indexURL]; ok {
+		return idx, idx.err
+	}
+
+	idx := &catalogIndex{}
+	idx.base, idx.schemas, idx.err = r.fetchIndex(ctx, indexURL)
+	if ctx.Err() == nil {
+		r.indexes[indexURL] = idx
+	}
+
+	return idx, idx.err
+}
+
+func (r *CatalogRegistry) fetchIndex(ctx context.Context, indexURL string) (*url.URL, map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed downloading schema index at %s: %s", indexURL, err)
+	}
+
+	resp, err := r.c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed downloading schema index at %s: %s", indexURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("error while downloading schema index at %s - received HTTP status %d", indexURL, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed downloading schema index at %s: %s", indexURL, err)
+	}
+
+	schemas := map[string]string{}
+	if err := json.Unmarshal(body, &schemas); err != nil {
+		return nil, nil, fmt.Errorf("failed parsing schema index at %s: %s", indexURL, err)
+	}
+
+	// Schema paths are relative to the location we were eventually redirected to
+	var base *url.URL
+	if resp.Request != nil {
+		base = resp.Request.URL
+	}
+	if base == nil {
+		if base, err = url.Parse(indexURL); err != nil {
+			return nil, nil, fmt.Errorf("failed parsing schema index URL %s: %s", indexURL, err)
+		}
+	}
+
+	return base, schemas, nil
+}
+
+// DownloadSchemaWithContext looks up the schema for a resource in the index, then downloads it
+func (r *CatalogRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	indexURL, err := schemaPath(r.indexTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		if b, err := r.cache.Get(resourceKind, resourceAPIVersion, k8sVersion); err == nil {
+			return b.([]byte), nil
+		}
+	}
+
+	idx, err := r.index(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := idx.schemas[resourceAPIVersion+"/"+resourceKind]
+	if !ok {
+		return nil, newNotFoundError(fmt.Errorf("no schema found"))
+	}
+
+	ref, err := url.Parse(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema path %s in index %s: %s", p, indexURL, err)
+	}
+
+	body, err := downloadURL(ctx, r.c, idx.base.ResolveReference(ref).String())
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		if err := r.cache.Set(resourceKind, resourceAPIVersion, k8sVersion, body); err != nil {
+			return nil, fmt.Errorf("failed writing schema to cache: %s", err)
+		}
+	}
+
+	return body, nil
+}
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (r *CatalogRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return r.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
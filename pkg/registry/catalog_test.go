@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCatalogDownloadSchema(t *testing.T) {
+	index := `{"apps/v1/Deployment": "apps/deployment-v1.json", "v1/Service": "../core/service-v1.json"}`
+
+	var mu sync.Mutex
+	indexFetches := 0
+	c := newMockHTTPDoer(func(u string) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch u {
+		case "http://kubernetesjson.dev/index.json":
+			indexFetches++
+			// Simulate a redirect: schema paths are relative to the final location
+			final, _ := url.Parse("http://mirror.kubernetesjson.dev/schemas/index.json")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(index)),
+				Request:    &http.Request{URL: final},
+			}, nil
+		case "http://mirror.kubernetesjson.dev/schemas/apps/deployment-v1.json":
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("deployment"))}, nil
+		case "http://mirror.kubernetesjson.dev/core/service-v1.json":
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("service"))}, nil
+		}
+
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	reg := CatalogRegistry{
+		c:             c,
+		indexTemplate: "http://kubernetesjson.dev/index.json",
+		indexes:       map[string]*catalogIndex{},
+	}
+
+	for _, testCase := range []struct {
+		kind, apiVersion string
+		expect           []byte
+		expectNotFound   bool
+	}{
+		{"Deployment", "apps/v1", []byte("deployment"), false},
+		{"Service", "v1", []byte("service"), false},
+		{"ConfigMap", "v1", nil, true},
+	} {
+		res, err := reg.DownloadSchema(testCase.kind, testCase.apiVersion, "1.18.0")
+		if _, notFound := err.(*NotFoundError); notFound != testCase.expectNotFound {
+			t.Errorf("%s: expected not found %t, got error %v", testCase.kind, testCase.expectNotFound, err)
+		}
+		if !bytes.Equal(res, testCase.expect) {
+			t.Errorf("%s: expected %s, got %s", testCase.kind, testCase.expect, res)
+		}
+	}
+
+	if indexFetches != 1 {
+		t.Errorf("expected index to be retrieved once, was retrieved %d times", indexFetches)
+	}
+}
@@ -0,0 +1,265 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// clusterLocation retrieves schemas from the cluster of the current kubeconfig context
+	clusterLocation = "cluster"
+	// clusterPrefix retrieves schemas from the cluster of a kubeconfig context, e.g. cluster+staging
+	clusterPrefix = "cluster+"
+)
+
+// openAPISpec contains the definitions of an OpenAPI v2 document, and the definition of each kind
+type openAPISpec struct {
+	definitions map[string]interface{}
+	kinds       map[string]string // definition names by apiVersion/Kind
+	err         error
+}
+
+// ClusterRegistry derives schemas from the OpenAPI document served by a Kubernetes API server, so that
+// resources are validated against the exact API of that cluster, including its custom resources.
+// The document is only retrieved once per run.
+type ClusterRegistry struct {
+	sync.Mutex
+	c         httpDoer
+	server    string
+	authorize func(req *http.Request) // adds credentials to a request
+	strict    bool
+	spec      *openAPISpec
+}
+
+func newClusterRegistry(kubeContext string, strict bool, skipTLS bool, noProxy string, timeout time.Duration) (*ClusterRegistry, error) {
+	kc, err := loadKubeconfig(kubeconfigPaths())
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising cluster registry: %s", err)
+	}
+
+	cluster, user, err := kc.context(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising cluster registry: %s", err)
+	}
+
+	c, authorize, err := newClusterHTTPClient(cluster, user, skipTLS, noProxy, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising cluster registry: %s", err)
+	}
+
+	return &ClusterRegistry{
+		c:         c,
+		server:    strings.TrimSuffix(cluster.Server, "/"),
+		authorize: authorize,
+		strict:    strict,
+	}, nil
+}
+
+// openAPI retrieves the OpenAPI document of the cluster. Failures are kept for the rest of the run,
+// unless the request was cancelled.
+func (r *ClusterRegistry) openAPI(ctx context.Context) (*openAPISpec, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.spec != nil {
+		return r.spec, r.spec.err
+	}
+
+	spec := &openAPISpec{}
+	spec.definitions, spec.kinds, spec.err = r.fetchOpenAPI(ctx)
+	if ctx.Err() == nil {
+		r.spec = spec
+	}
+
+	return spec, spec.err
+}
+
+func (r *ClusterRegistry) fetchOpenAPI(ctx context.Context) (map[string]interface{}, map[string]string, error) {
+	url := r.server + "/openapi/v2"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed downloading OpenAPI document at %s: %s", url, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	r.authorize(req)
+
+	resp, err := r.c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed downloading OpenAPI document at %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("error while downloading OpenAPI document at %s - received HTTP status %d", url, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed downloading OpenAPI document at %s: %s", url, err)
+	}
+
+	var doc struct {
+		Definitions map[string]interface{} `json:"definitions"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed parsing OpenAPI document at %s: %s", url, err)
+	}
+
+	return doc.Definitions, definitionsByKind(doc.Definitions), nil
+}
+
+// definitionsByKind indexes definitions by the apiVersion/Kind in their x-kubernetes-group-version-kind extension
+func definitionsByKind(definitions map[string]interface{}) map[string]string {
+	kinds := map[string]string{}
+	for name, def := range definitions {
+		d, _ := def.(map[string]interface{})
+		gvks, _ := d["x-kubernetes-group-version-kind"].([]interface{})
+		for _, gvk := range gvks {
+			g, _ := gvk.(map[string]interface{})
+			group, _ := g["group"].(string)
+			version, _ := g["version"].(string)
+			kind, _ := g["kind"].(string)
+
+			apiVersion := version
+			if group != "" {
+				apiVersion = group + "/" + version
+			}
+			kinds[apiVersion+"/"+kind] = name
+		}
+	}
+
+	return kinds
+}
+
+// collectRefs adds the names of the definitions referenced by obj, directly or indirectly, to refs
+func collectRefs(definitions map[string]interface{}, obj interface{}, refs map[string]bool) {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range o {
+			if ref, ok := v.(string); ok && k == "$ref" && strings.HasPrefix(ref, "#/definitions/") {
+				name := strings.TrimPrefix(ref, "#/definitions/")
+				if !refs[name] {
+					refs[name] = true
+					collectRefs(definitions, definitions[name], refs)
+				}
+				continue
+			}
+			collectRefs(definitions, v, refs)
+		}
+	case []interface{}:
+		for _, v := range o {
+			collectRefs(definitions, v, refs)
+		}
+	}
+}
+
+// toJSONSchema converts an OpenAPI schema to JSON schema in place, as openapi2jsonschema does:
+// int-or-string fields accept both types, and in strict mode, objects do not allow additional properties
+func toJSONSchema(schema interface{}, strict bool) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if s["format"] == "int-or-string" || s["x-kubernetes-int-or-string"] == true {
+		delete(s, "type")
+		delete(s, "format")
+		s["oneOf"] = []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "integer"}}
+	}
+
+	if properties, ok := s["properties"].(map[string]interface{}); ok {
+		if _, ok := s["additionalProperties"]; !ok && strict {
+			s["additionalProperties"] = false
+		}
+		for _, p := range properties {
+			toJSONSchema(p, strict)
+		}
+	}
+	toJSONSchema(s["additionalProperties"], strict)
+	toJSONSchema(s["not"], strict)
+
+	subschemas := []interface{}{}
+	for _, k := range []string{"items", "allOf", "anyOf", "oneOf"} {
+		if l, ok := s[k].([]interface{}); ok {
+			subschemas = append(subschemas, l...)
+		} else {
+			subschemas = append(subschemas, s[k])
+		}
+	}
+	for _, sub := range subschemas {
+		toJSONSchema(sub, strict)
+	}
+}
+
+// standaloneSchema returns a JSON schema for the definition name, embedding the definitions it references
+func standaloneSchema(definitions map[string]interface{}, name string, strict bool) ([]byte, error) {
+	refs := map[string]bool{}
+	collectRefs(definitions, definitions[name], refs)
+
+	defs := map[string]interface{}{}
+	for ref := range refs {
+		defs[ref] = definitions[ref]
+	}
+
+	// Definitions are shared between kinds, they are copied before being modified
+	b, err := json.Marshal(map[string]interface{}{"root": definitions[name], "definitions": defs})
+	if err != nil {
+		return nil, err
+	}
+	var schema struct {
+		Root        map[string]interface{} `json:"root"`
+		Definitions map[string]interface{} `json:"definitions"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, err
+	}
+
+	toJSONSchema(schema.Root, strict)
+	for _, def := range schema.Definitions {
+		toJSONSchema(def, strict)
+	}
+	if len(schema.Definitions) > 0 {
+		schema.Root["definitions"] = schema.Definitions
+	}
+
+	return json.Marshal(schema.Root)
+}
+
+// DownloadSchemaWithContext derives the schema for a resource from the OpenAPI document of the cluster.
+// The Kubernetes version is the one of the cluster.
+func (r *ClusterRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	spec, err := r.openAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name, ok := spec.kinds[resourceAPIVersion+"/"+resourceKind]
+	if !ok {
+		return nil, newNotFoundError(fmt.Errorf("no schema found"))
+	}
+
+	return standaloneSchema(spec.definitions, name, r.strict)
+}
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (r *ClusterRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return r.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
+
+// ServesKind returns true if the OpenAPI document of the cluster has a definition for resourceAPIVersion/resourceKind.
+// It is false if the document can not be retrieved.
+func (r *ClusterRegistry) ServesKind(ctx context.Context, resourceKind, resourceAPIVersion string) bool {
+	spec, err := r.openAPI(ctx)
+	if err != nil {
+		return false
+	}
+
+	_, ok := spec.kinds[resourceAPIVersion+"/"+resourceKind]
+	return ok
+}
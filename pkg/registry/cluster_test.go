@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const testOpenAPI = `{
+  "definitions": {
+    "io.k8s.api.apps.v1.Deployment": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1.DeploymentSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1"}]
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "type": "object",
+      "properties": {
+        "replicas": {"type": "integer"},
+        "maxSurge": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.util.intstr.IntOrString"}
+      }
+    },
+    "io.k8s.apimachinery.pkg.util.intstr.IntOrString": {"type": "string", "format": "int-or-string"},
+    "io.k8s.api.core.v1.ConfigMap": {
+      "type": "object",
+      "properties": {"data": {"type": "object", "additionalProperties": {"type": "string"}}},
+      "x-kubernetes-group-version-kind": [{"group": "", "kind": "ConfigMap", "version": "v1"}]
+    }
+  }
+}`
+
+func TestClusterRegistry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/openapi/v2" || r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testOpenAPI))
+	}))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+users:
+- name: test
+  user:
+    token: s3cret
+`, server.URL)), 0600); err != nil {
+		t.Fatalf("failed writing kubeconfig: %s", err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	for i, testCase := range []struct {
+		location, kind, apiVersion string
+		strict                     bool
+		resource                   string
+		expectValid                bool
+	}{
+		{"cluster", "Deployment", "apps/v1", false, `{"spec": {"replicas": 1, "maxSurge": "25%"}}`, true},
+		{"cluster", "Deployment", "apps/v1", false, `{"spec": {"replicas": 1, "maxSurge": 1}}`, true},
+		{"cluster", "Deployment", "apps/v1", false, `{"spec": {"replicas": "1"}}`, false},
+		{"cluster", "Deployment", "apps/v1", false, `{"spec": {"foo": 1}}`, true},
+		{"cluster+test", "Deployment", "apps/v1", true, `{"spec": {"foo": 1}}`, false},
+		{"cluster", "ConfigMap", "v1", true, `{"data": {"foo": "bar"}}`, true},
+	} {
+		reg, err := New(testCase.location, "", testCase.strict, false)
+		if err != nil {
+			t.Fatalf("%d - failed creating registry: %s", i, err)
+		}
+
+		b, err := reg.DownloadSchema(testCase.kind, testCase.apiVersion, "master")
+		if err != nil {
+			t.Fatalf("%d - failed downloading schema: %s", i, err)
+		}
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(b))
+		if err != nil {
+			t.Fatalf("%d - failed compiling schema: %s", i, err)
+		}
+		res, err := schema.Validate(gojsonschema.NewStringLoader(testCase.resource))
+		if err != nil || res.Valid() != testCase.expectValid {
+			t.Errorf("%d - expected valid to be %t, got %v, %v", i, testCase.expectValid, res.Errors(), err)
+		}
+	}
+
+	reg, _ := New("cluster", "", false, false)
+	if _, err := reg.DownloadSchema("Service", "v1", "master"); err == nil {
+		t.Errorf("expected an error for a kind missing from the cluster")
+	} else if _, notFound := err.(*NotFoundError); !notFound {
+		t.Errorf("expected a NotFoundError, got %s", err)
+	}
+	reg.DownloadSchema("Deployment", "apps/v1", "master")
+	if s, ok := AsKindServer(NewCircuitBreaker(reg, 3, time.Minute)); !ok || !s.ServesKind(context.Background(), "Deployment", "apps/v1") || s.ServesKind(context.Background(), "Service", "v1") {
+		t.Errorf("expected the cluster to serve Deployments but not Services")
+	}
+	if requests != 7 {
+		t.Errorf("expected the OpenAPI document to be retrieved once per registry, got %d requests", requests)
+	}
+
+	if _, err := New("cluster+does-not-exist", "", false, false); err == nil {
+		t.Errorf("expected an error for a missing context")
+	}
+}
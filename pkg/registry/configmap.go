@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// configMapPrefix retrieves schemas from a ConfigMap, e.g. configmap://namespace/name
+	configMapPrefix = "configmap://"
+	// configMapKeyTemplate is the key of the schema of a resource in a ConfigMap, the file names of
+	// github.com/yannh/kubernetes-json-schema so that folders of schemas can be stored as they are
+	configMapKeyTemplate = "{{ .ResourceKind }}{{ .KindSuffix }}.json"
+)
+
+// configMapData contains the schemas stored in a ConfigMap, by key
+type configMapData struct {
+	data map[string][]byte
+	err  error
+}
+
+// ConfigMapRegistry serves schemas stored in a ConfigMap of a Kubernetes cluster, e.g. created with
+// kubectl create configmap schemas --from-file=schemas/. The API server is reached with the credentials
+// of the service account of the pod when running in a cluster, or else with the current kubeconfig
+// context. The ConfigMap is only retrieved once per run.
+type ConfigMapRegistry struct {
+	sync.Mutex
+	c               httpDoer
+	server          string
+	authorize       func(req *http.Request) // adds credentials to a request
+	namespace, name string
+	configMap       *configMapData
+}
+
+func newConfigMapRegistry(location string, skipTLS bool, noProxy string, timeout time.Duration) (*ConfigMapRegistry, error) {
+	parts := strings.Split(location, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("failed initialising configmap registry: invalid location %s%s, must be %snamespace/name", configMapPrefix, location, configMapPrefix)
+	}
+
+	cluster, user, ok := inClusterCredentials()
+	if !ok {
+		kc, err := loadKubeconfig(kubeconfigPaths())
+		if err != nil {
+			return nil, fmt.Errorf("failed initialising configmap registry: %s", err)
+		}
+
+		if cluster, user, err = kc.context(""); err != nil {
+			return nil, fmt.Errorf("failed initialising configmap registry: %s", err)
+		}
+	}
+
+	c, authorize, err := newClusterHTTPClient(cluster, user, skipTLS, noProxy, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising configmap registry: %s", err)
+	}
+
+	return &ConfigMapRegistry{
+		c:         c,
+		server:    strings.TrimSuffix(cluster.Server, "/"),
+		authorize: authorize,
+		namespace: parts[0],
+		name:      parts[1],
+	}, nil
+}
+
+// data retrieves the content of the ConfigMap. Failures are kept for the rest of the run, unless the
+// request was cancelled.
+func (r *ConfigMapRegistry) data(ctx context.Context) (map[string][]byte, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.configMap != nil {
+		return r.configMap.data, r.configMap.err
+	}
+
+	cm := &configMapData{}
+	cm.data, cm.err = r.fetchConfigMap(ctx)
+	if ctx.Err() == nil {
+		r.configMap = cm
+	}
+
+	return cm.data, cm.err
+}
+
+func (r *ConfigMapRegistry) fetchConfigMap(ctx context.Context) (map[string][]byte, error) {
+	url := fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps/%s", r.server, r.namespace, r.name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading ConfigMap at %s: %s", url, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	r.authorize(req)
+
+	resp, err := r.c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading ConfigMap at %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error while downloading ConfigMap at %s - received HTTP status %d", url, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading ConfigMap at %s: %s", url, err)
+	}
+
+	var cm struct {
+		Data       map[string]string `json:"data"`
+		BinaryData map[string][]byte `json:"binaryData"`
+	}
+	if err := json.Unmarshal(body, &cm); err != nil {
+		return nil, fmt.Errorf("failed parsing ConfigMap at %s: %s", url, err)
+	}
+
+	data := map[string][]byte{}
+	for k, v := range cm.BinaryData {
+		data[k] = v
+	}
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+
+	return data, nil
+}
+
+// DownloadSchemaWithContext returns the schema for a resource from the ConfigMap, under the key named after its
+// kind and apiVersion, e.g. deployment-apps-v1.json
+func (r *ConfigMapRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	data, err := r.data(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := schemaPath(configMapKeyTemplate, resourceKind, resourceAPIVersion, k8sVersion, false)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, ok := data[key]
+	if !ok {
+		return nil, newNotFoundError(fmt.Errorf("no schema found in ConfigMap %s/%s under key %s", r.namespace, r.name, key))
+	}
+
+	return schema, nil
+}
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (r *ConfigMapRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return r.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
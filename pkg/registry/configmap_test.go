@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfigMap = `{
+  "apiVersion": "v1",
+  "kind": "ConfigMap",
+  "metadata": {"name": "schemas", "namespace": "validation"},
+  "data": {"deployment-apps-v1.json": "{\"type\": \"object\"}"},
+  "binaryData": {"widget-example-v1.json": "eyJ0eXBlIjogInN0cmluZyJ9"}
+}`
+
+func newTestConfigMapHandler(requests *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v1/namespaces/validation/configmaps/schemas" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(testConfigMap))
+	})
+}
+
+func TestConfigMapRegistry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(newTestConfigMapHandler(&requests))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+users:
+- name: test
+  user:
+    token: s3cret
+`, server.URL)), 0600); err != nil {
+		t.Fatalf("failed writing kubeconfig: %s", err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	reg, err := New("configmap://validation/schemas", "", false, false)
+	if err != nil {
+		t.Fatalf("failed creating registry: %s", err)
+	}
+
+	for i, testCase := range []struct {
+		kind, apiVersion string
+		expect           string
+		expectNotFound   bool
+	}{
+		{"Deployment", "apps/v1", `{"type": "object"}`, false},
+		{"Widget", "example.com/v1", `{"type": "string"}`, false},
+		{"Service", "v1", "", true},
+	} {
+		b, err := reg.DownloadSchema(testCase.kind, testCase.apiVersion, "master")
+		if _, notFound := err.(*NotFoundError); notFound != testCase.expectNotFound {
+			t.Errorf("%d - expected not found to be %t, got %v", i, testCase.expectNotFound, err)
+		}
+		if string(b) != testCase.expect {
+			t.Errorf("%d - expected schema %s, got %s", i, testCase.expect, b)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the ConfigMap to be retrieved once per registry, got %d requests", requests)
+	}
+
+	reg, _ = New("configmap://validation/does-not-exist", "", false, false)
+	if _, err := reg.DownloadSchema("Deployment", "apps/v1", "master"); err == nil {
+		t.Errorf("expected an error for a missing ConfigMap")
+	} else if _, notFound := err.(*NotFoundError); notFound {
+		t.Errorf("expected a missing ConfigMap not to be a NotFoundError")
+	}
+
+	for _, location := range []string{"configmap://schemas", "configmap://validation/", "configmap://a/b/c"} {
+		if _, err := New(location, "", false, false); err == nil {
+			t.Errorf("expected an error for invalid location %s", location)
+		}
+	}
+}
+
+func TestConfigMapRegistryInCluster(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(newTestConfigMapHandler(&requests))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0600); err != nil {
+		t.Fatalf("failed writing certificate authority: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatalf("failed writing token: %s", err)
+	}
+	defer func(d string) { serviceAccountDir = d }(serviceAccountDir)
+	serviceAccountDir = dir
+
+	u, _ := url.Parse(server.URL)
+	t.Setenv("KUBERNETES_SERVICE_HOST", u.Hostname())
+	t.Setenv("KUBERNETES_SERVICE_PORT", u.Port())
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "does-not-exist"))
+
+	reg, err := New("configmap://validation/schemas", "", false, false)
+	if err != nil {
+		t.Fatalf("failed creating registry: %s", err)
+	}
+	if b, err := reg.DownloadSchema("Deployment", "apps/v1", "master"); err != nil || string(b) != `{"type": "object"}` {
+		t.Errorf("expected the schema to be read with the service account credentials, got %s, %v", b, err)
+	}
+}
@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// crdPrefix marks a schema location as a file or folder of CustomResourceDefinitions, e.g. crd+./crds/
+const crdPrefix = "crd+"
+
+type openAPIValidation struct {
+	OpenAPIV3Schema map[string]interface{} `json:"openAPIV3Schema"`
+}
+
+type crdVersion struct {
+	Name   string             `json:"name"`
+	Served bool               `json:"served"`
+	Schema *openAPIValidation `json:"schema"`
+}
+
+type customResourceDefinition struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind string `json:"kind"`
+		} `json:"names"`
+		Version    string             `json:"version"`    // apiextensions.k8s.io/v1beta1 only
+		Validation *openAPIValidation `json:"validation"` // apiextensions.k8s.io/v1beta1 only, shared by all versions
+		Versions   []crdVersion       `json:"versions"`
+	} `json:"spec"`
+	Items []customResourceDefinition `json:"items"`
+}
+
+// CRDRegistry derives schemas from the CustomResourceDefinitions found in a file or folder
+type CRDRegistry struct {
+	sync.Mutex
+	path    string
+	strict  bool
+	crds    []customResourceDefinition
+	loaded  bool
+	loadErr error
+}
+
+func newCRDRegistry(path string, strict bool) (*CRDRegistry, error) {
+	return &CRDRegistry{
+		path:   path,
+		strict: strict,
+	}, nil
+}
+
+func crdsFromFile(p string) ([]customResourceDefinition, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening CRD file %s: %s", p, err)
+	}
+	defer f.Close()
+
+	crds := []customResourceDefinition{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024)
+	scanner.Split(resource.SplitYAMLDocument)
+	for scanner.Scan() {
+		var crd customResourceDefinition
+		if err := yaml.Unmarshal(scanner.Bytes(), &crd); err != nil {
+			return nil, fmt.Errorf("failed parsing CRD file %s: %s", p, err)
+		}
+		for _, c := range append([]customResourceDefinition{crd}, crd.Items...) {
+			if c.Kind == "CustomResourceDefinition" {
+				crds = append(crds, c)
+			}
+		}
+	}
+
+	return crds, scanner.Err()
+}
+
+// load reads all CRDs at the registry's path, once
+func (r *CRDRegistry) load() ([]customResourceDefinition, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.loaded {
+		return r.crds, r.loadErr
+	}
+	r.loaded = true
+
+	r.loadErr = filepath.Walk(r.path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+
+		crds, err := crdsFromFile(p)
+		r.crds = append(r.crds, crds...)
+		return err
+	})
+
+	return r.crds, r.loadErr
+}
+
+// schemaForVersion returns the schema for a specific version of a CRD. It errors if the
+// CRD does not declare or serve that version, rather than falling back to another version's schema.
+func (crd *customResourceDefinition) schemaForVersion(version string) (map[string]interface{}, error) {
+	if len(crd.Spec.Versions) == 0 {
+		if crd.Spec.Version != version {
+			return nil, fmt.Errorf("CustomResourceDefinition for %s in group %s does not declare version %s, declared versions: %s", crd.Spec.Names.Kind, crd.Spec.Group, version, crd.Spec.Version)
+		}
+		if crd.Spec.Validation == nil {
+			return nil, nil
+		}
+		return crd.Spec.Validation.OpenAPIV3Schema, nil
+	}
+
+	declared := []string{}
+	for _, v := range crd.Spec.Versions {
+		if v.Name != version {
+			declared = append(declared, v.Name)
+			continue
+		}
+		if !v.Served {
+			return nil, fmt.Errorf("CustomResourceDefinition for %s in group %s does not serve version %s", crd.Spec.Names.Kind, crd.Spec.Group, version)
+		}
+		if v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+			return v.Schema.OpenAPIV3Schema, nil
+		}
+		if crd.Spec.Validation != nil { // v1beta1 CRDs can share a schema across all versions
+			return crd.Spec.Validation.OpenAPIV3Schema, nil
+		}
+		return nil, nil
+	}
+
+	sort.Strings(declared)
+	return nil, fmt.Errorf("CustomResourceDefinition for %s in group %s does not declare version %s, declared versions: %s", crd.Spec.Names.Kind, crd.Spec.Group, version, strings.Join(declared, ", "))
+}
+
+// denyAdditionalProperties disallows undocumented fields in all objects below the root,
+// like kubectl does - and like scripts/openapi2jsonschema.py
+func denyAdditionalProperties(schema interface{}, root bool) {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		if _, ok := s["properties"]; ok && !root {
+			if _, ok := s["additionalProperties"]; !ok {
+				s["additionalProperties"] = false
+			}
+		}
+		for _, v := range s {
+			denyAdditionalProperties(v, false)
+		}
+	case []interface{}:
+		for _, v := range s {
+			denyAdditionalProperties(v, false)
+		}
+	}
+}
+
+// DownloadSchema returns the schema for the exact apiVersion of the resource, from the matching CRD
+func (r *CRDRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	crds, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	group, version := "", resourceAPIVersion
+	if i := strings.LastIndex(resourceAPIVersion, "/"); i >= 0 {
+		group, version = resourceAPIVersion[:i], resourceAPIVersion[i+1:]
+	}
+
+	for _, crd := range crds {
+		if crd.Spec.Group != group || crd.Spec.Names.Kind != resourceKind {
+			continue
+		}
+
+		schema, err := crd.schemaForVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		if schema == nil {
+			return nil, newNotFoundError(fmt.Errorf("no schema found"))
+		}
+
+		// Schemas are shared between resources, we work on a copy
+		b, err := json.Marshal(schema)
+		if err != nil {
+			return nil, err
+		}
+		if !r.strict {
+			return b, nil
+		}
+
+		var strictSchema map[string]interface{}
+		if err := json.Unmarshal(b, &strictSchema); err != nil {
+			return nil, err
+		}
+		denyAdditionalProperties(strictSchema, true)
+		return json.Marshal(strictSchema)
+	}
+
+	return nil, newNotFoundError(fmt.Errorf("no schema found"))
+}
@@ -0,0 +1,136 @@
+package registry
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const multiVersionCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: crontabs.stable.example.com
+spec:
+  group: stable.example.com
+  names:
+    kind: CronTab
+  versions:
+  - name: v1beta1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              cronSpec:
+                type: string
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              schedule:
+                type: string
+  - name: v1alpha1
+    served: false
+    storage: false
+`
+
+func TestCRDDownloadSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "crontab.yaml"), []byte(multiVersionCRD), 0644); err != nil {
+		t.Fatalf("failed writing CRD: %s", err)
+	}
+
+	for _, testCase := range []struct {
+		name              string
+		path              string
+		strict            bool
+		kind, apiVersion  string
+		expectContains    string
+		expectNotFound    bool
+		expectErrContains string
+		expectNotContains string
+	}{
+		{
+			name:              "selects the schema of the resource's version",
+			path:              dir,
+			kind:              "CronTab",
+			apiVersion:        "stable.example.com/v1",
+			expectContains:    "schedule",
+			expectNotContains: "cronSpec",
+		},
+		{
+			name:              "selects the schema of a non-storage version",
+			path:              dir,
+			kind:              "CronTab",
+			apiVersion:        "stable.example.com/v1beta1",
+			expectContains:    "cronSpec",
+			expectNotContains: "schedule",
+		},
+		{
+			name:           "strict mode disallows additional properties",
+			path:           dir,
+			strict:         true,
+			kind:           "CronTab",
+			apiVersion:     "stable.example.com/v1",
+			expectContains: `"additionalProperties":false`,
+		},
+		{
+			name:              "errors on undeclared versions",
+			path:              dir,
+			kind:              "CronTab",
+			apiVersion:        "stable.example.com/v2",
+			expectErrContains: "does not declare version v2, declared versions: v1, v1alpha1, v1beta1",
+		},
+		{
+			name:              "errors on versions that are not served",
+			path:              dir,
+			kind:              "CronTab",
+			apiVersion:        "stable.example.com/v1alpha1",
+			expectErrContains: "does not serve version v1alpha1",
+		},
+		{
+			name:           "kinds without a CRD are not found",
+			path:           dir,
+			kind:           "CronTab",
+			apiVersion:     "other.example.com/v1",
+			expectNotFound: true,
+		},
+		{
+			name:           "v1beta1 CRDs with a shared validation schema",
+			path:           "../../fixtures/registry/sagemaker.aws.amazon.com_trainingjobs.yaml",
+			kind:           "TrainingJob",
+			apiVersion:     "sagemaker.aws.amazon.com/v1",
+			expectContains: "TrainingJobStatus",
+		},
+	} {
+		reg, _ := newCRDRegistry(testCase.path, testCase.strict)
+		res, err := reg.DownloadSchema(testCase.kind, testCase.apiVersion, "master")
+
+		if _, notFound := err.(*NotFoundError); notFound != testCase.expectNotFound {
+			t.Errorf("%s: expected not found %t, got error %v", testCase.name, testCase.expectNotFound, err)
+		}
+		if testCase.expectErrContains != "" && (err == nil || !strings.Contains(err.Error(), testCase.expectErrContains)) {
+			t.Errorf("%s: expected error containing %q, got %v", testCase.name, testCase.expectErrContains, err)
+		}
+		if testCase.expectErrContains == "" && !testCase.expectNotFound && err != nil {
+			t.Errorf("%s: unexpected error %s", testCase.name, err)
+		}
+		if !strings.Contains(string(res), testCase.expectContains) {
+			t.Errorf("%s: expected schema to contain %s, got %s", testCase.name, testCase.expectContains, res)
+		}
+		if testCase.expectNotContains != "" && strings.Contains(string(res), testCase.expectNotContains) {
+			t.Errorf("%s: expected schema not to contain %s, got %s", testCase.name, testCase.expectNotContains, res)
+		}
+	}
+}
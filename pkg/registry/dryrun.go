@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DryRunRejectedError is returned when the API server rejects a resource submitted with dry-run, e.g.
+// because it is invalid, or denied by an admission webhook or a quota
+type DryRunRejectedError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *DryRunRejectedError) Error() string { return e.Message }
+
+// apiResource is a resource served by an API server, as listed by its discovery endpoints
+type apiResource struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+// apiResourceList are the resources served for an apiVersion
+type apiResourceList struct {
+	resources map[string]apiResource // by kind
+	err       error
+}
+
+// DryRunner submits resources to the API server of a cluster with dry-run, so that they go through
+// the validation and admission of the cluster - webhooks, quotas, policies - without being persisted.
+// The resources served for each apiVersion are only discovered once per run.
+type DryRunner struct {
+	sync.Mutex
+	c         httpDoer
+	server    string
+	authorize func(req *http.Request)
+	apis      map[string]*apiResourceList // by apiVersion
+}
+
+// NewDryRunner returns a DryRunner submitting resources to the cluster of a kubeconfig context, or of
+// the current context if empty
+func NewDryRunner(kubeContext string, skipTLS bool, noProxy string, timeout time.Duration) (*DryRunner, error) {
+	kc, err := loadKubeconfig(kubeconfigPaths())
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising server dry-run: %s", err)
+	}
+
+	cluster, user, err := kc.context(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising server dry-run: %s", err)
+	}
+
+	c, authorize, err := newClusterHTTPClient(cluster, user, skipTLS, noProxy, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising server dry-run: %s", err)
+	}
+
+	return &DryRunner{
+		c:         c,
+		server:    strings.TrimSuffix(cluster.Server, "/"),
+		authorize: authorize,
+		apis:      map[string]*apiResourceList{},
+	}, nil
+}
+
+// do sends a request to the API server, and returns the body of the response and its status code
+func (d *DryRunner) do(ctx context.Context, method, url, contentType string, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	d.authorize(req)
+
+	resp, err := d.c.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	return b, resp.StatusCode, err
+}
+
+// resource returns the resource serving kind in apiVersion, discovering the resources of apiVersion
+// on first use. Failures are kept for the rest of the run, unless the request was cancelled.
+func (d *DryRunner) resource(ctx context.Context, apiVersion, kind string) (apiResource, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	l, ok := d.apis[apiVersion]
+	if !ok {
+		l = &apiResourceList{}
+		l.resources, l.err = d.discover(ctx, apiVersion)
+		if ctx.Err() == nil {
+			d.apis[apiVersion] = l
+		}
+	}
+	if l.err != nil {
+		return apiResource{}, l.err
+	}
+
+	r, ok := l.resources[kind]
+	if !ok {
+		return apiResource{}, fmt.Errorf("%s %s is not served by the cluster", apiVersion, kind)
+	}
+	return r, nil
+}
+
+// apiURL returns the URL of the API of apiVersion: /api/v1 for the core group, /apis/<group>/<version> otherwise
+func (d *DryRunner) apiURL(apiVersion string) string {
+	if !strings.Contains(apiVersion, "/") {
+		return d.server + "/api/" + apiVersion
+	}
+	return d.server + "/apis/" + apiVersion
+}
+
+func (d *DryRunner) discover(ctx context.Context, apiVersion string) (map[string]apiResource, error) {
+	u := d.apiURL(apiVersion)
+
+	b, status, err := d.do(ctx, http.MethodGet, u, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed discovering the resources of %s at %s: %s", apiVersion, u, err)
+	}
+	if status == http.StatusNotFound {
+		return map[string]apiResource{}, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("error while discovering the resources of %s at %s - received HTTP status %d", apiVersion, u, status)
+	}
+
+	var list struct {
+		Resources []apiResource `json:"resources"`
+	}
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("failed parsing the resources of %s at %s: %s", apiVersion, u, err)
+	}
+
+	resources := map[string]apiResource{}
+	for _, r := range list.Resources {
+		if !strings.Contains(r.Name, "/") { // subresources, e.g. deployments/scale
+			resources[r.Kind] = r
+		}
+	}
+	return resources, nil
+}
+
+// DryRun submits the resource b, in YAML or JSON, to the API server with dry-run. Named resources are
+// applied with server-side apply, so that resources that already exist in the cluster are updated
+// rather than failing to be created. Namespaced resources without a namespace are submitted to the
+// default namespace. Rejections of the resource are returned as a *DryRunRejectedError.
+func (d *DryRunner) DryRun(ctx context.Context, b []byte, apiVersion, kind, namespace, name string) error {
+	r, err := d.resource(ctx, apiVersion, kind)
+	if err != nil {
+		return err
+	}
+
+	u := d.apiURL(apiVersion)
+	if r.Namespaced {
+		if namespace == "" {
+			namespace = "default"
+		}
+		u += "/namespaces/" + url.PathEscape(namespace)
+	}
+	u += "/" + r.Name
+
+	method, contentType := http.MethodPost, "application/yaml"
+	query := url.Values{"dryRun": {"All"}, "fieldManager": {"kubeconform"}}
+	if name != "" {
+		method, contentType = http.MethodPatch, "application/apply-patch+yaml"
+		u += "/" + url.PathEscape(name)
+		query.Set("force", "true")
+	}
+
+	body, status, err := d.do(ctx, method, u+"?"+query.Encode(), contentType, b)
+	if err != nil {
+		return fmt.Errorf("failed submitting %s %s with dry-run: %s", kind, name, err)
+	}
+	if status >= 200 && status < 300 {
+		return nil
+	}
+
+	// Errors are returned as a Status object, whose message explains the rejection
+	var s struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &s); err != nil || s.Message == "" {
+		s.Message = fmt.Sprintf("received HTTP status %d", status)
+	}
+	if rejected(status, s.Message) {
+		return &DryRunRejectedError{StatusCode: status, Message: s.Message}
+	}
+	return fmt.Errorf("failed submitting %s %s with dry-run: %s", kind, name, s.Message)
+}
+
+// rejected returns true if the API server responded that the resource itself was refused: invalid,
+// conflicting, or forbidden by admission or a quota - but not because the user can not create it
+func rejected(status int, message string) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		return true
+	case http.StatusForbidden:
+		// Authorization failures read: User "jane" cannot create resource "pods"
+		return !strings.Contains(message, " cannot ")
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunner(t *testing.T) {
+	discoveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/apps/v1":
+			discoveries++
+			w.Write([]byte(`{"resources": [{"name": "deployments", "kind": "Deployment", "namespaced": true}, {"name": "deployments/scale", "kind": "Scale", "namespaced": true}]}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1":
+			discoveries++
+			w.Write([]byte(`{"resources": [{"name": "configmaps", "kind": "ConfigMap", "namespaced": true}, {"name": "namespaces", "kind": "Namespace", "namespaced": false}]}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			if r.URL.Query().Get("dryRun") != "All" {
+				t.Errorf("expected a dry-run request, got %s", r.URL)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			switch {
+			case strings.Contains(string(body), "denied"):
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"kind": "Status", "status": "Failure", "message": "admission webhook \"policy.example.com\" denied the request: image tag latest is not allowed", "reason": "Forbidden", "code": 403}`))
+			case strings.Contains(string(body), "unauthorized"):
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"kind": "Status", "status": "Failure", "message": "deployments.apps \"web\" is forbidden: User \"ci\" cannot patch resource \"deployments\" in API group \"apps\" in the namespace \"default\"", "reason": "Forbidden", "code": 403}`))
+			default:
+				w.Write([]byte(fmt.Sprintf(`{"method": %q, "path": %q}`, r.Method, r.URL.Path)))
+			}
+		}
+	}))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(fmt.Sprintf(`
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+users:
+- name: test
+  user:
+    token: s3cret
+`, server.URL)), 0600); err != nil {
+		t.Fatalf("failed writing kubeconfig: %s", err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	d, err := NewDryRunner("", false, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, testCase := range []struct {
+		b, apiVersion, kind, namespace, name string
+		rejected, failed                     bool
+	}{
+		{"kind: Deployment", "apps/v1", "Deployment", "shop", "web", false, false},
+		{"kind: ConfigMap", "v1", "ConfigMap", "", "", false, false},
+		{"kind: Namespace", "v1", "Namespace", "", "shop", false, false},
+		{"kind: Deployment # denied", "apps/v1", "Deployment", "", "web", true, false},
+		{"kind: Deployment # unauthorized", "apps/v1", "Deployment", "", "web", false, true},
+		{"kind: Widget", "apps/v1", "Widget", "", "web", false, true},
+		{"kind: Widget", "example.com/v1", "Widget", "", "web", false, true},
+	} {
+		err := d.DryRun(context.Background(), []byte(testCase.b), testCase.apiVersion, testCase.kind, testCase.namespace, testCase.name)
+		var rejectedErr *DryRunRejectedError
+		if rejected := errors.As(err, &rejectedErr); rejected != testCase.rejected || (err != nil && !rejected) != testCase.failed {
+			t.Errorf("%d - expected rejected %t, failed %t, got %v", i, testCase.rejected, testCase.failed, err)
+		}
+	}
+
+	if discoveries != 2 {
+		t.Errorf("expected the resources of each apiVersion to be discovered once, got %d discoveries", discoveries)
+	}
+}
+
+func TestDryRunnerURL(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"resources": [{"name": "deployments", "kind": "Deployment", "namespaced": true}]}`))
+			return
+		}
+		got = append(got, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	d := &DryRunner{c: server.Client(), server: server.URL, authorize: func(*http.Request) {}, apis: map[string]*apiResourceList{}}
+	d.DryRun(context.Background(), nil, "apps/v1", "Deployment", "", "web")
+	d.DryRun(context.Background(), nil, "apps/v1", "Deployment", "shop", "")
+
+	expect := []string{
+		"PATCH /apis/apps/v1/namespaces/default/deployments/web application/apply-patch+yaml",
+		"POST /apis/apps/v1/namespaces/shop/deployments application/yaml",
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("expected requests:\n%s\ngot:\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+}
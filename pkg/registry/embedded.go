@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// embeddedSchemas contains the schemas compiled into the binary. It is only set
+// when building with the embedschemas tag, see embedded_schemas.go
+var embeddedSchemas fs.FS
+
+// embeddedPathTemplate is the layout of the embedded schemas, identical to kubernetes-json-schema's
+const embeddedPathTemplate = "{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+
+// HasEmbeddedSchemas returns true if kubeconform was built with schemas embedded in the binary
+func HasEmbeddedSchemas() bool {
+	return embeddedSchemas != nil
+}
+
+// EmbeddedRegistry serves schemas from a filesystem, usually compiled into the binary
+type EmbeddedRegistry struct {
+	fs           fs.FS
+	pathTemplate string
+	strict       bool
+}
+
+func newEmbeddedRegistry(fsys fs.FS, pathTemplate string, strict bool) (*EmbeddedRegistry, error) {
+	if fsys == nil {
+		return nil, fmt.Errorf("kubeconform was built without embedded schemas")
+	}
+
+	return &EmbeddedRegistry{
+		fs:           fsys,
+		pathTemplate: pathTemplate,
+		strict:       strict,
+	}, nil
+}
+
+// DownloadSchema retrieves the schema for the resource from the embedded filesystem
+func (r EmbeddedRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	schemaFile, err := schemaPath(r.pathTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := fs.ReadFile(r.fs, schemaFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+			return nil, newNotFoundError(fmt.Errorf("no schema found"))
+		}
+		return nil, fmt.Errorf("failed to open embedded schema %s: %s", schemaFile, err)
+	}
+
+	return content, nil
+}
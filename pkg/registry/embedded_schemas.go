@@ -0,0 +1,19 @@
+//go:build embedschemas
+// +build embedschemas
+
+package registry
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Schemas in the schemas/ folder are compiled into the binary when building with
+// -tags embedschemas. Run "make embed-schemas" to populate the folder.
+//
+//go:embed schemas
+var embeddedFS embed.FS
+
+func init() {
+	embeddedSchemas, _ = fs.Sub(embeddedFS, "schemas")
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedDownloadSchema(t *testing.T) {
+	fsys := fstest.MapFS{
+		"master-standalone/deployment-apps-v1.json":         {Data: []byte("deployment")},
+		"v1.18.0-standalone-strict/deployment-apps-v1.json": {Data: []byte("strict deployment")},
+	}
+
+	for _, testCase := range []struct {
+		strict                bool
+		kind, version, k8sVer string
+		expect                []byte
+		expectNotFound        bool
+	}{
+		{false, "Deployment", "apps/v1", "master", []byte("deployment"), false},
+		{true, "Deployment", "apps/v1", "1.18.0", []byte("strict deployment"), false},
+		{false, "Deployment", "apps/v1", "1.18.0", nil, true},
+		{false, "Service", "v1", "master", nil, true},
+	} {
+		reg, err := newEmbeddedRegistry(fsys, embeddedPathTemplate, testCase.strict)
+		if err != nil {
+			t.Fatalf("failed creating registry: %s", err)
+		}
+
+		res, err := reg.DownloadSchema(testCase.kind, testCase.version, testCase.k8sVer)
+		if _, notFound := err.(*NotFoundError); notFound != testCase.expectNotFound {
+			t.Errorf("%s %s: expected not found %t, got error %v", testCase.kind, testCase.k8sVer, testCase.expectNotFound, err)
+		}
+		if !bytes.Equal(res, testCase.expect) {
+			t.Errorf("%s %s: expected %s, got %s", testCase.kind, testCase.k8sVer, testCase.expect, res)
+		}
+	}
+
+	if _, err := newEmbeddedRegistry(nil, embeddedPathTemplate, false); err == nil {
+		t.Errorf("expected an error when no schemas are embedded")
+	}
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// kubeconfigExec configures an exec credential plugin, a command printing an ExecCredential of the
+// client.authentication.k8s.io API group, as used by kubectl for e.g. EKS, GKE or OIDC clusters
+type kubeconfigExec struct {
+	APIVersion         string              `json:"apiVersion"`
+	Command            string              `json:"command"`
+	Args               []string            `json:"args"`
+	Env                []kubeconfigExecEnv `json:"env"`
+	InteractiveMode    string              `json:"interactiveMode"`
+	ProvideClusterInfo bool                `json:"provideClusterInfo"`
+}
+
+type kubeconfigExecEnv struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// execCluster is the cluster passed to exec credential plugins setting provideClusterInfo
+type execCluster struct {
+	Server                   string `json:"server"`
+	CertificateAuthorityData []byte `json:"certificate-authority-data,omitempty"`
+	InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify,omitempty"`
+}
+
+// execCredential is the object read from, and for the request written to, exec credential plugins
+type execCredential struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Interactive bool         `json:"interactive"`
+		Cluster     *execCluster `json:"cluster,omitempty"`
+	} `json:"spec"`
+	Status *struct {
+		Token                 string `json:"token"`
+		ClientCertificateData string `json:"clientCertificateData"`
+		ClientKeyData         string `json:"clientKeyData"`
+	} `json:"status,omitempty"`
+}
+
+// runExecPlugin runs the exec credential plugin of a user, and returns the token or client certificate
+// and key it prints. Plugins are run once, credentials are not refreshed when they expire. Plugins do
+// not get a terminal, as resources may be read from the standard input.
+func runExecPlugin(plugin *kubeconfigExec, cluster *kubeconfigCluster) (token string, cert, key []byte, err error) {
+	if plugin.APIVersion == "" {
+		return "", nil, nil, fmt.Errorf("exec credential plugin %s has no apiVersion", plugin.Command)
+	}
+	if plugin.InteractiveMode == "Always" {
+		return "", nil, nil, fmt.Errorf("exec credential plugin %s requires an interactive terminal", plugin.Command)
+	}
+
+	req := execCredential{APIVersion: plugin.APIVersion, Kind: "ExecCredential"}
+	if plugin.ProvideClusterInfo {
+		ca, err := readFileOrData(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed reading certificate authority: %s", err)
+		}
+		req.Spec.Cluster = &execCluster{cluster.Server, ca, cluster.InsecureSkipTLSVerify}
+	}
+	info, err := json.Marshal(req)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	cmd := exec.Command(plugin.Command, plugin.Args...)
+	cmd.Env = append(os.Environ(), "KUBERNETES_EXEC_INFO="+string(info))
+	for _, env := range plugin.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", nil, nil, fmt.Errorf("failed running exec credential plugin %s: %s", plugin.Command, err)
+	}
+
+	var res execCredential
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		return "", nil, nil, fmt.Errorf("failed parsing output of exec credential plugin %s: %s", plugin.Command, err)
+	}
+	if res.Kind != "ExecCredential" || res.APIVersion != plugin.APIVersion {
+		return "", nil, nil, fmt.Errorf("exec credential plugin %s returned %s %s, expected %s ExecCredential", plugin.Command, res.APIVersion, res.Kind, plugin.APIVersion)
+	}
+	if res.Status == nil || (res.Status.Token == "" && res.Status.ClientCertificateData == "") {
+		return "", nil, nil, fmt.Errorf("exec credential plugin %s returned no token or client certificate", plugin.Command)
+	}
+
+	return strings.TrimSpace(res.Status.Token), []byte(res.Status.ClientCertificateData), []byte(res.Status.ClientKeyData), nil
+}
@@ -0,0 +1,250 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gcpTokenURL  = "https://oauth2.googleapis.com/token"
+	gcpScope     = "https://www.googleapis.com/auth/devstorage.read_only"
+	gcpJWTBearer = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+// gcpTokenSource retrieves OAuth2 access tokens for Google Cloud Storage as the Google SDKs do, from
+// the first source configured: an access token in GOOGLE_OAUTH_ACCESS_TOKEN, e.g. from "gcloud auth
+// print-access-token", the application default credentials - the service account key or user
+// credentials file set with GOOGLE_APPLICATION_CREDENTIALS, or written by "gcloud auth
+// application-default login" -, and the metadata server of GCE, GKE and Cloud Run. Requests are
+// anonymous if none is available. Tokens are retrieved on first use, and again when they expire.
+type gcpTokenSource struct {
+	c        httpDoer // client for the token endpoint
+	metadata httpDoer // client for the metadata server, without proxy
+	now      func() time.Time
+
+	sync.Mutex
+	token    string
+	expires  time.Time // zero if the token does not expire
+	resolved bool
+}
+
+func newGCPTokenSource(c httpDoer) *gcpTokenSource {
+	return &gcpTokenSource{
+		c:        c,
+		metadata: &http.Client{Transport: &http.Transport{}, Timeout: 5 * time.Second},
+		now:      time.Now,
+	}
+}
+
+// get returns the access token to add to requests, empty for anonymous requests
+func (s *gcpTokenSource) get(ctx context.Context) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.resolved && (s.token == "" || s.expires.IsZero() || s.expires.Sub(s.now()) > 5*time.Minute) {
+		return s.token, nil
+	}
+
+	token, expires, err := s.resolve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed retrieving Google credentials: %s", err)
+	}
+	s.token, s.expires, s.resolved = token, expires, true
+	return token, nil
+}
+
+func (s *gcpTokenSource) resolve(ctx context.Context) (string, time.Time, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, time.Time{}, nil
+	}
+
+	if path := applicationDefaultCredentialsFile(); path != "" {
+		return s.applicationDefault(ctx, path)
+	}
+
+	// Outside of Google Cloud the metadata server is unreachable, requests are then anonymous
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if token, expires, err := s.metadataServer(ctx); err == nil {
+		return token, expires, nil
+	}
+
+	return "", time.Time{}, nil
+}
+
+// applicationDefaultCredentialsFile returns the path of the application default credentials, set
+// with GOOGLE_APPLICATION_CREDENTIALS or written by gcloud, or an empty string if there are none
+func applicationDefaultCredentialsFile() string {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	path := filepath.Join(dir, "application_default_credentials.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// gcpCredentialsFile is a service account key or user credentials file
+type gcpCredentialsFile struct {
+	Type string `json:"type"`
+
+	// service_account
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+
+	// authorized_user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// gcpToken is the response of the token endpoint and of the metadata server
+type gcpToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (t gcpToken) expires(now time.Time) time.Time {
+	if t.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return now.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// applicationDefault exchanges the application default credentials in path for an access token
+func (s *gcpTokenSource) applicationDefault(ctx context.Context, path string) (string, time.Time, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed reading application default credentials: %s", err)
+	}
+	var f gcpCredentialsFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed parsing application default credentials %s: %s", path, err)
+	}
+
+	tokenURL := f.TokenURI
+	if tokenURL == "" {
+		tokenURL = gcpTokenURL
+	}
+	form := url.Values{}
+	switch f.Type {
+	case "service_account":
+		assertion, err := gcpJWTAssertion(f, tokenURL, s.now())
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed signing token request for %s: %s", f.ClientEmail, err)
+		}
+		form.Set("grant_type", gcpJWTBearer)
+		form.Set("assertion", assertion)
+
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", f.ClientID)
+		form.Set("client_secret", f.ClientSecret)
+		form.Set("refresh_token", f.RefreshToken)
+
+	default:
+		return "", time.Time{}, fmt.Errorf("application default credentials of type %q are not supported - set GOOGLE_OAUTH_ACCESS_TOKEN, e.g. with \"gcloud auth print-access-token\"", f.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var t gcpToken
+	if err := getJSON(s.c, req, &t); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed retrieving access token: %s", err)
+	}
+	if t.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("no access token returned by %s", tokenURL)
+	}
+	return t.AccessToken, t.expires(s.now()), nil
+}
+
+// gcpJWTAssertion returns the JWT signed with the key of a service account, exchanged at the token
+// endpoint for an access token. See https://developers.google.com/identity/protocols/oauth2/service-account
+func gcpJWTAssertion(f gcpCredentialsFile, aud string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(f.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("invalid private key: %s", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("private key is not an RSA key")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": f.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   f.ClientEmail,
+		"scope": gcpScope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	h := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// metadataServer returns the access token of the service account attached to the instance
+func (s *gcpTokenSource) metadataServer(ctx context.Context) (string, time.Time, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "169.254.169.254"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token?scopes="+url.QueryEscape(gcpScope), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var t gcpToken
+	if err := getJSON(s.metadata, req, &t); err != nil {
+		return "", time.Time{}, err
+	}
+	if t.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("no access token returned by the metadata server")
+	}
+	return t.AccessToken, t.expires(s.now()), nil
+}
@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGCPTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token" && r.FormValue("grant_type") == gcpJWTBearer:
+			parts := strings.Split(r.FormValue("assertion"), ".")
+			if len(parts) != 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			h := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			var c struct{ Iss, Aud, Scope string }
+			json.Unmarshal(claims, &c)
+			if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, h[:], signature) != nil || c.Iss != "sa@project.iam.gserviceaccount.com" || c.Aud != server.URL+"/token" || c.Scope != gcpScope {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token": "service-account-token", "expires_in": 3599}`))
+		case r.URL.Path == "/token" && r.FormValue("grant_type") == "refresh_token":
+			if r.FormValue("refresh_token") != "refresh" || r.FormValue("client_id") != "client" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token": "user-token", "expires_in": 3599}`))
+		case r.URL.Path == "/computeMetadata/v1/instance/service-accounts/default/token":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"access_token": "metadata-token", "expires_in": 3599}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	write := func(name string, v interface{}) string {
+		b, _ := json.Marshal(v)
+		path := filepath.Join(dir, name)
+		ioutil.WriteFile(path, b, 0644)
+		return path
+	}
+	serviceAccount := write("service-account.json", map[string]string{
+		"type":           "service_account",
+		"client_email":   "sa@project.iam.gserviceaccount.com",
+		"private_key":    privateKey,
+		"private_key_id": "key-id",
+		"token_uri":      server.URL + "/token",
+	})
+	user := write("user.json", map[string]string{
+		"type":          "authorized_user",
+		"client_id":     "client",
+		"client_secret": "secret",
+		"refresh_token": "refresh",
+		"token_uri":     server.URL + "/token",
+	})
+	revoked := write("revoked.json", map[string]string{
+		"type":          "authorized_user",
+		"client_id":     "client",
+		"refresh_token": "revoked",
+		"token_uri":     server.URL + "/token",
+	})
+	external := write("external.json", map[string]string{"type": "external_account"})
+	gcloud := filepath.Join(dir, "gcloud")
+	os.Mkdir(gcloud, 0755)
+	b, _ := ioutil.ReadFile(user)
+	ioutil.WriteFile(filepath.Join(gcloud, "application_default_credentials.json"), b, 0644)
+
+	for i, testCase := range []struct {
+		env       map[string]string
+		gcloud    bool
+		expect    string
+		expectErr string
+	}{
+		{map[string]string{"GOOGLE_OAUTH_ACCESS_TOKEN": "env-token"}, false, "env-token", ""},
+		{map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": serviceAccount}, false, "service-account-token", ""},
+		{map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": user}, false, "user-token", ""},
+		{map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": revoked}, false, "", "HTTP status 401"},
+		{map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": external}, false, "", "of type \"external_account\" are not supported"},
+		{map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": filepath.Join(dir, "missing.json")}, false, "", "failed reading application default credentials"},
+		{map[string]string{}, true, "user-token", ""},
+		{map[string]string{"GCE_METADATA_HOST": strings.TrimPrefix(server.URL, "http://")}, false, "metadata-token", ""},
+		{map[string]string{}, false, "", ""},
+	} {
+		env := map[string]string{
+			"GOOGLE_OAUTH_ACCESS_TOKEN":      "",
+			"GOOGLE_APPLICATION_CREDENTIALS": "",
+			"CLOUDSDK_CONFIG":                filepath.Join(dir, "none"),
+			"GCE_METADATA_HOST":              strings.TrimPrefix(server.URL, "http://") + "/unreachable",
+		}
+		if testCase.gcloud {
+			env["CLOUDSDK_CONFIG"] = gcloud
+		}
+		for k, v := range testCase.env {
+			env[k] = v
+		}
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+
+		s := newGCPTokenSource(server.Client())
+		got, err := s.get(context.Background())
+		if testCase.expectErr != "" {
+			if err == nil || !strings.Contains(err.Error(), testCase.expectErr) {
+				t.Errorf("test %d: expected error containing %s, got %v", i+1, testCase.expectErr, err)
+			}
+			continue
+		}
+		if err != nil || got != testCase.expect {
+			t.Errorf("test %d: expected %s, got %s, %v", i+1, testCase.expect, got, err)
+		}
+	}
+}
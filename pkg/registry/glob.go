@@ -0,0 +1,204 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isGlob returns true for local schema locations given as a glob pattern, e.g. ./schemas/**
+func isGlob(schemaLocation string) bool {
+	return !strings.HasPrefix(schemaLocation, "http") && !strings.Contains(schemaLocation, "{{") &&
+		strings.ContainsAny(schemaLocation, "*?[")
+}
+
+// GlobRegistry serves schemas from the local files matching a glob pattern. The files are indexed
+// once at startup, by the apiVersion and kind their schema declares, or else by their file name.
+type GlobRegistry struct {
+	kinds     map[string]string // schema files by apiVersion/Kind
+	basenames map[string]string // schema files that do not declare their kind, by file name
+}
+
+func newGlobRegistry(pattern string) (*GlobRegistry, error) {
+	files, err := globFiles(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed initialising glob registry: %s", err)
+	}
+
+	reg := &GlobRegistry{
+		kinds:     map[string]string{},
+		basenames: map[string]string{},
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			continue
+		}
+
+		kinds, ok := schemaKinds(b)
+		if !ok {
+			continue // not a JSON schema
+		}
+		if len(kinds) == 0 {
+			if _, ok := reg.basenames[filepath.Base(f)]; !ok {
+				reg.basenames[filepath.Base(f)] = f
+			}
+		}
+		for _, k := range kinds {
+			if _, ok := reg.kinds[k]; !ok {
+				reg.kinds[k] = f
+			}
+		}
+	}
+
+	if len(reg.kinds) == 0 && len(reg.basenames) == 0 {
+		return nil, fmt.Errorf("failed initialising glob registry: no schema found matching %s", pattern)
+	}
+
+	return reg, nil
+}
+
+// schemaKinds returns the apiVersion/Kind of the resources a JSON schema is for, from its
+// x-kubernetes-group-version-kind extension or the enums of its apiVersion and kind properties
+func schemaKinds(b []byte) ([]string, bool) {
+	var schema struct {
+		GVK []struct {
+			Group   string `json:"group"`
+			Version string `json:"version"`
+			Kind    string `json:"kind"`
+		} `json:"x-kubernetes-group-version-kind"`
+		Properties struct {
+			APIVersion struct {
+				Enum []string `json:"enum"`
+			} `json:"apiVersion"`
+			Kind struct {
+				Enum []string `json:"enum"`
+			} `json:"kind"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, false
+	}
+
+	kinds := []string{}
+	for _, gvk := range schema.GVK {
+		apiVersion := gvk.Version
+		if gvk.Group != "" {
+			apiVersion = gvk.Group + "/" + gvk.Version
+		}
+		kinds = append(kinds, apiVersion+"/"+gvk.Kind)
+	}
+	if len(kinds) == 0 && len(schema.Properties.APIVersion.Enum) == 1 && len(schema.Properties.Kind.Enum) == 1 {
+		kinds = append(kinds, schema.Properties.APIVersion.Enum[0]+"/"+schema.Properties.Kind.Enum[0])
+	}
+
+	return kinds, true
+}
+
+// globFiles returns the files matching pattern, in lexical order. As well as the syntax of
+// filepath.Match, ** matches any number of folders.
+func globFiles(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+
+	// Folders are only walked from the first segment containing a pattern
+	i := 0
+	for i < len(segments)-1 && !strings.ContainsAny(segments[i], "*?[") {
+		i++
+	}
+	root := strings.Join(segments[:i], "/")
+	if root == "" && i > 0 {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+
+	files := []string{}
+	err := filepath.Walk(filepath.FromSlash(root), func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), p)
+		if err != nil {
+			return nil
+		}
+		if matchSegments(segments[i:], strings.Split(filepath.ToSlash(rel), "/")) {
+			files = append(files, p)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// matchSegments matches the segments of a path against the segments of a pattern
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
+
+// schemaFile returns the file containing the schema of a resource. Schemas that do not declare their
+// kind are looked up by the file names of github.com/yannh/kubernetes-json-schema.
+func (r GlobRegistry) schemaFile(resourceKind, resourceAPIVersion, k8sVersion string) (string, bool) {
+	if f, ok := r.kinds[resourceAPIVersion+"/"+resourceKind]; ok {
+		return f, true
+	}
+
+	name, err := schemaPath("{{ .ResourceKind }}{{ .KindSuffix }}.json", resourceKind, resourceAPIVersion, k8sVersion, false)
+	if err != nil {
+		return "", false
+	}
+	f, ok := r.basenames[name]
+	return f, ok
+}
+
+// DownloadSchema reads the schema for a resource from the file indexed for its apiVersion and kind
+func (r GlobRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	f, ok := r.schemaFile(resourceKind, resourceAPIVersion, k8sVersion)
+	if !ok {
+		return nil, newNotFoundError(fmt.Errorf("no schema found"))
+	}
+
+	b, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema %s", f)
+	}
+
+	return b, nil
+}
+
+// SchemaURL returns the file:// URL of the schema of a resource
+func (r GlobRegistry) SchemaURL(resourceKind, resourceAPIVersion, k8sVersion string) (string, error) {
+	f, ok := r.schemaFile(resourceKind, resourceAPIVersion, k8sVersion)
+	if !ok {
+		return "", fmt.Errorf("no schema found")
+	}
+
+	return fileURL(f)
+}
+
+// LoadReference reads a document referenced by a schema from a file:// URL
+func (r GlobRegistry) LoadReference(ctx context.Context, ref string) ([]byte, error) {
+	return readFileURL(ref)
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchSegments(t *testing.T) {
+	for i, testCase := range []struct {
+		pattern, path []string
+		expect        bool
+	}{
+		{[]string{"**"}, []string{"deployment.json"}, true},
+		{[]string{"**"}, []string{"v1.18.0", "deployment.json"}, true},
+		{[]string{"**", "*.json"}, []string{"a", "b", "deployment.json"}, true},
+		{[]string{"**", "*.json"}, []string{"deployment.json"}, true},
+		{[]string{"**", "*.json"}, []string{"a", "README.md"}, false},
+		{[]string{"*", "*.json"}, []string{"deployment.json"}, false},
+		{[]string{"*", "*.json"}, []string{"v1.18.0", "deployment.json"}, true},
+		{[]string{"v1.*", "**", "deployment.json"}, []string{"v2.0", "deployment.json"}, false},
+	} {
+		if got := matchSegments(testCase.pattern, testCase.path); got != testCase.expect {
+			t.Errorf("%d - expected %t for %v matching %v, got %t", i, testCase.expect, testCase.path, testCase.pattern, got)
+		}
+	}
+}
+
+func TestGlobRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconform-glob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"v1.18.0/deployment.json":                      `{"x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1"}]}`,
+		"v1.18.0/service.json":                         `{"properties": {"apiVersion": {"enum": ["v1"]}, "kind": {"enum": ["Service"]}}}`,
+		"crds/sagemaker/trainingjob-sagemaker-v1.json": `{"type": "object"}`,
+		"crds/README.md":                               "Schemas for our CRDs",
+		"v1.19.0/deployment.json":                      `{"x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1"}], "description": "1.19"}`,
+	}
+	for f, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, f), []byte(content), 0644)
+	}
+
+	reg, err := newGlobRegistry(filepath.Join(dir, "**"))
+	if err != nil {
+		t.Fatalf("failed creating glob registry: %s", err)
+	}
+
+	for _, testCase := range []struct {
+		kind, apiVersion string
+		expect           string
+		expectNotFound   bool
+	}{
+		{"Deployment", "apps/v1", files["v1.18.0/deployment.json"], false},
+		{"Service", "v1", files["v1.18.0/service.json"], false},
+		{"TrainingJob", "sagemaker.aws.amazon.com/v1", files["crds/sagemaker/trainingjob-sagemaker-v1.json"], false},
+		{"ConfigMap", "v1", "", true},
+	} {
+		res, err := reg.DownloadSchema(testCase.kind, testCase.apiVersion, "1.18.0")
+		if _, notFound := err.(*NotFoundError); notFound != testCase.expectNotFound {
+			t.Errorf("%s: expected not found %t, got error %v", testCase.kind, testCase.expectNotFound, err)
+		}
+		if !bytes.Equal(res, []byte(testCase.expect)) && !testCase.expectNotFound {
+			t.Errorf("%s: expected %s, got %s", testCase.kind, testCase.expect, res)
+		}
+	}
+
+	if _, err := newGlobRegistry(filepath.Join(dir, "crds", "*.md")); err == nil {
+		t.Errorf("expected an error when no schema matches the pattern")
+	}
+}
+
+func TestIsGlob(t *testing.T) {
+	for location, expect := range map[string]bool{
+		"./schemas/**":                     true,
+		"schemas/*/deployment.json":        true,
+		"schemas/{{ .ResourceKind }}.json": false,
+		"https://example.com/schemas/**":   false,
+		"schemas/":                         false,
+	} {
+		if got := isGlob(location); got != expect {
+			t.Errorf("%s: expected %t, got %t", location, expect, got)
+		}
+	}
+}
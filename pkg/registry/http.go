@@ -1,75 +1,105 @@
 package registry
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/yannh/kubeconform/pkg/cache"
 )
 
-type httpGetter interface {
-	Get(url string) (resp *http.Response, err error)
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
 }
 
 // SchemaRegistry is a file repository (local or remote) that contains JSON schemas for Kubernetes resources
 type SchemaRegistry struct {
-	c                  httpGetter
+	c                  httpDoer
 	schemaPathTemplate string
 	cache              cache.Cache
 	strict             bool
 }
 
-func newHTTPRegistry(schemaPathTemplate string, cacheFolder string, strict bool, skipTLS bool) (*SchemaRegistry, error) {
+// newHTTPClient returns the client used to retrieve schemas. Requests fail after timeout, if not 0.
+// The default .netrc entry is only sent to netrcDefaultHost, see netrcTransport.
+func newHTTPClient(skipTLS bool, noProxy string, timeout time.Duration, netrcDefaultHost string) *http.Client {
 	reghttp := &http.Transport{
 		MaxIdleConns:       100,
 		IdleConnTimeout:    3 * time.Second,
 		DisableCompression: true,
-		Proxy:              http.ProxyFromEnvironment,
+		Proxy:              proxyFunc(noProxy),
 	}
 
 	if skipTLS {
 		reghttp.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
-	var filecache cache.Cache = nil
-	if cacheFolder != "" {
-		fi, err := os.Stat(cacheFolder)
-		if err != nil {
-			return nil, fmt.Errorf("failed opening cache folder %s: %s", cacheFolder, err)
-		}
-		if !fi.IsDir() {
-			return nil, fmt.Errorf("cache folder %s is not a directory", err)
-		}
+	if entries := loadNetrc(); len(entries) > 0 {
+		return &http.Client{Transport: &netrcTransport{base: reghttp, entries: entries, defaultHost: netrcDefaultHost}, Timeout: timeout}
+	}
 
-		filecache = cache.NewOnDiskCache(cacheFolder)
+	return &http.Client{Transport: reghttp, Timeout: timeout}
+}
+
+// httpsHost returns the host of a schema location served over HTTPS, or an empty string
+func httpsHost(location string) string {
+	if !strings.HasPrefix(location, "https://") {
+		return ""
+	}
+	host := strings.SplitN(strings.TrimPrefix(location, "https://"), "/", 2)[0]
+	u := url.URL{Host: host[strings.LastIndex(host, "@")+1:]}
+	return u.Hostname()
+}
+
+func newFileCache(cacheFolder string) (cache.Cache, error) {
+	if cacheFolder == "" {
+		return nil, nil
+	}
+
+	fi, err := os.Stat(cacheFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening cache folder %s: %s", cacheFolder, err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("cache folder %s is not a directory", err)
+	}
+
+	return cache.NewOnDiskCache(cacheFolder), nil
+}
+
+func newHTTPRegistry(schemaPathTemplate string, cacheFolder string, strict bool, skipTLS bool, noProxy string, timeout time.Duration, netrcDefaultHost string) (*SchemaRegistry, error) {
+	filecache, err := newFileCache(cacheFolder)
+	if err != nil {
+		return nil, err
 	}
 
 	return &SchemaRegistry{
-		c:                  &http.Client{Transport: reghttp},
+		c:                  newHTTPClient(skipTLS, noProxy, timeout, netrcDefaultHost),
 		schemaPathTemplate: schemaPathTemplate,
 		cache:              filecache,
 		strict:             strict,
 	}, nil
 }
 
-// DownloadSchema downloads the schema for a particular resource from an HTTP server
-func (r SchemaRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
-	url, err := schemaPath(r.schemaPathTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+// downloadURL retrieves a schema over HTTP, returning a NotFoundError on 404
+func downloadURL(ctx context.Context, c httpDoer, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed downloading schema at %s: %s", url, err)
 	}
 
-	if r.cache != nil {
-		if b, err := r.cache.Get(resourceKind, resourceAPIVersion, k8sVersion); err == nil {
-			return b.([]byte), nil
-		}
-	}
+	resp, err := c.Do(req)
+	return readSchemaResponse(url, resp, err)
+}
 
-	resp, err := r.c.Get(url)
+// readSchemaResponse returns the body of the response to a schema request, or a NotFoundError on 404
+func readSchemaResponse(url string, resp *http.Response, err error) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed downloading schema at %s: %s", url, err)
 	}
@@ -88,6 +118,27 @@ func (r SchemaRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVers
 		return nil, fmt.Errorf("failed downloading schema at %s: %s", url, err)
 	}
 
+	return body, nil
+}
+
+// DownloadSchemaWithContext downloads the schema for a particular resource from an HTTP server
+func (r SchemaRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	url, err := schemaPath(r.schemaPathTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cache != nil {
+		if b, err := r.cache.Get(resourceKind, resourceAPIVersion, k8sVersion); err == nil {
+			return b.([]byte), nil
+		}
+	}
+
+	body, err := downloadURL(ctx, r.c, url)
+	if err != nil {
+		return nil, err
+	}
+
 	if r.cache != nil {
 		if err := r.cache.Set(resourceKind, resourceAPIVersion, k8sVersion, body); err != nil {
 			return nil, fmt.Errorf("failed writing schema to cache: %s", err)
@@ -96,3 +147,8 @@ func (r SchemaRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVers
 
 	return body, nil
 }
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (r SchemaRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return r.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
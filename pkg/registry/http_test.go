@@ -2,30 +2,33 @@ package registry
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
-type mockHTTPGetter struct {
+type mockHTTPDoer struct {
 	httpGet func(string) (*http.Response, error)
 }
 
-func newMockHTTPGetter(f func(string) (*http.Response, error)) *mockHTTPGetter {
-	return &mockHTTPGetter{
+func newMockHTTPDoer(f func(string) (*http.Response, error)) *mockHTTPDoer {
+	return &mockHTTPDoer{
 		httpGet: f,
 	}
 }
-func (m mockHTTPGetter) Get(url string) (resp *http.Response, err error) {
-	return m.httpGet(url)
+func (m mockHTTPDoer) Do(req *http.Request) (*http.Response, error) {
+	return m.httpGet(req.URL.String())
 }
 
 func TestDownloadSchema(t *testing.T) {
 	for _, testCase := range []struct {
 		name                                         string
-		c                                            httpGetter
+		c                                            httpDoer
 		schemaPathTemplate                           string
 		strict                                       bool
 		resourceKind, resourceAPIVersion, k8sversion string
@@ -34,7 +37,7 @@ func TestDownloadSchema(t *testing.T) {
 	}{
 		{
 			"error when downloading",
-			newMockHTTPGetter(func(url string) (resp *http.Response, err error) {
+			newMockHTTPDoer(func(url string) (resp *http.Response, err error) {
 				return nil, fmt.Errorf("failed downloading from registry")
 			}),
 			"http://kubernetesjson.dev",
@@ -47,7 +50,7 @@ func TestDownloadSchema(t *testing.T) {
 		},
 		{
 			"getting 404",
-			newMockHTTPGetter(func(url string) (resp *http.Response, err error) {
+			newMockHTTPDoer(func(url string) (resp *http.Response, err error) {
 				return &http.Response{
 					StatusCode: http.StatusNotFound,
 					Body:       ioutil.NopCloser(strings.NewReader("http response mock body")),
@@ -63,7 +66,7 @@ func TestDownloadSchema(t *testing.T) {
 		},
 		{
 			"getting 503",
-			newMockHTTPGetter(func(url string) (resp *http.Response, err error) {
+			newMockHTTPDoer(func(url string) (resp *http.Response, err error) {
 				return &http.Response{
 					StatusCode: http.StatusServiceUnavailable,
 					Body:       ioutil.NopCloser(strings.NewReader("http response mock body")),
@@ -79,7 +82,7 @@ func TestDownloadSchema(t *testing.T) {
 		},
 		{
 			"200",
-			newMockHTTPGetter(func(url string) (resp *http.Response, err error) {
+			newMockHTTPDoer(func(url string) (resp *http.Response, err error) {
 				return &http.Response{
 					StatusCode: http.StatusOK,
 					Body:       ioutil.NopCloser(strings.NewReader("http response mock body")),
@@ -115,3 +118,41 @@ func TestDownloadSchema(t *testing.T) {
 	}
 
 }
+
+func TestDownloadURLCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	if _, err := downloadURL(ctx, newHTTPClient(false, "", 0, ""), server.URL); err == nil {
+		t.Errorf("expected an error when the request is cancelled")
+	}
+}
+
+func TestNewTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	reg, err := NewWithOpts(server.URL+"/{{ .ResourceKind }}.json", Opts{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := reg.DownloadSchema("Deployment", "apps/v1", "master"); err == nil {
+		t.Errorf("expected an error when the schema location does not respond within its timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the request to fail after its timeout, took %s", elapsed)
+	}
+}
@@ -0,0 +1,286 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+type kubeconfigCluster struct {
+	Server                   string `json:"server"`
+	CertificateAuthority     string `json:"certificate-authority"`
+	CertificateAuthorityData []byte `json:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+}
+
+type kubeconfigUser struct {
+	Token                 string                  `json:"token"`
+	TokenFile             string                  `json:"tokenFile"`
+	ClientCertificate     string                  `json:"client-certificate"`
+	ClientCertificateData []byte                  `json:"client-certificate-data"`
+	ClientKey             string                  `json:"client-key"`
+	ClientKeyData         []byte                  `json:"client-key-data"`
+	Username              string                  `json:"username"`
+	Password              string                  `json:"password"`
+	Exec                  *kubeconfigExec         `json:"exec"`
+	AuthProvider          *kubeconfigAuthProvider `json:"auth-provider"`
+}
+
+type kubeconfigAuthProvider struct {
+	Name string `json:"name"`
+}
+
+type kubeconfigContext struct {
+	Cluster string `json:"cluster"`
+	User    string `json:"user"`
+}
+
+// kubeconfig is the subset of a kubeconfig file needed to connect to a cluster. Auth-provider
+// credential plugins, deprecated in favour of exec plugins, are not supported.
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string            `json:"name"`
+		Cluster kubeconfigCluster `json:"cluster"`
+	} `json:"clusters"`
+	Contexts []struct {
+		Name    string            `json:"name"`
+		Context kubeconfigContext `json:"context"`
+	} `json:"contexts"`
+	Users []struct {
+		Name string         `json:"name"`
+		User kubeconfigUser `json:"user"`
+	} `json:"users"`
+}
+
+// kubeconfigPaths returns the kubeconfig files to load, from the KUBECONFIG environment variable
+// or ~/.kube/config
+func kubeconfigPaths() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// resolvePath makes a file path from a kubeconfig file relative to the folder of that file, as kubectl does
+func resolvePath(dir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+// loadKubeconfig reads and merges kubeconfig files. As with kubectl, the first file to set a value wins.
+func loadKubeconfig(paths []string) (*kubeconfig, error) {
+	merged := &kubeconfig{}
+	seen := map[string]bool{}
+	for _, p := range paths {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed reading kubeconfig %s: %s", p, err)
+		}
+
+		var kc kubeconfig
+		if err := yaml.Unmarshal(b, &kc); err != nil {
+			return nil, fmt.Errorf("failed parsing kubeconfig %s: %s", p, err)
+		}
+
+		dir := filepath.Dir(p)
+		if merged.CurrentContext == "" {
+			merged.CurrentContext = kc.CurrentContext
+		}
+		for _, c := range kc.Clusters {
+			if !seen["cluster/"+c.Name] {
+				seen["cluster/"+c.Name] = true
+				c.Cluster.CertificateAuthority = resolvePath(dir, c.Cluster.CertificateAuthority)
+				merged.Clusters = append(merged.Clusters, c)
+			}
+		}
+		for _, c := range kc.Contexts {
+			if !seen["context/"+c.Name] {
+				seen["context/"+c.Name] = true
+				merged.Contexts = append(merged.Contexts, c)
+			}
+		}
+		for _, u := range kc.Users {
+			if !seen["user/"+u.Name] {
+				seen["user/"+u.Name] = true
+				u.User.TokenFile = resolvePath(dir, u.User.TokenFile)
+				u.User.ClientCertificate = resolvePath(dir, u.User.ClientCertificate)
+				u.User.ClientKey = resolvePath(dir, u.User.ClientKey)
+				if u.User.Exec != nil && strings.ContainsRune(u.User.Exec.Command, filepath.Separator) {
+					u.User.Exec.Command = resolvePath(dir, u.User.Exec.Command)
+				}
+				merged.Users = append(merged.Users, u)
+			}
+		}
+	}
+
+	if len(merged.Clusters) == 0 {
+		return nil, fmt.Errorf("no cluster found in kubeconfig %s", strings.Join(paths, string(filepath.ListSeparator)))
+	}
+
+	return merged, nil
+}
+
+// context returns the cluster and user of a context, or of the current context if name is empty
+func (kc *kubeconfig) context(name string) (*kubeconfigCluster, *kubeconfigUser, error) {
+	if name == "" {
+		name = kc.CurrentContext
+	}
+	if name == "" {
+		return nil, nil, fmt.Errorf("no current context set in kubeconfig")
+	}
+
+	var ctx *kubeconfigContext
+	for i := range kc.Contexts {
+		if kc.Contexts[i].Name == name {
+			ctx = &kc.Contexts[i].Context
+		}
+	}
+	if ctx == nil {
+		return nil, nil, fmt.Errorf("context %s not found in kubeconfig", name)
+	}
+
+	var cluster *kubeconfigCluster
+	for i := range kc.Clusters {
+		if kc.Clusters[i].Name == ctx.Cluster {
+			cluster = &kc.Clusters[i].Cluster
+		}
+	}
+	if cluster == nil {
+		return nil, nil, fmt.Errorf("cluster %s of context %s not found in kubeconfig", ctx.Cluster, name)
+	}
+
+	user := &kubeconfigUser{}
+	for i := range kc.Users {
+		if kc.Users[i].Name == ctx.User {
+			user = &kc.Users[i].User
+		}
+	}
+
+	return cluster, user, nil
+}
+
+// readFileOrData returns data if set, or the content of the file at p
+func readFileOrData(data []byte, p string) ([]byte, error) {
+	if len(data) > 0 || p == "" {
+		return data, nil
+	}
+	return ioutil.ReadFile(p)
+}
+
+// newClusterHTTPClient returns an HTTP client authenticating to cluster with the credentials of user,
+// and a function adding token or basic authentication to requests. Requests fail after timeout, if not 0.
+func newClusterHTTPClient(cluster *kubeconfigCluster, user *kubeconfigUser, skipTLS bool, noProxy string, timeout time.Duration) (*http.Client, func(req *http.Request), error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: skipTLS || cluster.InsecureSkipTLSVerify}
+
+	ca, err := readFileOrData(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading certificate authority: %s", err)
+	}
+	if len(ca) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, nil, fmt.Errorf("failed parsing certificate authority")
+		}
+	}
+
+	if user.AuthProvider != nil {
+		return nil, nil, fmt.Errorf("auth-provider %s credentials are not supported, use an exec credential plugin", user.AuthProvider.Name)
+	}
+
+	cert, err := readFileOrData(user.ClientCertificateData, user.ClientCertificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading client certificate: %s", err)
+	}
+	key, err := readFileOrData(user.ClientKeyData, user.ClientKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed reading client key: %s", err)
+	}
+	token := user.Token
+	if user.Exec != nil {
+		execToken, execCert, execKey, err := runExecPlugin(user.Exec, cluster)
+		if err != nil {
+			return nil, nil, err
+		}
+		if execToken != "" {
+			token = execToken
+		}
+		if len(execCert) > 0 {
+			cert, key = execCert, execKey
+		}
+	}
+	if len(cert) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed loading client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+
+	if token == "" && user.TokenFile != "" {
+		b, err := ioutil.ReadFile(user.TokenFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed reading token file: %s", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+
+	authorize := func(req *http.Request) {
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case user.Username != "":
+			req.SetBasicAuth(user.Username, user.Password)
+		}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy:           proxyFunc(noProxy),
+			IdleConnTimeout: 3 * time.Second,
+		},
+		Timeout: timeout,
+	}, authorize, nil
+}
+
+// serviceAccountDir contains the credentials mounted in pods for their service account
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// inClusterCredentials returns the API server and credentials of the service account of the pod
+// kubeconform runs in, and false when not running in a pod
+func inClusterCredentials() (*kubeconfigCluster, *kubeconfigUser, bool) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, nil, false
+	}
+
+	tokenFile := filepath.Join(serviceAccountDir, "token")
+	if _, err := os.Stat(tokenFile); err != nil {
+		return nil, nil, false
+	}
+
+	return &kubeconfigCluster{
+		Server:               "https://" + net.JoinHostPort(host, port),
+		CertificateAuthority: filepath.Join(serviceAccountDir, "ca.crt"),
+	}, &kubeconfigUser{TokenFile: tokenFile}, true
+}
@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKubeconfig(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	if err := os.WriteFile(first, []byte(`
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+    certificate-authority: ca.crt
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: alice
+users:
+- name: alice
+  user:
+    tokenFile: token
+`), 0600); err != nil {
+		t.Fatalf("failed writing kubeconfig: %s", err)
+	}
+	if err := os.WriteFile(second, []byte(`
+current-context: prod
+clusters:
+- name: dev
+  cluster:
+    server: https://overridden.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+    insecure-skip-tls-verify: true
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: bob
+users:
+- name: bob
+  user:
+    username: bob
+    password: hunter2
+- name: carol
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: ./bin/credentials
+      args: ["--cluster", "prod"]
+`), 0600); err != nil {
+		t.Fatalf("failed writing kubeconfig: %s", err)
+	}
+
+	kc, err := loadKubeconfig([]string{first, filepath.Join(dir, "does-not-exist"), second})
+	if err != nil {
+		t.Fatalf("failed loading kubeconfig: %s", err)
+	}
+
+	cluster, user, err := kc.context("")
+	if err != nil {
+		t.Fatalf("failed resolving current context: %s", err)
+	}
+	if cluster.Server != "https://dev.example.com" || cluster.CertificateAuthority != filepath.Join(dir, "ca.crt") {
+		t.Errorf("expected the dev cluster from the first file, got %+v", cluster)
+	}
+	if user.TokenFile != filepath.Join(dir, "token") {
+		t.Errorf("expected the token file to be relative to the kubeconfig, got %s", user.TokenFile)
+	}
+
+	cluster, user, err = kc.context("prod")
+	if err != nil {
+		t.Fatalf("failed resolving context prod: %s", err)
+	}
+	if cluster.Server != "https://prod.example.com" || !cluster.InsecureSkipTLSVerify || user.Username != "bob" {
+		t.Errorf("unexpected cluster %+v or user %+v for context prod", cluster, user)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name == "carol" && (u.User.Exec == nil || u.User.Exec.Command != filepath.Join(dir, "bin", "credentials") || len(u.User.Exec.Args) != 2) {
+			t.Errorf("expected the exec command to be relative to the kubeconfig, got %+v", u.User.Exec)
+		}
+	}
+
+	if _, _, err := kc.context("staging"); err == nil {
+		t.Errorf("expected an error for a missing context")
+	}
+
+	if _, err := loadKubeconfig([]string{filepath.Join(dir, "does-not-exist")}); err == nil {
+		t.Errorf("expected an error without kubeconfig")
+	}
+}
+
+func TestClusterHTTPClientExec(t *testing.T) {
+	dir := t.TempDir()
+	plugin := filepath.Join(dir, "credentials.sh")
+	if err := os.WriteFile(plugin, []byte(`#!/bin/sh
+case "$KUBERNETES_EXEC_INFO" in
+  *'"server":"https://dev.example.com"'*) ;;
+  *) exit 1 ;;
+esac
+echo '{"apiVersion": "client.authentication.k8s.io/v1", "kind": "ExecCredential", "status": {"token": "'$TOKEN'"}}'
+`), 0700); err != nil {
+		t.Fatalf("failed writing exec credential plugin: %s", err)
+	}
+
+	cluster := &kubeconfigCluster{Server: "https://dev.example.com"}
+	for i, testCase := range []struct {
+		user   kubeconfigUser
+		expect string
+	}{
+		{
+			kubeconfigUser{Exec: &kubeconfigExec{
+				APIVersion:         "client.authentication.k8s.io/v1",
+				Command:            plugin,
+				Env:                []kubeconfigExecEnv{{"TOKEN", "s3cr3t"}},
+				ProvideClusterInfo: true,
+			}},
+			"Bearer s3cr3t",
+		},
+		{
+			kubeconfigUser{Exec: &kubeconfigExec{APIVersion: "client.authentication.k8s.io/v1", Command: plugin}},
+			"",
+		},
+		{
+			kubeconfigUser{Exec: &kubeconfigExec{APIVersion: "client.authentication.k8s.io/v1beta1", Command: plugin, ProvideClusterInfo: true}},
+			"",
+		},
+		{
+			kubeconfigUser{Exec: &kubeconfigExec{APIVersion: "client.authentication.k8s.io/v1", Command: plugin, InteractiveMode: "Always"}},
+			"",
+		},
+		{
+			kubeconfigUser{Token: "static", AuthProvider: &kubeconfigAuthProvider{"oidc"}},
+			"",
+		},
+	} {
+		_, authorize, err := newClusterHTTPClient(cluster, &testCase.user, false, "", 0)
+		if testCase.expect == "" {
+			if err == nil {
+				t.Errorf("test %d: expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error %s", i+1, err)
+			continue
+		}
+		req, _ := http.NewRequest(http.MethodGet, cluster.Server, nil)
+		authorize(req)
+		if got := req.Header.Get("Authorization"); got != testCase.expect {
+			t.Errorf("test %d: expected Authorization %q, got %q", i+1, testCase.expect, got)
+		}
+	}
+}
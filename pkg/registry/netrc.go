@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type netrcEntry struct {
+	machine, login, password string // an empty machine is the default entry
+}
+
+// parseNetrc parses the content of a .netrc file. Macro definitions are ignored.
+func parseNetrc(r io.Reader) []netrcEntry {
+	entries := []netrcEntry{}
+	var current *netrcEntry
+
+	scanner := bufio.NewScanner(r)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != "" // macro definitions end with an empty line
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			if strings.HasPrefix(fields[i], "#") {
+				break
+			}
+
+			value := ""
+			if i+1 < len(fields) {
+				value = fields[i+1]
+			}
+
+			switch fields[i] {
+			case "machine":
+				entries = append(entries, netrcEntry{machine: value})
+				current = &entries[len(entries)-1]
+				i++
+			case "default":
+				entries = append(entries, netrcEntry{})
+				current = &entries[len(entries)-1]
+			case "login":
+				if current != nil {
+					current.login = value
+				}
+				i++
+			case "password":
+				if current != nil {
+					current.password = value
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+
+	return entries
+}
+
+// netrcCredentials returns the credentials for host, falling back to the default entry if useDefault
+// is set
+func netrcCredentials(entries []netrcEntry, host string, useDefault bool) (login, password string, ok bool) {
+	for _, e := range entries {
+		if e.machine == host {
+			return e.login, e.password, true
+		}
+	}
+	if !useDefault {
+		return "", "", false
+	}
+	for _, e := range entries {
+		if e.machine == "" {
+			return e.login, e.password, true
+		}
+	}
+
+	return "", "", false
+}
+
+// loadNetrc reads the .netrc file from the path in the NETRC environment variable,
+// or from the home directory of the user
+func loadNetrc() []netrcEntry {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	return parseNetrc(f)
+}
+
+// netrcTransport adds Basic authentication from .netrc to requests that do not have credentials yet.
+// The default entry matches any host, so it is only sent over HTTPS to defaultHost, the host of the
+// schema location the user configured - never to the hosts schemas link to or redirect to.
+type netrcTransport struct {
+	base        http.RoundTripper
+	entries     []netrcEntry
+	defaultHost string
+}
+
+func (t *netrcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		host := req.URL.Hostname()
+		useDefault := t.defaultHost != "" && req.URL.Scheme == "https" && host == t.defaultHost
+		if login, password, ok := netrcCredentials(t.entries, host, useDefault); ok {
+			req = req.Clone(req.Context()) // RoundTrippers must not modify the request
+			req.SetBasicAuth(login, password)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	netrc := `# schemas
+machine schemas.example.com login alice password s3cret
+machine other.example.com
+  login bob
+  password hunter2 # comment
+macdef init
+  machine evil.example.com login mallory password x
+
+default login anonymous password guest
+`
+	expected := []netrcEntry{
+		{"schemas.example.com", "alice", "s3cret"},
+		{"other.example.com", "bob", "hunter2"},
+		{"", "anonymous", "guest"},
+	}
+	if got := parseNetrc(strings.NewReader(netrc)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	for i, testCase := range []struct {
+		host       string
+		useDefault bool
+		login      string
+	}{
+		{"schemas.example.com", false, "alice"},
+		{"other.example.com", true, "bob"},
+		{"unknown.example.com", true, "anonymous"},
+		{"unknown.example.com", false, ""},
+	} {
+		if login, _, _ := netrcCredentials(expected, testCase.host, testCase.useDefault); login != testCase.login {
+			t.Errorf("test %d: expected login %s for %s, got %s", i+1, testCase.login, testCase.host, login)
+		}
+	}
+
+	if _, _, ok := netrcCredentials(expected[:2], "unknown.example.com", true); ok {
+		t.Errorf("expected no credentials for an unknown host without default entry")
+	}
+}
+
+func TestNetrcTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		w.Write([]byte(user + ":" + password))
+	}))
+	defer server.Close()
+
+	netrcFile := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(netrcFile, []byte("machine 127.0.0.1 login alice password s3cret\n"), 0600); err != nil {
+		t.Fatalf("failed writing netrc file: %s", err)
+	}
+	t.Setenv("NETRC", netrcFile)
+
+	c := newHTTPClient(false, "", 0, "")
+	body, err := downloadURL(context.Background(), c, server.URL)
+	if err != nil || string(body) != "alice:s3cret" {
+		t.Errorf("expected credentials from netrc to be sent, got %s, %v", body, err)
+	}
+
+	// Explicit credentials take precedence
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.SetBasicAuth("bob", "hunter2")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := readSchemaResponse(server.URL, resp, nil); string(body) != "bob:hunter2" {
+		t.Errorf("expected explicit credentials to be sent, got %s", body)
+	}
+
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "does-not-exist"))
+	if body, _ := downloadURL(context.Background(), newHTTPClient(false, "", 0, ""), server.URL); string(body) != ":" {
+		t.Errorf("expected no credentials without netrc file, got %s", body)
+	}
+}
+
+func TestNetrcTransportDefault(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		w.Write([]byte(user + ":" + password))
+	})
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	netrcFile := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(netrcFile, []byte("default login anonymous password guest\n"), 0600); err != nil {
+		t.Fatalf("failed writing netrc file: %s", err)
+	}
+	t.Setenv("NETRC", netrcFile)
+
+	for i, testCase := range []struct {
+		location string
+		url      string
+		expect   string
+	}{
+		{tlsServer.URL + "/{{ .ResourceKind }}.json", tlsServer.URL, "anonymous:guest"},
+		{"", tlsServer.URL, ":"}, // e.g. bucket requests
+		{"https://schemas.example.com/{{ .ResourceKind }}.json", tlsServer.URL, ":"}, // other hosts
+		{server.URL + "/{{ .ResourceKind }}.json", server.URL, ":"},                  // plain HTTP
+	} {
+		body, err := downloadURL(context.Background(), newHTTPClient(true, "", 0, httpsHost(testCase.location)), testCase.url)
+		if err != nil || string(body) != testCase.expect {
+			t.Errorf("test %d: expected %s, got %s, %v", i+1, testCase.expect, body, err)
+		}
+	}
+}
+
+func TestHTTPSHost(t *testing.T) {
+	for i, testCase := range []struct {
+		location, expect string
+	}{
+		{"https://schemas.example.com/{{ .ResourceKind }}.json", "schemas.example.com"},
+		{"https://user@schemas.example.com:8443/schemas", "schemas.example.com"},
+		{"https://[::1]:8443", "::1"},
+		{"http://schemas.example.com/{{ .ResourceKind }}.json", ""},
+		{"./schemas/{{ .ResourceKind }}.json", ""},
+	} {
+		if got := httpsHost(testCase.location); got != testCase.expect {
+			t.Errorf("test %d: expected %s, got %s", i+1, testCase.expect, got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// proxyFunc returns the function selecting the proxy of HTTP requests. If noProxy is empty, it is
+// http.ProxyFromEnvironment. Otherwise requests are sent to the proxy set with HTTPS_PROXY or
+// HTTP_PROXY, except those to hosts matching noProxy, which replaces NO_PROXY: a comma-separated
+// list of host names, domains - matching their subdomains too, or only them if prefixed with a dot -,
+// IP addresses and ranges, optionally with a port, or "*" to match all hosts. Requests to localhost
+// and loopback addresses are never proxied.
+func proxyFunc(noProxy string) func(*http.Request) (*url.URL, error) {
+	if noProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+
+	patterns := strings.Split(noProxy, ",")
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := proxyFromEnv(req.URL.Scheme)
+		if proxy == "" || !useProxy(req.URL, patterns) {
+			return nil, nil
+		}
+		return parseProxy(proxy)
+	}
+}
+
+// proxyFromEnv returns the proxy set in the environment for requests with scheme
+func proxyFromEnv(scheme string) string {
+	names := []string{"HTTP_PROXY", "http_proxy"}
+	if scheme == "https" {
+		names = []string{"HTTPS_PROXY", "https_proxy"}
+	}
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseProxy parses a proxy address, that may be given without a scheme, e.g. proxy.example.com:3128
+func parseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		if u, err := url.Parse("http://" + proxy); err == nil {
+			return u, nil
+		}
+	}
+	return u, err
+}
+
+// useProxy returns false if requests to u are not to be proxied, as it is local or matches
+// one of the no-proxy patterns
+func useProxy(u *url.URL, patterns []string) bool {
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if pattern == "*" {
+			return false
+		}
+		if _, ipNet, err := net.ParseCIDR(pattern); err == nil {
+			if ip != nil && ipNet.Contains(ip) {
+				return false
+			}
+			continue
+		}
+
+		patternPort := ""
+		if h, p, err := net.SplitHostPort(pattern); err == nil {
+			pattern, patternPort = h, p
+		}
+		if patternPort != "" && patternPort != port {
+			continue
+		}
+		if patternIP := net.ParseIP(pattern); patternIP != nil {
+			if ip != nil && patternIP.Equal(ip) {
+				return false
+			}
+			continue
+		}
+		if strings.HasPrefix(pattern, "*.") {
+			pattern = pattern[1:]
+		}
+		if strings.HasPrefix(pattern, ".") {
+			if strings.HasSuffix(host, pattern) {
+				return false
+			}
+			continue
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return false
+		}
+	}
+
+	return true
+}
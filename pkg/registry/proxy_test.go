@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "proxy.example.com:3128")
+	t.Setenv("HTTP_PROXY", "http://proxy.example.com:8080")
+	t.Setenv("NO_PROXY", "*")
+
+	for i, testCase := range []struct {
+		noProxy string
+		url     string
+		expect  string
+	}{
+		{"internal.example.com", "https://internal.example.com/schema.json", ""},
+		{"example.com", "https://schemas.example.com/schema.json", ""},
+		{".example.com", "https://example.com/schema.json", "http://proxy.example.com:3128"},
+		{"*.example.com", "https://schemas.example.com/schema.json", ""},
+		{"example.com", "https://notexample.com/schema.json", "http://proxy.example.com:3128"},
+		{"example.com", "http://github.com/schema.json", "http://proxy.example.com:8080"},
+		{"10.0.0.0/8, 192.168.1.1", "https://10.1.2.3/schema.json", ""},
+		{"10.0.0.0/8, 192.168.1.1", "https://192.168.1.1/schema.json", ""},
+		{"10.0.0.0/8, 192.168.1.1", "https://192.168.1.2/schema.json", "http://proxy.example.com:3128"},
+		{"example.com:8443", "https://example.com:8443/schema.json", ""},
+		{"example.com:8443", "https://example.com/schema.json", "http://proxy.example.com:3128"},
+		{"*", "https://example.com/schema.json", ""},
+		{"example.org", "https://localhost/schema.json", ""},
+		{"example.org", "http://127.0.0.1:8080/schema.json", ""},
+		{"example.org", "https://github.com/schema.json", "http://proxy.example.com:3128"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, testCase.url, nil)
+		proxy, err := proxyFunc(testCase.noProxy)(req)
+		if err != nil {
+			t.Errorf("test %d: unexpected error %s", i+1, err)
+			continue
+		}
+		got := ""
+		if proxy != nil {
+			got = proxy.String()
+		}
+		if got != testCase.expect {
+			t.Errorf("test %d: expected proxy %q for %s, got %q", i+1, testCase.expect, testCase.url, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+)
+
+// ReferenceLoader is implemented by registries serving schemas that may $ref other documents on the
+// same server or filesystem. Relative $refs are resolved against the URL of the schema.
+type ReferenceLoader interface {
+	// SchemaURL returns the absolute URL the schema of a resource is retrieved from
+	SchemaURL(resourceKind, resourceAPIVersion, k8sVersion string) (string, error)
+	// LoadReference retrieves a document referenced by a schema
+	LoadReference(ctx context.Context, url string) ([]byte, error)
+}
+
+// wrapper is implemented by registries adding behaviour to another registry
+type wrapper interface {
+	Unwrap() Registry
+}
+
+// KindServer is implemented by registries that know every kind they serve, such as clusters. They are
+// authoritative for these kinds: their schemas are used without querying other schema locations.
+type KindServer interface {
+	// ServesKind returns true if the registry serves the schema of resourceAPIVersion/resourceKind
+	ServesKind(ctx context.Context, resourceKind, resourceAPIVersion string) bool
+}
+
+// AsKindServer returns the KindServer of reg, or of the registry it wraps
+func AsKindServer(reg Registry) (KindServer, bool) {
+	for {
+		if s, ok := reg.(KindServer); ok {
+			return s, true
+		}
+		w, ok := reg.(wrapper)
+		if !ok {
+			return nil, false
+		}
+		reg = w.Unwrap()
+	}
+}
+
+// AsReferenceLoader returns the ReferenceLoader of reg, or of the registry it wraps
+func AsReferenceLoader(reg Registry) (ReferenceLoader, bool) {
+	for {
+		if l, ok := reg.(ReferenceLoader); ok {
+			return l, true
+		}
+		w, ok := reg.(wrapper)
+		if !ok {
+			return nil, false
+		}
+		reg = w.Unwrap()
+	}
+}
+
+// SchemaURL returns the URL of the schema of a resource
+func (r SchemaRegistry) SchemaURL(resourceKind, resourceAPIVersion, k8sVersion string) (string, error) {
+	return schemaPath(r.schemaPathTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+}
+
+// LoadReference downloads a document referenced by a schema, with the HTTP client of the registry
+func (r SchemaRegistry) LoadReference(ctx context.Context, url string) ([]byte, error) {
+	return downloadURL(ctx, r.c, url)
+}
+
+// SchemaURL returns the file:// URL of the schema of a resource
+func (r LocalRegistry) SchemaURL(resourceKind, resourceAPIVersion, k8sVersion string) (string, error) {
+	schemaFile, err := schemaPath(r.pathTemplate, resourceKind, resourceAPIVersion, k8sVersion, r.strict)
+	if err != nil {
+		return "", err
+	}
+
+	return fileURL(schemaFile)
+}
+
+// LoadReference reads a document referenced by a schema from a file:// URL
+func (r LocalRegistry) LoadReference(ctx context.Context, ref string) ([]byte, error) {
+	return readFileURL(ref)
+}
+
+// fileURL returns the file:// URL of a path
+func fileURL(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// readFileURL reads the file at a file:// URL
+func readFileURL(ref string) ([]byte, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "file" {
+		return nil, fmt.Errorf("failed loading %s: not a file URL", ref)
+	}
+
+	return ioutil.ReadFile(filepath.FromSlash(u.Path))
+}
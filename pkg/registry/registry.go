@@ -2,11 +2,17 @@ package registry
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 )
 
+// catalogPrefix marks a schema location as the URL of a schema index, e.g. catalog+https://example.com/index.json
+const catalogPrefix = "catalog+"
+
 type Manifest struct {
 	Kind, Version string
 }
@@ -16,6 +22,20 @@ type Registry interface {
 	DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error)
 }
 
+// ContextRegistry is implemented by registries whose downloads can be cancelled, such as remote ones
+type ContextRegistry interface {
+	Registry
+	DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error)
+}
+
+// DownloadSchemaWithContext downloads the schema for a resource from reg, with ctx if reg is a ContextRegistry
+func DownloadSchemaWithContext(ctx context.Context, reg Registry, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	if r, ok := reg.(ContextRegistry); ok {
+		return r.DownloadSchemaWithContext(ctx, resourceKind, resourceAPIVersion, k8sVersion)
+	}
+	return reg.DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion)
+}
+
 // Retryable indicates whether an error is a temporary or a permanent failure
 type Retryable interface {
 	IsNotFound() bool
@@ -32,6 +52,30 @@ func newNotFoundError(err error) *NotFoundError {
 func (e *NotFoundError) Error() string   { return e.err.Error() }
 func (e *NotFoundError) Retryable() bool { return false }
 
+// templateFuncs are the functions schema location templates can use, in addition to the builtin
+// functions of text/template, e.g. {{ if .Group }}crds/{{ .Group }}{{ else }}core{{ end }}
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"replace":    strings.ReplaceAll,
+	"hasPrefix":  strings.HasPrefix,
+	"hasSuffix":  strings.HasSuffix,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+}
+
+// schemaPathData are the fields of schema location templates
+type schemaPathData struct {
+	NormalizedKubernetesVersion string
+	StrictSuffix                string
+	ResourceKind                string
+	ResourceAPIVersion          string
+	KindSuffix                  string
+	SchemaRevision              string // empty, schema locations are templated with a revision with WithSchemaRevision
+	Group                       string // empty for the core group, e.g. "apps" in "apps/v1"
+	Kind                        string // as written in resources, e.g. "Deployment"
+}
+
 func schemaPath(tpl, resourceKind, resourceAPIVersion, k8sVersion string, strict bool) (string, error) {
 	normalisedVersion := k8sVersion
 	if normalisedVersion != "master" {
@@ -51,23 +95,25 @@ func schemaPath(tpl, resourceKind, resourceAPIVersion, k8sVersion string, strict
 		kindSuffix += "-" + strings.ToLower(groupParts[1])
 	}
 
-	tmpl, err := template.New("tpl").Parse(tpl)
+	group := ""
+	if len(groupParts) > 1 {
+		group = groupParts[0]
+	}
+
+	tmpl, err := template.New("schema location").Funcs(templateFuncs).Parse(tpl)
 	if err != nil {
 		return "", err
 	}
 
-	tplData := struct {
-		NormalizedKubernetesVersion string
-		StrictSuffix                string
-		ResourceKind                string
-		ResourceAPIVersion          string
-		KindSuffix                  string
-	}{
+	tplData := schemaPathData{
 		normalisedVersion,
 		strictSuffix,
 		strings.ToLower(resourceKind),
 		groupParts[len(groupParts)-1],
 		kindSuffix,
+		"",
+		group,
+		resourceKind,
 	}
 
 	var buf bytes.Buffer
@@ -79,20 +125,132 @@ func schemaPath(tpl, resourceKind, resourceAPIVersion, k8sVersion string, strict
 	return buf.String(), nil
 }
 
-func New(schemaLocation string, cache string, strict bool, skipTLS bool) (Registry, error) {
+// checkSchemaPath returns an error if the schema location template tpl can not be parsed, or fails
+// to execute for a resource of the core group or of a named group, so that errors in conditional
+// sections are also reported when the registry is created
+func checkSchemaPath(tpl string) error {
+	for _, apiVersion := range []string{"v1", "apps/v1"} {
+		if _, err := schemaPath(tpl, "Deployment", apiVersion, "master", true); err != nil {
+			return fmt.Errorf("invalid template %s: %s", tpl, err)
+		}
+	}
+	return nil
+}
+
+// schemaRevisionPlaceholder matches the {{ .SchemaRevision }} template field in schema locations
+var schemaRevisionPlaceholder = regexp.MustCompile(`{{-?\s*\.SchemaRevision\s*-?}}`)
+
+// WithSchemaRevision returns schemaLocation with the {{ .SchemaRevision }} template field set to
+// revision. The field is empty in schema locations that are not templated with a revision.
+func WithSchemaRevision(schemaLocation, revision string) string {
+	return schemaRevisionPlaceholder.ReplaceAllLiteralString(schemaLocation, revision)
+}
+
+// expandSchemaLocation returns the path template for a schema location given as "default" or as a base URL
+func expandSchemaLocation(schemaLocation string) string {
 	if schemaLocation == "default" {
-		schemaLocation = "https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+		return "https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
 	} else if !strings.HasSuffix(schemaLocation, "json") { // If we dont specify a full templated path, we assume the paths of our fork of kubernetes-json-schema
-		schemaLocation += "/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+		return schemaLocation + "/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"
+	}
+
+	return schemaLocation
+}
+
+// SchemaURL returns the address of the schema for a resource at a schema location. Schema locations
+// that do not map a resource to a single address, such as CRD files or catalogs, are returned unchanged.
+func SchemaURL(schemaLocation, resourceKind, resourceAPIVersion, k8sVersion string, strict bool) string {
+	tpl := schemaLocation
+	switch {
+	case strings.HasPrefix(schemaLocation, crdPrefix), strings.HasPrefix(schemaLocation, catalogPrefix),
+		schemaLocation == clusterLocation, strings.HasPrefix(schemaLocation, clusterPrefix), isGlob(schemaLocation):
+		return schemaLocation
+	case schemaLocation == "embedded":
+		tpl = "embedded:" + embeddedPathTemplate
+	case strings.HasPrefix(schemaLocation, configMapPrefix):
+		tpl = schemaLocation + "/" + configMapKeyTemplate
+	case strings.HasPrefix(schemaLocation, s3Prefix), strings.HasPrefix(schemaLocation, gcsPrefix):
+		if i := strings.LastIndex(tpl, "?region="); i >= 0 {
+			tpl = tpl[:i]
+		}
+	default:
+		tpl = expandSchemaLocation(schemaLocation)
+	}
+
+	u, err := schemaPath(tpl, resourceKind, resourceAPIVersion, k8sVersion, strict)
+	if err != nil {
+		return schemaLocation
+	}
+	return u
+}
+
+// Opts are the settings of the registries returned by NewWithOpts
+type Opts struct {
+	Cache   string        // folder to cache schemas downloaded over HTTP in
+	Strict  bool          // use the strict schemas, disallowing additional properties
+	SkipTLS bool          // skip TLS validation when downloading schemas
+	NoProxy string        // hosts, domains and IP ranges to connect to without the proxy, overriding NO_PROXY
+	Timeout time.Duration // requests to remote registries fail after Timeout, if not 0
+}
+
+// New returns the registry for a schema location
+func New(schemaLocation string, cache string, strict bool, skipTLS bool) (Registry, error) {
+	return NewWithOpts(schemaLocation, Opts{Cache: cache, Strict: strict, SkipTLS: skipTLS})
+}
+
+// NewWithOpts returns the registry for a schema location, with opts
+func NewWithOpts(schemaLocation string, opts Opts) (Registry, error) {
+	cache, strict, skipTLS, noProxy, timeout := opts.Cache, opts.Strict, opts.SkipTLS, opts.NoProxy, opts.Timeout
+	if strings.HasPrefix(schemaLocation, crdPrefix) {
+		return newCRDRegistry(strings.TrimPrefix(schemaLocation, crdPrefix), strict)
+	}
+
+	if schemaLocation == clusterLocation {
+		return newClusterRegistry("", strict, skipTLS, noProxy, timeout)
+	}
+
+	if strings.HasPrefix(schemaLocation, clusterPrefix) {
+		return newClusterRegistry(strings.TrimPrefix(schemaLocation, clusterPrefix), strict, skipTLS, noProxy, timeout)
+	}
+
+	if strings.HasPrefix(schemaLocation, configMapPrefix) {
+		return newConfigMapRegistry(strings.TrimPrefix(schemaLocation, configMapPrefix), skipTLS, noProxy, timeout)
+	}
+
+	if strings.HasPrefix(schemaLocation, s3Prefix) || strings.HasPrefix(schemaLocation, gcsPrefix) {
+		return newBucketRegistry(schemaLocation, cache, strict, skipTLS, noProxy, timeout)
+	}
+
+	if schemaLocation == "embedded" {
+		return newEmbeddedRegistry(embeddedSchemas, embeddedPathTemplate, strict)
+	}
+
+	if strings.HasPrefix(schemaLocation, catalogPrefix) {
+		indexLocation := strings.TrimPrefix(schemaLocation, catalogPrefix)
+		if err := checkSchemaPath(indexLocation); err != nil {
+			return nil, fmt.Errorf("failed initialising schema catalog registry: %s", err)
+		}
+		return newCatalogRegistry(indexLocation, cache, strict, skipTLS, noProxy, timeout)
+	}
+
+	if isGlob(schemaLocation) {
+		return newGlobRegistry(schemaLocation)
+	}
+
+	// The default .netrc entry is not sent to the default schema location, which the user did not configure
+	netrcDefaultHost := ""
+	if schemaLocation != "default" {
+		netrcDefaultHost = httpsHost(schemaLocation)
 	}
+	schemaLocation = expandSchemaLocation(schemaLocation)
 
 	// try to compile the schemaLocation template to ensure it is valid
-	if _, err := schemaPath(schemaLocation, "Deployment", "v1", "master", true); err != nil {
+	if err := checkSchemaPath(schemaLocation); err != nil {
 		return nil, fmt.Errorf("failed initialising schema location registry: %s", err)
 	}
 
 	if strings.HasPrefix(schemaLocation, "http") {
-		return newHTTPRegistry(schemaLocation, cache, strict, skipTLS)
+		return newHTTPRegistry(schemaLocation, cache, strict, skipTLS, noProxy, timeout, netrcDefaultHost)
 	}
 
 	return newLocalRegistry(schemaLocation, strict)
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"testing"
 )
 
@@ -56,3 +57,144 @@ func TestSchemaPath(t *testing.T) {
 		}
 	}
 }
+
+func TestSchemaURL(t *testing.T) {
+	for i, testCase := range []struct {
+		schemaLocation, expected string
+		strict                   bool
+	}{
+		{
+			"default",
+			"https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/v1.18.0-standalone-strict/deployment-apps-v1.json",
+			true,
+		},
+		{
+			"https://example.com/schemas",
+			"https://example.com/schemas/v1.18.0-standalone/deployment-apps-v1.json",
+			false,
+		},
+		{
+			"/schemas/{{ .ResourceKind }}.json",
+			"/schemas/deployment.json",
+			false,
+		},
+		{
+			"embedded",
+			"embedded:v1.18.0-standalone/deployment-apps-v1.json",
+			false,
+		},
+		{
+			"./schemas/**",
+			"./schemas/**",
+			false,
+		},
+		{
+			"configmap://validation/schemas",
+			"configmap://validation/schemas/deployment-apps-v1.json",
+			false,
+		},
+		{
+			"s3://bucket/{{ .ResourceKind }}.json?region=eu-west-1",
+			"s3://bucket/deployment.json",
+			false,
+		},
+		{
+			"crd+crds.yaml",
+			"crd+crds.yaml",
+			false,
+		},
+		{
+			"cluster+staging",
+			"cluster+staging",
+			false,
+		},
+		{
+			"catalog+https://example.com/index.json",
+			"catalog+https://example.com/index.json",
+			false,
+		},
+	} {
+		if got := SchemaURL(testCase.schemaLocation, "Deployment", "apps/v1", "1.18.0", testCase.strict); got != testCase.expected {
+			t.Errorf("%d - got %s, expected %s", i+1, got, testCase.expected)
+		}
+	}
+}
+
+func TestWithSchemaRevision(t *testing.T) {
+	for i, testCase := range []struct {
+		schemaLocation, revision, expected string
+	}{
+		{"/schemas/{{ .SchemaRevision }}/{{ .ResourceKind }}.json", "v3", "/schemas/v3/{{ .ResourceKind }}.json"},
+		{"/schemas/{{.SchemaRevision}}/{{ .ResourceKind }}.json", "v3", "/schemas/v3/{{ .ResourceKind }}.json"},
+		{"/schemas/{{ .ResourceKind }}.json", "v3", "/schemas/{{ .ResourceKind }}.json"},
+	} {
+		if got := WithSchemaRevision(testCase.schemaLocation, testCase.revision); got != testCase.expected {
+			t.Errorf("%d - got %s, expected %s", i+1, got, testCase.expected)
+		}
+	}
+
+	if got, err := schemaPath("/schemas/{{ .SchemaRevision }}/{{ .ResourceKind }}.json", "Deployment", "apps/v1", "master", false); err != nil || got != "/schemas//deployment.json" {
+		t.Errorf("expected an empty schema revision, got %s, %v", got, err)
+	}
+}
+
+func TestSchemaPathConditionals(t *testing.T) {
+	tpl := "/schemas/{{ if .Group }}crds/{{ .Group }}/{{ .Kind }}_{{ .ResourceAPIVersion }}{{ else }}core/{{ .ResourceKind }}{{ end }}.json"
+	for i, testCase := range []struct {
+		resourceKind, resourceAPIVersion, expected string
+	}{
+		{"Service", "v1", "/schemas/core/service.json"},
+		{"TrainingJob", "sagemaker.aws.amazon.com/v1", "/schemas/crds/sagemaker.aws.amazon.com/TrainingJob_v1.json"},
+	} {
+		if got, err := schemaPath(tpl, testCase.resourceKind, testCase.resourceAPIVersion, "master", false); err != nil || got != testCase.expected {
+			t.Errorf("%d - got %s, %v, expected %s", i+1, got, err, testCase.expected)
+		}
+	}
+
+	got, err := schemaPath(`/schemas/{{ replace .Group "." "_" | upper }}/{{ trimSuffix .Kind "List" | lower }}.json`, "WidgetList", "example.com/v1", "master", false)
+	if err != nil || got != "/schemas/EXAMPLE_COM/widget.json" {
+		t.Errorf("got %s, %v, expected /schemas/EXAMPLE_COM/widget.json", got, err)
+	}
+}
+
+func TestCheckSchemaPath(t *testing.T) {
+	for i, testCase := range []struct {
+		tpl   string
+		valid bool
+	}{
+		{"/schemas/{{ .ResourceKind }}{{ .KindSuffix }}.json", true},
+		{"/schemas/{{ if .Group }}{{ .Group }}/{{ end }}{{ .Kind }}.json", true},
+		{"/schemas/{{ if .Group }}{{ .Group }}/{{ end }{{ .Kind }}.json", false},
+		{"/schemas/{{ if .Group }}{{ .Grop }}/{{ end }}{{ .Kind }}.json", false},
+		{"/schemas/{{ title .Kind }}.json", false},
+	} {
+		if err := checkSchemaPath(testCase.tpl); (err == nil) != testCase.valid {
+			t.Errorf("%d - expected valid %t, got %v", i+1, testCase.valid, err)
+		}
+	}
+
+	if _, err := New("/schemas/{{ if .Group }}{{ .Grop }}{{ end }}.json", "", false, false); err == nil {
+		t.Errorf("expected an error creating a registry with an invalid template")
+	}
+}
+
+// contextRegistry is a registry returning the error of the context of its downloads
+type contextRegistry struct{ mockRegistry }
+
+func (r *contextRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return nil, ctx.Err()
+}
+
+func TestDownloadSchemaWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	plain := &mockRegistry{schema: []byte(`{}`)}
+	if b, err := DownloadSchemaWithContext(ctx, plain, "Deployment", "apps/v1", "master"); err != nil || string(b) != `{}` || plain.calls != 1 {
+		t.Errorf("expected registries without context to be queried, got %s, %v", b, err)
+	}
+
+	if _, err := DownloadSchemaWithContext(ctx, &contextRegistry{}, "Deployment", "apps/v1", "master"); err != context.Canceled {
+		t.Errorf("expected the context to be passed to context registries, got %v", err)
+	}
+}
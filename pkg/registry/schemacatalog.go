@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// schemaCatalog lists schema locations in a file shared between projects, so that they do not
+// each have to repeat the same -schema-location flags
+type schemaCatalog struct {
+	Registries []struct {
+		Name     string `json:"name"`
+		Location string `json:"location"`
+	} `json:"registries"`
+}
+
+// LoadSchemaCatalog returns the schema locations listed in the catalog at location, a URL or a
+// path, in the order they are listed
+func LoadSchemaCatalog(ctx context.Context, location string, skipTLS bool, noProxy string) ([]string, error) {
+	var b []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		b, err = fetchSchemaCatalog(ctx, newHTTPClient(skipTLS, noProxy, 0, httpsHost(location)), location)
+	} else {
+		b, err = ioutil.ReadFile(location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading schema catalog %s: %s", location, err)
+	}
+
+	var catalog schemaCatalog
+	if err := yaml.Unmarshal(b, &catalog); err != nil {
+		return nil, fmt.Errorf("failed parsing schema catalog %s: %s", location, err)
+	}
+
+	locations := []string{}
+	for i, reg := range catalog.Registries {
+		if reg.Location == "" {
+			name := reg.Name
+			if name == "" {
+				name = fmt.Sprintf("%d", i)
+			}
+			return nil, fmt.Errorf("registry %s of schema catalog %s has no location", name, location)
+		}
+		locations = append(locations, reg.Location)
+	}
+
+	return locations, nil
+}
+
+func fetchSchemaCatalog(ctx context.Context, c httpDoer, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received HTTP status %d", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
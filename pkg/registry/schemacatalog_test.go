@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSchemaCatalog(t *testing.T) {
+	catalog := `registries:
+- name: core
+  location: default
+- name: crds
+  location: 'https://example.com/crds/{{ .ResourceKind }}.json'
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/catalog.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(catalog))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "catalog.yaml")
+	os.WriteFile(p, []byte(catalog), 0644)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte("registries:\n- name: crds\n"), 0644)
+
+	expect := []string{"default", "https://example.com/crds/{{ .ResourceKind }}.json"}
+	for _, testCase := range []struct {
+		location  string
+		expect    []string
+		expectErr bool
+	}{
+		{p, expect, false},
+		{srv.URL + "/catalog.yaml", expect, false},
+		{srv.URL + "/missing.yaml", nil, true},
+		{filepath.Join(dir, "missing.yaml"), nil, true},
+		{invalid, nil, true},
+	} {
+		got, err := LoadSchemaCatalog(context.Background(), testCase.location, false, "")
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("%s: expected error %t, got %v", testCase.location, testCase.expectErr, err)
+		}
+		if err == nil && !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("%s: expected %v, got %v", testCase.location, testCase.expect, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// SharedCache wraps a Registry with a cache exposed over HTTP, so that schemas downloaded by one
+// kubeconform run can be reused by others, for example across CI runners. Schemas are retrieved
+// with a GET on the cache URL followed by a key for the schema, and stored with a PUT on the same URL.
+// Failures of the cache are not reported - the schema is then downloaded from the registry.
+type SharedCache struct {
+	reg      Registry
+	c        *http.Client
+	cacheURL string
+	location string
+	strict   bool
+}
+
+// isRemote returns true for schema locations that are retrieved over HTTP
+func isRemote(schemaLocation string) bool {
+	return schemaLocation == "default" || strings.HasPrefix(schemaLocation, "http") || strings.HasPrefix(schemaLocation, catalogPrefix) ||
+		strings.HasPrefix(schemaLocation, s3Prefix) || strings.HasPrefix(schemaLocation, gcsPrefix)
+}
+
+// NewSharedCache returns a registry that checks the cache at cacheURL before querying reg, and
+// stores schemas downloaded from reg in it. Registries for local schema locations are returned as is.
+func NewSharedCache(reg Registry, schemaLocation string, cacheURL string, strict bool, skipTLS bool, noProxy string) Registry {
+	if !isRemote(schemaLocation) {
+		return reg
+	}
+
+	return &SharedCache{
+		reg:      reg,
+		c:        newHTTPClient(skipTLS, noProxy, 0, httpsHost(cacheURL)),
+		cacheURL: strings.TrimSuffix(cacheURL, "/"),
+		location: schemaLocation,
+		strict:   strict,
+	}
+}
+
+func (sc *SharedCache) url(resourceKind, resourceAPIVersion, k8sVersion string) string {
+	hash := md5.Sum([]byte(fmt.Sprintf("%s-%s-%s-%s-%t", sc.location, resourceKind, resourceAPIVersion, k8sVersion, sc.strict)))
+	return sc.cacheURL + "/" + hex.EncodeToString(hash[:])
+}
+
+func (sc *SharedCache) get(ctx context.Context, url string) ([]byte, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false
+	}
+
+	resp, err := sc.c.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false
+	}
+
+	return body, true
+}
+
+func (sc *SharedCache) put(ctx context.Context, url string, schema []byte) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(schema))
+	if err != nil {
+		return
+	}
+
+	resp, err := sc.c.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// DownloadSchemaWithContext retrieves the schema from the shared cache, or from the registry on a cache miss
+func (sc *SharedCache) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	url := sc.url(resourceKind, resourceAPIVersion, k8sVersion)
+	if schema, ok := sc.get(ctx, url); ok {
+		return schema, nil
+	}
+
+	schema, err := DownloadSchemaWithContext(ctx, sc.reg, resourceKind, resourceAPIVersion, k8sVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.put(ctx, url, schema)
+
+	return schema, nil
+}
+
+// DownloadSchema downloads the schema for a resource as DownloadSchemaWithContext does, without a context
+func (sc *SharedCache) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return sc.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
+
+// Unwrap returns the registry the shared cache is in front of
+func (sc *SharedCache) Unwrap() Registry {
+	return sc.reg
+}
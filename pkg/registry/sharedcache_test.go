@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSharedCache(t *testing.T) {
+	var mu sync.Mutex
+	stored := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			if b, ok := stored[r.URL.Path]; ok {
+				w.Write(b)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case http.MethodPut:
+			stored[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+		}
+	}))
+	defer server.Close()
+
+	reg := &mockRegistry{schema: []byte(`{"type": "object"}`)}
+	sc := NewSharedCache(reg, "default", server.URL+"/", false, false, "")
+
+	for i := 0; i < 3; i++ {
+		schema, err := sc.DownloadSchema("Deployment", "apps/v1", "1.18.0")
+		if err != nil || string(schema) != `{"type": "object"}` {
+			t.Errorf("expected schema to be retrieved, got %s, %v", schema, err)
+		}
+	}
+	if reg.calls != 1 {
+		t.Errorf("expected registry to be called once, got %d", reg.calls)
+	}
+	if len(stored) != 1 {
+		t.Errorf("expected 1 schema to be stored in the cache, got %d", len(stored))
+	}
+
+	// Strict schemas are stored separately
+	strictReg := &mockRegistry{schema: []byte(`{"type": "object", "additionalProperties": false}`)}
+	NewSharedCache(strictReg, "default", server.URL, true, false, "").DownloadSchema("Deployment", "apps/v1", "1.18.0")
+	if strictReg.calls != 1 || len(stored) != 2 {
+		t.Errorf("expected strict schema to be downloaded and stored, got %d calls and %d schemas stored", strictReg.calls, len(stored))
+	}
+
+	// Errors of the registry are not cached
+	failing := &mockRegistry{err: fmt.Errorf("connection refused")}
+	if _, err := NewSharedCache(failing, "default", server.URL, false, false, "").DownloadSchema("Service", "v1", "1.18.0"); err == nil {
+		t.Errorf("expected registry error to be returned")
+	}
+	if len(stored) != 2 {
+		t.Errorf("expected errors not to be stored in the cache")
+	}
+}
+
+func TestSharedCacheUnavailable(t *testing.T) {
+	reg := &mockRegistry{schema: []byte(`{"type": "object"}`)}
+	sc := NewSharedCache(reg, "https://example.com/{{ .ResourceKind }}.json", "http://127.0.0.1:1", false, false, "")
+	if schema, err := sc.DownloadSchema("Deployment", "apps/v1", "1.18.0"); err != nil || schema == nil {
+		t.Errorf("expected schema to be downloaded from the registry when the cache is unavailable, got %v", err)
+	}
+}
+
+func TestSharedCacheLocalRegistries(t *testing.T) {
+	reg := &mockRegistry{}
+	for _, location := range []string{"./schemas", "embedded", "crd+crds.yaml"} {
+		if sc := NewSharedCache(reg, location, "http://cache", false, false, ""); sc != reg {
+			t.Errorf("expected registry for %s not to be wrapped", location)
+		}
+	}
+}
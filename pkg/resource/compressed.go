@@ -0,0 +1,73 @@
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// isCompressedFile returns true for gzip-compressed YAML or JSON files, e.g. deployment.yaml.gz
+func isCompressedFile(info os.FileInfo) bool {
+	if info.IsDir() {
+		return false
+	}
+	name := strings.ToLower(info.Name())
+	if !strings.HasSuffix(name, ".gz") {
+		return false
+	}
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".json")
+}
+
+// decompressReader decompresses the reader it wraps on the first Read, see Decompress
+type decompressReader struct {
+	r   io.Reader
+	dr  io.Reader // reader of the decompressed content, once the first bytes are read
+	err error
+}
+
+// Decompress returns a reader of the decompressed content of r if it is gzip-compressed, as told by
+// its first bytes, and of r as it is otherwise. Failures to decompress are returned by Read, so that
+// they are reported as read errors.
+func Decompress(r io.Reader) io.Reader {
+	return &decompressReader{r: r}
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if d.dr == nil && d.err == nil {
+		d.dr, d.err = decompress(d.r)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.dr.Read(p)
+}
+
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed decompressing gzip: %s", err)
+		}
+		return gr, nil
+
+	case bytes.HasPrefix(magic, zstdMagic):
+		// The Go standard library has no zstd decoder
+		return nil, fmt.Errorf("zstd-compressed content is not supported, only gzip")
+	}
+
+	return br, nil
+}
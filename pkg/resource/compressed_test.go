@@ -0,0 +1,56 @@
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestIsCompressedFile(t *testing.T) {
+	for i, testCase := range []struct {
+		filename string
+		expect   bool
+	}{
+		{"file.yaml.gz", true},
+		{"/path/to/my/file.YML.GZ", true},
+		{"file.json.zst", false},
+		{"file.yaml", false},
+		{"file.txt.gz", false},
+		{"file.gz", false},
+	} {
+		if got := isCompressedFile(NewMockFileInfo(testCase.filename)); got != testCase.expect {
+			t.Errorf("test %d: for filename %s, expected %t, got %t", i+1, testCase.filename, testCase.expect, got)
+		}
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\n"
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(manifest))
+	w.Close()
+
+	for i, testCase := range []struct {
+		content []byte
+		expect  string
+		err     bool
+	}{
+		{gz.Bytes(), manifest, false},
+		{[]byte(manifest), manifest, false},
+		{[]byte{}, "", false},
+		{gz.Bytes()[:12], "", true},
+		{[]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, "", true},
+	} {
+		b, err := io.ReadAll(Decompress(bytes.NewReader(testCase.content)))
+		if (err != nil) != testCase.err {
+			t.Errorf("test %d: expected error %t, got %v", i+1, testCase.err, err)
+			continue
+		}
+		if err == nil && string(b) != testCase.expect {
+			t.Errorf("test %d: expected %q, got %q", i+1, testCase.expect, b)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package resource
+
+import (
+	"bytes"
+	"strings"
+)
+
+// directivePrefix starts comments setting options for the resources of a file, e.g.
+// # kubeconform: schema-location=./schemas/{{ .ResourceKind }}.json
+// Other tools see them as plain YAML comments.
+const directivePrefix = "# kubeconform:"
+
+// schemaLocationDirectives returns the schema locations set with directives in the comments at the
+// top of doc, the first document of a file. Directives after the first line of YAML are ignored.
+func schemaLocationDirectives(doc []byte) []string {
+	var locations []string
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || bytes.Equal(line, []byte("---")) {
+			continue
+		}
+		if line[0] != '#' {
+			break
+		}
+		if !bytes.HasPrefix(line, []byte(directivePrefix)) {
+			continue
+		}
+
+		directive := strings.TrimSpace(string(line[len(directivePrefix):]))
+		if location := strings.TrimPrefix(directive, "schema-location="); location != directive && location != "" {
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
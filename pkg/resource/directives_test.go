@@ -0,0 +1,45 @@
+package resource
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaLocationDirectives(t *testing.T) {
+	for i, testCase := range []struct {
+		doc    string
+		expect []string
+	}{
+		{"kind: Deployment\n", nil},
+		{"# kubeconform: schema-location=./local\nkind: Deployment\n", []string{"./local"}},
+		{"---\n# a comment\n\n#kubeconform: schema-location=./ignored\n# kubeconform: schema-location=./local\n# kubeconform: schema-location=default\nkind: Deployment\n", []string{"./local", "default"}},
+		{"kind: Deployment\n# kubeconform: schema-location=./local\n", nil},
+		{"# kubeconform: unknown=1\n# kubeconform: schema-location=\nkind: Deployment\n", nil},
+	} {
+		if got := schemaLocationDirectives([]byte(testCase.doc)); !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("%d - expected %v, got %v", i, testCase.expect, got)
+		}
+	}
+}
+
+func TestFromStreamSchemaLocationDirectives(t *testing.T) {
+	stream := "# kubeconform: schema-location=./local\nkind: Deployment\n---\nkind: List\nitems:\n- kind: Service\n"
+	resources, errors := FromStream(context.Background(), "stdin", strings.NewReader(stream))
+	go func() {
+		for range errors {
+		}
+	}()
+
+	n := 0
+	for res := range resources {
+		n++
+		if !reflect.DeepEqual(res.SchemaLocations, []string{"./local"}) {
+			t.Errorf("expected schema locations of document %d to be set, got %v", res.DocumentIndex, res.SchemaLocations)
+		}
+	}
+	if n != 2 {
+		t.Errorf("expected 2 resources, got %d", n)
+	}
+}
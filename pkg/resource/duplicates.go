@@ -0,0 +1,49 @@
+package resource
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DuplicateResourceError is returned for a resource with the same apiVersion, kind, namespace and name
+// as a resource in a previous document of the same file - usually a copy-paste mistake
+type DuplicateResourceError struct {
+	Version, Kind, Namespace, Name string
+	DocumentIndex                  int // Index of the document containing the duplicate
+	FirstDocumentIndex             int // Index of the document the resource was first found in
+}
+
+func (e *DuplicateResourceError) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + e.Name
+	}
+	return fmt.Sprintf("duplicate resource %s %s %s in documents %d and %d", e.Version, e.Kind, name, e.FirstDocumentIndex, e.DocumentIndex)
+}
+
+// duplicates records the resources found in a file, by apiVersion, kind, namespace and name
+type duplicates map[Signature]int
+
+// check returns a DuplicateResourceError if a resource with the same identity was found before in the
+// file. Resources that can not be parsed or have no fixed name are ignored.
+func (d duplicates) check(res *Resource) error {
+	sig, err := res.Signature()
+	if err != nil || sig.Name == "" || strings.HasSuffix(sig.Name, "{{ generateName }}") {
+		return nil
+	}
+
+	key := Signature{Kind: sig.Kind, Version: sig.Version, Namespace: sig.Namespace, Name: sig.Name}
+	if first, ok := d[key]; ok {
+		return &DuplicateResourceError{
+			Version:            sig.Version,
+			Kind:               sig.Kind,
+			Namespace:          sig.Namespace,
+			Name:               sig.Name,
+			DocumentIndex:      res.DocumentIndex,
+			FirstDocumentIndex: first,
+		}
+	}
+	d[key] = res.DocumentIndex
+
+	return nil
+}
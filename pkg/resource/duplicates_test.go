@@ -0,0 +1,74 @@
+package resource
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicatesInReader(t *testing.T) {
+	for i, testCase := range []struct {
+		stream     string
+		duplicates []string
+	}{
+		{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n",
+			[]string{},
+		},
+		{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n",
+			[]string{"duplicate resource v1 ConfigMap a in documents 0 and 1"},
+		},
+		{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: x\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: a\n  namespace: x\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: x\n",
+			[]string{"duplicate resource v1 ConfigMap x/a in documents 0 and 2"},
+		},
+		{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: x\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: y\n",
+			[]string{},
+		},
+		{
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: a\n---\napiVersion: extensions/v1beta1\nkind: Deployment\nmetadata:\n  name: a\n",
+			[]string{},
+		},
+		{
+			"apiVersion: v1\nkind: Pod\nmetadata:\n  generateName: a-\n---\napiVersion: v1\nkind: Pod\nmetadata:\n  generateName: a-\n",
+			[]string{},
+		},
+		{
+			"apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nkind: ConfigMap\n",
+			[]string{},
+		},
+		{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n",
+			[]string{"duplicate resource v1 ConfigMap a in documents 0 and 1", "duplicate resource v1 ConfigMap a in documents 0 and 2"},
+		},
+	} {
+		resources := make(chan Resource, 10)
+		errs := make(chan error, 10)
+		findResourcesInReader("file.yaml", strings.NewReader(testCase.stream), ListExpand, nil, resources, errs, make([]byte, 1024))
+		close(resources)
+		close(errs)
+
+		nResources := 0
+		for range resources {
+			nResources++
+		}
+		if nResources != strings.Count(testCase.stream, "---")+1 {
+			t.Errorf("test %d: expected all resources to be sent, got %d", i+1, nResources)
+		}
+
+		duplicates := []string{}
+		for err := range errs {
+			var dupErr *DuplicateResourceError
+			if de, ok := err.(DiscoveryError); !ok || !de.Duplicate() || !errors.As(de.Err, &dupErr) {
+				t.Errorf("test %d: expected a duplicate resource error, got %s", i+1, err)
+				continue
+			}
+			duplicates = append(duplicates, dupErr.Error())
+		}
+		if strings.Join(duplicates, "\n") != strings.Join(testCase.duplicates, "\n") {
+			t.Errorf("test %d: expected duplicates %q, got %q", i+1, testCase.duplicates, duplicates)
+		}
+	}
+}
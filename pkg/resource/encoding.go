@@ -0,0 +1,113 @@
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// utf16Reader transcodes a UTF-16 stream to UTF-8
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	buf   bytes.Buffer
+}
+
+func (u *utf16Reader) readUnit() (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return utf8.RuneError, nil // odd number of bytes, the last one is discarded
+		}
+		return 0, err
+	}
+	return u.order.Uint16(b[:]), nil
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for u.buf.Len() < len(p) {
+		unit, err := u.readUnit()
+		if err != nil {
+			if u.buf.Len() > 0 {
+				break
+			}
+			return 0, err
+		}
+
+		r := rune(unit)
+		if utf16.IsSurrogate(r) {
+			next, err := u.readUnit()
+			if err != nil {
+				next = utf8.RuneError
+			}
+			r = utf16.DecodeRune(r, rune(next))
+		}
+		u.buf.WriteRune(r)
+	}
+
+	return u.buf.Read(p)
+}
+
+// normalizeEncoding returns a reader for the UTF-8 content of r. Only streams starting with a
+// byte order mark are modified: the UTF-8 BOM is stripped, and UTF-16 streams are transcoded to UTF-8.
+// Anything else is assumed to be UTF-8 already.
+func normalizeEncoding(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	start, _ := br.Peek(len(bomUTF8))
+
+	switch {
+	case bytes.HasPrefix(start, bomUTF8):
+		br.Discard(len(bomUTF8))
+		return br
+	case bytes.HasPrefix(start, bomUTF16LE):
+		br.Discard(len(bomUTF16LE))
+		return &utf16Reader{r: br, order: binary.LittleEndian}
+	case bytes.HasPrefix(start, bomUTF16BE):
+		br.Discard(len(bomUTF16BE))
+		return &utf16Reader{r: br, order: binary.BigEndian}
+	}
+
+	return br
+}
+
+// crlfReader replaces Windows line endings (CRLF) with LF
+type crlfReader struct {
+	r *bufio.Reader
+}
+
+func (c *crlfReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b == '\r' {
+			if next, err := c.r.Peek(1); err == nil && next[0] == '\n' {
+				continue
+			}
+		}
+		p[n] = b
+		n++
+	}
+
+	return n, nil
+}
+
+// NormalizeNewlines returns a reader for the UTF-8 content of r, with CRLF line endings replaced with LF.
+// Lone carriage returns are left untouched.
+func NormalizeNewlines(r io.Reader) io.Reader {
+	return &crlfReader{r: bufio.NewReader(normalizeEncoding(r))}
+}
@@ -0,0 +1,75 @@
+package resource
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, bigEndian bool) []byte {
+	var b bytes.Buffer
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b.Write([]byte{byte(u >> 8), byte(u)})
+		} else {
+			b.Write([]byte{byte(u), byte(u >> 8)})
+		}
+	}
+	return b.Bytes()
+}
+
+func TestNormalizeEncoding(t *testing.T) {
+	const manifest = "kind: ConfigMap\napiVersion: v1\ndata:\n  greeting: héllo 👋\n---\nkind: Secret\n"
+	for i, testCase := range []struct {
+		name  string
+		input []byte
+	}{
+		{"utf-8", []byte(manifest)},
+		{"utf-8 with BOM", append([]byte{0xEF, 0xBB, 0xBF}, manifest...)},
+		{"utf-16 little endian", append([]byte{0xFF, 0xFE}, encodeUTF16(manifest, false)...)},
+		{"utf-16 big endian", append([]byte{0xFE, 0xFF}, encodeUTF16(manifest, true)...)},
+	} {
+		got, err := ioutil.ReadAll(normalizeEncoding(bytes.NewReader(testCase.input)))
+		if err != nil {
+			t.Errorf("test %d - %s: unexpected error %s", i+1, testCase.name, err)
+		}
+		if string(got) != manifest {
+			t.Errorf("test %d - %s: expected %q, got %q", i+1, testCase.name, manifest, got)
+		}
+	}
+
+	// Content that is not UTF-8 but has no byte order mark is left untouched
+	latin1 := []byte("kind: ConfigMap\ndata:\n  name: caf\xe9\n")
+	if got, _ := ioutil.ReadAll(normalizeEncoding(bytes.NewReader(latin1))); !bytes.Equal(got, latin1) {
+		t.Errorf("expected content without byte order mark to be unchanged, got %q", got)
+	}
+
+	// Short inputs
+	for _, input := range [][]byte{{}, {0xEF}, {0xFF, 0xFE, 'a'}} {
+		if _, err := ioutil.ReadAll(normalizeEncoding(bytes.NewReader(input))); err != nil {
+			t.Errorf("unexpected error for input %q: %s", input, err)
+		}
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	for i, testCase := range []struct {
+		input, expect []byte
+	}{
+		{[]byte("kind: ConfigMap\napiVersion: v1\n"), []byte("kind: ConfigMap\napiVersion: v1\n")},
+		{[]byte("kind: ConfigMap\r\napiVersion: v1\r\n---\r\nkind: Secret\r\n"), []byte("kind: ConfigMap\napiVersion: v1\n---\nkind: Secret\n")},
+		{[]byte("a: b\rc\r"), []byte("a: b\rc\r")},
+		{[]byte("\r\n\r\n\r"), []byte("\n\n\r")},
+		{append([]byte{0xFF, 0xFE}, encodeUTF16("kind: ConfigMap\r\n\u0a0d", false)...), []byte("kind: ConfigMap\n\u0a0d")},
+		{[]byte{}, []byte{}},
+	} {
+		got, err := ioutil.ReadAll(NormalizeNewlines(bytes.NewReader(testCase.input)))
+		if err != nil {
+			t.Errorf("test %d: unexpected error %s", i+1, err)
+		}
+		if !bytes.Equal(got, testCase.expect) {
+			t.Errorf("test %d: expected %q, got %q", i+1, testCase.expect, got)
+		}
+	}
+}
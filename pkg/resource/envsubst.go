@@ -0,0 +1,91 @@
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// What to do with variables that are not set, when substituting environment variables
+const (
+	UndefinedEmpty = "empty" // replace them with an empty string, as envsubst does
+	UndefinedKeep  = "keep"  // leave them as they are
+	UndefinedError = "error" // fail reading the stream
+)
+
+// envVariable matches $VAR and ${VAR}
+var envVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// UndefinedVariableError is returned when substituting a variable that is not set, with UndefinedError
+type UndefinedVariableError struct {
+	Name string
+	Line int
+}
+
+func (e *UndefinedVariableError) Error() string {
+	return fmt.Sprintf("variable %s is not set, line %d", e.Name, e.Line)
+}
+
+// envsubstReader substitutes variables in a stream, line by line
+type envsubstReader struct {
+	r         *bufio.Reader
+	lookup    func(string) (string, bool)
+	undefined string
+	line      int
+	buf       bytes.Buffer
+}
+
+func (e *envsubstReader) Read(p []byte) (int, error) {
+	for e.buf.Len() == 0 {
+		line, err := e.r.ReadBytes('\n')
+		if len(line) > 0 {
+			e.line++
+			if subErr := e.substitute(line); subErr != nil {
+				return 0, subErr
+			}
+		}
+		if err != nil {
+			if e.buf.Len() > 0 {
+				break
+			}
+			return 0, err
+		}
+	}
+
+	return e.buf.Read(p)
+}
+
+func (e *envsubstReader) substitute(line []byte) error {
+	var err error
+	e.buf.Write(envVariable.ReplaceAllFunc(line, func(match []byte) []byte {
+		name := envVariable.FindSubmatch(match)
+		varName := string(name[1])
+		if varName == "" {
+			varName = string(name[2])
+		}
+
+		if value, ok := e.lookup(varName); ok {
+			return []byte(value)
+		}
+		switch e.undefined {
+		case UndefinedKeep:
+			return match
+		case UndefinedError:
+			if err == nil {
+				err = &UndefinedVariableError{Name: varName, Line: e.line}
+			}
+		}
+		return []byte{}
+	}))
+
+	return err
+}
+
+// Envsubst returns a reader for the UTF-8 content of r, with the variables written $VAR or ${VAR}
+// replaced by their value returned by lookup, e.g. os.LookupEnv. undefined sets what happens to
+// variables that are not set: UndefinedEmpty (the default, if empty), UndefinedKeep or UndefinedError.
+func Envsubst(r io.Reader, lookup func(string) (string, bool), undefined string) io.Reader {
+	return &envsubstReader{r: bufio.NewReader(normalizeEncoding(r)), lookup: lookup, undefined: undefined}
+}
@@ -0,0 +1,40 @@
+package resource
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEnvsubst(t *testing.T) {
+	env := map[string]string{"NAME": "web", "REPLICAS": "3", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	for i, testCase := range []struct {
+		have      string
+		undefined string
+		expect    string
+		expectErr string
+	}{
+		{"name: ${NAME}\nreplicas: $REPLICAS\n", "", "name: web\nreplicas: 3\n", ""},
+		{"name: ${NAME}-${EMPTY}x\n", "", "name: web-x\n", ""},
+		{"price: $5 $$ ${ 1}\n", "", "price: $5 $$ ${ 1}\n", ""},
+		{"name: ${UNSET}\nimage: $UNSET", "", "name: \nimage: ", ""},
+		{"name: ${UNSET}\nimage: $UNSET", UndefinedKeep, "name: ${UNSET}\nimage: $UNSET", ""},
+		{"name: ${NAME}\nimage: $UNSET\n", UndefinedError, "", "variable UNSET is not set, line 2"},
+	} {
+		got, err := io.ReadAll(Envsubst(strings.NewReader(testCase.have), lookup, testCase.undefined))
+		if testCase.expectErr != "" {
+			if err == nil || err.Error() != testCase.expectErr {
+				t.Errorf("%d - expected error %q, got %v", i, testCase.expectErr, err)
+			}
+			continue
+		}
+		if err != nil || string(got) != testCase.expect {
+			t.Errorf("%d - expected %q, got %q, %v", i, testCase.expect, got, err)
+		}
+	}
+}
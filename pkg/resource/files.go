@@ -2,7 +2,10 @@ package resource
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -27,6 +30,37 @@ func (de DiscoveryError) Error() string {
 	return de.Err.Error()
 }
 
+// FileTooLargeError is returned for files bigger than the maximum file size
+type FileTooLargeError struct {
+	Size, MaxSize int64
+	Decompressed  bool // the decompressed content is too large, Size is the number of bytes read until then
+}
+
+func (e *FileTooLargeError) Error() string {
+	if e.Decompressed {
+		return fmt.Sprintf("decompressed size exceeds the maximum file size of %d bytes", e.MaxSize)
+	}
+	return fmt.Sprintf("file size %d bytes exceeds the maximum file size of %d bytes", e.Size, e.MaxSize)
+}
+
+// TooLarge returns true if the file was skipped because it exceeds the maximum file size
+func (de DiscoveryError) TooLarge() bool {
+	var tooLargeErr *FileTooLargeError
+	return errors.As(de.Err, &tooLargeErr)
+}
+
+// Duplicate returns true if the error was caused by a resource found more than once in the same file
+func (de DiscoveryError) Duplicate() bool {
+	var duplicateErr *DuplicateResourceError
+	return errors.As(de.Err, &duplicateErr)
+}
+
+// Unreadable returns true if the error was caused by a file or folder that could not be opened or stat'ed
+func (de DiscoveryError) Unreadable() bool {
+	var pathErr *os.PathError
+	return errors.As(de.Err, &pathErr)
+}
+
 func isIgnored(path string, ignoreFilePatterns []string) (bool, error) {
 	for _, p := range ignoreFilePatterns {
 		m, err := regexp.MatchString(p, path)
@@ -40,43 +74,74 @@ func isIgnored(path string, ignoreFilePatterns []string) (bool, error) {
 	return false, nil
 }
 
-func findFilesInFolders(ctx context.Context, paths []string, ignoreFilePatterns []string) (chan string, chan error) {
-	files := make(chan string)
-	errors := make(chan error)
+// FilesOpts configures how resources are discovered and read from files
+type FilesOpts struct {
+	IgnoreFilePatterns []string // regular expressions specifying paths to ignore
+	MaxFileSize        int64    // files bigger than this many bytes are not read, nor decompressed further, 0 for no limit
+	NormalizeNewlines  bool     // replace CRLF line endings with LF before parsing
+	Envsubst           bool     // substitute environment variables written $VAR or ${VAR} before parsing
+	EnvsubstUndefined  string   // what to do with variables that are not set with Envsubst - UndefinedEmpty if empty
+	ListMode           string   // how resources of kind List are handled - ListExpand if empty
+	Decompress         bool     // read gzip-compressed files, and find .gz files in folders
+	OnlyDocIndexes     []int    // if set, only the documents at these indexes in each file are read, starting at 0
+}
 
-	go func() {
-		for _, path := range paths {
-			// we handle errors in the walk function directly
-			// so it should be safe to discard the outer error
-			err := filepath.Walk(path, func(p string, i os.FileInfo, err error) error {
-				select {
-				case <-ctx.Done():
-					return io.EOF
-				default:
-				}
+// walkFiles calls fn for the files to read resources from in path, and the folders below it
+func walkFiles(ctx context.Context, path string, opts FilesOpts, fn func(p string)) error {
+	err := filepath.Walk(path, func(p string, i os.FileInfo, err error) error {
+		select {
+		case <-ctx.Done():
+			return io.EOF
+		default:
+		}
 
-				if err != nil {
-					return err
-				}
+		if err != nil {
+			return err
+		}
 
-				if !isYAMLFile(i) && !isJSONFile(i) {
-					return nil
-				}
+		if !isYAMLFile(i) && !isJSONFile(i) && !(opts.Decompress && isCompressedFile(i)) {
+			return nil
+		}
 
-				ignored, err := isIgnored(p, ignoreFilePatterns)
-				if err != nil {
-					return err
-				}
-				if ignored {
-					return nil
-				}
+		ignored, err := isIgnored(p, opts.IgnoreFilePatterns)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return nil
+		}
+
+		fn(p)
+
+		return nil
+	})
+
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// FindFiles returns the files resources are read from in paths and the folders below them, as
+// FromFilesWithOpts does. The list can be kept by callers, e.g. to only validate the files that changed.
+func FindFiles(ctx context.Context, paths []string, opts FilesOpts) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		if err := walkFiles(ctx, path, opts, func(p string) { files = append(files, p) }); err != nil {
+			return nil, DiscoveryError{path, err}
+		}
+	}
 
-				files <- p
+	return files, nil
+}
 
-				return nil
-			})
+func findFilesInFolders(ctx context.Context, paths []string, opts FilesOpts) (chan string, chan error) {
+	files := make(chan string)
+	errors := make(chan error)
 
-			if err != nil && err != io.EOF {
+	go func() {
+		for _, path := range paths {
+			if err := walkFiles(ctx, path, opts, func(p string) { files <- p }); err != nil {
 				errors <- DiscoveryError{path, err}
 			}
 		}
@@ -87,20 +152,44 @@ func findFilesInFolders(ctx context.Context, paths []string, ignoreFilePatterns
 	return files, errors
 }
 
-func findResourcesInReader(p string, f io.Reader, resources chan<- Resource, errors chan<- error, buf []byte) {
+// selectedDocument returns true if the document at index i is read, when only the documents at
+// indexes are
+func selectedDocument(indexes []int, i int) bool {
+	if len(indexes) == 0 {
+		return true
+	}
+	for _, index := range indexes {
+		if index == i {
+			return true
+		}
+	}
+	return false
+}
+
+func findResourcesInReader(p string, f io.Reader, listMode string, onlyDocIndexes []int, resources chan<- Resource, errors chan<- error, buf []byte) {
 	maxBufSize := 256 * 1024 * 1024
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(normalizeEncoding(f))
 	// We start with a buf that is 4MB, scanner will resize it up to 256MB if needed
 	// https://github.com/golang/go/blob/aeea5bacbf79fb945edbeac6cd7630dd70c4d9ce/src/bufio/scan.go#L191
 	scanner.Buffer(buf, maxBufSize)
-	scanner.Split(SplitYAMLDocument)
+	var line int
+	scanner.Split(splitYAMLDocumentLines(&line))
 	nRes := 0
-	for scanner.Scan() {
-		if len(scanner.Text()) > 0 {
-			res := Resource{Path: p, Bytes: []byte(scanner.Text())}
-			for _, subres := range res.Resources() {
+	seen := duplicates{}
+	var schemaLocations []string
+	for i := 0; scanner.Scan(); i++ {
+		if i == 0 {
+			schemaLocations = schemaLocationDirectives(scanner.Bytes())
+		}
+		if len(scanner.Text()) > 0 && selectedDocument(onlyDocIndexes, i) {
+			res := Resource{Path: p, Bytes: []byte(scanner.Text()), DocumentIndex: i, Line: line, SchemaLocations: schemaLocations}
+			for _, subres := range res.ResourcesWithListMode(listMode) {
+				dupErr := seen.check(&subres)
 				resources <- subres
 				nRes++
+				if dupErr != nil {
+					errors <- DiscoveryError{p, dupErr}
+				}
 			}
 		}
 	}
@@ -112,7 +201,7 @@ func findResourcesInReader(p string, f io.Reader, resources chan<- Resource, err
 	}
 }
 
-func findResourcesInFile(p string, resources chan<- Resource, errors chan<- error, buf []byte) {
+func findResourcesInFile(p string, opts FilesOpts, resources chan<- Resource, errors chan<- error, buf []byte) {
 	f, err := os.Open(p)
 	defer f.Close()
 
@@ -121,20 +210,63 @@ func findResourcesInFile(p string, resources chan<- Resource, errors chan<- erro
 		return
 	}
 
-	findResourcesInReader(p, f, resources, errors, buf)
+	if opts.MaxFileSize > 0 {
+		fi, err := f.Stat()
+		if err != nil {
+			errors <- DiscoveryError{p, err}
+			return
+		}
+		if fi.Size() > opts.MaxFileSize {
+			errors <- DiscoveryError{p, &FileTooLargeError{Size: fi.Size(), MaxSize: opts.MaxFileSize}}
+			return
+		}
+	}
+
+	var r io.Reader = f
+	if opts.Decompress {
+		r = Decompress(r)
+		if opts.MaxFileSize > 0 {
+			// The decompressed content is read first, so that no resource is validated from a file that
+			// expands beyond the maximum file size
+			b, err := io.ReadAll(io.LimitReader(r, opts.MaxFileSize+1))
+			if err != nil {
+				errors <- DiscoveryError{p, err}
+				return
+			}
+			if int64(len(b)) > opts.MaxFileSize {
+				errors <- DiscoveryError{p, &FileTooLargeError{Size: int64(len(b)), MaxSize: opts.MaxFileSize, Decompressed: true}}
+				return
+			}
+			r = bytes.NewReader(b)
+		}
+	}
+	if opts.NormalizeNewlines {
+		r = NormalizeNewlines(r)
+	}
+	if opts.Envsubst {
+		r = Envsubst(r, os.LookupEnv, opts.EnvsubstUndefined)
+	}
+
+	findResourcesInReader(p, r, opts.ListMode, opts.OnlyDocIndexes, resources, errors, buf)
 }
 
+// FromFiles reads resources from files and folders, skipping paths matching one of ignoreFilePatterns
 func FromFiles(ctx context.Context, paths []string, ignoreFilePatterns []string) (<-chan Resource, <-chan error) {
+	return FromFilesWithOpts(ctx, paths, FilesOpts{IgnoreFilePatterns: ignoreFilePatterns})
+}
+
+// FromFilesWithOpts reads resources from files and folders
+func FromFilesWithOpts(ctx context.Context, paths []string, opts FilesOpts) (<-chan Resource, <-chan error) {
 	resources := make(chan Resource)
 
-	files, errors := findFilesInFolders(ctx, paths, ignoreFilePatterns)
+	files, errors := findFilesInFolders(ctx, paths, opts)
 
 	go func() {
 		initialBufSize := 4 * 1024 * 1024   // This is the initial size - scanner will resize if needed
 		buf := make([]byte, initialBufSize) // We reuse the same buffer to avoid multiple large memory allocations
 
 		for p := range files {
-			findResourcesInFile(p, resources, errors, buf)
+			findResourcesInFile(p, opts, resources, errors, buf)
 		}
 
 		close(errors)
@@ -1,7 +1,13 @@
 package resource
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -181,7 +187,7 @@ lorem: ipsum
 		}()
 
 		r := strings.NewReader(testCase.yamlData)
-		findResourcesInReader(testCase.filePath, r, res, errs, buf)
+		findResourcesInReader(testCase.filePath, r, ListExpand, nil, res, errs, buf)
 		close(res)
 		close(errs)
 		wg.Wait()
@@ -202,3 +208,158 @@ lorem: ipsum
 		}
 	}
 }
+
+func TestDiscoveryErrorUnreadable(t *testing.T) {
+	_, err := os.Open("/does-not-exist")
+	for i, testCase := range []struct {
+		err    DiscoveryError
+		expect bool
+	}{
+		{DiscoveryError{"/does-not-exist", err}, true},
+		{DiscoveryError{"file.yaml", bufio.ErrTooLong}, false},
+	} {
+		if got := testCase.err.Unreadable(); got != testCase.expect {
+			t.Errorf("test %d: expected %t, got %t", i+1, testCase.expect, got)
+		}
+	}
+}
+
+func TestFindResourcesInFileMaxFileSize(t *testing.T) {
+	f, err := ioutil.TempFile("", "kubeconform-*.yaml")
+	if err != nil {
+		t.Fatalf("failed creating temporary file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("kind: ConfigMap\napiVersion: v1\n")
+	f.Close()
+
+	// A small compressed file expanding to more than 1kB
+	gz, err := ioutil.TempFile("", "kubeconform-*.yaml.gz")
+	if err != nil {
+		t.Fatalf("failed creating temporary file: %s", err)
+	}
+	defer os.Remove(gz.Name())
+	w := gzip.NewWriter(gz)
+	w.Write([]byte("kind: ConfigMap\napiVersion: v1\n# " + strings.Repeat("-", 2048) + "\n"))
+	w.Close()
+	gz.Close()
+
+	for i, testCase := range []struct {
+		compressed     bool
+		maxFileSize    int64
+		expectTooLarge bool
+	}{
+		{false, 0, false},
+		{false, 1024, false},
+		{false, 31, false},
+		{false, 30, true},
+		{true, 0, false},
+		{true, 4096, false},
+		{true, 1024, true},
+	} {
+		p, opts := f.Name(), FilesOpts{MaxFileSize: testCase.maxFileSize}
+		if testCase.compressed {
+			p, opts.Decompress = gz.Name(), true
+		}
+		resources := make(chan Resource, 10)
+		errors := make(chan error, 10)
+		findResourcesInFile(p, opts, resources, errors, make([]byte, 1024))
+		close(resources)
+		close(errors)
+
+		nResources := 0
+		for range resources {
+			nResources++
+		}
+		tooLarge := false
+		for err := range errors {
+			if de, ok := err.(DiscoveryError); ok && de.TooLarge() {
+				tooLarge = true
+			}
+		}
+		if tooLarge != testCase.expectTooLarge {
+			t.Errorf("test %d: expected file too large to be %t, got %t", i+1, testCase.expectTooLarge, tooLarge)
+		}
+		if tooLarge && nResources != 0 {
+			t.Errorf("test %d: expected no resource to be read from a file that is too large, got %d", i+1, nResources)
+		}
+	}
+}
+
+func TestFindResourcesInFileOnlyDocIndexes(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "resources.yaml")
+	// The second and fourth documents are duplicates, the third can not be parsed
+	content := "kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: a\n---\nkind: ConfigMap\napiVersion: v1\nmetadata:\n  name: b\n---\nkind: [x\n---\nkind: ConfigMap\napiVersion: v1\nmetadata:\n  name: b\n"
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, testCase := range []struct {
+		onlyDocIndexes []int
+		expect         []int
+		expectErrs     int
+	}{
+		{nil, []int{0, 1, 2, 3}, 1},
+		{[]int{0, 3}, []int{0, 3}, 0},
+		{[]int{1, 3}, []int{1, 3}, 1},
+		{[]int{42}, []int{0}, 0},
+	} {
+		resources := make(chan Resource, 10)
+		errors := make(chan error, 10)
+		findResourcesInFile(p, FilesOpts{OnlyDocIndexes: testCase.onlyDocIndexes}, resources, errors, make([]byte, 1024))
+		close(resources)
+		close(errors)
+
+		indexes := []int{}
+		for res := range resources {
+			indexes = append(indexes, res.DocumentIndex)
+		}
+		if !reflect.DeepEqual(indexes, testCase.expect) {
+			t.Errorf("test %d: expected documents %v to be read, got %v", i+1, testCase.expect, indexes)
+		}
+		if len(errors) != testCase.expectErrs {
+			t.Errorf("test %d: expected %d duplicate errors, got %d", i+1, testCase.expectErrs, len(errors))
+		}
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.yaml", "b.json", "c.txt", "sub/d.yml", "sub/e.jsonnet", "ignored/f.yaml", "g.yaml.gz", "h.txt.gz"} {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed creating folder: %s", err)
+		}
+		if err := os.WriteFile(p, []byte("kind: ConfigMap\napiVersion: v1\n"), 0644); err != nil {
+			t.Fatalf("failed writing file: %s", err)
+		}
+	}
+
+	for i, testCase := range []struct {
+		opts   FilesOpts
+		expect []string
+	}{
+		{FilesOpts{}, []string{"a.yaml", "b.json", "ignored/f.yaml", "sub/d.yml"}},
+		{FilesOpts{IgnoreFilePatterns: []string{"ignored"}}, []string{"a.yaml", "b.json", "sub/d.yml"}},
+		{FilesOpts{Decompress: true}, []string{"a.yaml", "b.json", "g.yaml.gz", "ignored/f.yaml", "sub/d.yml"}},
+	} {
+		files, err := FindFiles(context.Background(), []string{dir}, testCase.opts)
+		if err != nil {
+			t.Fatalf("test %d: failed finding files: %s", i+1, err)
+		}
+		got := []string{}
+		for _, f := range files {
+			rel, _ := filepath.Rel(dir, f)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if strings.Join(got, ",") != strings.Join(testCase.expect, ",") {
+			t.Errorf("test %d: expected %v, got %v", i+1, testCase.expect, got)
+		}
+	}
+
+	if _, err := FindFiles(context.Background(), []string{filepath.Join(dir, "does-not-exist")}, FilesOpts{}); err == nil {
+		t.Errorf("expected an error for a missing folder")
+	} else if de, ok := err.(DiscoveryError); !ok || !de.Unreadable() {
+		t.Errorf("expected an unreadable DiscoveryError, got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// LabelFix records the labels added to a resource of a file
+type LabelFix struct {
+	Path          string
+	DocumentIndex int
+	Kind, Name    string
+	Labels        []string // keys of the labels added, in lexical order
+}
+
+var (
+	metadataLine = regexp.MustCompile(`^metadata:[ \t]*(#.*)?$`)
+	labelsLine   = regexp.MustCompile(`^([ \t]+)labels:[ \t]*([^#]*?)[ \t]*(#.*)?$`)
+)
+
+// FixLabels adds the labels missing from the resources of the YAML file at path, and writes the file
+// back. The file is edited line by line: labels are inserted in the metadata of each resource, with the
+// indentation of the surrounding lines, and comments and formatting are left untouched. Kinds in
+// skipKinds, Lists and resources written in flow style are not fixed.
+func FixLabels(path string, labels map[string]string, skipKinds map[string]struct{}) ([]LabelFix, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixes := []LabelFix{}
+	var out bytes.Buffer
+	for i, doc := range splitDocuments(b) {
+		fixed, fix, err := addLabels(doc, labels, skipKinds)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %s", i, err)
+		}
+		if fix != nil {
+			fix.Path, fix.DocumentIndex = path, i
+			fixes = append(fixes, *fix)
+		}
+		out.Write(fixed)
+	}
+
+	if len(fixes) == 0 {
+		return fixes, nil
+	}
+
+	return fixes, os.WriteFile(path, out.Bytes(), fi.Mode())
+}
+
+// splitDocuments splits a YAML stream into documents, each starting with its --- separator if it
+// has one. Joining the documents returns the stream unchanged.
+func splitDocuments(b []byte) [][]byte {
+	docs := [][]byte{}
+	start := 0
+	for i := 0; i < len(b); {
+		end := bytes.IndexByte(b[i:], '\n')
+		if end < 0 {
+			end = len(b) - i - 1
+		}
+		line := b[i : i+end+1]
+		if i > start && isDocumentSeparator(line) {
+			docs = append(docs, b[start:i])
+			start = i
+		}
+		i += end + 1
+	}
+
+	return append(docs, b[start:])
+}
+
+func isDocumentSeparator(line []byte) bool {
+	line = bytes.TrimRight(line, "\r\n")
+	return bytes.HasPrefix(line, []byte("---")) && (len(line) == 3 || line[3] == ' ' || line[3] == '\t')
+}
+
+// addLabels returns doc with the labels it is missing added to its metadata, and the labels added
+func addLabels(doc []byte, labels map[string]string, skipKinds map[string]struct{}) ([]byte, *LabelFix, error) {
+	var r map[string]interface{}
+	if err := yaml.Unmarshal(doc, &r); err != nil || r == nil {
+		return doc, nil, nil // parse errors are reported by the validation
+	}
+	kind, _ := r["kind"].(string)
+	if _, skip := skipKinds[kind]; skip || kind == "" {
+		return doc, nil, nil
+	}
+	if _, isList := r["items"]; isList && strings.HasSuffix(kind, "List") {
+		return doc, nil, nil
+	}
+
+	metadata, _ := r["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	existing, _ := metadata["labels"].(map[string]interface{})
+	missing := []string{}
+	for k := range labels {
+		if _, ok := existing[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return doc, nil, nil
+	}
+	sort.Strings(missing)
+
+	fixed, err := insertLabels(doc, labels, missing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %s", kind, name, err)
+	}
+
+	// The edit is checked, in case the document is laid out in a way we did not expect
+	var check map[string]interface{}
+	if err := yaml.Unmarshal(fixed, &check); err != nil {
+		return nil, nil, fmt.Errorf("%s %s: failed adding labels: %s", kind, name, err)
+	}
+	checkMetadata, _ := check["metadata"].(map[string]interface{})
+	checkLabels, _ := checkMetadata["labels"].(map[string]interface{})
+	for k, v := range labels {
+		if checkLabels[k] != v && existing[k] == nil {
+			return nil, nil, fmt.Errorf("%s %s: failed adding label %s", kind, name, k)
+		}
+	}
+
+	return fixed, &LabelFix{Kind: kind, Name: name, Labels: missing}, nil
+}
+
+// insertLabels inserts the missing labels in the metadata of doc, in lexical order
+func insertLabels(doc []byte, labels map[string]string, missing []string) ([]byte, error) {
+	newline := "\n"
+	if bytes.Contains(doc, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	lines := strings.SplitAfter(string(doc), "\n")
+
+	// The metadata block extends to the next line that is not indented
+	metadataStart := -1
+	for i, line := range lines {
+		if metadataLine.MatchString(strings.TrimRight(line, "\r\n")) {
+			metadataStart = i
+			break
+		}
+	}
+	if metadataStart < 0 {
+		return nil, fmt.Errorf("no metadata block to add labels to")
+	}
+	metadataEnd, childIndent := len(lines), ""
+	for i := metadataStart + 1; i < len(lines); i++ {
+		indent, content := splitIndent(lines[i])
+		if content == "" || content[0] == '#' {
+			continue
+		}
+		if indent == "" {
+			metadataEnd = i
+			break
+		}
+		if childIndent == "" {
+			childIndent = indent
+		}
+	}
+	if childIndent == "" {
+		childIndent = "  "
+	}
+
+	labelLines := func(indent string) []string {
+		l := []string{}
+		for _, k := range missing {
+			l = append(l, indent+yamlScalar(k)+": "+yamlScalar(labels[k])+newline)
+		}
+		return l
+	}
+
+	for i := metadataStart + 1; i < metadataEnd; i++ {
+		m := labelsLine.FindStringSubmatch(strings.TrimRight(lines[i], "\r\n"))
+		if m == nil || m[1] != childIndent {
+			continue
+		}
+
+		switch m[2] {
+		case "":
+		case "{}", "null", "~":
+			comment := ""
+			if m[3] != "" {
+				comment = " " + m[3]
+			}
+			lines[i] = childIndent + "labels:" + comment + newline
+		default:
+			return nil, fmt.Errorf("labels written in flow style can not be fixed")
+		}
+
+		// Labels are added after the last line of the labels block
+		last, labelIndent := i, ""
+		for j := i + 1; j < metadataEnd; j++ {
+			indent, content := splitIndent(lines[j])
+			if content == "" || content[0] == '#' {
+				continue
+			}
+			if len(indent) <= len(childIndent) {
+				break
+			}
+			if labelIndent == "" {
+				labelIndent = indent
+			}
+			last = j
+		}
+		if labelIndent == "" {
+			labelIndent = childIndent + "  "
+		}
+
+		return joinLines(lines, last+1, labelLines(labelIndent)), nil
+	}
+
+	// Without labels, a labels block is added at the end of the metadata block
+	last := metadataStart
+	for i := metadataStart + 1; i < metadataEnd; i++ {
+		if _, content := splitIndent(lines[i]); content != "" && content[0] != '#' {
+			last = i
+		}
+	}
+	if strings.HasPrefix(lines[last], "metadata:") && strings.TrimSpace(strings.TrimPrefix(strings.TrimRight(lines[last], "\r\n"), "metadata:")) != "" {
+		return nil, fmt.Errorf("metadata written in flow style can not be fixed")
+	}
+
+	return joinLines(lines, last+1, append([]string{childIndent + "labels:" + newline}, labelLines(childIndent+"  ")...)), nil
+}
+
+// splitIndent returns the leading spaces and tabs of line, and the rest of the line without its newline
+func splitIndent(line string) (string, string) {
+	line = strings.TrimRight(line, "\r\n")
+	content := strings.TrimLeft(line, " \t")
+	return line[:len(line)-len(content)], strings.TrimRight(content, " \t")
+}
+
+// joinLines returns lines with inserted added before lines[at]. A newline is added to the line before
+// the insertion if it has none, at the end of the document.
+func joinLines(lines []string, at int, inserted []string) []byte {
+	var b strings.Builder
+	for i, line := range lines {
+		if i == at {
+			for _, l := range inserted {
+				b.WriteString(l)
+			}
+		}
+		b.WriteString(line)
+		if i == at-1 && !strings.HasSuffix(line, "\n") && at == len(lines) {
+			b.WriteString("\n")
+		}
+	}
+	if at >= len(lines) {
+		for _, l := range inserted {
+			b.WriteString(l)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// yamlScalar returns s as a YAML scalar, quoted if it would not be read back as the same string
+func yamlScalar(s string) string {
+	var v map[string]interface{}
+	if err := yaml.Unmarshal([]byte("k: "+s), &v); err == nil && v["k"] == s && !strings.ContainsAny(s, "#\"'") {
+		return s
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,110 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddLabels(t *testing.T) {
+	labels := map[string]string{"app.kubernetes.io/managed-by": "platform", "tier": "true"}
+	for i, testCase := range []struct {
+		doc    string
+		expect string
+		added  []string
+	}{
+		{
+			// Labels are added to the existing labels, comments are kept
+			"---\n# a deployment\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n    name: web # the name\n    labels:\n        app: web\n    # trailing comment\nspec:\n  replicas: 1\n",
+			"---\n# a deployment\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n    name: web # the name\n    labels:\n        app: web\n        app.kubernetes.io/managed-by: platform\n        tier: \"true\"\n    # trailing comment\nspec:\n  replicas: 1\n",
+			[]string{"app.kubernetes.io/managed-by", "tier"},
+		},
+		{
+			// A labels block is added at the end of the metadata, without trailing newline
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  labels:\n    app.kubernetes.io/managed-by: platform\n    tier: \"true\"\n",
+			[]string{"app.kubernetes.io/managed-by", "tier"},
+		},
+		{
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  labels: {} # none yet\n  name: settings\ndata:\n  a: b\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  labels: # none yet\n    app.kubernetes.io/managed-by: platform\n    tier: \"true\"\n  name: settings\ndata:\n  a: b\n",
+			[]string{"app.kubernetes.io/managed-by", "tier"},
+		},
+		{
+			// Only missing labels are added, existing values are kept
+			"kind: ConfigMap\nmetadata:\n  name: settings\n  labels:\n    tier: backend\n",
+			"kind: ConfigMap\nmetadata:\n  name: settings\n  labels:\n    tier: backend\n    app.kubernetes.io/managed-by: platform\n",
+			[]string{"app.kubernetes.io/managed-by"},
+		},
+		{
+			"kind: ConfigMap\r\nmetadata:\r\n  name: settings\r\n",
+			"kind: ConfigMap\r\nmetadata:\r\n  name: settings\r\n  labels:\r\n    app.kubernetes.io/managed-by: platform\r\n    tier: \"true\"\r\n",
+			[]string{"app.kubernetes.io/managed-by", "tier"},
+		},
+		{
+			"kind: List\nitems:\n- kind: ConfigMap\n  metadata:\n    name: settings\n",
+			"kind: List\nitems:\n- kind: ConfigMap\n  metadata:\n    name: settings\n",
+			nil,
+		},
+		{
+			"kind: Secret\nmetadata:\n  name: skipped\n",
+			"kind: Secret\nmetadata:\n  name: skipped\n",
+			nil,
+		},
+		{"# only a comment\n", "# only a comment\n", nil},
+	} {
+		got, fix, err := addLabels([]byte(testCase.doc), labels, map[string]struct{}{"Secret": {}})
+		if err != nil {
+			t.Errorf("%d - unexpected error: %s", i, err)
+			continue
+		}
+		if string(got) != testCase.expect {
+			t.Errorf("%d - expected:\n%s\ngot:\n%s", i, testCase.expect, got)
+		}
+		var added []string
+		if fix != nil {
+			added = fix.Labels
+		}
+		if !reflect.DeepEqual(added, testCase.added) {
+			t.Errorf("%d - expected labels %v to be added, got %v", i, testCase.added, added)
+		}
+	}
+}
+
+func TestAddLabelsErrors(t *testing.T) {
+	labels := map[string]string{"tier": "backend"}
+	for i, doc := range []string{
+		"kind: ConfigMap\nmetadata:\n  name: settings\n  labels: {app: web}\n",
+		"kind: ConfigMap\nmetadata: {name: settings}\n",
+		"kind: ConfigMap\ndata:\n  a: b\n",
+	} {
+		if _, _, err := addLabels([]byte(doc), labels, nil); err == nil {
+			t.Errorf("%d - expected an error", i)
+		}
+	}
+}
+
+func TestFixLabels(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "manifests.yaml")
+	content := "kind: ConfigMap\nmetadata:\n  name: a\n---\nkind: ConfigMap\nmetadata:\n  name: b\n  labels:\n    tier: backend\n---\n"
+	if err := os.WriteFile(p, []byte(content), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	fixes, err := FixLabels(p, map[string]string{"tier": "backend"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []LabelFix{{Path: p, DocumentIndex: 0, Kind: "ConfigMap", Name: "a", Labels: []string{"tier"}}}; !reflect.DeepEqual(fixes, expect) {
+		t.Errorf("expected fixes %+v, got %+v", expect, fixes)
+	}
+
+	b, _ := os.ReadFile(p)
+	if expect := "kind: ConfigMap\nmetadata:\n  name: a\n  labels:\n    tier: backend\n---\nkind: ConfigMap\nmetadata:\n  name: b\n  labels:\n    tier: backend\n---\n"; string(b) != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, b)
+	}
+	if fi, _ := os.Stat(p); fi.Mode().Perm() != 0640 {
+		t.Errorf("expected the file mode to be kept, got %s", fi.Mode())
+	}
+}
@@ -0,0 +1,212 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// fluxKustomizationAPIGroup is the API group of Flux Kustomizations
+const fluxKustomizationAPIGroup = "kustomize.toolkit.fluxcd.io/"
+
+// FluxKustomization is a Flux Kustomization found in a file, and the files it applies
+type FluxKustomization struct {
+	Path      string // file the Kustomization is declared in
+	Name      string
+	Namespace string
+	Source    string   // folder of spec.path, empty if the Kustomization is not expanded
+	Files     []string // files applied from Source
+	Skipped   string   // why the Kustomization is not expanded, if it is not
+}
+
+// fluxKustomization holds the fields of a Flux Kustomization locating the manifests it applies
+type fluxKustomization struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Path      string `json:"path"`
+		SourceRef struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"sourceRef"`
+	} `json:"spec"`
+}
+
+// FluxKustomizationFiles returns the files resources are read from in paths, followed by the files
+// applied by the Flux Kustomizations declared in them, and in the files they apply. Sources are not
+// fetched: the spec.path of Kustomizations whose source is a GitRepository is read from the git
+// repository holding the Kustomization - or the current folder outside of git repositories. Others
+// are not expanded. Folders are expanded as kustomize-controller does, with their kustomization file
+// if they have one, or else with the manifests below them, folders with a kustomization file being
+// expanded with it.
+func FluxKustomizationFiles(ctx context.Context, paths []string, opts FilesOpts) ([]string, []FluxKustomization, error) {
+	files, err := FindFiles(ctx, paths, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, f := range files {
+		seen[filepath.Clean(f)] = true
+	}
+	sources := map[string][]string{} // files of the folders already expanded
+	kustomizations := []FluxKustomization{}
+
+	// Files are appended to while iterating, to follow the Kustomizations they declare
+	for i := 0; i < len(files); i++ {
+		ks, err := fluxKustomizations(files[i])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, k := range ks {
+			fk := FluxKustomization{Path: files[i], Name: k.Metadata.Name, Namespace: k.Metadata.Namespace}
+			if kind := k.Spec.SourceRef.Kind; kind != "" && kind != "GitRepository" {
+				fk.Skipped = fmt.Sprintf("sources of kind %s are not read", kind)
+				kustomizations = append(kustomizations, fk)
+				continue
+			}
+
+			root, err := sourceRoot(files[i])
+			if err != nil {
+				return nil, nil, err
+			}
+			fk.Source = filepath.Join(root, k.Spec.Path)
+
+			sourceFiles, ok := sources[fk.Source]
+			if !ok {
+				if sourceFiles, err = fluxPathFiles(ctx, fk.Source, opts); err != nil {
+					return nil, nil, fmt.Errorf("Kustomization %s in %s: %s", k.Metadata.Name, files[i], err)
+				}
+				sources[fk.Source] = sourceFiles
+			}
+			fk.Files = sourceFiles
+			for _, f := range sourceFiles {
+				if !seen[filepath.Clean(f)] {
+					seen[filepath.Clean(f)] = true
+					files = append(files, f)
+				}
+			}
+			kustomizations = append(kustomizations, fk)
+		}
+	}
+
+	return files, kustomizations, nil
+}
+
+// fluxKustomizations returns the Flux Kustomizations declared in the file at p. Documents that can
+// not be parsed are left to validation to report.
+func fluxKustomizations(p string) ([]fluxKustomization, error) {
+	if strings.HasSuffix(strings.ToLower(p), ".gz") { // Kustomizations are not looked for in compressed files
+		return nil, nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := []fluxKustomization{}
+	for _, doc := range splitDocuments(b) {
+		var k fluxKustomization
+		if err := yaml.Unmarshal(doc, &k); err != nil {
+			continue
+		}
+		if k.Kind == "Kustomization" && strings.HasPrefix(k.APIVersion, fluxKustomizationAPIGroup) {
+			ks = append(ks, k)
+		}
+	}
+
+	return ks, nil
+}
+
+// sourceRoot returns the root of the git repository holding the file at p, or the current folder
+// if p is not in a git repository. The root is absolute if p is, so that the files found in it can
+// be compared with those given.
+func sourceRoot(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			if filepath.IsAbs(p) {
+				return dir, nil
+			}
+			wd, err := os.Getwd()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Rel(wd, dir)
+		}
+		if filepath.Dir(dir) == dir {
+			if filepath.IsAbs(p) {
+				return os.Getwd()
+			}
+			return ".", nil
+		}
+	}
+}
+
+// fluxPathFiles returns the files applied from the folder at dir, with its kustomization file if it
+// has one, or else the manifests below it, expanding the folders with a kustomization file with it
+func fluxPathFiles(ctx context.Context, dir string, opts FilesOpts) ([]string, error) {
+	if kfile, ok := KustomizationFile(dir); ok {
+		return KustomizationFiles(kfile)
+	}
+
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a folder", dir)
+	}
+
+	files := []string{}
+	err = filepath.Walk(dir, func(p string, i os.FileInfo, err error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if i.IsDir() {
+			if p == dir {
+				return nil
+			}
+			if kfile, ok := KustomizationFile(p); ok {
+				kfiles, err := KustomizationFiles(kfile)
+				if err != nil {
+					return err
+				}
+				files = append(files, kfiles...)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !isYAMLFile(i) && !isJSONFile(i) {
+			return nil
+		}
+		if ignored, err := isIgnored(p, opts.IgnoreFilePatterns); err != nil || ignored {
+			return err
+		}
+		files = append(files, p)
+		return nil
+	})
+
+	return files, err
+}
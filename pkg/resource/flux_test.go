@@ -0,0 +1,109 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFluxKustomizationFiles(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"clusters/prod/flux-system.yaml": `apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: flux-system
+spec:
+  path: ./clusters/prod
+  sourceRef:
+    kind: GitRepository
+    name: flux-system
+`,
+		"clusters/prod/apps.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: apps
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: apps
+spec:
+  path: ./apps
+  sourceRef:
+    kind: GitRepository
+    name: flux-system
+---
+apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: infrastructure
+spec:
+  path: ./infrastructure
+  sourceRef:
+    kind: OCIRepository
+    name: platform
+`,
+		"apps/web/deployment.yaml":   "kind: Deployment\napiVersion: apps/v1\n",
+		"apps/web/README.md":         "# web\n",
+		"apps/db/kustomization.yaml": "resources:\n- configmap.yaml\n",
+		"apps/db/configmap.yaml":     "kind: ConfigMap\napiVersion: v1\n",
+		"apps/db/unreferenced.yaml":  "kind: ConfigMap\napiVersion: v1\n",
+	} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, kustomizations, err := FluxKustomizationFiles(context.Background(), []string{filepath.Join(root, "clusters/prod/apps.yaml")}, FilesOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	expect := []string{"clusters/prod/apps.yaml", "apps/db/configmap.yaml", "apps/web/deployment.yaml"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected files %v, got %v", expect, got)
+	}
+
+	if len(kustomizations) != 2 {
+		t.Fatalf("expected 2 Kustomizations, got %+v", kustomizations)
+	}
+	if k := kustomizations[0]; k.Name != "apps" || k.Skipped != "" || len(k.Files) != 2 {
+		t.Errorf("expected Kustomization apps to apply 2 files, got %+v", k)
+	}
+	if k := kustomizations[1]; k.Name != "infrastructure" || k.Skipped == "" || len(k.Files) != 0 {
+		t.Errorf("expected Kustomization infrastructure not to be expanded, got %+v", k)
+	}
+
+	// The Kustomization applying the folder it is declared in is followed once
+	files, kustomizations, err = FluxKustomizationFiles(context.Background(), []string{filepath.Join(root, "clusters/prod/flux-system.yaml")}, FilesOpts{})
+	if err != nil || len(files) != 4 || len(kustomizations) != 3 {
+		t.Errorf("expected 4 files and 3 Kustomizations, got %v, %+v, %v", files, kustomizations, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "clusters/prod/missing.yaml"), []byte(`apiVersion: kustomize.toolkit.fluxcd.io/v1
+kind: Kustomization
+metadata:
+  name: missing
+spec:
+  path: ./missing
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := FluxKustomizationFiles(context.Background(), []string{filepath.Join(root, "clusters/prod/missing.yaml")}, FilesOpts{}); err == nil || !strings.Contains(err.Error(), "Kustomization missing") {
+		t.Errorf("expected an error for a missing path, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitCommand is the git binary used to list the files changed since a base revision
+var gitCommand = "git"
+
+// runGit runs git with args in the working directory, and returns its output
+func runGit(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, gitCommand, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("failed running git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("failed running git %s: %s", args[0], err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// GitDiffFiles returns the files resources are read from, as FindFiles does, among the files changed
+// since the base revision of the git repository of the working directory: the files that differ
+// between base and the working tree, e.g. git diff --name-only base. base is passed to git diff as is,
+// e.g. origin/main... for the changes since the merge base of origin/main. Deleted files are skipped,
+// renamed files are returned with their new path. If paths are given, only changes below them are
+// returned.
+func GitDiffFiles(ctx context.Context, base string, paths []string, opts FilesOpts) ([]string, error) {
+	// git diff lists paths relative to the root of the repository
+	root, err := runGit(ctx, "rev-parse", "--show-cdup")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"diff", "--name-only", "-z", "--no-renames", "--diff-filter=d", base, "--"}
+	out, err := runGit(ctx, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return changedFiles(strings.TrimSpace(string(root)), out, opts)
+}
+
+// changedFiles returns the files resources are read from among the NUL-separated paths, relative to
+// root, listed by git diff. Files that no longer exist are skipped.
+func changedFiles(root string, out []byte, opts FilesOpts) ([]string, error) {
+	files := []string{}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+
+		p := filepath.Join(root, filepath.FromSlash(name))
+		info, err := os.Stat(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, DiscoveryError{p, err}
+		}
+
+		if !isYAMLFile(info) && !isJSONFile(info) {
+			continue
+		}
+
+		ignored, err := isIgnored(p, opts.IgnoreFilePatterns)
+		if err != nil {
+			return nil, err
+		}
+		if !ignored {
+			files = append(files, p)
+		}
+	}
+
+	return files, nil
+}
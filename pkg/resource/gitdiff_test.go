@@ -0,0 +1,40 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"apps/web/deployment.yaml", "apps/web/service.yml", "apps/web/README.md", "apps/db/values.json", "apps/db/main.jsonnet", "vendor/crd.yaml"} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := []byte("apps/web/deployment.yaml\x00apps/web/README.md\x00apps/web/service.yml\x00apps/web/deleted.yaml\x00apps/db/main.jsonnet\x00apps/db/values.json\x00vendor/crd.yaml\x00")
+	got, err := changedFiles(root, out, FilesOpts{IgnoreFilePatterns: []string{"^" + root + "/vendor/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{
+		filepath.Join(root, "apps/web/deployment.yaml"),
+		filepath.Join(root, "apps/web/service.yml"),
+		filepath.Join(root, "apps/db/values.json"),
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %+v, got %+v", expect, got)
+	}
+
+	if got, err = changedFiles(root, []byte{}, FilesOpts{}); err != nil || len(got) != 0 {
+		t.Errorf("expected no files for an empty diff, got %+v, %v", got, err)
+	}
+}
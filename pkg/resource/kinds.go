@@ -0,0 +1,89 @@
+package resource
+
+import "strings"
+
+// builtinShortnames are the short names kubectl accepts for the kinds of Kubernetes, by kind
+var builtinShortnames = map[string][]string{
+	"CertificateSigningRequest": {"csr"},
+	"ClusterRole":               {},
+	"ClusterRoleBinding":        {},
+	"ComponentStatus":           {"cs"},
+	"ConfigMap":                 {"cm"},
+	"CronJob":                   {"cj"},
+	"CustomResourceDefinition":  {"crd", "crds"},
+	"DaemonSet":                 {"ds"},
+	"Deployment":                {"deploy"},
+	"Endpoints":                 {"ep"},
+	"Event":                     {"ev"},
+	"HorizontalPodAutoscaler":   {"hpa"},
+	"Ingress":                   {"ing"},
+	"Job":                       {},
+	"LimitRange":                {"limits"},
+	"Namespace":                 {"ns"},
+	"NetworkPolicy":             {"netpol"},
+	"Node":                      {"no"},
+	"PersistentVolume":          {"pv"},
+	"PersistentVolumeClaim":     {"pvc"},
+	"Pod":                       {"po"},
+	"PodDisruptionBudget":       {"pdb"},
+	"PodSecurityPolicy":         {"psp"},
+	"PriorityClass":             {"pc"},
+	"ReplicaSet":                {"rs"},
+	"ReplicationController":     {"rc"},
+	"ResourceQuota":             {"quota"},
+	"Role":                      {},
+	"RoleBinding":               {},
+	"Secret":                    {},
+	"Service":                   {"svc"},
+	"ServiceAccount":            {"sa"},
+	"StatefulSet":               {"sts"},
+	"StorageClass":              {"sc"},
+}
+
+// kindAliases are the kinds that resources with a kind starting with a lower-case letter - short
+// names, plurals, or kinds in lower case - are read as, by lower-case alias
+var kindAliases = func() map[string]string {
+	aliases := map[string]string{}
+	for kind, shortnames := range builtinShortnames {
+		aliases[strings.ToLower(kind)] = kind
+		aliases[plural(strings.ToLower(kind))] = kind
+		for _, shortname := range shortnames {
+			aliases[shortname] = kind
+		}
+	}
+	return aliases
+}()
+
+// plural returns the plural of a lower-case kind, as used in the names of Kubernetes API resources
+func plural(kind string) string {
+	switch {
+	case strings.HasSuffix(kind, "endpoints"):
+		return kind
+	case strings.HasSuffix(kind, "y"):
+		return strings.TrimSuffix(kind, "y") + "ies"
+	case strings.HasSuffix(kind, "s"):
+		return kind + "es"
+	default:
+		return kind + "s"
+	}
+}
+
+// NormalizeKind returns the kind a resource of kind is read as, looking kind up in shortnames, e.g.
+// the short names of custom resources: wf=Workflow, then in the short names, plurals and lower-case
+// names of Kubernetes kinds. Kinds are CamelCase, so only kinds starting with a lower-case letter are
+// looked up. Short names are not case-sensitive.
+func NormalizeKind(kind string, shortnames map[string]string) string {
+	if kind == "" || kind[0] < 'a' || kind[0] > 'z' {
+		return kind
+	}
+
+	for shortname, k := range shortnames {
+		if strings.EqualFold(shortname, kind) {
+			return k
+		}
+	}
+	if k, ok := kindAliases[strings.ToLower(kind)]; ok {
+		return k
+	}
+	return kind
+}
@@ -0,0 +1,51 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestNormalizeKind(t *testing.T) {
+	shortnames := map[string]string{"WF": "Workflow"}
+
+	for _, testCase := range []struct {
+		kind   string
+		expect string
+	}{
+		{"Deployment", "Deployment"},
+		{"deploy", "Deployment"},
+		{"deployments", "Deployment"},
+		{"deployment", "Deployment"},
+		{"svc", "Service"},
+		{"networkpolicies", "NetworkPolicy"},
+		{"endpoints", "Endpoints"},
+		{"ingresses", "Ingress"},
+		{"wf", "Workflow"},
+		{"Wf", "Wf"},
+		{"widget", "widget"},
+	} {
+		if got := resource.NormalizeKind(testCase.kind, shortnames); got != testCase.expect {
+			t.Errorf("kind %q - expected %q, got %q", testCase.kind, testCase.expect, got)
+		}
+	}
+}
+
+func TestSignatureKindAliases(t *testing.T) {
+	for _, testCase := range []struct {
+		kind   string
+		expect string
+	}{
+		{"deploy", "Deployment"},
+		{"wf", "wf"},
+	} {
+		res := resource.Resource{Bytes: []byte("apiVersion: v1\nkind: " + testCase.kind + "\nmetadata:\n  name: foo\n")}
+		sig, err := res.Signature()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sig.Kind != testCase.expect {
+			t.Errorf("kind %q - expected %q, got %q", testCase.kind, testCase.expect, sig.Kind)
+		}
+	}
+}
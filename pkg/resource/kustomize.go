@@ -0,0 +1,139 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// kustomizationFileNames are the names kustomize looks for in a kustomization folder, in order
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomization holds the fields of a kustomization.yaml referencing files with resources
+type kustomization struct {
+	Resources  []string `json:"resources"`
+	Bases      []string `json:"bases"`
+	Components []string `json:"components"`
+	Patches    []struct {
+		Path string `json:"path"`
+	} `json:"patches"`
+	PatchesStrategicMerge []string `json:"patchesStrategicMerge"`
+}
+
+// KustomizationFile returns the kustomization file of path, if path is a kustomization file
+// or a folder containing one.
+func KustomizationFile(path string) (string, bool) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+
+	if !fi.IsDir() {
+		for _, name := range kustomizationFileNames {
+			if filepath.Base(path) == name {
+				return path, true
+			}
+		}
+		return "", false
+	}
+
+	for _, name := range kustomizationFileNames {
+		p := filepath.Join(path, name)
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+// KustomizationFiles returns the files referenced by the resources, bases, components, patches
+// and patchesStrategicMerge of the kustomization file at path, following the kustomizations
+// they reference. Remote references and JSON 6902 patches, which are not resources, are left out.
+func KustomizationFiles(path string) ([]string, error) {
+	files := []string{}
+	err := kustomizationFiles(filepath.Clean(path), []string{}, map[string]bool{}, func(p string) { files = append(files, p) })
+	return files, err
+}
+
+func kustomizationFiles(path string, stack []string, seen map[string]bool, fn func(p string)) error {
+	for i, p := range stack {
+		if p == path {
+			return fmt.Errorf("cyclic kustomization reference: %s", strings.Join(append(stack[i:], path), " -> "))
+		}
+	}
+	if seen[path] {
+		return nil
+	}
+	seen[path] = true
+	stack = append(stack, path)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var k kustomization
+	if err := yaml.Unmarshal(b, &k); err != nil {
+		return fmt.Errorf("failed parsing %s: %s", path, err)
+	}
+
+	refs := append(append(append([]string{}, k.Resources...), k.Bases...), k.Components...)
+	for _, p := range k.PatchesStrategicMerge {
+		if !strings.Contains(p, "\n") { // Patches can also be written inline
+			refs = append(refs, p)
+		}
+	}
+	for _, p := range k.Patches {
+		if p.Path != "" && !isJSONPatchFile(filepath.Join(filepath.Dir(path), p.Path)) {
+			refs = append(refs, p.Path)
+		}
+	}
+
+	for _, ref := range refs {
+		if isRemoteReference(ref) {
+			continue
+		}
+
+		p := filepath.Join(filepath.Dir(path), ref)
+		if kfile, ok := KustomizationFile(p); ok {
+			if err := kustomizationFiles(kfile, stack, seen, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fi, err := os.Stat(p)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("%s: %s is a folder without kustomization file", path, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			fn(p)
+		}
+	}
+
+	return nil
+}
+
+// isRemoteReference returns true for kustomization references to git repositories or URLs
+func isRemoteReference(ref string) bool {
+	return strings.Contains(ref, "://") || strings.HasPrefix(ref, "git@") || strings.HasPrefix(ref, "github.com/")
+}
+
+// isJSONPatchFile returns true if the file at p holds a list of JSON 6902 operations
+func isJSONPatchFile(p string) bool {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return false
+	}
+
+	var ops []interface{}
+	return yaml.Unmarshal(b, &ops) == nil
+}
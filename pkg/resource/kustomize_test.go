@@ -0,0 +1,59 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKustomizationFile(t *testing.T) {
+	for i, testCase := range []struct {
+		path   string
+		expect string
+		ok     bool
+	}{
+		{"../../fixtures/kustomize/base", "../../fixtures/kustomize/base/kustomization.yaml", true},
+		{"../../fixtures/kustomize/base/kustomization.yaml", "../../fixtures/kustomize/base/kustomization.yaml", true},
+		{"../../fixtures/kustomize/base/configmap.yaml", "", false},
+		{"../../fixtures/kustomize", "", false},
+		{"../../fixtures/kustomize/does-not-exist", "", false},
+	} {
+		if got, ok := KustomizationFile(testCase.path); got != testCase.expect || ok != testCase.ok {
+			t.Errorf("%d - expected %q, %t, got %q, %t", i, testCase.expect, testCase.ok, got, ok)
+		}
+	}
+}
+
+func TestKustomizationFiles(t *testing.T) {
+	files, err := KustomizationFiles("../../fixtures/kustomize/overlay/kustomization.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{
+		"../../fixtures/kustomize/base/configmap.yaml",
+		"../../fixtures/kustomize/components/monitoring/servicemonitor.yaml",
+		"../../fixtures/kustomize/overlay/configmap-patch.yaml",
+	}
+	if !reflect.DeepEqual(files, expect) {
+		t.Errorf("expected %v, got %v", expect, files)
+	}
+}
+
+func TestKustomizationFilesErrors(t *testing.T) {
+	_, err := KustomizationFiles("../../fixtures/kustomize/cyclic/a/kustomization.yaml")
+	if err == nil || !strings.HasPrefix(err.Error(), "cyclic kustomization reference: ") {
+		t.Errorf("expected a cyclic reference error, got %v", err)
+	}
+
+	dir := t.TempDir()
+	kfile := filepath.Join(dir, "kustomization.yaml")
+	if err := os.WriteFile(kfile, []byte("resources:\n- missing.yaml\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := KustomizationFiles(kfile); err == nil {
+		t.Errorf("expected an error for a missing resource")
+	}
+}
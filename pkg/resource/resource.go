@@ -9,18 +9,23 @@ import (
 
 // Resource represents a Kubernetes resource within a file
 type Resource struct {
-	Path   string
-	Bytes  []byte
-	sig    *Signature // Cache signature parsing
-	sigErr error      // Cache potential signature parsing error
+	Path            string
+	Bytes           []byte
+	DocumentIndex   int        // Index of the YAML document within its file or stream, starting at 0
+	Line            int        // line of the file or stream the document starts at, starting at 1 - 0 if unknown
+	SchemaLocations []string   // schema locations set with directives at the top of the file, searched first
+	sig             *Signature // Cache signature parsing
+	sigErr          error      // Cache potential signature parsing error
 }
 
 // Signature is a key representing a Kubernetes resource
 type Signature struct {
 	Kind, Version, Namespace, Name string
+	Owned                          bool // the resource has ownerReferences, e.g. it is managed by a controller
 }
 
-// Signature computes a signature for a resource, based on its Kind, Version, Namespace & Name
+// Signature computes a signature for a resource, based on its Kind, Version, Namespace & Name. Short
+// names and plurals of kinds, e.g. deploy or deployments, are read as the kind, see NormalizeKind.
 func (res *Resource) Signature() (*Signature, error) {
 	if res.sig != nil {
 		return res.sig, res.sigErr
@@ -30,9 +35,10 @@ func (res *Resource) Signature() (*Signature, error) {
 		APIVersion string `yaml:"apiVersion"`
 		Kind       string `yaml:"kind"`
 		Metadata   struct {
-			Name         string `yaml:"name"`
-			Namespace    string `yaml:"namespace"`
-			GenerateName string `yaml:"generateName"`
+			Name            string      `yaml:"name"`
+			Namespace       string      `yaml:"namespace"`
+			GenerateName    string      `yaml:"generateName"`
+			OwnerReferences interface{} `yaml:"ownerReferences"` // any type, so that malformed ownerReferences are left to validation
 		} `yaml:"Metadata"`
 	}{}
 	err := yaml.Unmarshal(res.Bytes, &resource)
@@ -42,8 +48,10 @@ func (res *Resource) Signature() (*Signature, error) {
 		name = resource.Metadata.GenerateName + "{{ generateName }}"
 	}
 
+	ownerReferences, _ := resource.Metadata.OwnerReferences.([]interface{})
+
 	// We cache the result to not unmarshall every time we want to access the signature
-	res.sig = &Signature{Kind: resource.Kind, Version: resource.APIVersion, Namespace: resource.Metadata.Namespace, Name: name}
+	res.sig = &Signature{Kind: NormalizeKind(resource.Kind, nil), Version: resource.APIVersion, Namespace: resource.Metadata.Namespace, Name: name, Owned: len(ownerReferences) > 0}
 
 	if err != nil { // Exit if there was an error unmarshalling
 		res.sigErr = err
@@ -81,6 +89,7 @@ func (res *Resource) SignatureFromMap(m map[string]interface{}) (*Signature, err
 	}
 
 	var name, ns string
+	var owned bool
 	Metadata, ok := m["metadata"].(map[string]interface{})
 	if ok {
 		name, _ = Metadata["name"].(string)
@@ -88,13 +97,31 @@ func (res *Resource) SignatureFromMap(m map[string]interface{}) (*Signature, err
 		if _, ok := Metadata["generateName"].(string); ok {
 			name = Metadata["generateName"].(string) + "{{ generateName }}"
 		}
+		ownerReferences, _ := Metadata["ownerReferences"].([]interface{})
+		owned = len(ownerReferences) > 0
 	}
 
 	// We cache the result to not unmarshall every time we want to access the signature
-	res.sig = &Signature{Kind: Kind, Version: APIVersion, Namespace: ns, Name: name}
+	res.sig = &Signature{Kind: NormalizeKind(Kind, nil), Version: APIVersion, Namespace: ns, Name: name, Owned: owned}
 	return res.sig, nil
 }
 
+// How resources of kind List are handled
+const (
+	ListExpand   = "expand"   // validate the items of Lists as separate resources, the default
+	ListValidate = "validate" // validate Lists as they are, against the schema of List
+	ListSkip     = "skip"     // skip Lists, without validating their items
+)
+
+// ResourcesWithListMode returns the items of a List as Resources does with ListExpand (or an empty
+// listMode), and the resource itself otherwise
+func (res *Resource) ResourcesWithListMode(listMode string) []Resource {
+	if listMode != "" && listMode != ListExpand {
+		return []Resource{*res}
+	}
+	return res.Resources()
+}
+
 // Resources returns a list of resources if the resource is of type List, a single resource otherwise
 // See https://github.com/yannh/kubeconform/issues/53
 func (res *Resource) Resources() []Resource {
@@ -107,10 +134,15 @@ func (res *Resource) Resources() []Resource {
 			Items   []interface{}
 		}{}
 
-		yaml.Unmarshal(res.Bytes, &list)
+		// Lists whose items can not be read are returned as they are, failing with a parse error - so
+		// that they are reported, without stopping the validation of the other documents of the stream
+		if err := yaml.Unmarshal(res.Bytes, &list); err != nil {
+			res.sigErr = fmt.Errorf("failed reading List items: %s", err)
+			return []Resource{*res}
+		}
 
 		for _, item := range list.Items {
-			r := Resource{Path: res.Path}
+			r := Resource{Path: res.Path, DocumentIndex: res.DocumentIndex, Line: res.Line, SchemaLocations: res.SchemaLocations}
 			r.Bytes, _ = yaml.Marshal(item)
 			resources = append(resources, r)
 		}
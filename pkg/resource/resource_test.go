@@ -36,6 +36,40 @@ spec:
 			},
 			err: nil,
 		},
+		{
+			name: "pod owned by a replicaset",
+			have: []byte(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-5d8f-x2k9p
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: ReplicaSet
+    name: web-5d8f
+`),
+			want: resource.Signature{
+				Kind:    "Pod",
+				Version: "v1",
+				Owned:   true,
+			},
+			err: nil,
+		},
+		{
+			name: "malformed ownerReferences",
+			have: []byte(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  ownerReferences: web-5d8f
+`),
+			want: resource.Signature{
+				Kind:    "Pod",
+				Version: "v1",
+			},
+			err: nil,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -46,7 +80,8 @@ spec:
 		}
 		if sig.Version != testCase.want.Version ||
 			sig.Kind != testCase.want.Kind ||
-			sig.Namespace != testCase.want.Namespace {
+			sig.Namespace != testCase.want.Namespace ||
+			sig.Owned != testCase.want.Owned {
 			t.Errorf("test \"%s\": received %+v, expected %+v", testCase.name, sig, testCase.want)
 		}
 	}
@@ -66,6 +101,15 @@ func TestSignatureFromMap(t *testing.T) {
 				Name:      "bob",
 			},
 		},
+		{
+			"apiVersion: apps/v1\nkind: ReplicaSet\nmetadata:\n  name: web-5d8f\n  ownerReferences:\n  - kind: Deployment\n    name: web\n",
+			resource.Signature{
+				Kind:    "ReplicaSet",
+				Version: "apps/v1",
+				Name:    "web-5d8f",
+				Owned:   true,
+			},
+		},
 	}
 
 	for i, testCase := range testCases {
@@ -155,3 +199,45 @@ Items:
 		}
 	}
 }
+
+func TestResourcesInvalidList(t *testing.T) {
+	res := resource.Resource{
+		Path:          "foo",
+		Bytes:         []byte("apiVersion: v1\nkind: List\nitems: foo\n"),
+		DocumentIndex: 3,
+	}
+
+	subres := res.Resources()
+	if len(subres) != 1 {
+		t.Fatalf("expected the List to be returned, found %d resources", len(subres))
+	}
+	if _, err := subres[0].Signature(); err == nil || subres[0].DocumentIndex != 3 {
+		t.Errorf("expected an error for document 3, got %v for document %d", err, subres[0].DocumentIndex)
+	}
+}
+
+func TestResourcesWithListMode(t *testing.T) {
+	res := resource.Resource{
+		Path:  "foo",
+		Bytes: []byte("apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n- apiVersion: v1\n  kind: Secret\n"),
+	}
+
+	for _, testCase := range []struct {
+		listMode string
+		expect   []string
+	}{
+		{"", []string{"ConfigMap", "Secret"}},
+		{resource.ListExpand, []string{"ConfigMap", "Secret"}},
+		{resource.ListValidate, []string{"List"}},
+		{resource.ListSkip, []string{"List"}},
+	} {
+		kinds := []string{}
+		for _, subres := range res.ResourcesWithListMode(testCase.listMode) {
+			sig, _ := subres.Signature()
+			kinds = append(kinds, sig.Kind)
+		}
+		if !reflect.DeepEqual(kinds, testCase.expect) {
+			t.Errorf("list mode %q - expected %v, got %v", testCase.listMode, testCase.expect, kinds)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package resource
+
+// ShardByKind distributes resources over n channels, sending all resources of the same apiVersion
+// and kind to the same channel, so that a worker reading from a channel handles every resource of
+// the kinds it is assigned. Kinds are assigned to channels in turn as they are first found, so that
+// kinds are spread evenly. Resources that can not be parsed are sent to the first channel. The
+// channels are closed once resources is.
+func ShardByKind(resources <-chan Resource, n int) []<-chan Resource {
+	shards := make([]chan Resource, n)
+	out := make([]<-chan Resource, n)
+	for i := range shards {
+		shards[i] = make(chan Resource)
+		out[i] = shards[i]
+	}
+
+	go func() {
+		assigned := map[string]int{} // channel of each apiVersion/kind
+		for res := range resources {
+			i := 0
+			if sig, err := res.Signature(); err == nil {
+				key := sig.Version + "/" + sig.Kind
+				var ok bool
+				if i, ok = assigned[key]; !ok {
+					i = len(assigned) % n
+					assigned[key] = i
+				}
+			}
+			shards[i] <- res
+		}
+		for _, shard := range shards {
+			close(shard)
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,45 @@
+package resource
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardByKind(t *testing.T) {
+	resources := make(chan Resource)
+	go func() {
+		for i := 0; i < 100; i++ {
+			kind := []string{"Deployment", "Service", "ConfigMap", "Secret"}[i%4]
+			resources <- Resource{Bytes: []byte(fmt.Sprintf("apiVersion: v1\nkind: %s\nmetadata:\n  name: r%d\n", kind, i))}
+		}
+		resources <- Resource{Bytes: []byte("not: [valid")}
+		close(resources)
+	}()
+
+	var mu sync.Mutex
+	shardOfKind := map[string]int{}
+	n := 0
+	wg := sync.WaitGroup{}
+	for i, shard := range ShardByKind(resources, 3) {
+		wg.Add(1)
+		go func(i int, shard <-chan Resource) {
+			defer wg.Done()
+			for res := range shard {
+				mu.Lock()
+				n++
+				sig, _ := res.Signature()
+				if previous, ok := shardOfKind[sig.Kind]; ok && previous != i {
+					t.Errorf("%s sent to shards %d and %d", sig.Kind, previous, i)
+				}
+				shardOfKind[sig.Kind] = i
+				mu.Unlock()
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	if n != 101 {
+		t.Errorf("expected 101 resources, got %d", n)
+	}
+}
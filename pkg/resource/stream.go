@@ -41,32 +41,95 @@ func SplitYAMLDocument(data []byte, atEOF bool) (advance int, token []byte, err
 	return 0, nil, nil
 }
 
+// splitYAMLDocumentLines wraps SplitYAMLDocument, setting line to the line the last document returned
+// starts at, starting at 1
+func splitYAMLDocumentLines(line *int) bufio.SplitFunc {
+	next := 1
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := SplitYAMLDocument(data, atEOF)
+		if token != nil {
+			*line = next
+			next += bytes.Count(data[:advance], []byte("\n"))
+		}
+		return advance, token, err
+	}
+}
+
+// isBlank returns true for documents only containing whitespace and comments, such as the
+// documents of empty templates and the trailing separator in the output of helm template
+func isBlank(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' && !bytes.Equal(line, []byte("---")) {
+			return false
+		}
+	}
+	return true
+}
+
+// StreamOpts sets how resources are read from a stream
+type StreamOpts struct {
+	ListMode       string // how resources of kind List are handled - ListExpand if empty
+	OnlyDocIndexes []int  // if set, only the documents at these indexes are read, starting at 0
+}
+
 // FromStream reads resources from a byte stream, usually here stdin
 func FromStream(ctx context.Context, path string, r io.Reader) (<-chan Resource, <-chan error) {
+	return FromStreamWithOpts(ctx, path, r, StreamOpts{})
+}
+
+// FromStreamWithOpts reads resources from a byte stream as FromStream does, with opts
+func FromStreamWithOpts(ctx context.Context, path string, r io.Reader, opts StreamOpts) (<-chan Resource, <-chan error) {
 	resources := make(chan Resource)
 	errors := make(chan error)
 
 	go func() {
-		const initialBufSize = 4 * 1024 * 1024 // Start with 4MB
-		const maxBufSize = 256 * 1024 * 1024   // Start with 4MB
+		const initialBufSize = 64 * 1024     // Start with 64KB, the scanner grows the buffer for larger documents
+		const maxBufSize = 256 * 1024 * 1024 // Resize up to 256MB
 
-		scanner := bufio.NewScanner(r)
+		scanner := bufio.NewScanner(normalizeEncoding(r))
 		buf := make([]byte, initialBufSize)
-		scanner.Buffer(buf, maxBufSize) // Resize up to 256MB
-		scanner.Split(SplitYAMLDocument)
+		scanner.Buffer(buf, maxBufSize)
+		var line int
+		scanner.Split(splitYAMLDocumentLines(&line))
 
+		// Documents are sent to the validators as soon as they are read, so only the document
+		// being read is kept in the buffer - not the whole stream
+		nDocuments, nSent := 0, 0
+		seen := duplicates{}
+		var schemaLocations []string
 	SCAN:
-		for scanner.Scan() {
+		for i := 0; scanner.Scan(); i++ {
 			select {
 			case <-ctx.Done():
 				break SCAN
 			default:
 			}
-			res := Resource{Path: path, Bytes: scanner.Bytes()}
-			for _, subres := range res.Resources() {
+			nDocuments++
+			if i == 0 {
+				schemaLocations = schemaLocationDirectives(scanner.Bytes())
+			}
+			if isBlank(scanner.Bytes()) || !selectedDocument(opts.OnlyDocIndexes, i) {
+				continue
+			}
+			nSent++
+			// The scanner reuses its buffer, the document is copied as it can still be validated after the next Scan
+			res := Resource{Path: path, Bytes: []byte(scanner.Text()), DocumentIndex: i, Line: line, SchemaLocations: schemaLocations}
+			for _, subres := range res.ResourcesWithListMode(opts.ListMode) {
+				dupErr := seen.check(&subres)
 				resources <- subres
+				if dupErr != nil {
+					errors <- DiscoveryError{path, dupErr}
+				}
 			}
 		}
+		// Streams of blank documents are still reported once, so that they are counted as parsed
+		if nDocuments > 0 && nSent == 0 && ctx.Err() == nil {
+			resources <- Resource{Path: path, Bytes: []byte{}}
+		}
+		if err := scanner.Err(); err != nil {
+			errors <- DiscoveryError{path, err}
+		}
 
 		close(resources)
 		close(errors)
@@ -3,11 +3,13 @@ package resource_test
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/yannh/kubeconform/pkg/resource"
 )
@@ -66,6 +68,7 @@ apiVersion: v2
 						Path: "myfile",
 						Bytes: []byte(`apiVersion: v2
 `),
+						DocumentIndex: 1,
 					},
 				},
 				Errors: []error{},
@@ -95,12 +98,14 @@ kind: ReplicationController`),
 						Path: "myfile",
 						Bytes: []byte(`apiVersion: v1
 kind: Deployment`),
+						DocumentIndex: 1,
 					},
 					{
 						Path: "myfile",
 						Bytes: []byte(`apiVersion: v2
 kind: CronJob
 `),
+						DocumentIndex: 2,
 					},
 				},
 				Errors: []error{},
@@ -128,6 +133,50 @@ kind: ReplicationController`),
 						Bytes: []byte(`apiVersion: v1
 kind: Deployment
 `),
+						DocumentIndex: 1,
+					},
+				},
+				Errors: []error{},
+			},
+		},
+		{
+			Have: have{
+				Path: "myfile",
+				Reader: strings.NewReader(`---
+# Source: chart/templates/rc.yaml
+apiVersion: v1
+kind: ReplicationController
+---
+# Source: chart/templates/empty.yaml
+
+---
+`),
+			},
+			Want: want{
+				Resources: []resource.Resource{
+					{
+						Path: "myfile",
+						Bytes: []byte(`---
+# Source: chart/templates/rc.yaml
+apiVersion: v1
+kind: ReplicationController`),
+					},
+				},
+				Errors: []error{},
+			},
+		},
+		{
+			Have: have{
+				Path: "myfile",
+				Reader: strings.NewReader(`# nothing to see here
+---
+`),
+			},
+			Want: want{
+				Resources: []resource.Resource{
+					{
+						Path:  "myfile",
+						Bytes: []byte{},
 					},
 				},
 				Errors: []error{},
@@ -154,6 +203,9 @@ kind: Deployment
 				if !bytes.Equal(v.Bytes, testCase.Want.Resources[i].Bytes) {
 					t.Errorf("test %d - for resource %d, got '%s', expected '%s'", testi, i, string(res[i].Bytes), string(testCase.Want.Resources[i].Bytes))
 				}
+				if v.DocumentIndex != testCase.Want.Resources[i].DocumentIndex {
+					t.Errorf("test %d - for resource %d, got document index %d, expected %d", testi, i, v.DocumentIndex, testCase.Want.Resources[i].DocumentIndex)
+				}
 			}
 
 			wg.Done()
@@ -173,3 +225,135 @@ kind: Deployment
 		wg.Wait()
 	}
 }
+
+func TestFromStreamIsIncremental(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	resChan, _ := resource.FromStream(context.Background(), "stdin", r)
+	go w.Write([]byte("apiVersion: v1\nkind: ConfigMap\n---\n"))
+
+	select {
+	case res := <-resChan:
+		if sig, err := res.Signature(); err != nil || sig.Kind != "ConfigMap" {
+			t.Errorf("expected a ConfigMap, got %+v, %v", sig, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the first document to be sent before the end of the stream")
+	}
+}
+
+func TestFromStreamDocumentsAreNotOverwritten(t *testing.T) {
+	// Documents larger than the initial buffer force the scanner to reuse and grow it
+	var stream bytes.Buffer
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&stream, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm-%d\ndata:\n  key: %s\n---\n", i, strings.Repeat("a", 4096*(i%20)))
+	}
+
+	resChan, _ := resource.FromStream(context.Background(), "stdin", &stream)
+	res := []resource.Resource{}
+	for r := range resChan {
+		res = append(res, r)
+	}
+
+	if len(res) != 50 {
+		t.Fatalf("expected 50 resources, got %d", len(res))
+	}
+	for i, r := range res {
+		if !bytes.Contains(r.Bytes, []byte(fmt.Sprintf("name: cm-%d\n", i))) {
+			t.Errorf("expected resource %d to be cm-%d, got %.80q", i, i, r.Bytes)
+		}
+	}
+}
+
+func BenchmarkFromStream(b *testing.B) {
+	var stream bytes.Buffer
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&stream, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm-%d\ndata:\n  key: value\n---\n", i)
+	}
+	data := stream.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resChan, _ := resource.FromStream(context.Background(), "stdin", bytes.NewReader(data))
+		for range resChan {
+		}
+	}
+}
+
+func TestFromStreamLines(t *testing.T) {
+	stream := "# comment\napiVersion: v1\nkind: A\n---\n\n---\nkind: B\nx: 1\n---\nkind: C\n"
+	resChan, _ := resource.FromStream(context.Background(), "stdin", strings.NewReader(stream))
+
+	lines := []int{}
+	for r := range resChan {
+		lines = append(lines, r.Line)
+	}
+
+	if expect := []int{1, 7, 10}; !reflect.DeepEqual(lines, expect) {
+		t.Errorf("expected resources to start at lines %v, got %v", expect, lines)
+	}
+}
+
+func TestFromStreamInvalidDocuments(t *testing.T) {
+	stream := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: [x
+---
+apiVersion: v1
+kind: List
+items: foo
+---
+- a
+- b
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+`
+	resChan, errChan := resource.FromStream(context.Background(), "foo", strings.NewReader(stream))
+	indexes := []int{}
+	go func() {
+		for range errChan {
+		}
+	}()
+	for res := range resChan {
+		indexes = append(indexes, res.DocumentIndex)
+	}
+
+	if expect := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(indexes, expect) {
+		t.Errorf("expected documents %v to be read, got %v", expect, indexes)
+	}
+}
+
+func TestFromStreamOnlyDocIndexes(t *testing.T) {
+	stream := "kind: A\n---\nkind: [x\n---\nkind: C\n---\nkind: D\n"
+	for i, testCase := range []struct {
+		onlyDocIndexes []int
+		expect         []int
+	}{
+		{nil, []int{0, 1, 2, 3}},
+		{[]int{0, 3}, []int{0, 3}},
+		{[]int{2, 42}, []int{2}},
+		{[]int{42}, []int{0}}, // a stream without selected documents is reported once, as empty
+	} {
+		resChan, errChan := resource.FromStreamWithOpts(context.Background(), "stdin", strings.NewReader(stream), resource.StreamOpts{OnlyDocIndexes: testCase.onlyDocIndexes})
+		go func() {
+			for range errChan {
+			}
+		}()
+		indexes := []int{}
+		for res := range resChan {
+			indexes = append(indexes, res.DocumentIndex)
+		}
+		if !reflect.DeepEqual(indexes, testCase.expect) {
+			t.Errorf("test %d: expected documents %v to be read, got %v", i+1, testCase.expect, indexes)
+		}
+	}
+}
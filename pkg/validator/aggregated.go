@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+// DefaultAggregatedAPIs are the apiVersions of well-known APIs served by aggregated API servers, such
+// as metrics-server. They are not part of the OpenAPI document schemas are generated from, so resources
+// of these APIs are skipped instead of failing when their schema can not be found.
+var DefaultAggregatedAPIs = []string{
+	"metrics.k8s.io/v1beta1",
+	"custom.metrics.k8s.io/v1beta1",
+	"custom.metrics.k8s.io/v1beta2",
+	"external.metrics.k8s.io/v1beta1",
+}
+
+// aggregatedAPIs returns the apiVersions and apiVersion/Kinds of aggregated APIs, where "default"
+// stands for DefaultAggregatedAPIs. If apis is nil, DefaultAggregatedAPIs are used.
+func aggregatedAPIs(apis []string) map[string]struct{} {
+	if apis == nil {
+		apis = []string{"default"}
+	}
+
+	m := map[string]struct{}{}
+	for _, api := range apis {
+		if api == "default" {
+			for _, d := range DefaultAggregatedAPIs {
+				m[d] = struct{}{}
+			}
+			continue
+		}
+		m[api] = struct{}{}
+	}
+
+	return m
+}
+
+func (val *v) isAggregated(sig *resource.Signature) bool {
+	if _, ok := val.aggregatedAPIs[sig.Version]; ok {
+		return true
+	}
+	_, ok := val.aggregatedAPIs[sig.Version+"/"+sig.Kind]
+	return ok
+}
@@ -0,0 +1,11 @@
+package validator
+
+// apiVersionAllowed returns true if apiVersion is one of the allowed apiVersions
+func apiVersionAllowed(apiVersion string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == apiVersion {
+			return true
+		}
+	}
+	return false
+}
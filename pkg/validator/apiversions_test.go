@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestValidateAllowedAPIVersions(t *testing.T) {
+	allowed := map[string][]string{
+		"Deployment":              {"apps/v1"},
+		"HorizontalPodAutoscaler": {"autoscaling/v2", "autoscaling/v1"},
+	}
+
+	for i, testCase := range []struct {
+		rawResource string
+		expect      Status
+		expectErr   string
+	}{
+		{"apiVersion: apps/v1\nkind: Deployment\n", Valid, ""},
+		{"apiVersion: extensions/v1beta1\nkind: Deployment\n", Error, "apiVersion extensions/v1beta1 is not allowed for Deployment, allowed: apps/v1"},
+		{"apiVersion: autoscaling/v1\nkind: HorizontalPodAutoscaler\n", Valid, ""},
+		{"apiVersion: autoscaling/v2beta2\nkind: HorizontalPodAutoscaler\n", Error, "apiVersion autoscaling/v2beta2 is not allowed for HorizontalPodAutoscaler, allowed: autoscaling/v2, autoscaling/v1"},
+		{"apiVersion: batch/v1beta1\nkind: CronJob\n", Valid, ""},
+	} {
+		val, err := New(nil, Opts{SyntaxOnly: true, AllowedAPIVersions: allowed})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected status %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if got.Status == Error && (got.Code != DisallowedAPIVersion || got.Err.Error() != testCase.expectErr) {
+			t.Errorf("%d - expected %q with code %q, got %q with code %q", i, testCase.expectErr, DisallowedAPIVersion, got.Err, got.Code)
+		}
+	}
+}
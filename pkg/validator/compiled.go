@@ -0,0 +1,146 @@
+package validator
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"github.com/yannh/kubeconform/pkg/registry"
+)
+
+// compiledSchema is the result of compiling a schema, shared by all kinds using identical schema bytes
+type compiledSchema struct {
+	once   sync.Once
+	schema *gojsonschema.Schema
+	err    error
+}
+
+// schemaCompiler compiles schemas, keyed by a checksum of their content. Generated schemas are often
+// identical for several kinds or apiVersions, they are only compiled once.
+type schemaCompiler struct {
+	sync.Mutex
+	schemas    map[[sha256.Size]byte]*compiledSchema
+	references map[string][]byte // documents referenced by schemas, by URL
+	documents  map[*gojsonschema.Schema]interface{}
+	referenced map[*gojsonschema.Schema]map[string][]byte // documents referenced by each schema, by URL
+}
+
+func newSchemaCompiler() *schemaCompiler {
+	return &schemaCompiler{
+		schemas:    map[[sha256.Size]byte]*compiledSchema{},
+		references: map[string][]byte{},
+		documents:  map[*gojsonschema.Schema]interface{}{},
+		referenced: map[*gojsonschema.Schema]map[string][]byte{},
+	}
+}
+
+// compile returns the compiled schema for b. Workers compiling the same schema concurrently wait
+// for the first compilation to finish. If refs is set, the $refs of the schema are resolved against
+// base, the URL it was retrieved from, and loaded with refs. Schemas are compiled as written in
+// draft, or in the draft of their $schema if empty.
+func (c *schemaCompiler) compile(ctx context.Context, b []byte, base string, refs registry.ReferenceLoader, draft string) (*gojsonschema.Schema, error) {
+	// Relative $refs of identical schemas in the same folder resolve to the same documents
+	h := sha256.New()
+	if refs != nil {
+		h.Write([]byte(base[:strings.LastIndex(base, "/")+1] + "\x00"))
+	}
+	h.Write([]byte(draft + "\x00"))
+	h.Write(b)
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+
+	c.Lock()
+	cs, ok := c.schemas[sum]
+	if !ok {
+		cs = &compiledSchema{}
+		c.schemas[sum] = cs
+	}
+	c.Unlock()
+
+	cs.once.Do(func() {
+		document, err := decodeJSON(b)
+		if err != nil {
+			cs.err = err
+			return
+		}
+
+		// Schemas of drafts gojsonschema does not support are converted before being compiled
+		d := documentDraft(draft, document)
+		var loader gojsonschema.JSONLoader = gojsonschema.NewBytesLoader(b)
+		if d != "" {
+			if cs.err = toDraft7(d, document, nil); cs.err != nil {
+				return
+			}
+			loader = gojsonschema.NewGoLoader(document)
+		}
+		var scope *dynamicScope
+		referenced := map[string][]byte{}
+		if refs != nil {
+			// gojsonschema loads the root document again from its URL to resolve references, it is
+			// seeded with the content already retrieved so it is not downloaded twice
+			url := strings.SplitN(base, "#", 2)[0]
+			c.Lock()
+			if _, ok := c.references[url]; !ok {
+				c.references[url] = b
+			}
+			c.Unlock()
+			scope = newDynamicScope(url)
+			loader = refLoader{ctx: ctx, c: c, refs: refs, source: base, b: b, draft: draft, scope: scope, referenced: referenced}
+		}
+		cs.schema, cs.err = newSchemaLoader(d).Compile(loader)
+		if refs != nil {
+			delete(referenced, strings.SplitN(base, "#", 2)[0]) // the schema itself
+		}
+		if cs.err == nil && scope != nil {
+			if cs.err = scope.check(); cs.err != nil {
+				cs.schema = nil
+			}
+		}
+		if cs.err == nil {
+			c.Lock()
+			c.documents[cs.schema] = document
+			c.referenced[cs.schema] = referenced
+			c.Unlock()
+		}
+	})
+
+	return cs.schema, cs.err
+}
+
+// document returns the decoded JSON document schema was compiled from
+func (c *schemaCompiler) document(schema *gojsonschema.Schema) (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	document, ok := c.documents[schema]
+	return document, ok
+}
+
+// referencedDocuments returns the documents schema references, by URL, as they were retrieved
+func (c *schemaCompiler) referencedDocuments(schema *gojsonschema.Schema) map[string][]byte {
+	c.Lock()
+	defer c.Unlock()
+	return c.referenced[schema]
+}
+
+// loadReference retrieves a document referenced by a schema, once per URL
+func (c *schemaCompiler) loadReference(ctx context.Context, refs registry.ReferenceLoader, url string) ([]byte, error) {
+	c.Lock()
+	b, ok := c.references[url]
+	c.Unlock()
+	if ok {
+		return b, nil
+	}
+
+	b, err := refs.LoadReference(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	c.references[url] = b
+	c.Unlock()
+
+	return b, nil
+}
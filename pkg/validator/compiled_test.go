@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	"github.com/yannh/kubeconform/pkg/registry"
+)
+
+func TestSchemaCompilerSharesIdenticalSchemas(t *testing.T) {
+	c := newSchemaCompiler()
+	reg := newMockRegistry(func() ([]byte, error) { return []byte(`{"type": "object"}`), nil })
+
+	widget, err := c.downloadSchema(context.Background(), []registry.Registry{reg}, "Widget", "example.com/v1", "master", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	gadget, err := c.downloadSchema(context.Background(), []registry.Registry{reg}, "Gadget", "example.com/v1", "master", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if widget != gadget {
+		t.Errorf("expected identical schemas to be compiled once")
+	}
+
+	other, err := c.compile(context.Background(), []byte(`{"type": "string"}`), "", nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if other == widget {
+		t.Errorf("expected different schemas to be compiled separately")
+	}
+
+	if _, err := c.compile(context.Background(), []byte(`{"type": 42}`), "", nil, ""); err == nil {
+		t.Errorf("expected an error compiling an invalid schema")
+	}
+	if _, err := c.compile(context.Background(), []byte(`{"type": 42}`), "", nil, ""); err == nil {
+		t.Errorf("expected the compilation error to be returned again")
+	}
+}
+
+func TestSchemaCompilerConcurrent(t *testing.T) {
+	c := newSchemaCompiler()
+	schemas := make([]*gojsonschema.Schema, 8)
+
+	var wg sync.WaitGroup
+	for i := range schemas {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			schemas[i], _ = c.compile(context.Background(), []byte(`{"type": "object"}`), "", nil, "")
+		}(i)
+	}
+	wg.Wait()
+
+	for i, s := range schemas {
+		if s == nil || s != schemas[0] {
+			t.Errorf("%d - expected all workers to get the same compiled schema", i)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// Rules checking resources against the other resources validated
+const (
+	RuleServiceSelector = "service-selector" // the selector of a Service matches the pods of a workload
+	RuleIngressBackend  = "ingress-backend"  // the Services an Ingress routes traffic to exist
+	RuleHPATarget       = "hpa-target"       // the resource a HorizontalPodAutoscaler scales exists
+	RulePDBSelector     = "pdb-selector"     // the selector of a PodDisruptionBudget matches the pods of a workload
+)
+
+var crossResourceRules = map[string]func(c *CrossResourceChecks, r crossResource) error{
+	RuleServiceSelector: checkServiceSelector,
+	RuleIngressBackend:  checkIngressBackend,
+	RuleHPATarget:       checkHPATarget,
+	RulePDBSelector:     checkPDBSelector,
+}
+
+// podTemplateLabels are the paths to the labels of the pods of workloads, by kind
+var podTemplateLabels = map[string][]string{
+	"Pod":                   {"metadata", "labels"},
+	"Deployment":            {"spec", "template", "metadata", "labels"},
+	"StatefulSet":           {"spec", "template", "metadata", "labels"},
+	"DaemonSet":             {"spec", "template", "metadata", "labels"},
+	"ReplicaSet":            {"spec", "template", "metadata", "labels"},
+	"ReplicationController": {"spec", "template", "metadata", "labels"},
+	"Job":                   {"spec", "template", "metadata", "labels"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "metadata", "labels"},
+}
+
+// crossResource is a resource recorded for cross-resource checks
+type crossResource struct {
+	res     resource.Resource
+	sig     resource.Signature
+	checked bool // false for resources that were skipped: they can be referenced, but are not checked
+	obj     map[string]interface{}
+}
+
+// CrossResourceChecks records the resources validated, to check them against each other with
+// cross-resource rules once all have been validated. Resources are kept in memory until then.
+type CrossResourceChecks struct {
+	sync.Mutex
+	rules     []string
+	resources []crossResource
+	byKind    map[string][]crossResource
+}
+
+// NewCrossResourceChecks returns an empty record of resources, checked with the given rules
+func NewCrossResourceChecks(rules []string) (*CrossResourceChecks, error) {
+	for _, rule := range rules {
+		if _, ok := crossResourceRules[rule]; !ok {
+			return nil, fmt.Errorf("unknown cross-resource rule %s, must be one of %s, %s, %s or %s", rule, RuleServiceSelector, RuleIngressBackend, RuleHPATarget, RulePDBSelector)
+		}
+	}
+
+	return &CrossResourceChecks{rules: rules}, nil
+}
+
+func (c *CrossResourceChecks) add(res resource.Resource, sig resource.Signature, checked bool) {
+	c.Lock()
+	defer c.Unlock()
+	c.resources = append(c.resources, crossResource{res: res, sig: sig, checked: checked})
+}
+
+// Check runs the rules against the resources recorded, and returns an error result for each resource
+// breaking a rule, sorted by file and document
+func (c *CrossResourceChecks) Check() []Result {
+	c.Lock()
+	defer c.Unlock()
+
+	c.byKind = map[string][]crossResource{}
+	for i := range c.resources {
+		if err := yaml.Unmarshal(c.resources[i].res.Bytes, &c.resources[i].obj); err != nil {
+			continue
+		}
+		c.byKind[c.resources[i].sig.Kind] = append(c.byKind[c.resources[i].sig.Kind], c.resources[i])
+	}
+
+	results := []Result{}
+	for _, r := range c.resources {
+		if !r.checked || r.obj == nil {
+			continue
+		}
+		for _, rule := range c.rules {
+			if err := crossResourceRules[rule](c, r); err != nil {
+				results = append(results, Result{Resource: r.res, Err: err, Status: Error, Code: BrokenReference})
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Resource.Path != results[j].Resource.Path {
+			return results[i].Resource.Path < results[j].Resource.Path
+		}
+		return results[i].Resource.DocumentIndex < results[j].Resource.DocumentIndex
+	})
+
+	return results
+}
+
+// find returns the resource of a kind and name in the namespace of from, if it was recorded.
+// Resources without namespace are considered to be in the namespace of any other resource.
+func (c *CrossResourceChecks) find(kind, name string, from crossResource) (crossResource, bool) {
+	for _, r := range c.byKind[kind] {
+		if r.sig.Name == name && sameNamespace(r.sig.Namespace, from.sig.Namespace) {
+			return r, true
+		}
+	}
+	return crossResource{}, false
+}
+
+func sameNamespace(a, b string) bool {
+	return a == b || a == "" || b == ""
+}
+
+// nested returns the value at path in obj, or nil
+func nested(obj interface{}, path ...string) interface{} {
+	for _, key := range path {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = m[key]
+	}
+	return obj
+}
+
+func nestedString(obj interface{}, path ...string) string {
+	s, _ := nested(obj, path...).(string)
+	return s
+}
+
+func checkServiceSelector(c *CrossResourceChecks, r crossResource) error {
+	if r.sig.Kind != "Service" || nestedString(r.obj, "spec", "type") == "ExternalName" {
+		return nil
+	}
+	selector, _ := nested(r.obj, "spec", "selector").(map[string]interface{})
+	if len(selector) == 0 {
+		return nil
+	}
+
+	for kind, path := range podTemplateLabels {
+		for _, w := range c.byKind[kind] {
+			if !sameNamespace(w.sig.Namespace, r.sig.Namespace) {
+				continue
+			}
+			labels, _ := nested(w.obj, path...).(map[string]interface{})
+			if matchesSelector(labels, selector) {
+				return nil
+			}
+		}
+	}
+
+	pairs := []string{}
+	for k, v := range selector {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(pairs)
+	return fmt.Errorf("selector %s matches the pods of no workload among the resources validated", strings.Join(pairs, ","))
+}
+
+func matchesSelector(labels, selector map[string]interface{}) bool {
+	if len(labels) == 0 {
+		return false
+	}
+	for k, v := range selector {
+		if l, ok := labels[k]; !ok || fmt.Sprint(l) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkIngressBackend(c *CrossResourceChecks, r crossResource) error {
+	if r.sig.Kind != "Ingress" {
+		return nil
+	}
+
+	// Backends reference Services with service.name, or serviceName before networking.k8s.io/v1
+	backendService := func(backend interface{}) string {
+		if name := nestedString(backend, "service", "name"); name != "" {
+			return name
+		}
+		return nestedString(backend, "serviceName")
+	}
+	services := []string{
+		backendService(nested(r.obj, "spec", "defaultBackend")),
+		backendService(nested(r.obj, "spec", "backend")),
+	}
+	rules, _ := nested(r.obj, "spec", "rules").([]interface{})
+	for _, rule := range rules {
+		paths, _ := nested(rule, "http", "paths").([]interface{})
+		for _, p := range paths {
+			services = append(services, backendService(nested(p, "backend")))
+		}
+	}
+
+	missing := []string{}
+	seen := map[string]bool{}
+	for _, name := range services {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if _, ok := c.find("Service", name, r); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("backend Service %s not found among the resources validated", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func checkHPATarget(c *CrossResourceChecks, r crossResource) error {
+	if r.sig.Kind != "HorizontalPodAutoscaler" {
+		return nil
+	}
+
+	kind, name := nestedString(r.obj, "spec", "scaleTargetRef", "kind"), nestedString(r.obj, "spec", "scaleTargetRef", "name")
+	if kind == "" || name == "" {
+		return nil
+	}
+	if _, ok := c.find(kind, name, r); !ok {
+		return fmt.Errorf("scaleTargetRef %s %s not found among the resources validated", kind, name)
+	}
+
+	return nil
+}
+
+func checkPDBSelector(c *CrossResourceChecks, r crossResource) error {
+	if r.sig.Kind != "PodDisruptionBudget" {
+		return nil
+	}
+	// An empty selector selects all the pods of the namespace
+	selector, _ := nested(r.obj, "spec", "selector").(map[string]interface{})
+	if len(selector) == 0 {
+		return nil
+	}
+
+	for kind, path := range podTemplateLabels {
+		for _, w := range c.byKind[kind] {
+			if !sameNamespace(w.sig.Namespace, r.sig.Namespace) {
+				continue
+			}
+			labels, _ := nested(w.obj, path...).(map[string]interface{})
+			if matchesLabelSelector(labels, selector) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("selector %s matches the pods of no workload among the resources validated", formatLabelSelector(selector))
+}
+
+// matchesLabelSelector returns true if labels match the matchLabels and matchExpressions of a label
+// selector, as used by PodDisruptionBudgets and workloads
+func matchesLabelSelector(labels, selector map[string]interface{}) bool {
+	if matchLabels, _ := selector["matchLabels"].(map[string]interface{}); len(matchLabels) > 0 && !matchesSelector(labels, matchLabels) {
+		return false
+	}
+
+	expressions, _ := selector["matchExpressions"].([]interface{})
+	for _, e := range expressions {
+		key, operator := nestedString(e, "key"), nestedString(e, "operator")
+		values, _ := nested(e, "values").([]interface{})
+		l, ok := labels[key]
+		in := false
+		for _, v := range values {
+			if ok && fmt.Sprint(l) == fmt.Sprint(v) {
+				in = true
+			}
+		}
+
+		switch {
+		case operator == "In" && !in,
+			operator == "NotIn" && in,
+			operator == "Exists" && !ok,
+			operator == "DoesNotExist" && ok:
+			return false
+		}
+	}
+
+	return true
+}
+
+// formatLabelSelector returns a label selector as written by kubectl, e.g. app=web,tier in (a,b)
+func formatLabelSelector(selector map[string]interface{}) string {
+	requirements := []string{}
+	matchLabels, _ := selector["matchLabels"].(map[string]interface{})
+	for k, v := range matchLabels {
+		requirements = append(requirements, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(requirements)
+
+	expressions, _ := selector["matchExpressions"].([]interface{})
+	for _, e := range expressions {
+		key := nestedString(e, "key")
+		values, _ := nested(e, "values").([]interface{})
+		vs := []string{}
+		for _, v := range values {
+			vs = append(vs, fmt.Sprint(v))
+		}
+		switch nestedString(e, "operator") {
+		case "In":
+			requirements = append(requirements, fmt.Sprintf("%s in (%s)", key, strings.Join(vs, ",")))
+		case "NotIn":
+			requirements = append(requirements, fmt.Sprintf("%s notin (%s)", key, strings.Join(vs, ",")))
+		case "Exists":
+			requirements = append(requirements, key)
+		case "DoesNotExist":
+			requirements = append(requirements, "!"+key)
+		}
+	}
+
+	return strings.Join(requirements, ",")
+}
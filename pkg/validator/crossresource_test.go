@@ -0,0 +1,89 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestCrossResourceChecks(t *testing.T) {
+	if _, err := NewCrossResourceChecks([]string{"service-selector", "pod-security"}); err == nil {
+		t.Errorf("expected an error for an unknown rule")
+	}
+
+	checks, err := NewCrossResourceChecks([]string{RuleServiceSelector, RuleIngressBackend, RuleHPATarget, RulePDBSelector})
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := New(nil, Opts{SyntaxOnly: true, SkipKinds: map[string]struct{}{"ConfigMap": {}}, CrossResourceChecks: checks})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range []string{
+		"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  template:\n    metadata:\n      labels:\n        app: web\n        tier: frontend\n",
+		"apiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: report\nspec:\n  jobTemplate:\n    spec:\n      template:\n        metadata:\n          labels:\n            app: report\n",
+		"apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  selector:\n    app: web\n",
+		"apiVersion: v1\nkind: Service\nmetadata:\n  name: report\nspec:\n  selector:\n    app: report\n",
+		"apiVersion: v1\nkind: Service\nmetadata:\n  name: api\n  namespace: shop\nspec:\n  selector:\n    app: api\n    tier: backend\n",
+		"apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: other\nspec:\n  selector:\n    app: web\n",
+		"apiVersion: v1\nkind: Service\nmetadata:\n  name: db\nspec:\n  type: ExternalName\n  externalName: db.example.com\n",
+		"apiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  defaultBackend:\n    service:\n      name: web\n  rules:\n  - http:\n      paths:\n      - backend:\n          service:\n            name: web\n      - backend:\n          service:\n            name: search\n",
+		"apiVersion: extensions/v1beta1\nkind: Ingress\nmetadata:\n  name: legacy\nspec:\n  backend:\n    serviceName: gone\n",
+		"apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: Deployment\n    name: web\n",
+		"apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: api\n  namespace: shop\nspec:\n  scaleTargetRef:\n    apiVersion: apps/v1\n    kind: StatefulSet\n    name: api\n",
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: skipped\n",
+		"apiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  minAvailable: 1\n  selector:\n    matchLabels:\n      app: web\n",
+		"apiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: api\n  namespace: shop\nspec:\n  minAvailable: 1\n  selector:\n    matchLabels:\n      app: api\n",
+		"apiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: frontends\n  namespace: shop\nspec:\n  maxUnavailable: 1\n  selector:\n    matchExpressions:\n    - key: tier\n      operator: In\n      values: [frontend, backend]\n    - key: app\n      operator: Exists\n",
+		"apiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: untiered\n  namespace: shop\nspec:\n  maxUnavailable: 1\n  selector:\n    matchExpressions:\n    - key: app\n      operator: In\n      values: [web]\n    - key: tier\n      operator: DoesNotExist\n",
+		"apiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: all\n  namespace: shop\nspec:\n  maxUnavailable: 1\n  selector: {}\n",
+	} {
+		val.ValidateResource(resource.Resource{Path: "manifests.yaml", DocumentIndex: i, Bytes: []byte(r)})
+	}
+
+	expect := []struct {
+		documentIndex int
+		err           string
+	}{
+		{4, "selector app=api,tier=backend matches the pods of no workload among the resources validated"},
+		{5, "selector app=web matches the pods of no workload among the resources validated"},
+		{7, "backend Service search not found among the resources validated"},
+		{8, "backend Service gone not found among the resources validated"},
+		{10, "scaleTargetRef StatefulSet api not found among the resources validated"},
+		{13, "selector app=api matches the pods of no workload among the resources validated"},
+		{15, "selector app in (web),!tier matches the pods of no workload among the resources validated"},
+	}
+	got := checks.Check()
+	if len(got) != len(expect) {
+		t.Fatalf("expected %d results, got %d: %+v", len(expect), len(got), got)
+	}
+	for i, res := range got {
+		if res.Status != Error || res.Code != BrokenReference {
+			t.Errorf("%d - expected an error with code %s, got %d with code %s", i, BrokenReference, res.Status, res.Code)
+		}
+		if res.Resource.DocumentIndex != expect[i].documentIndex || res.Err.Error() != expect[i].err {
+			t.Errorf("%d - expected %q for document %d, got %q for document %d", i, expect[i].err, expect[i].documentIndex, res.Err, res.Resource.DocumentIndex)
+		}
+	}
+}
+
+func TestCrossResourceChecksSkipped(t *testing.T) {
+	checks, _ := NewCrossResourceChecks([]string{RuleHPATarget})
+	val, err := New(nil, Opts{SyntaxOnly: true, OnlyKinds: map[string]struct{}{"Deployment": {}}, CrossResourceChecks: checks})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resources that are skipped are not checked, but can be referenced
+	for _, r := range []string{
+		"apiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: web\nspec:\n  scaleTargetRef:\n    kind: Deployment\n    name: missing\n",
+		"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+	} {
+		val.ValidateResource(resource.Resource{Bytes: []byte(r)})
+	}
+
+	if got := checks.Check(); len(got) != 0 {
+		t.Errorf("expected no results for skipped resources, got %+v", got)
+	}
+}
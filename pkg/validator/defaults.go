@@ -0,0 +1,26 @@
+package validator
+
+// applyDefaults sets the default values declared in schema on the fields of value that are missing,
+// as the API server does for structural schemas. Defaults are only applied to fields of objects that
+// exist in value, objects are not created to hold defaulted fields. See walkSchema for the subschemas
+// defaults are looked up in.
+func applyDefaults(root, schema, value interface{}) {
+	walkSchema(root, schema, value, "", 0, func(s map[string]interface{}, value interface{}, _ string) {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		properties, _ := s["properties"].(map[string]interface{})
+		for name, propertySchema := range properties {
+			if _, ok := object[name]; ok {
+				continue
+			}
+			if ps, ok := propertySchema.(map[string]interface{}); ok {
+				if d, ok := ps["default"]; ok {
+					object[name] = deepCopy(d)
+				}
+			}
+		}
+	})
+}
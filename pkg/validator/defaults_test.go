@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	for i, testCase := range []struct {
+		schema, value, expect string
+	}{
+		{
+			"{properties: {spec: {properties: {replicas: {default: 1}, paused: {default: false}}}}}",
+			"{spec: {paused: true}}",
+			"{spec: {replicas: 1, paused: true}}",
+		},
+		{
+			"{properties: {spec: {properties: {replicas: {default: 1}}}}}",
+			"{}",
+			"{}",
+		},
+		{
+			"{properties: {ports: {items: {properties: {protocol: {default: TCP}}}}}}",
+			"{ports: [{port: 80}, {port: 53, protocol: UDP}]}",
+			"{ports: [{port: 80, protocol: TCP}, {port: 53, protocol: UDP}]}",
+		},
+		{
+			"{additionalProperties: {properties: {enabled: {default: true}}}}",
+			"{a: {}, b: {enabled: false}}",
+			"{a: {enabled: true}, b: {enabled: false}}",
+		},
+		{
+			"{definitions: {spec: {properties: {replicas: {default: 1}}}}, allOf: [{properties: {spec: {$ref: '#/definitions/spec'}}}]}",
+			"{spec: {}}",
+			"{spec: {replicas: 1}}",
+		},
+		{
+			"{properties: {spec: {anyOf: [{properties: {replicas: {default: 1}}}]}}}",
+			"{spec: {}}",
+			"{spec: {}}",
+		},
+		{
+			"{definitions: {node: {properties: {child: {$ref: '#/definitions/node'}, name: {default: x}}}}, $ref: '#/definitions/node'}",
+			"{child: {child: {}}}",
+			"{name: x, child: {name: x, child: {name: x}}}",
+		},
+	} {
+		var schema interface{}
+		var value, expect map[string]interface{}
+		yaml.Unmarshal([]byte(testCase.schema), &schema)
+		yaml.Unmarshal([]byte(testCase.value), &value)
+		yaml.Unmarshal([]byte(testCase.expect), &expect)
+
+		applyDefaults(schema, schema, value)
+		if !reflect.DeepEqual(value, expect) {
+			t.Errorf("%d - expected %+v, got %+v", i, expect, value)
+		}
+	}
+}
+
+func TestValidateApplyDefaults(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"spec": {"type": "object", "required": ["replicas"], "properties": {"replicas": {"type": "integer", "default": 1}}}}}`)
+	rawResource := []byte("kind: Widget\napiVersion: v1\nspec: {}\n")
+
+	for _, applyDefaults := range []bool{false, true} {
+		compiler := newSchemaCompiler()
+		val := v{
+			opts:           Opts{SkipKinds: map[string]struct{}{}, RejectKinds: map[string]struct{}{}, ApplyDefaults: applyDefaults},
+			schemaDownload: compiler.downloadSchema,
+			compiler:       compiler,
+			regs: []registry.Registry{newMockRegistry(func() ([]byte, error) {
+				return schema, nil
+			})},
+		}
+
+		expect := Invalid
+		if applyDefaults {
+			expect = Valid
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: rawResource}); got.Status != expect {
+			t.Errorf("with defaults %t, expected status %d, got %d: %s", applyDefaults, expect, got.Status, got.Err)
+		}
+	}
+}
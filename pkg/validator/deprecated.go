@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"sort"
+)
+
+// deprecatedFields returns the paths of the fields set in value whose schema is marked with
+// deprecated: true, in lexical order. See walkSchema for the subschemas that are looked up.
+func deprecatedFields(root, schema, value interface{}) []string {
+	seen := map[string]struct{}{}
+	walkSchema(root, schema, value, "", 0, func(s map[string]interface{}, _ interface{}, path string) {
+		if deprecated, _ := s["deprecated"].(bool); deprecated && path != "" {
+			seen[path] = struct{}{}
+		}
+	})
+
+	fields := []string{}
+	for path := range seen {
+		fields = append(fields, path)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
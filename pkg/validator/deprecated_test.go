@@ -0,0 +1,81 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDeprecatedFields(t *testing.T) {
+	for i, testCase := range []struct {
+		schema, value string
+		expect        []string
+	}{
+		{
+			"{properties: {spec: {properties: {old: {deprecated: true}, new: {}}}}}",
+			"{spec: {new: 1}}",
+			[]string{},
+		},
+		{
+			"{properties: {spec: {properties: {old: {deprecated: true}, new: {}}}}}",
+			"{spec: {old: 1, new: 1}}",
+			[]string{"spec.old"},
+		},
+		{
+			"{properties: {ports: {items: {properties: {hostIP: {deprecated: true}}}}}}",
+			"{ports: [{port: 80}, {port: 53, hostIP: 10.0.0.1}]}",
+			[]string{"ports.1.hostIP"},
+		},
+		{
+			"{definitions: {spec: {properties: {old: {deprecated: true}}}}, allOf: [{properties: {spec: {$ref: '#/definitions/spec'}}}], deprecated: true}",
+			"{spec: {old: 1}}",
+			[]string{"spec.old"},
+		},
+		{
+			"{additionalProperties: {deprecated: true}}",
+			"{b: 1, a: 2}",
+			[]string{"a", "b"},
+		},
+	} {
+		var schema, value interface{}
+		yaml.Unmarshal([]byte(testCase.schema), &schema)
+		yaml.Unmarshal([]byte(testCase.value), &value)
+
+		if got := deprecatedFields(schema, schema, value); !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("%d - expected %v, got %v", i, testCase.expect, got)
+		}
+	}
+}
+
+func TestValidateWarnDeprecatedFields(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"spec": {"type": "object", "properties": {"old": {"type": "integer", "deprecated": true}}}}}`)
+
+	warnings := []Warning{}
+	compiler := newSchemaCompiler()
+	val := v{
+		opts: Opts{
+			SkipKinds:            map[string]struct{}{},
+			RejectKinds:          map[string]struct{}{},
+			WarnDeprecatedFields: true,
+			Warnings:             func(w Warning) { warnings = append(warnings, w) },
+		},
+		schemaDownload: compiler.downloadSchema,
+		compiler:       compiler,
+		regs: []registry.Registry{newMockRegistry(func() ([]byte, error) {
+			return schema, nil
+		})},
+	}
+
+	res := resource.Resource{Path: "widget.yaml", Bytes: []byte("kind: Widget\napiVersion: v1\nmetadata:\n  name: w\nspec:\n  old: 1\n")}
+	if got := val.ValidateResource(res); got.Status != Valid {
+		t.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+	}
+
+	expect := []Warning{{Code: DeprecatedField, Path: "widget.yaml", Message: "Widget w: field spec.old is deprecated"}}
+	if !reflect.DeepEqual(warnings, expect) {
+		t.Errorf("expected warnings %+v, got %+v", expect, warnings)
+	}
+}
@@ -0,0 +1,72 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+// BenchmarkValidateDispatch compares workers reading resources from a shared channel, as by default,
+// with resources sharded by kind across workers, as with -shard-by-kind
+func BenchmarkValidateDispatch(b *testing.B) {
+	const nWorkers = 8
+	kinds := []string{"Deployment", "Service", "ConfigMap", "Secret", "Ingress", "Job", "CronJob", "Role"}
+
+	dir := b.TempDir()
+	for _, kind := range kinds {
+		schema := `{"type": "object", "required": ["metadata"], "properties": {"metadata": {"type": "object", "properties": {"name": {"type": "string"}}}}}`
+		os.WriteFile(filepath.Join(dir, strings.ToLower(kind)+".json"), []byte(schema), 0644)
+	}
+
+	for _, testCase := range []struct {
+		name     string
+		dispatch func(<-chan resource.Resource) []<-chan resource.Resource
+	}{
+		{"round-robin", func(resources <-chan resource.Resource) []<-chan resource.Resource {
+			shards := make([]<-chan resource.Resource, nWorkers)
+			for i := range shards {
+				shards[i] = resources
+			}
+			return shards
+		}},
+		{"sharded-by-kind", func(resources <-chan resource.Resource) []<-chan resource.Resource {
+			return resource.ShardByKind(resources, nWorkers)
+		}},
+	} {
+		b.Run(testCase.name, func(b *testing.B) {
+			val, err := New([]string{filepath.Join(dir, "{{ .ResourceKind }}.json")}, Opts{})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			resources := make(chan resource.Resource)
+			go func() {
+				for i := 0; i < b.N; i++ {
+					kind := kinds[i%len(kinds)]
+					resources <- resource.Resource{Bytes: []byte(fmt.Sprintf("apiVersion: v1\nkind: %s\nmetadata:\n  name: r%d\n", kind, i))}
+				}
+				close(resources)
+			}()
+
+			b.ResetTimer()
+			wg := sync.WaitGroup{}
+			for _, shard := range testCase.dispatch(resources) {
+				wg.Add(1)
+				go func(shard <-chan resource.Resource) {
+					defer wg.Done()
+					for res := range shard {
+						if got := val.ValidateResource(res); got.Status != Valid {
+							b.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+						}
+					}
+				}(shard)
+			}
+			wg.Wait()
+		})
+	}
+}
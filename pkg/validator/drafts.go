@@ -0,0 +1,458 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"github.com/yannh/kubeconform/pkg/registry"
+)
+
+// schemaDrafts are the JSON schema drafts a schema location can declare its schemas are written in,
+// with a prefix, e.g. draft-2020-12+https://example.com/schemas/{{ .ResourceKind }}.json
+var schemaDrafts = map[string]gojsonschema.Draft{
+	"draft-04":      gojsonschema.Draft4,
+	"draft-06":      gojsonschema.Draft6,
+	"draft-07":      gojsonschema.Draft7,
+	"draft-2019-09": gojsonschema.Draft7,
+	"draft-2020-12": gojsonschema.Draft7,
+}
+
+// metaSchemaDrafts are the drafts detected from the $schema of schemas that gojsonschema does not
+// detect itself
+var metaSchemaDrafts = map[string]string{
+	"https://json-schema.org/draft/2019-09/schema": "draft-2019-09",
+	"https://json-schema.org/draft/2020-12/schema": "draft-2020-12",
+}
+
+// splitDraft returns the draft declared by a schema location, and the schema location without it.
+// The draft is empty if the schema location does not declare one.
+func splitDraft(schemaLocation string) (string, string) {
+	if i := strings.Index(schemaLocation, "+"); i > 0 {
+		if _, ok := schemaDrafts[schemaLocation[:i]]; ok {
+			return schemaLocation[:i], schemaLocation[i+1:]
+		}
+	}
+	return "", schemaLocation
+}
+
+// draftRegistry is a registry serving schemas written in a declared draft
+type draftRegistry struct {
+	registry.Registry
+	draft string
+}
+
+func (r draftRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return registry.DownloadSchemaWithContext(ctx, r.Registry, resourceKind, resourceAPIVersion, k8sVersion)
+}
+
+func (r draftRegistry) Unwrap() registry.Registry {
+	return r.Registry
+}
+
+// declaredDraft returns the draft declared for the schema location of reg, if any
+func declaredDraft(reg registry.Registry) string {
+	for reg != nil {
+		if d, ok := reg.(draftRegistry); ok {
+			return d.draft
+		}
+		w, ok := reg.(interface{ Unwrap() registry.Registry })
+		if !ok {
+			break
+		}
+		reg = w.Unwrap()
+	}
+	return ""
+}
+
+// documentDraft returns the draft of a schema document: the declared one, or else the one of its
+// $schema if gojsonschema does not detect it itself. An empty draft is detected by gojsonschema.
+func documentDraft(declared string, document interface{}) string {
+	if declared != "" {
+		return declared
+	}
+	m, _ := document.(map[string]interface{})
+	s, _ := m["$schema"].(string)
+	return metaSchemaDrafts[strings.TrimSuffix(s, "#")]
+}
+
+// newSchemaLoader returns a schema loader for schemas written in draft, detecting it if empty
+func newSchemaLoader(draft string) *gojsonschema.SchemaLoader {
+	sl := gojsonschema.NewSchemaLoader()
+	if d, ok := schemaDrafts[draft]; ok {
+		sl.AutoDetect = false
+		sl.Draft = d
+	}
+	return sl
+}
+
+// toDraft7 rewrites a draft 2019-09 or 2020-12 schema to draft-07 in place, as gojsonschema does not
+// support these drafts: prefixItems and items become items and additionalItems, dependentRequired and
+// dependentSchemas become dependencies, $refs with sibling keywords are moved to allOf, and anchors
+// are resolved to JSON pointers, see resolveAnchors. Dynamic references to the dynamic anchors in
+// outer - the URLs of the subschemas of the root schema declaring them, by name - resolve to these
+// subschemas, see dynamicScope. Keywords without a draft-07 equivalent fail the conversion, rather
+// than being ignored. Schemas of other drafts are left unchanged.
+func toDraft7(draft string, schema interface{}, outer map[string]string) error {
+	if draft != "draft-2019-09" && draft != "draft-2020-12" {
+		return nil
+	}
+
+	dynamic, err := dynamicAnchors(schema)
+	if err != nil {
+		return err
+	}
+	// A dynamic reference only resolves to the outermost dynamic anchor if its target declares
+	// the same dynamic anchor, $recursiveRef if the root of the document sets $recursiveAnchor
+	targets := map[string]string{}
+	for name, ptr := range dynamic {
+		if ref, ok := outer[name]; ok && (name != "" || ptr == "") {
+			targets[name] = ref
+		}
+	}
+
+	anchors := map[string]string{}
+	collectAnchors(schema, "", anchors)
+	if err := resolveAnchors(schema, anchors, targets); err != nil {
+		return err
+	}
+
+	return toDraft7Keywords(draft, schema)
+}
+
+// toDraft7Keywords rewrites the keywords of schema and its subschemas to their draft-07 equivalent
+func toDraft7Keywords(draft string, schema interface{}) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, k := range []string{"unevaluatedProperties", "unevaluatedItems", "minContains", "maxContains"} {
+		if _, ok := s[k]; ok {
+			return fmt.Errorf("keyword %s of %s is not supported", k, draft)
+		}
+	}
+
+	// Keywords next to a $ref are ignored in draft-07, but not in later drafts: the $ref is added
+	// to allOf instead
+	if ref, ok := s["$ref"]; ok && len(s) > 1 {
+		allOf, _ := s["allOf"].([]interface{})
+		s["allOf"] = append(allOf, map[string]interface{}{"$ref": ref})
+		delete(s, "$ref")
+	}
+
+	if prefixItems, ok := s["prefixItems"]; ok {
+		if items, ok := s["items"]; ok {
+			s["additionalItems"] = items
+		}
+		s["items"] = prefixItems
+		delete(s, "prefixItems")
+	}
+
+	for _, k := range []string{"dependentRequired", "dependentSchemas"} {
+		deps, ok := s[k].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dependencies, ok := s["dependencies"].(map[string]interface{})
+		if !ok {
+			dependencies = map[string]interface{}{}
+			s["dependencies"] = dependencies
+		}
+		for p, d := range deps {
+			dependencies[p] = d
+		}
+		delete(s, k)
+	}
+
+	subschemas := []interface{}{}
+	for _, k := range []string{"properties", "patternProperties", "definitions", "$defs", "dependencies"} {
+		if m, ok := s[k].(map[string]interface{}); ok {
+			for _, sub := range m {
+				subschemas = append(subschemas, sub)
+			}
+		}
+	}
+	for _, k := range []string{"items", "allOf", "anyOf", "oneOf"} {
+		if l, ok := s[k].([]interface{}); ok {
+			subschemas = append(subschemas, l...)
+		} else {
+			subschemas = append(subschemas, s[k])
+		}
+	}
+	for _, k := range []string{"additionalProperties", "additionalItems", "contains", "propertyNames", "not", "if", "then", "else"} {
+		subschemas = append(subschemas, s[k])
+	}
+	for _, sub := range subschemas {
+		if err := toDraft7Keywords(draft, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nonSchemaKeywords are the keywords whose values are instances rather than subschemas, anchors
+// and references are not looked up in them
+var nonSchemaKeywords = map[string]bool{"const": true, "default": true, "enum": true, "examples": true}
+
+// schemaMapKeywords are the keywords whose values are maps of subschemas by name
+var schemaMapKeywords = map[string]bool{"$defs": true, "definitions": true, "dependencies": true, "dependentSchemas": true, "patternProperties": true, "properties": true}
+
+// forEachSubschema calls f with each value of the keywords of s that may contain subschemas, and the
+// JSON pointer of the value relative to s
+func forEachSubschema(s map[string]interface{}, f func(ptr string, sub interface{}) error) error {
+	escape := strings.NewReplacer("~", "~0", "/", "~1").Replace
+	for k, v := range s {
+		if nonSchemaKeywords[k] {
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !schemaMapKeywords[k] || !ok {
+			if err := f("/"+escape(k), v); err != nil {
+				return err
+			}
+			continue
+		}
+		for name, sub := range m {
+			if err := f("/"+escape(k)+"/"+escape(name), sub); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectAnchors records the JSON pointers of the subschemas of schema declaring an $anchor or a
+// $dynamicAnchor in anchors, by anchor name. ptr is the JSON pointer of schema.
+func collectAnchors(schema interface{}, ptr string, anchors map[string]string) {
+	switch v := schema.(type) {
+	case map[string]interface{}:
+		for _, k := range []string{"$anchor", "$dynamicAnchor"} {
+			if name, ok := v[k].(string); ok {
+				anchors[name] = ptr
+			}
+		}
+		forEachSubschema(v, func(p string, sub interface{}) error {
+			collectAnchors(sub, ptr+p, anchors)
+			return nil
+		})
+	case []interface{}:
+		for i, sub := range v {
+			collectAnchors(sub, ptr+"/"+strconv.Itoa(i), anchors)
+		}
+	}
+}
+
+// dynamicAnchors returns the JSON pointers of the subschemas of schema declaring a dynamic anchor, by
+// name: $dynamicAnchor, and $recursiveAnchor as an empty name. Dynamic anchors declared twice in a
+// document, e.g. by embedded schemas with their own $id, are not supported.
+func dynamicAnchors(schema interface{}) (map[string]string, error) {
+	anchors := map[string]string{}
+	var walk func(schema interface{}, ptr string) error
+	walk = func(schema interface{}, ptr string) error {
+		switch v := schema.(type) {
+		case map[string]interface{}:
+			name, ok := v["$dynamicAnchor"].(string)
+			if recursive, _ := v["$recursiveAnchor"].(bool); recursive {
+				name, ok = "", true
+			}
+			if ok {
+				if _, seen := anchors[name]; seen {
+					return fmt.Errorf("unsupported dynamic scope: %s is declared more than once", dynamicAnchorName(name))
+				}
+				anchors[name] = ptr
+			}
+			return forEachSubschema(v, func(p string, sub interface{}) error { return walk(sub, ptr+p) })
+		case []interface{}:
+			for i, sub := range v {
+				if err := walk(sub, ptr+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	err := walk(schema, "")
+	return anchors, err
+}
+
+// dynamicRefs returns the names of the dynamic anchors schema has dynamic references to, an empty
+// name for $recursiveRef
+func dynamicRefs(schema interface{}) []string {
+	names := []string{}
+	var walk func(schema interface{})
+	walk = func(schema interface{}) {
+		switch v := schema.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$dynamicRef"].(string); ok && strings.HasPrefix(ref, "#") && !strings.HasPrefix(ref, "#/") {
+				names = append(names, ref[1:])
+			}
+			if _, ok := v["$recursiveRef"]; ok {
+				names = append(names, "")
+			}
+			forEachSubschema(v, func(_ string, sub interface{}) error { walk(sub); return nil })
+		case []interface{}:
+			for _, sub := range v {
+				walk(sub)
+			}
+		}
+	}
+
+	walk(schema)
+	return names
+}
+
+func dynamicAnchorName(name string) string {
+	if name == "" {
+		return "$recursiveAnchor"
+	}
+	return "$dynamicAnchor " + name
+}
+
+// dynamicScope resolves the dynamic references of the documents of a schema, which gojsonschema does
+// not support. A dynamic reference resolves to the outermost schema in the dynamic scope declaring
+// its dynamic anchor. The root schema is the outermost schema of every dynamic scope, so references to
+// the dynamic anchors it declares resolve to it, e.g. for a schema extending another one. Other
+// references resolve within their document, which is only correct if no other document declares the
+// same dynamic anchor - or they would resolve to one or the other depending on the path to them.
+type dynamicScope struct {
+	sync.Mutex
+	root      string              // URL of the root schema
+	outer     map[string]string   // URLs of the subschemas of the root schema declaring dynamic anchors, by name
+	documents map[string][]string // documents declaring each dynamic anchor
+	resolved  map[string][]string // documents resolving their dynamic references to each dynamic anchor themselves
+}
+
+func newDynamicScope(root string) *dynamicScope {
+	return &dynamicScope{root: root, outer: map[string]string{}, documents: map[string][]string{}, resolved: map[string][]string{}}
+}
+
+// add records the dynamic anchors of the draft 2019-09 or 2020-12 document at url, and returns the
+// ones of the root schema its dynamic references resolve to, as toDraft7 expects them. The root
+// schema is loaded first.
+func (d *dynamicScope) add(url, draft string, document interface{}) (map[string]string, error) {
+	if draft != "draft-2019-09" && draft != "draft-2020-12" {
+		return nil, nil
+	}
+
+	anchors, err := dynamicAnchors(document)
+	if err != nil {
+		return nil, fmt.Errorf("%s in %s", err, url)
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	for name, ptr := range anchors {
+		if url == d.root {
+			d.outer[name] = url + "#" + ptr
+		}
+		d.documents[name] = append(d.documents[name], url)
+	}
+	for _, name := range dynamicRefs(document) {
+		if _, ok := d.outer[name]; !ok {
+			if _, ok := anchors[name]; ok {
+				d.resolved[name] = append(d.resolved[name], url)
+			}
+		}
+	}
+
+	outer := make(map[string]string, len(d.outer))
+	for name, ref := range d.outer {
+		outer[name] = ref
+	}
+	return outer, nil
+}
+
+// check returns an error if dynamic references resolved within their document could resolve to
+// another document, depending on the path to them
+func (d *dynamicScope) check() error {
+	d.Lock()
+	defer d.Unlock()
+	names := []string{}
+	for name := range d.resolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		documents := d.documents[name]
+		for _, other := range documents {
+			if other != d.resolved[name][0] {
+				return fmt.Errorf("unsupported dynamic scope: %s is declared in %s and %s, but not in the root schema", dynamicAnchorName(name), d.resolved[name][0], other)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAnchors rewrites the references of schema that draft-07 does not support to $refs with a
+// JSON pointer, using the anchors found with collectAnchors: $refs to plain-name fragments, e.g.
+// #node, $dynamicRef and $recursiveRef. Dynamic references resolve to the URL in targets for their
+// dynamic anchor if any, see dynamicScope, or else within the document, to the subschema declaring
+// the anchor. Dynamic references to other documents are not supported.
+func resolveAnchors(schema interface{}, anchors map[string]string, targets map[string]string) error {
+	switch v := schema.(type) {
+	case map[string]interface{}:
+		pointer := func(k, ref string) (string, error) {
+			if ref == "#" || strings.HasPrefix(ref, "#/") {
+				return ref, nil
+			}
+			if strings.HasPrefix(ref, "#") {
+				if ptr, ok := anchors[ref[1:]]; ok {
+					return "#" + ptr, nil
+				}
+			}
+			return "", fmt.Errorf("%s %s can not be resolved within the schema", k, ref)
+		}
+
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#") && !strings.HasPrefix(ref, "#/") && ref != "#" {
+			ptr, err := pointer("$ref", ref)
+			if err != nil {
+				return err
+			}
+			v["$ref"] = ptr
+		}
+
+		// Keywords next to a $ref are ignored in draft-07, dynamic references are added to allOf instead
+		for _, k := range []string{"$dynamicRef", "$recursiveRef"} {
+			ref, ok := v[k].(string)
+			if !ok {
+				continue
+			}
+			if k == "$recursiveRef" && ref != "#" {
+				return fmt.Errorf("$recursiveRef %s is not supported, must be #", ref)
+			}
+			ptr, ok := targets[strings.TrimPrefix(ref, "#")]
+			if !ok || strings.HasPrefix(ref, "#/") {
+				var err error
+				if ptr, err = pointer(k, ref); err != nil {
+					return err
+				}
+			}
+			allOf, _ := v["allOf"].([]interface{})
+			v["allOf"] = append(allOf, map[string]interface{}{"$ref": ptr})
+			delete(v, k)
+		}
+
+		for _, k := range []string{"$anchor", "$dynamicAnchor", "$recursiveAnchor"} {
+			delete(v, k)
+		}
+
+		return forEachSubschema(v, func(_ string, sub interface{}) error {
+			return resolveAnchors(sub, anchors, targets)
+		})
+	case []interface{}:
+		for _, sub := range v {
+			if err := resolveAnchors(sub, anchors, targets); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
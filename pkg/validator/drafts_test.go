@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestSplitDraft(t *testing.T) {
+	for i, testCase := range []struct {
+		schemaLocation, draft, location string
+	}{
+		{"default", "", "default"},
+		{"draft-2020-12+https://example.com/schemas", "draft-2020-12", "https://example.com/schemas"},
+		{"draft-04+./schemas/{{ .ResourceKind }}.json", "draft-04", "./schemas/{{ .ResourceKind }}.json"},
+		{"draft-05+./schemas", "", "draft-05+./schemas"},
+		{"cluster+staging", "", "cluster+staging"},
+	} {
+		draft, location := splitDraft(testCase.schemaLocation)
+		if draft != testCase.draft || location != testCase.location {
+			t.Errorf("%d - expected %q, %q, got %q, %q", i, testCase.draft, testCase.location, draft, location)
+		}
+	}
+}
+
+func TestValidateSchemaDrafts(t *testing.T) {
+	dir := t.TempDir()
+	for name, schema := range map[string]string{
+		// draft 2020-12, detected from $schema
+		"detected/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {"ports": {"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}},
+      "dependentRequired": {"min": ["max"]}
+    }
+  }
+}`,
+		// draft 2020-12, declared by the schema location
+		"declared/widget.json": `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "$ref": "#/$defs/spec"
+    }
+  },
+  "$defs": {
+    "spec": {"type": "object", "properties": {"ports": {"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}}}
+  }
+}`,
+		// draft 2020-12 with a dynamic reference to the node of a tree
+		"dynamic/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {"spec": {"$ref": "#/$defs/node"}},
+  "$defs": {
+    "node": {
+      "$dynamicAnchor": "node",
+      "type": "object",
+      "properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"$dynamicRef": "#node", "required": ["name"]}}}
+    }
+  }
+}`,
+		// draft 2019-09 with a recursive reference to the root
+		"recursive/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2019-09/schema",
+  "$recursiveAnchor": true,
+  "type": "object",
+  "properties": {"name": {"type": "string"}, "spec": {"$recursiveRef": "#"}}
+}`,
+		// draft 2020-12 extending the tree of the dynamic schema, overriding its dynamic anchor so
+		// that all its nodes require an id
+		"extended/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {"spec": {"$ref": "#/$defs/node"}},
+  "$defs": {
+    "node": {"$dynamicAnchor": "node", "$ref": "../dynamic/widget.json#/$defs/node", "required": ["id"]}
+  }
+}`,
+		// draft 2020-12 extending the tree of the dynamic schema in a document it references
+		"extension/node.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$dynamicAnchor": "node",
+  "$ref": "../dynamic/widget.json#/$defs/node",
+  "required": ["id"]
+}`,
+		// draft 2020-12 reaching the tree of the dynamic schema directly and through the extension,
+		// its nodes require an id or not depending on the path
+		"diamond/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {"spec": {"properties": {"a": {"$ref": "../dynamic/widget.json#/$defs/node"}, "b": {"$ref": "../extension/node.json"}}}}
+}`,
+		// draft 2020-12 declaring the same dynamic anchor in two embedded schemas
+		"ambiguous/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {"a": {"$id": "a", "$dynamicAnchor": "node"}, "b": {"$id": "b", "$dynamicAnchor": "node"}}
+}`,
+		// draft 2020-12 with keywords next to a $ref, applied along with it
+		"siblings/widget.json": `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {"spec": {"$ref": "#/$defs/base", "required": ["name"]}},
+  "$defs": {"base": {"type": "object", "properties": {"name": {"type": "string"}}}}
+}`,
+		"unresolved/widget.json":  `{"$schema": "https://json-schema.org/draft/2020-12/schema", "properties": {"spec": {"$dynamicRef": "#missing"}}}`,
+		"unsupported/widget.json": `{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object", "unevaluatedProperties": false}`,
+		"draft4/widget.json":      `{"type": "object", "properties": {"spec": {"properties": {"replicas": {"maximum": 3, "exclusiveMaximum": true}}}}}`,
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed creating folder: %s", err)
+		}
+		if err := os.WriteFile(p, []byte(schema), 0644); err != nil {
+			t.Fatalf("failed writing schema: %s", err)
+		}
+	}
+
+	for i, testCase := range []struct {
+		schemaLocation string
+		rawResource    string
+		expect         Status
+	}{
+		{"detected", "spec: {ports: [http, 80, 443]}", Valid},
+		{"detected", "spec: {ports: [80, 443]}", Invalid},
+		{"detected", "spec: {ports: [http, https]}", Invalid},
+		{"detected", "spec: {min: 1}", Invalid},
+		{"detected", "spec: {min: 1, max: 2}", Valid},
+		{"draft-2020-12+declared", "spec: {ports: [http, 80]}", Valid},
+		{"draft-2020-12+declared", "spec: {ports: [http, https]}", Invalid},
+		{"declared", "spec: {ports: [http, 80]}", Invalid}, // read as draft-07 without the declaration
+		{"dynamic", "spec: {name: root, children: [{name: leaf, children: []}]}", Valid},
+		{"dynamic", "spec: {name: root, children: [{name: 1}]}", Invalid},
+		{"dynamic", "spec: {name: root, children: [{children: []}]}", Invalid},
+		{"recursive", "spec: {name: root, spec: {name: child}}", Valid},
+		{"recursive", "spec: {name: root, spec: {name: 1}}", Invalid},
+		{"siblings", "spec: {name: web}", Valid},
+		{"siblings", "spec: {}", Invalid},
+		{"siblings", "spec: {name: 1}", Invalid},
+		{"draft-2020-12+siblings", "spec: {}", Invalid},
+		{"draft-07+siblings", "spec: {}", Valid}, // keywords next to a $ref are ignored in draft-07
+		{"extended", "spec: {name: root, id: 1, children: [{name: leaf, id: 2, children: []}]}", Valid},
+		{"extended", "spec: {name: root, id: 1, children: [{name: leaf, children: []}]}", Invalid},
+		{"extended", "spec: {name: root, children: []}", Invalid},
+		{"diamond", "spec: {}", Error},
+		{"ambiguous", "spec: {}", Error},
+		{"unresolved", "spec: {}", Error},
+		{"unsupported", "spec: {}", Error},
+		{"draft-04+draft4", "spec: {replicas: 2}", Valid},
+		{"draft-04+draft4", "spec: {replicas: 3}", Invalid},
+		{"draft-07+draft4", "spec: {replicas: 2}", Error},
+	} {
+		draft, location := splitDraft(testCase.schemaLocation)
+		if draft != "" {
+			draft += "+"
+		}
+		val, err := New([]string{draft + filepath.Join(dir, location, "{{ .ResourceKind }}.json")}, Opts{})
+		if err != nil {
+			t.Fatalf("%d - failed creating validator: %s", i, err)
+		}
+		res := resource.Resource{Bytes: []byte("apiVersion: example.com/v1\nkind: Widget\n" + testCase.rawResource)}
+		if got := val.ValidateResource(res); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+)
+
+// DryRunner submits resources to a cluster without persisting them, see registry.DryRunner
+type DryRunner interface {
+	DryRun(ctx context.Context, b []byte, apiVersion, kind, namespace, name string) error
+}
+
+// serverDryRun submits a resource that passed schema validation to the cluster of ServerDryRun.
+// Resources rejected by the cluster are invalid, with the code ServerRejected; resources that could
+// not be submitted are errors, with the code DryRunError. Server-side failures are prefixed with
+// "server dry-run", to tell them apart from schema validation failures.
+func (val *v) serverDryRun(ctx context.Context, result Result) Result {
+	sig, err := result.Resource.Signature()
+	if err != nil {
+		return result
+	}
+
+	err = val.opts.ServerDryRun.DryRun(ctx, result.Resource.Bytes, sig.Version, sig.Kind, sig.Namespace, sig.Name)
+	var rejected *registry.DryRunRejectedError
+	switch {
+	case err == nil:
+		return result
+	case errors.As(err, &rejected):
+		result.Status, result.Code = Invalid, ServerRejected
+		result.Err = fmt.Errorf("server dry-run: %s", rejected.Message)
+	default:
+		result.Status, result.Code = Error, DryRunError
+		result.Err = fmt.Errorf("server dry-run: %s", err)
+	}
+
+	return result
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+type fakeDryRunner map[string]error // errors by resource name
+
+func (f fakeDryRunner) DryRun(ctx context.Context, b []byte, apiVersion, kind, namespace, name string) error {
+	return f[name]
+}
+
+func TestServerDryRun(t *testing.T) {
+	val, err := New(nil, Opts{
+		SyntaxOnly: true,
+		SkipKinds:  map[string]struct{}{"Secret": {}},
+		ServerDryRun: fakeDryRunner{
+			"denied":      &registry.DryRunRejectedError{StatusCode: 403, Message: `admission webhook "policy.example.com" denied the request: image tag latest is not allowed`},
+			"unreachable": fmt.Errorf("failed submitting Deployment unreachable with dry-run: connection refused"),
+			"skipped":     fmt.Errorf("skipped resources are not submitted"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, testCase := range []struct {
+		rawResource string
+		status      Status
+		code        ErrorCode
+		err         string
+	}{
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n", Valid, "", ""},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: denied\n", Invalid, ServerRejected, `server dry-run: admission webhook "policy.example.com" denied the request: image tag latest is not allowed`},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: unreachable\n", Error, DryRunError, "server dry-run: failed submitting Deployment unreachable with dry-run: connection refused"},
+		{"apiVersion: v1\nkind: Secret\nmetadata:\n  name: skipped\n", Skipped, "", ""},
+		{"apiVersion: apps/v1\nmetadata:\n  name: denied\n", Error, ParseError, "error while parsing: missing 'kind' key"},
+	} {
+		got := val.(ContextValidator).ValidateResourceWithContext(context.Background(), resource.Resource{Bytes: []byte(testCase.rawResource)})
+		gotErr := ""
+		if got.Err != nil {
+			gotErr = got.Err.Error()
+		}
+		if got.Status != testCase.status || got.Code != testCase.code || gotErr != testCase.err {
+			t.Errorf("%d - expected %d %q %q, got %d %q %q", i, testCase.status, testCase.code, testCase.err, got.Status, got.Code, gotErr)
+		}
+	}
+}
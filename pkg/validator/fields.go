@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// fieldSchema is a schema the field at a path of a resource is validated against
+type fieldSchema struct {
+	path   string
+	schema *gojsonschema.Schema
+}
+
+// loadFieldSchemas compiles the schemas given for -only-fields, sorted by path for each kind
+func loadFieldSchemas(onlyFields map[string]map[string]string) (map[string][]fieldSchema, error) {
+	fieldSchemas := map[string][]fieldSchema{}
+	for kind, schemaFiles := range onlyFields {
+		for path, schemaFile := range schemaFiles {
+			b, err := ioutil.ReadFile(schemaFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed reading schema %s: %s", schemaFile, err)
+			}
+			schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(b))
+			if err != nil {
+				return nil, fmt.Errorf("failed compiling schema %s: %s", schemaFile, err)
+			}
+			fieldSchemas[kind] = append(fieldSchemas[kind], fieldSchema{path: path, schema: schema})
+		}
+		sort.Slice(fieldSchemas[kind], func(i, j int) bool {
+			return fieldSchemas[kind][i].path < fieldSchemas[kind][j].path
+		})
+	}
+
+	return fieldSchemas, nil
+}
+
+// lookupField returns the value at a dot-separated path of a resource
+func lookupField(r map[string]interface{}, path string) (interface{}, bool) {
+	var obj interface{} = r
+	for _, key := range strings.Split(path, ".") {
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if obj, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return obj, true
+}
+
+// fieldErrors validates the fields of a resource against their schemas, and returns a description
+// of each violation. Missing fields are only reported if failOnMissing is set.
+func fieldErrors(fields []fieldSchema, r map[string]interface{}, failOnMissing bool) ([]string, error) {
+	errs := []string{}
+	for _, f := range fields {
+		value, ok := lookupField(r, f.path)
+		if !ok {
+			if failOnMissing {
+				errs = append(errs, fmt.Sprintf("For field %s: field is missing", f.path))
+			}
+			continue
+		}
+
+		results, err := f.schema.Validate(gojsonschema.NewGoLoader(value))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, errMsg := range results.Errors() {
+			field := f.path
+			if subfield := errMsg.Details()["field"].(string); subfield != "(root)" {
+				field += "." + subfield
+			}
+			errs = append(errs, fmt.Sprintf("For field %s: %s", field, errMsg.Description()))
+		}
+	}
+
+	return errs, nil
+}
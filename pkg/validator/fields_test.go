@@ -0,0 +1,98 @@
+package validator
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestValidateOnlyFields(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "containers.json")
+	schema := []byte(`{"type": "array", "items": {"type": "object", "required": ["resources"]}}`)
+	if err := ioutil.WriteFile(schemaFile, schema, 0644); err != nil {
+		t.Fatalf("failed writing schema: %s", err)
+	}
+
+	fieldSchemas, err := loadFieldSchemas(map[string]map[string]string{"Deployment": {"spec.template.spec.containers": schemaFile}})
+	if err != nil {
+		t.Fatalf("failed loading schemas: %s", err)
+	}
+
+	for i, testCase := range []struct {
+		name          string
+		rawResource   string
+		failOnMissing bool
+		expect        Status
+		expectErr     string
+	}{
+		{
+			"valid field",
+			"apiVersion: apps/v1\nkind: Deployment\nspec:\n  template:\n    spec:\n      containers:\n      - name: foo\n        resources: {}\n",
+			false,
+			Valid,
+			"",
+		},
+		{
+			"invalid field",
+			"apiVersion: apps/v1\nkind: Deployment\nspec:\n  template:\n    spec:\n      containers:\n      - name: foo\n",
+			false,
+			Invalid,
+			"For field spec.template.spec.containers.0: resources is required",
+		},
+		{
+			"missing field passes",
+			"apiVersion: apps/v1\nkind: Deployment\nspec: {}\n",
+			false,
+			Valid,
+			"",
+		},
+		{
+			"missing field fails",
+			"apiVersion: apps/v1\nkind: Deployment\nspec: {}\n",
+			true,
+			Invalid,
+			"For field spec.template.spec.containers: field is missing",
+		},
+		{
+			"field in a value that is not an object",
+			"apiVersion: apps/v1\nkind: Deployment\nspec: foo\n",
+			true,
+			Invalid,
+			"For field spec.template.spec.containers: field is missing",
+		},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:           map[string]struct{}{},
+				RejectKinds:         map[string]struct{}{},
+				FailOnMissingFields: testCase.failOnMissing,
+			},
+			fieldSchemas: fieldSchemas,
+		}
+
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - %s: expected status %d, got %d: %s", i, testCase.name, testCase.expect, got.Status, got.Err)
+		}
+		if testCase.expectErr != "" && (got.Err == nil || got.Err.Error() != testCase.expectErr) {
+			t.Errorf("%d - %s: expected error %q, got %v", i, testCase.name, testCase.expectErr, got.Err)
+		}
+	}
+}
+
+func TestLoadFieldSchemasInvalid(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(schemaFile, []byte(`{"type": 42}`), 0644); err != nil {
+		t.Fatalf("failed writing schema: %s", err)
+	}
+
+	for _, f := range []string{schemaFile, filepath.Join(dir, "missing.json")} {
+		if _, err := loadFieldSchemas(map[string]map[string]string{"Deployment": {"spec": f}}); err == nil {
+			t.Errorf("expected an error loading %s", f)
+		}
+	}
+}
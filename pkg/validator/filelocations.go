@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yannh/kubeconform/pkg/cache"
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+// fileRegistries are the registries searched for resources of files setting schema locations with
+// a directive: the registries of these locations, then the ones of the validator. Schemas found are
+// cached separately from those found for other files. Resources annotated with a schema revision
+// are searched for in the same registries, with the revision set in their schema locations.
+type fileRegistries struct {
+	once      sync.Once
+	regs      []registry.Registry
+	locations []string
+	cache     cache.Cache
+	err       error
+}
+
+// schemaRevisionPattern matches the schema revisions that can be set in schema locations
+var schemaRevisionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// schemaRevision returns the schema revision a resource is annotated with, if RevisionAnnotation is set
+func (val *v) schemaRevision(r map[string]interface{}) (string, error) {
+	if val.opts.RevisionAnnotation == "" {
+		return "", nil
+	}
+
+	metadata, _ := r["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	revision, _ := annotations[val.opts.RevisionAnnotation].(string)
+	if revision != "" && !schemaRevisionPattern.MatchString(revision) {
+		return "", fmt.Errorf("invalid schema revision %q in annotation %s, must only contain letters, digits, '.', '_' and '-'", revision, val.opts.RevisionAnnotation)
+	}
+
+	return revision, nil
+}
+
+// registriesFor returns the registries to search for the schema of a resource, their schema
+// locations and the cache of the schemas found in them
+func (val *v) registriesFor(res resource.Resource, revision string) ([]registry.Registry, []string, cache.Cache, error) {
+	if len(res.SchemaLocations) == 0 && revision == "" {
+		return val.regs, val.locations, val.schemaCache, nil
+	}
+
+	if revision != "" {
+		return val.revisionRegistriesFor(res, revision)
+	}
+
+	e, _ := val.fileRegistries.LoadOrStore(strings.Join(res.SchemaLocations, "\n"), &fileRegistries{})
+	fr := e.(*fileRegistries)
+	fr.once.Do(func() {
+		regs, err := newRegistries(res.SchemaLocations, val.opts, val.opts.Strict)
+		if err != nil {
+			fr.err = fmt.Errorf("invalid schema location set in file: %s", err)
+			return
+		}
+		fr.regs = append(regs, val.regs...)
+		fr.locations = append(append([]string{}, res.SchemaLocations...), val.locations...)
+		fr.cache = cache.NewInMemoryCache()
+		val.debugf("%s - searching schema locations %s first", res.Path, strings.Join(res.SchemaLocations, ", "))
+	})
+
+	return fr.regs, fr.locations, fr.cache, fr.err
+}
+
+// revisionRegistriesFor returns the registries to search for the schema of a resource annotated with
+// a schema revision, as registriesFor does
+func (val *v) revisionRegistriesFor(res resource.Resource, revision string) ([]registry.Registry, []string, cache.Cache, error) {
+	key := "revision=" + revision + "\n" + strings.Join(res.SchemaLocations, "\n")
+	e, _ := val.fileRegistries.LoadOrStore(key, &fileRegistries{})
+	fr := e.(*fileRegistries)
+	fr.once.Do(func() {
+		opts := val.opts
+		opts.RegistryTimeouts = map[string]time.Duration{}
+		for _, location := range append(append([]string{}, res.SchemaLocations...), val.locations...) {
+			revisionLocation := registry.WithSchemaRevision(location, revision)
+			if timeout, ok := val.opts.RegistryTimeouts[location]; ok {
+				opts.RegistryTimeouts[revisionLocation] = timeout
+			}
+			fr.locations = append(fr.locations, revisionLocation)
+		}
+
+		if fr.regs, fr.err = newRegistries(fr.locations, opts, val.opts.Strict); fr.err != nil {
+			fr.err = fmt.Errorf("invalid schema location for schema revision %s: %s", revision, fr.err)
+			return
+		}
+		fr.cache = cache.NewInMemoryCache()
+		val.debugf("%s - searching schema locations for schema revision %s", res.Path, revision)
+	})
+
+	return fr.regs, fr.locations, fr.cache, fr.err
+}
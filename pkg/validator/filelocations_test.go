@@ -0,0 +1,81 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestValidateFileSchemaLocations(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "widget.json"), []byte(`{"type": "object", "required": ["spec"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "gadget.json"), []byte(`{"type": "object"}`), 0644)
+
+	val, err := New([]string{filepath.Join(dir, "{{ .ResourceKind }}.json")}, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := t.TempDir()
+	os.WriteFile(filepath.Join(local, "widget.json"), []byte(`{"type": "object"}`), 0644)
+	localLocation := filepath.Join(local, "{{ .ResourceKind }}.json")
+
+	for i, testCase := range []struct {
+		kind            string
+		schemaLocations []string
+		expect          Status
+	}{
+		{"Widget", nil, Invalid},
+		{"Widget", []string{localLocation}, Valid},
+		{"Gadget", []string{localLocation}, Valid},
+		{"Widget", nil, Invalid},
+		{"Widget", []string{"{{ .ResourceKind"}, Error},
+	} {
+		res := resource.Resource{Bytes: []byte("kind: " + testCase.kind + "\napiVersion: v1\n"), SchemaLocations: testCase.schemaLocations}
+		if got := val.ValidateResource(res); got.Status != testCase.expect {
+			t.Errorf("%d - expected status %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+}
+
+func TestValidateSchemaRevision(t *testing.T) {
+	dir := t.TempDir()
+	for revision, schema := range map[string]string{
+		"":   `{"type": "object", "required": ["spec"]}`,
+		"v2": `{"type": "object", "required": ["size"]}`,
+		"v3": `{"type": "object", "required": ["sizeBytes"]}`,
+	} {
+		os.MkdirAll(filepath.Join(dir, revision), 0755)
+		os.WriteFile(filepath.Join(dir, revision, "widget.json"), []byte(schema), 0644)
+	}
+
+	val, err := New([]string{filepath.Join(dir, "{{ .SchemaRevision }}", "{{ .ResourceKind }}.json")}, Opts{RevisionAnnotation: "schema-revision", IgnoreMissingSchemas: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, testCase := range []struct {
+		revision, field string
+		expect          Status
+	}{
+		{"", "spec", Valid},
+		{"", "size", Invalid},
+		{"v2", "size", Valid},
+		{"v2", "sizeBytes", Invalid},
+		{"v3", "sizeBytes", Valid},
+		{"v3", "size", Invalid},
+		{"v4", "size", Skipped},
+		{"../v2", "size", Error},
+	} {
+		annotations := ""
+		if testCase.revision != "" {
+			annotations = "  annotations:\n    schema-revision: " + testCase.revision + "\n"
+		}
+		res := resource.Resource{Bytes: []byte("kind: Widget\napiVersion: v1\nmetadata:\n  name: w\n" + annotations + testCase.field + ": {}\n")}
+		if got := val.ValidateResource(res); got.Status != testCase.expect {
+			t.Errorf("%d - expected status %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package validator
+
+import "strings"
+
+// removeKey deletes the field at path from obj. A "*" path element matches every
+// element of a list, or every value of a map.
+func removeKey(obj interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		if path[0] == "*" {
+			for k := range o {
+				if len(path) == 1 {
+					delete(o, k)
+				} else {
+					removeKey(o[k], path[1:])
+				}
+			}
+			return
+		}
+
+		if len(path) == 1 {
+			delete(o, path[0])
+			return
+		}
+		if v, ok := o[path[0]]; ok {
+			removeKey(v, path[1:])
+		}
+
+	case []interface{}:
+		if path[0] != "*" || len(path) == 1 {
+			return // list elements can only be traversed, not removed
+		}
+		for _, item := range o {
+			removeKey(item, path[1:])
+		}
+	}
+}
+
+// removeKeys deletes the fields at the given dot-separated paths from a resource,
+// for example status, metadata.managedFields or spec.containers.*.image
+func removeKeys(r map[string]interface{}, paths []string) {
+	for _, p := range paths {
+		removeKey(r, strings.Split(p, "."))
+	}
+}
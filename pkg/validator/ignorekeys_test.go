@@ -0,0 +1,81 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestRemoveKeys(t *testing.T) {
+	for i, testCase := range []struct {
+		name        string
+		rawResource string
+		paths       []string
+		expect      string
+	}{
+		{
+			"remove a top-level key",
+			`
+kind: Deployment
+status:
+  replicas: 1
+`,
+			[]string{"status"},
+			`kind: Deployment`,
+		},
+		{
+			"remove a nested key",
+			`
+metadata:
+  name: foo
+  managedFields:
+  - manager: kubectl
+`,
+			[]string{"metadata.managedFields"},
+			`
+metadata:
+  name: foo
+`,
+		},
+		{
+			"remove a key in every list element",
+			`
+spec:
+  containers:
+  - name: a
+    image: a:latest
+  - name: b
+    image: b:latest
+`,
+			[]string{"spec.containers.*.image"},
+			`
+spec:
+  containers:
+  - name: a
+  - name: b
+`,
+		},
+		{
+			"paths that do not exist are ignored",
+			`
+kind: Deployment
+`,
+			[]string{"status.replicas", "spec.*.foo", "kind.foo"},
+			`kind: Deployment`,
+		},
+	} {
+		var r, expect map[string]interface{}
+		if err := yaml.Unmarshal([]byte(testCase.rawResource), &r); err != nil {
+			t.Fatalf("test %d - %s: failed unmarshalling resource: %s", i+1, testCase.name, err)
+		}
+		if err := yaml.Unmarshal([]byte(testCase.expect), &expect); err != nil {
+			t.Fatalf("test %d - %s: failed unmarshalling expected resource: %s", i+1, testCase.name, err)
+		}
+
+		removeKeys(r, testCase.paths)
+		if !reflect.DeepEqual(r, expect) {
+			t.Errorf("test %d - %s: expected %+v, got %+v", i+1, testCase.name, expect, r)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Image references are parsed as github.com/distribution/reference parses them with
+// ParseNormalizedNamed, which container runtimes use to pull images. The grammar is kept here rather
+// than imported, so that this opt-in check does not add a dependency to kubeconform.
+const (
+	refNameTotalLengthMax = 255
+	refDomainComponent    = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+	refIPv6Address        = `\[(?:[a-fA-F0-9:]+)\]`
+	refDomain             = `(?:` + refDomainComponent + `(?:\.` + refDomainComponent + `)*|` + refIPv6Address + `)(?::[0-9]+)?`
+	refPathComponent      = `[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*`
+	refName               = `(?:` + refDomain + `/)?` + refPathComponent + `(?:/` + refPathComponent + `)*`
+	refTag                = `[\w][\w.-]{0,127}`
+	refDigest             = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[[:xdigit:]]{32,}`
+
+	defaultImageDomain = "docker.io" // domain of the images that do not name their registry
+)
+
+var (
+	referenceRegexp  = regexp.MustCompile(`^(` + refName + `)(?::(` + refTag + `))?(?:@(` + refDigest + `))?$`)
+	tagRegexp        = regexp.MustCompile(`^` + refTag + `$`)
+	identifierRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+)
+
+// digestLengths are the lengths of the hex-encoded digests of the algorithms images can be pinned with
+var digestLengths = map[string]int{
+	"sha256": 64,
+	"sha384": 96,
+	"sha512": 128,
+}
+
+// podSpecPaths lists where the pod spec is found for the kinds that contain one, by group/Kind.
+// Custom resources of the same kind name in other groups are not checked.
+var podSpecPaths = map[string][]string{
+	"/Pod":                   {"spec"},
+	"/PodTemplate":           {"template", "spec"},
+	"/ReplicationController": {"spec", "template", "spec"},
+	"apps/ReplicaSet":        {"spec", "template", "spec"},
+	"apps/Deployment":        {"spec", "template", "spec"},
+	"apps/StatefulSet":       {"spec", "template", "spec"},
+	"apps/DaemonSet":         {"spec", "template", "spec"},
+	"extensions/ReplicaSet":  {"spec", "template", "spec"},
+	"extensions/Deployment":  {"spec", "template", "spec"},
+	"extensions/DaemonSet":   {"spec", "template", "spec"},
+	"batch/Job":              {"spec", "template", "spec"},
+	"batch/CronJob":          {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// imageReference is a container image reference, e.g. registry.example.com:5000/team/app:1.0@sha256:...
+type imageReference struct {
+	domain string // registry of the image, docker.io if the reference does not name one
+	path   string // repository in the registry, prefixed with library/ for official Docker Hub images
+	digest string
+
+	explicitDomain bool // the reference names its registry
+}
+
+// parseImageReference parses an image reference as a container runtime does before pulling it
+func parseImageReference(image string) (*imageReference, error) {
+	if image == "" {
+		return nil, fmt.Errorf("must be non-empty")
+	}
+	if identifierRegexp.MatchString(image) {
+		return nil, fmt.Errorf("invalid repository name, cannot specify 64-byte hexadecimal strings")
+	}
+
+	m := referenceRegexp.FindStringSubmatch(image)
+	if m == nil {
+		if lower := strings.ToLower(image); referenceRegexp.MatchString(lower) {
+			return nil, fmt.Errorf("repository name must be lowercase")
+		}
+		if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") && !strings.Contains(image, "@") && !tagRegexp.MatchString(image[i+1:]) {
+			return nil, fmt.Errorf("invalid tag %q", image[i+1:])
+		}
+		return nil, fmt.Errorf("invalid reference format")
+	}
+
+	name := m[1]
+	if len(name) > refNameTotalLengthMax {
+		return nil, fmt.Errorf("repository name must not be more than %d characters", refNameTotalLengthMax)
+	}
+
+	ref := &imageReference{domain: defaultImageDomain, path: name, digest: m[3]}
+
+	// The first component of the name is the registry if it looks like a host, as Docker decides
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" || strings.ToLower(first) != first {
+			ref.domain, ref.path, ref.explicitDomain = first, name[i+1:], true
+		}
+	}
+	if ref.domain == defaultImageDomain && !strings.Contains(ref.path, "/") {
+		ref.path = "library/" + ref.path
+	}
+
+	if ref.digest != "" {
+		parts := strings.SplitN(ref.digest, ":", 2)
+		length, ok := digestLengths[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported digest algorithm %s", parts[0])
+		}
+		if len(parts[1]) != length {
+			return nil, fmt.Errorf("invalid checksum digest length")
+		}
+	}
+
+	return ref, nil
+}
+
+// validateImageReference checks that an image is a valid image reference, that names its registry
+// if requireRegistry is set
+func validateImageReference(image string, requireRegistry bool) error {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return err
+	}
+
+	if requireRegistry && !ref.explicitDomain {
+		return fmt.Errorf("missing registry, would be pulled from %s/%s", ref.domain, ref.path)
+	}
+
+	return nil
+}
+
+// validateImages checks the images of all containers in the pod spec of a resource
+func validateImages(apiVersion, kind string, r map[string]interface{}, requireRegistry bool) error {
+	group := ""
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group = apiVersion[:i]
+	}
+	path, ok := podSpecPaths[group+"/"+kind]
+	if !ok {
+		return nil
+	}
+
+	var spec interface{} = r
+	for _, k := range path {
+		m, ok := spec.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		spec = m[k]
+	}
+	podSpec, ok := spec.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, ok := podSpec[field].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			image, ok := container["image"].(string)
+			if !ok {
+				continue // missing images and type errors are reported by the schema validation
+			}
+			if err := validateImageReference(image, requireRegistry); err != nil {
+				return fmt.Errorf("invalid image %q for %s.%s[%d]: %s", image, strings.Join(path, "."), field, i, err)
+			}
+		}
+	}
+
+	return nil
+}
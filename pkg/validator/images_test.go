@@ -0,0 +1,196 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateImageReference(t *testing.T) {
+	for i, testCase := range []struct {
+		image           string
+		requireRegistry bool
+		expectErr       string
+	}{
+		{"nginx", false, ""},
+		{"nginx:1.21", false, ""},
+		{"library/nginx:latest", false, ""},
+		{"registry.example.com:5000/team/app:v1.0.0-rc.1", false, ""},
+		{"localhost/app", false, ""},
+		{"app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", false, ""},
+		{"app:1.0@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", false, ""},
+		{"", false, "must be non-empty"},
+		{"Nginx:1.21", false, "repository name must be lowercase"},
+		{"nginx:1.21!", false, `invalid tag "1.21!"`},
+		{"nginx:", false, `invalid tag ""`},
+		{"app@sha256:1234", false, "invalid reference format"},
+		{"nginx 1.21", false, "invalid reference format"},
+		{"/nginx", false, "invalid reference format"},
+		{strings.Repeat("a", 256), false, "repository name must not be more than 255 characters"},
+		{"app@sha256:0123456789abcdef0123456789abcdef", false, "invalid checksum digest length"},
+		{"app@md5:0123456789abcdef0123456789abcdef", false, "unsupported digest algorithm md5"},
+		{"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", false, "invalid repository name, cannot specify 64-byte hexadecimal strings"},
+		{"[::1]:5000/app", false, ""},
+		{"registry.example.com/app:1.0", true, ""},
+		{"localhost/app", true, ""},
+		{"registry:5000/app", true, ""},
+		{"nginx:1.21", true, "missing registry, would be pulled from docker.io/library/nginx"},
+		{"team/app", true, "missing registry, would be pulled from docker.io/team/app"},
+	} {
+		err := validateImageReference(testCase.image, testCase.requireRegistry)
+		if testCase.expectErr == "" && err != nil {
+			t.Errorf("%d - expected %q to be valid, got %s", i, testCase.image, err)
+		}
+		if testCase.expectErr != "" && (err == nil || err.Error() != testCase.expectErr) {
+			t.Errorf("%d - expected error %q for %q, got %v", i, testCase.expectErr, testCase.image, err)
+		}
+	}
+}
+
+func TestValidateImages(t *testing.T) {
+	for i, testCase := range []struct {
+		name            string
+		rawResource     string
+		requireRegistry bool
+		expectErr       string
+	}{
+		{
+			"kind without pod spec",
+			"apiVersion: v1\nkind: ConfigMap\ndata:\n  image: not a valid image",
+			false,
+			"",
+		},
+		{
+			"valid deployment",
+			`
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+      - image: busybox
+      containers:
+      - image: nginx:1.21
+      - image: registry.example.com/sidecar
+`,
+			false,
+			"",
+		},
+		{
+			"invalid container image in deployment",
+			`
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx:1.21
+      - image: "nginx:1.21!"
+`,
+			false,
+			`invalid image "nginx:1.21!" for spec.template.spec.containers[1]: invalid tag "1.21!"`,
+		},
+		{
+			"invalid init container image in pod",
+			`
+apiVersion: v1
+kind: Pod
+spec:
+  initContainers:
+  - image: Busybox
+  containers:
+  - image: nginx
+`,
+			false,
+			`invalid image "Busybox" for spec.initContainers[0]: repository name must be lowercase`,
+		},
+		{
+			"invalid image in cronjob",
+			`
+apiVersion: batch/v1
+kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - image: "my app"
+`,
+			false,
+			`invalid image "my app" for spec.jobTemplate.spec.template.spec.containers[0]: invalid reference format`,
+		},
+		{
+			"missing image and malformed pod spec are left to the schema validation",
+			`
+apiVersion: apps/v1
+kind: StatefulSet
+spec:
+  template:
+    spec:
+      containers:
+      - name: foo
+      - image: 1
+---
+`,
+			false,
+			"",
+		},
+		{
+			"custom resource of a kind with a pod spec in another group",
+			`
+apiVersion: example.com/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: "my app"
+`,
+			false,
+			"",
+		},
+		{
+			"deployment in the extensions group",
+			`
+apiVersion: extensions/v1beta1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - image: "my app"
+`,
+			false,
+			`invalid image "my app" for spec.template.spec.containers[0]: invalid reference format`,
+		},
+		{
+			"image without registry when one is required",
+			`
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - image: registry.example.com/app:1.0
+  - image: nginx:1.21
+`,
+			true,
+			`invalid image "nginx:1.21" for spec.containers[1]: missing registry, would be pulled from docker.io/library/nginx`,
+		},
+	} {
+		r := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(testCase.rawResource), &r); err != nil {
+			t.Fatalf("%d - failed parsing test resource: %s", i, err)
+		}
+		err := validateImages(r["apiVersion"].(string), r["kind"].(string), r, testCase.requireRegistry)
+		if testCase.expectErr == "" && err != nil {
+			t.Errorf("%d - %s: expected no error, got %s", i, testCase.name, err)
+		}
+		if testCase.expectErr != "" && (err == nil || err.Error() != testCase.expectErr) {
+			t.Errorf("%d - %s: expected error %s, got %v", i, testCase.name, testCase.expectErr, err)
+		}
+	}
+}
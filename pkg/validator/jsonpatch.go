@@ -0,0 +1,259 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// patchOperation is a single operation of an RFC 6902 JSON Patch
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from"`
+	Value interface{} `json:"value"`
+}
+
+type jsonPatch []patchOperation
+
+func parseJSONPatch(b []byte) (jsonPatch, error) {
+	var p jsonPatch
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+
+	for i, op := range p {
+		switch op.Op {
+		case "add", "remove", "replace", "move", "copy", "test":
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+
+	return p, nil
+}
+
+// splitPointer splits a JSON pointer (RFC 6901) into its unescaped reference tokens
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > length || (i == length && !allowEnd) {
+		return 0, fmt.Errorf("invalid array index %s", token)
+	}
+	return i, nil
+}
+
+// get returns the value at the location of the reference tokens
+func get(doc interface{}, tokens []string) (interface{}, error) {
+	for _, t := range tokens {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			v, ok := d[t]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found", t)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(t, len(d), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, fmt.Errorf("can not traverse %s", t)
+		}
+	}
+	return doc, nil
+}
+
+// update replaces the container at tokens[:len(tokens)-1] with the result of f, which receives the
+// container and the last reference token. Arrays can not be modified in place, so we rebuild the path.
+func update(doc interface{}, tokens []string, f func(container interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return f(doc, tokens[0])
+	}
+
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		child, ok := d[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found", tokens[0])
+		}
+		v, err := update(child, tokens[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		d[tokens[0]] = v
+		return d, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(d), false)
+		if err != nil {
+			return nil, err
+		}
+		v, err := update(d[i], tokens[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		d[i] = v
+		return d, nil
+	default:
+		return nil, fmt.Errorf("can not traverse %s", tokens[0])
+	}
+}
+
+func add(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return update(doc, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[key] = value
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(c), true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("can not add %s", key)
+		}
+	})
+}
+
+func remove(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("can not remove the whole document")
+	}
+	return update(doc, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("key %s not found", key)
+			}
+			delete(c, key)
+			return c, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("can not remove %s", key)
+		}
+	})
+}
+
+// deepCopy copies a value decoded from JSON or YAML
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = deepCopy(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = deepCopy(v)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// normalize converts a value to the types encoding/json produces, so values coming from
+// YAML resources and from JSON patches compare equal
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
+
+// apply applies the patch to a resource, returning the patched resource
+func (p jsonPatch) apply(r map[string]interface{}) (map[string]interface{}, error) {
+	var doc interface{} = r
+	for _, op := range p {
+		path, err := splitPointer(op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			doc, err = add(doc, path, deepCopy(op.Value))
+		case "remove":
+			doc, err = remove(doc, path)
+		case "replace":
+			if doc, err = remove(doc, path); err == nil {
+				doc, err = add(doc, path, deepCopy(op.Value))
+			}
+		case "move", "copy":
+			var from []string
+			var v interface{}
+			if from, err = splitPointer(op.From); err != nil {
+				return nil, err
+			}
+			if v, err = get(doc, from); err != nil {
+				break
+			}
+			if op.Op == "move" {
+				if doc, err = remove(doc, from); err != nil {
+					break
+				}
+			} else {
+				v = deepCopy(v)
+			}
+			doc, err = add(doc, path, v)
+		case "test":
+			var v interface{}
+			if v, err = get(doc, path); err == nil && !reflect.DeepEqual(normalize(v), normalize(op.Value)) {
+				err = fmt.Errorf("test failed, value at %s differs", op.Path)
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed applying %s operation on %s: %s", op.Op, op.Path, err)
+		}
+	}
+
+	patched, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patched resource is not an object")
+	}
+
+	return patched, nil
+}
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestJSONPatchApply(t *testing.T) {
+	for i, testCase := range []struct {
+		name        string
+		rawResource string
+		patch       string
+		expect      string
+		expectErr   bool
+	}{
+		{
+			"add a field",
+			`metadata: {name: foo}`,
+			`[{"op": "add", "path": "/metadata/namespace", "value": "default"}]`,
+			`metadata: {name: foo, namespace: default}`,
+			false,
+		},
+		{
+			"add to a list, escaped keys",
+			`metadata: {labels: {"app.kubernetes.io/name": foo}}
+items: [a, c]`,
+			`[{"op": "add", "path": "/items/1", "value": "b"}, {"op": "add", "path": "/items/-", "value": "d"},
+			  {"op": "replace", "path": "/metadata/labels/app.kubernetes.io~1name", "value": "bar"}]`,
+			`metadata: {labels: {"app.kubernetes.io/name": bar}}
+items: [a, b, c, d]`,
+			false,
+		},
+		{
+			"remove, move and copy",
+			`spec: {a: 1, b: 2, c: [x, y]}`,
+			`[{"op": "remove", "path": "/spec/a"}, {"op": "move", "from": "/spec/b", "path": "/spec/d"},
+			  {"op": "copy", "from": "/spec/c/0", "path": "/spec/e"}, {"op": "remove", "path": "/spec/c/0"}]`,
+			`spec: {d: 2, c: [y], e: x}`,
+			false,
+		},
+		{
+			"successful test",
+			`spec: {replicas: 2}`,
+			`[{"op": "test", "path": "/spec/replicas", "value": 2}, {"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+			`spec: {replicas: 3}`,
+			false,
+		},
+		{
+			"failed test",
+			`spec: {replicas: 2}`,
+			`[{"op": "test", "path": "/spec/replicas", "value": 3}]`,
+			``,
+			true,
+		},
+		{
+			"removing a missing key",
+			`spec: {replicas: 2}`,
+			`[{"op": "remove", "path": "/spec/foo"}]`,
+			``,
+			true,
+		},
+		{
+			"index out of range",
+			`items: [a]`,
+			`[{"op": "replace", "path": "/items/3", "value": "b"}]`,
+			``,
+			true,
+		},
+	} {
+		var r, expect map[string]interface{}
+		if err := yaml.Unmarshal([]byte(testCase.rawResource), &r); err != nil {
+			t.Fatalf("test %d - %s: failed unmarshalling resource: %s", i+1, testCase.name, err)
+		}
+		if err := yaml.Unmarshal([]byte(testCase.expect), &expect); err != nil {
+			t.Fatalf("test %d - %s: failed unmarshalling expected resource: %s", i+1, testCase.name, err)
+		}
+
+		p, err := parseJSONPatch([]byte(testCase.patch))
+		if err != nil {
+			t.Fatalf("test %d - %s: failed parsing patch: %s", i+1, testCase.name, err)
+		}
+
+		got, err := p.apply(r)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("test %d - %s: expected error %t, got %v", i+1, testCase.name, testCase.expectErr, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, expect) {
+			t.Errorf("test %d - %s: expected %+v, got %+v", i+1, testCase.name, expect, got)
+		}
+	}
+}
+
+func TestParseJSONPatchUnsupportedOp(t *testing.T) {
+	if _, err := parseJSONPatch([]byte(`[{"op": "merge", "path": "/a"}]`)); err == nil {
+		t.Errorf("expected an error for an unsupported operation")
+	}
+}
@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rules from k8s.io/apimachinery/pkg/util/validation. Importing apimachinery would pull in most of
+// its module and its dependencies for a few regular expressions, so they are copied here, and used
+// for the other resources checked against the same rules, such as the keys of Secrets.
+const (
+	qualifiedNameMaxLength   = 63
+	labelValueMaxLength      = 63
+	dns1123SubdomainMaxLen   = 253
+	totalAnnotationSizeLimit = 256 * 1024
+)
+
+var (
+	qualifiedNameRegexp    = regexp.MustCompile("^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$")
+	labelValueRegexp       = regexp.MustCompile("^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$")
+	dns1123SubdomainRegexp = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$")
+)
+
+// validateQualifiedName checks a label or annotation key, in the form [prefix/]name
+func validateQualifiedName(key string) error {
+	name := key
+	parts := strings.Split(key, "/")
+	switch len(parts) {
+	case 1:
+	case 2:
+		prefix := parts[0]
+		name = parts[1]
+		if len(prefix) == 0 {
+			return fmt.Errorf("prefix part must be non-empty")
+		}
+		if len(prefix) > dns1123SubdomainMaxLen {
+			return fmt.Errorf("prefix part must be no more than %d characters", dns1123SubdomainMaxLen)
+		}
+		if !dns1123SubdomainRegexp.MatchString(prefix) {
+			return fmt.Errorf("prefix part must be a lowercase RFC 1123 subdomain")
+		}
+	default:
+		return fmt.Errorf("must consist of an optional prefix and a name, separated by a '/'")
+	}
+
+	if len(name) == 0 {
+		return fmt.Errorf("name part must be non-empty")
+	}
+	if len(name) > qualifiedNameMaxLength {
+		return fmt.Errorf("name part must be no more than %d characters", qualifiedNameMaxLength)
+	}
+	if !qualifiedNameRegexp.MatchString(name) {
+		return fmt.Errorf("name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character")
+	}
+
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if len(value) > labelValueMaxLength {
+		return fmt.Errorf("must be no more than %d characters", labelValueMaxLength)
+	}
+	if !labelValueRegexp.MatchString(value) {
+		return fmt.Errorf("must be empty or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character")
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of m in a stable order, so errors are reproducible
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateLabelSyntax applies the Kubernetes syntax rules for label and annotation keys and values
+// to the metadata of a resource. These are enforced by the API server but not by the JSON schemas.
+func validateLabelSyntax(r map[string]interface{}) error {
+	metadata, ok := r["metadata"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+		for _, k := range sortedKeys(labels) {
+			if err := validateQualifiedName(k); err != nil {
+				return fmt.Errorf("invalid label key %q: %s", k, err)
+			}
+			v, ok := labels[k].(string)
+			if !ok {
+				continue // type errors are reported by the schema validation
+			}
+			if err := validateLabelValue(v); err != nil {
+				return fmt.Errorf("invalid value %q for label %s: %s", v, k, err)
+			}
+		}
+	}
+
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		totalSize := 0
+		for _, k := range sortedKeys(annotations) {
+			if err := validateQualifiedName(k); err != nil {
+				return fmt.Errorf("invalid annotation key %q: %s", k, err)
+			}
+			v, _ := annotations[k].(string)
+			totalSize += len(k) + len(v)
+		}
+		if totalSize > totalAnnotationSizeLimit {
+			return fmt.Errorf("annotations are too long: must have at most %d bytes", totalAnnotationSizeLimit)
+		}
+	}
+
+	return nil
+}
+
+// missingLabels returns the labels of required the metadata of a resource does not set, in order
+func missingLabels(r map[string]interface{}, required []string) []string {
+	labels := map[string]interface{}{}
+	if metadata, ok := r["metadata"].(map[string]interface{}); ok {
+		if l, ok := metadata["labels"].(map[string]interface{}); ok {
+			labels = l
+		}
+	}
+
+	missing := []string{}
+	for _, k := range required {
+		if _, ok := labels[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateLabelSyntax(t *testing.T) {
+	for i, testCase := range []struct {
+		name        string
+		rawResource string
+		expectErr   bool
+	}{
+		{
+			"no metadata",
+			"kind: ConfigMap",
+			false,
+		},
+		{
+			"valid labels and annotations",
+			`
+metadata:
+  labels:
+    app: my-app
+    app.kubernetes.io/name: my_app.1
+    empty: ""
+  annotations:
+    example.com/some-annotation: "any value / is fine"
+`,
+			false,
+		},
+		{
+			"label key with invalid characters",
+			`
+metadata:
+  labels:
+    my app: foo
+`,
+			true,
+		},
+		{
+			"label key with uppercase prefix",
+			`
+metadata:
+  labels:
+    Example.com/app: foo
+`,
+			true,
+		},
+		{
+			"label key with an empty prefix",
+			`
+metadata:
+  labels:
+    /app: foo
+`,
+			true,
+		},
+		{
+			"label key with too many slashes",
+			`
+metadata:
+  labels:
+    a/b/c: foo
+`,
+			true,
+		},
+		{
+			"label key name too long",
+			`
+metadata:
+  labels:
+    ` + strings.Repeat("a", 64) + `: foo
+`,
+			true,
+		},
+		{
+			"label value too long",
+			`
+metadata:
+  labels:
+    app: ` + strings.Repeat("a", 64) + `
+`,
+			true,
+		},
+		{
+			"label value ending with a dash",
+			`
+metadata:
+  labels:
+    app: foo-
+`,
+			true,
+		},
+		{
+			"invalid annotation key",
+			`
+metadata:
+  annotations:
+    -foo: bar
+`,
+			true,
+		},
+	} {
+		var r map[string]interface{}
+		if err := yaml.Unmarshal([]byte(testCase.rawResource), &r); err != nil {
+			t.Fatalf("test %d - %s: failed unmarshalling resource: %s", i+1, testCase.name, err)
+		}
+
+		err := validateLabelSyntax(r)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("test %d - %s: expected error %t, got %v", i+1, testCase.name, testCase.expectErr, err)
+		}
+	}
+}
+
+func TestValidateRequiredLabels(t *testing.T) {
+	for i, testCase := range []struct {
+		rawResource string
+		expect      Status
+		expectErr   string
+	}{
+		{"kind: ConfigMap\napiVersion: v1\nmetadata:\n  labels:\n    app: web\n    tier: backend\n", Valid, ""},
+		{"kind: ConfigMap\napiVersion: v1\nmetadata:\n  labels:\n    app: web\n", Error, "missing required labels: tier"},
+		{"kind: ConfigMap\napiVersion: v1\n", Error, "missing required labels: app, tier"},
+	} {
+		val, err := New(nil, Opts{SyntaxOnly: true, RequiredLabels: []string{"app", "tier"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected status %d, got %d", i, testCase.expect, got.Status)
+		}
+		if got.Err != nil && got.Err.Error() != testCase.expectErr {
+			t.Errorf("%d - expected error %q, got %q", i, testCase.expectErr, got.Err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"strings"
+)
+
+// clusterScopedKinds are the built-in Kubernetes kinds that are not namespaced, by API group and kind
+var clusterScopedKinds = map[string]struct{}{
+	"/ComponentStatus":  {},
+	"/Namespace":        {},
+	"/Node":             {},
+	"/PersistentVolume": {},
+	"admissionregistration.k8s.io/MutatingWebhookConfiguration":   {},
+	"admissionregistration.k8s.io/ValidatingWebhookConfiguration": {},
+	"apiextensions.k8s.io/CustomResourceDefinition":               {},
+	"apiregistration.k8s.io/APIService":                           {},
+	"authentication.k8s.io/TokenReview":                           {},
+	"authorization.k8s.io/SelfSubjectAccessReview":                {},
+	"authorization.k8s.io/SelfSubjectRulesReview":                 {},
+	"authorization.k8s.io/SubjectAccessReview":                    {},
+	"certificates.k8s.io/CertificateSigningRequest":               {},
+	"flowcontrol.apiserver.k8s.io/FlowSchema":                     {},
+	"flowcontrol.apiserver.k8s.io/PriorityLevelConfiguration":     {},
+	"networking.k8s.io/IngressClass":                              {},
+	"node.k8s.io/RuntimeClass":                                    {},
+	"policy/PodSecurityPolicy":                                    {},
+	"extensions/PodSecurityPolicy":                                {},
+	"rbac.authorization.k8s.io/ClusterRole":                       {},
+	"rbac.authorization.k8s.io/ClusterRoleBinding":                {},
+	"scheduling.k8s.io/PriorityClass":                             {},
+	"storage.k8s.io/CSIDriver":                                    {},
+	"storage.k8s.io/CSINode":                                      {},
+	"storage.k8s.io/StorageClass":                                 {},
+	"storage.k8s.io/VolumeAttachment":                             {},
+}
+
+// isClusterScoped returns true for built-in kinds that are not namespaced. Custom resources are assumed to be namespaced.
+func isClusterScoped(apiVersion, kind string) bool {
+	group := ""
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group = apiVersion[:i]
+	}
+
+	_, ok := clusterScopedKinds[group+"/"+kind]
+	return ok
+}
+
+// setDefaultNamespace sets the namespace of namespaced resources that do not have one,
+// as kubectl apply -n would
+func setDefaultNamespace(r map[string]interface{}, apiVersion, kind, namespace string) {
+	if isClusterScoped(apiVersion, kind) {
+		return
+	}
+
+	if r["metadata"] == nil {
+		r["metadata"] = map[string]interface{}{}
+	}
+	metadata, ok := r["metadata"].(map[string]interface{})
+	if !ok {
+		return // type errors are reported by the schema validation
+	}
+
+	switch ns := metadata["namespace"].(type) {
+	case nil:
+		metadata["namespace"] = namespace
+	case string:
+		if ns == "" {
+			metadata["namespace"] = namespace
+		}
+	}
+}
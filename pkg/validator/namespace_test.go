@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestSetDefaultNamespace(t *testing.T) {
+	for i, testCase := range []struct {
+		name        string
+		rawResource string
+		expect      string
+	}{
+		{
+			"namespaced resource without namespace",
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: foo\n",
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: foo\n  namespace: default-ns\n",
+		},
+		{
+			"namespaced resource without metadata",
+			"apiVersion: v1\nkind: ConfigMap\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  namespace: default-ns\n",
+		},
+		{
+			"namespaced resource with an empty namespace",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  namespace: \"\"\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  namespace: default-ns\n",
+		},
+		{
+			"namespaced resource with a namespace",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  namespace: other\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  namespace: other\n",
+		},
+		{
+			"custom resource",
+			"apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: foo\n",
+			"apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: foo\n  namespace: default-ns\n",
+		},
+		{
+			"core cluster-scoped resource",
+			"apiVersion: v1\nkind: Namespace\nmetadata:\n  name: foo\n",
+			"apiVersion: v1\nkind: Namespace\nmetadata:\n  name: foo\n",
+		},
+		{
+			"cluster-scoped resource in a group",
+			"apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: foo\n",
+			"apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: foo\n",
+		},
+		{
+			"kind with the name of a cluster-scoped kind in another group",
+			"apiVersion: example.com/v1\nkind: Node\n",
+			"apiVersion: example.com/v1\nkind: Node\nmetadata:\n  namespace: default-ns\n",
+		},
+		{
+			"invalid metadata is left to the schema validation",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata: foo\n",
+			"apiVersion: v1\nkind: ConfigMap\nmetadata: foo\n",
+		},
+	} {
+		r, expect := map[string]interface{}{}, map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(testCase.rawResource), &r); err != nil {
+			t.Fatalf("%d - failed parsing test resource: %s", i, err)
+		}
+		if err := yaml.Unmarshal([]byte(testCase.expect), &expect); err != nil {
+			t.Fatalf("%d - failed parsing expected resource: %s", i, err)
+		}
+
+		setDefaultNamespace(r, r["apiVersion"].(string), r["kind"].(string), "default-ns")
+		if !reflect.DeepEqual(r, expect) {
+			t.Errorf("%d - %s: expected %+v, got %+v", i, testCase.name, expect, r)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// schemaLocationsFor returns the schema locations that can serve a schema for a resource, starting with
+// the location the schema is used from: the first one returning a valid schema
+func schemaLocationsFor(ctx context.Context, regs []registry.Registry, locations []string, kind, version, k8sVersion string) []string {
+	winner, others := "", []string{}
+	for _, i := range queryOrder(ctx, regs, kind, version) {
+		reg := regs[i]
+		b, err := registry.DownloadSchemaWithContext(ctx, reg, kind, version, k8sVersion)
+		if err != nil {
+			continue
+		}
+		if winner == "" {
+			if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(b)); err == nil {
+				winner = locations[i]
+				// Other locations are not queried for kinds an authoritative location serves
+				if s, ok := registry.AsKindServer(reg); ok && s.ServesKind(ctx, kind, version) {
+					break
+				}
+				continue
+			}
+		}
+		others = append(others, locations[i])
+	}
+
+	if winner == "" {
+		return others
+	}
+	return append([]string{winner}, others...)
+}
+
+// warnSchemaOverrides logs a warning if the schema for a resource can be retrieved from more than one
+// schema location, to help find schema locations given in the wrong order. Each kind is only checked once.
+func (val *v) warnSchemaOverrides(ctx context.Context, sig *resource.Signature) {
+	if _, checked := val.overridesChecked.LoadOrStore(sig.Version+"/"+sig.Kind, true); checked {
+		return
+	}
+
+	locations := schemaLocationsFor(ctx, val.regs, val.locations, sig.Kind, sig.Version, val.opts.KubernetesVersion)
+	if len(locations) > 1 {
+		val.warnf(SchemaOverridden, "", "schema for %s %s found in %d schema locations, using %s - also found in %s", sig.Version, sig.Kind, len(locations), locations[0], strings.Join(locations[1:], ", "))
+	}
+}
@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestSchemaLocationsFor(t *testing.T) {
+	schema := func() ([]byte, error) { return []byte(`{"type": "object"}`), nil }
+	invalid := func() ([]byte, error) { return []byte(`<html>error page</html>`), nil }
+	missing := func() ([]byte, error) { return nil, nil }
+	missing2, _ := registry.New("/does-not-exist/{{ .ResourceKind }}.json", "", false, false)
+
+	for i, testCase := range []struct {
+		regs   []registry.Registry
+		expect []string
+	}{
+		{[]registry.Registry{newMockRegistry(schema)}, []string{"a"}},
+		{[]registry.Registry{newMockRegistry(schema), newMockRegistry(schema)}, []string{"a", "b"}},
+		{[]registry.Registry{newMockRegistry(invalid), newMockRegistry(schema)}, []string{"b", "a"}},
+		{[]registry.Registry{missing2, newMockRegistry(schema), newMockRegistry(missing)}, []string{"b", "c"}},
+		{[]registry.Registry{missing2}, []string{}},
+	} {
+		got := schemaLocationsFor(context.Background(), testCase.regs, []string{"a", "b", "c"}[:len(testCase.regs)], "Deployment", "apps/v1", "1.18.0")
+		if !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("%d - expected %v, got %v", i, testCase.expect, got)
+		}
+	}
+}
+
+func TestWarnSchemaOverrides(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	schema := func() ([]byte, error) { return []byte(`{"type": "object"}`), nil }
+	val := v{
+		opts: Opts{
+			SkipKinds:           map[string]struct{}{},
+			RejectKinds:         map[string]struct{}{},
+			WarnSchemaOverrides: true,
+		},
+		schemaDownload: newSchemaCompiler().downloadSchema,
+		regs:           []registry.Registry{newMockRegistry(schema), newMockRegistry(schema)},
+		locations:      []string{"./local", "default"},
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := val.ValidateResource(resource.Resource{Bytes: []byte("kind: Deployment\napiVersion: apps/v1\n")}); got.Status != Valid {
+			t.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+		}
+	}
+
+	if n := strings.Count(buf.String(), "warning:"); n != 1 {
+		t.Errorf("expected 1 warning, got %d: %s", n, buf.String())
+	}
+	if !strings.Contains(buf.String(), "schema for apps/v1 Deployment found in 2 schema locations, using ./local - also found in default") {
+		t.Errorf("unexpected warning: %s", buf.String())
+	}
+}
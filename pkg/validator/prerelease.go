@@ -0,0 +1,26 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+// prereleaseVersion matches alpha and beta versions of Kubernetes APIs, such as v1alpha1 or v2beta3
+var prereleaseVersion = regexp.MustCompile(`^v[0-9]+(alpha|beta)[0-9]*$`)
+
+// isPrerelease returns true if apiVersion is an alpha or beta version of an API, e.g. batch/v1beta1
+func isPrerelease(apiVersion string) bool {
+	return prereleaseVersion.MatchString(apiVersion[strings.LastIndex(apiVersion, "/")+1:])
+}
+
+// prereleaseAllowed returns true if the apiVersion or apiVersion/Kind of sig is part of AllowedPrereleaseAPIs
+func (val *v) prereleaseAllowed(sig *resource.Signature) bool {
+	for _, api := range val.opts.AllowedPrereleaseAPIs {
+		if api == sig.Version || api == sig.Version+"/"+sig.Kind {
+			return true
+		}
+	}
+	return false
+}
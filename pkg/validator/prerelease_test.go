@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestIsPrerelease(t *testing.T) {
+	for _, testCase := range []struct {
+		apiVersion string
+		expect     bool
+	}{
+		{"v1", false},
+		{"apps/v1", false},
+		{"batch/v1beta1", true},
+		{"autoscaling/v2beta2", true},
+		{"flowcontrol.apiserver.k8s.io/v1alpha1", true},
+		{"example.com/v1alpha", true},
+		{"example.com/betav1", false},
+		{"example.com/v1beta1-custom", false},
+	} {
+		if got := isPrerelease(testCase.apiVersion); got != testCase.expect {
+			t.Errorf("%s - expected %t, got %t", testCase.apiVersion, testCase.expect, got)
+		}
+	}
+}
+
+func TestValidateNoPrereleaseAPIs(t *testing.T) {
+	for i, testCase := range []struct {
+		rawResource string
+		allowed     []string
+		expect      Status
+	}{
+		{"apiVersion: apps/v1\nkind: Deployment\n", nil, Valid},
+		{"apiVersion: batch/v1beta1\nkind: CronJob\n", nil, Error},
+		{"apiVersion: batch/v1beta1\nkind: CronJob\n", []string{"batch/v1beta1"}, Valid},
+		{"apiVersion: batch/v1beta1\nkind: CronJob\n", []string{"batch/v1beta1/CronJob"}, Valid},
+		{"apiVersion: batch/v1beta1\nkind: Job\n", []string{"batch/v1beta1/CronJob"}, Error},
+	} {
+		val, err := New(nil, Opts{SyntaxOnly: true, NoPrereleaseAPIs: true, AllowedPrereleaseAPIs: testCase.allowed})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected status %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if got.Status == Error && got.Code != PrereleaseAPI {
+			t.Errorf("%d - expected code %q, got %q", i, PrereleaseAPI, got.Code)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+)
+
+// SchemaSource describes a schema used during validation, or a document referenced with $ref by
+// the schema at ReferencedBy
+type SchemaSource struct {
+	URL          string `json:"url"`
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	SHA256       string `json:"sha256"`
+	ReferencedBy string `json:"referencedBy,omitempty"`
+}
+
+// Provenance records the schemas used during validation, so that runs in different
+// environments can be checked to have used identical schemas
+type Provenance struct {
+	sync.Mutex
+	sources map[string]SchemaSource
+}
+
+// NewProvenance returns an empty record of schema sources
+func NewProvenance() *Provenance {
+	return &Provenance{
+		sources: map[string]SchemaSource{},
+	}
+}
+
+// add records the schema of kind retrieved from url, and the documents it references, by URL
+func (p *Provenance) add(url, apiVersion, kind string, schema []byte, references map[string][]byte) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.addSource(SchemaSource{URL: url, APIVersion: apiVersion, Kind: kind}, schema)
+	for refURL, b := range references {
+		p.addSource(SchemaSource{URL: refURL, APIVersion: apiVersion, Kind: kind, ReferencedBy: url}, b)
+	}
+}
+
+func (p *Provenance) addSource(s SchemaSource, b []byte) {
+	sum := sha256.Sum256(b)
+	s.SHA256 = hex.EncodeToString(sum[:])
+	p.sources[s.URL+"/"+s.APIVersion+"/"+s.Kind+"/"+s.SHA256+"/"+s.ReferencedBy] = s
+}
+
+// Sources returns the schemas and referenced documents recorded, sorted by URL, API version and kind
+func (p *Provenance) Sources() []SchemaSource {
+	p.Lock()
+	defer p.Unlock()
+
+	sources := []SchemaSource{}
+	for _, s := range p.sources {
+		sources = append(sources, s)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].URL != sources[j].URL {
+			return sources[i].URL < sources[j].URL
+		}
+		if sources[i].APIVersion != sources[j].APIVersion {
+			return sources[i].APIVersion < sources[j].APIVersion
+		}
+		if sources[i].Kind != sources[j].Kind {
+			return sources[i].Kind < sources[j].Kind
+		}
+		if sources[i].SHA256 != sources[j].SHA256 {
+			return sources[i].SHA256 < sources[j].SHA256
+		}
+		return sources[i].ReferencedBy < sources[j].ReferencedBy
+	})
+
+	return sources
+}
+
+// WriteJSON writes the schemas recorded to w as a JSON array
+func (p *Provenance) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(p.Sources(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,98 @@
+package validator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/cache"
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestProvenance(t *testing.T) {
+	schema := func() ([]byte, error) { return []byte(`{"type": "object"}`), nil }
+	missing, _ := registry.New("/does-not-exist/{{ .ResourceKind }}.json", "", false, false)
+
+	provenance := NewProvenance()
+	val := v{
+		opts: Opts{
+			SkipKinds:         map[string]struct{}{},
+			RejectKinds:       map[string]struct{}{},
+			KubernetesVersion: "1.18.0",
+			Strict:            true,
+			Provenance:        provenance,
+		},
+		schemaCache:    cache.NewInMemoryCache(),
+		schemaDownload: newSchemaCompiler().downloadSchema,
+		regs:           []registry.Registry{missing, newMockRegistry(schema)},
+		locations:      []string{"/does-not-exist/{{ .ResourceKind }}.json", "https://example.com/schemas"},
+	}
+
+	for _, r := range []string{
+		"kind: Deployment\napiVersion: apps/v1\n",
+		"kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: foo\n",
+		"kind: Service\napiVersion: v1\n",
+	} {
+		if got := val.ValidateResource(resource.Resource{Bytes: []byte(r)}); got.Status != Valid {
+			t.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+		}
+	}
+
+	sum := "ff419ebbeba438f66900abe77818ce940702bdfe70fa173cb94beecee8d3f112"
+	expect := []SchemaSource{
+		{URL: "https://example.com/schemas/v1.18.0-standalone-strict/deployment-apps-v1.json", APIVersion: "apps/v1", Kind: "Deployment", SHA256: sum},
+		{URL: "https://example.com/schemas/v1.18.0-standalone-strict/service-v1.json", APIVersion: "v1", Kind: "Service", SHA256: sum},
+	}
+	if got := provenance.Sources(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %+v, got %+v", expect, got)
+	}
+
+	var buf bytes.Buffer
+	if err := provenance.WriteJSON(&buf); err != nil {
+		t.Errorf("failed writing provenance: %s", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"sha256": "`+sum+`"`)) {
+		t.Errorf("expected checksum in output, got %s", buf.String())
+	}
+}
+
+func TestProvenanceReferences(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "widget.json"), []byte(widgetSchemaWithRefs), 0644)
+	os.WriteFile(filepath.Join(dir, "_definitions.json"), []byte(definitions), 0644)
+
+	provenance := NewProvenance()
+	val, err := New([]string{filepath.Join(dir, "{{ .ResourceKind }}.json")}, Opts{Provenance: provenance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []string{
+		"apiVersion: example.com/v1\nkind: Widget\nspec:\n  replicas: 2\n",
+		"apiVersion: example.com/v2\nkind: Widget\nspec:\n  replicas: 2\n",
+	} {
+		if got := val.ValidateResource(resource.Resource{Bytes: []byte(r)}); got.Status != Valid {
+			t.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+		}
+	}
+
+	widget := filepath.Join(dir, "widget.json")
+	refURL := "file://" + filepath.ToSlash(filepath.Join(dir, "_definitions.json"))
+	widgetSum := sha256.Sum256([]byte(widgetSchemaWithRefs))
+	refSum := sha256.Sum256([]byte(definitions))
+	expect := []SchemaSource{
+		{URL: widget, APIVersion: "example.com/v1", Kind: "Widget", SHA256: hex.EncodeToString(widgetSum[:])},
+		{URL: widget, APIVersion: "example.com/v2", Kind: "Widget", SHA256: hex.EncodeToString(widgetSum[:])},
+		{URL: refURL, APIVersion: "example.com/v1", Kind: "Widget", SHA256: hex.EncodeToString(refSum[:]), ReferencedBy: widget},
+		{URL: refURL, APIVersion: "example.com/v2", Kind: "Widget", SHA256: hex.EncodeToString(refSum[:]), ReferencedBy: widget},
+	}
+	sort.SliceStable(expect, func(i, j int) bool { return expect[i].URL < expect[j].URL })
+	if got := provenance.Sources(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %+v, got %+v", expect, got)
+	}
+}
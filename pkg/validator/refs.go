@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/xeipuuv/gojsonreference"
+	"github.com/xeipuuv/gojsonschema"
+	"github.com/yannh/kubeconform/pkg/registry"
+)
+
+// refLoader is a gojsonschema loader for a document at an absolute URL. The documents it references
+// with the same URL scheme are retrieved with the registry the schema comes from, others with the
+// default gojsonschema loader.
+type refLoader struct {
+	ctx    context.Context
+	c      *schemaCompiler
+	refs   registry.ReferenceLoader
+	source string
+	b      []byte        // content of the document, retrieved from source if nil
+	draft  string        // draft declared by the schema location, see toDraft7
+	scope  *dynamicScope // dynamic anchors of the documents loaded for the same schema
+
+	referenced map[string][]byte // documents loaded for the same schema, other than its own, by URL
+}
+
+func (l refLoader) JsonSource() interface{} {
+	return l.source
+}
+
+func (l refLoader) LoadJSON() (interface{}, error) {
+	url := strings.SplitN(l.source, "#", 2)[0]
+	b := l.b
+	if b == nil {
+		var err error
+		if b, err = l.c.loadReference(l.ctx, l.refs, url); err != nil {
+			return nil, err
+		}
+		l.referenced[url] = b
+	}
+
+	document, err := decodeJSON(b)
+	if err != nil {
+		return nil, err
+	}
+	draft := documentDraft(l.draft, document)
+	outer, err := l.scope.add(url, draft, document)
+	if err != nil {
+		return nil, err
+	}
+	if err := toDraft7(draft, document, outer); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// decodeJSON decodes a JSON document with numbers as json.Number, as gojsonschema expects
+func decodeJSON(b []byte) (interface{}, error) {
+	var document interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func (l refLoader) JsonReference() (gojsonreference.JsonReference, error) {
+	return gojsonreference.NewJsonReference(l.source)
+}
+
+func (l refLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return refLoaderFactory{l}
+}
+
+type refLoaderFactory struct {
+	root refLoader
+}
+
+func (f refLoaderFactory) New(source string) gojsonschema.JSONLoader {
+	scheme := f.root.source[:strings.Index(f.root.source, ":")+1]
+	if !strings.HasPrefix(source, scheme) {
+		return gojsonschema.NewReferenceLoader(source)
+	}
+
+	return refLoader{ctx: f.root.ctx, c: f.root.c, refs: f.root.refs, source: source, draft: f.root.draft, scope: f.root.scope, referenced: f.root.referenced}
+}
@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+const (
+	widgetSchemaWithRefs = `{"type": "object", "properties": {"spec": {"$ref": "_definitions.json#/definitions/spec"}}}`
+	definitions          = `{"definitions": {"spec": {"type": "object", "required": ["replicas"], "properties": {"replicas": {"type": "integer"}}}}}`
+)
+
+func TestValidateSchemaWithExternalRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconform-refs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "schemas"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "schemas", "widget.json"), []byte(widgetSchemaWithRefs), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "schemas", "_definitions.json"), []byte(definitions), 0644)
+
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		http.ServeFile(w, r, filepath.Join(dir, r.URL.Path))
+	}))
+	defer server.Close()
+
+	for _, location := range []string{
+		filepath.Join(dir, "schemas", "{{ .ResourceKind }}.json"),
+		server.URL + "/schemas/{{ .ResourceKind }}.json",
+	} {
+		val, err := New([]string{location}, Opts{})
+		if err != nil {
+			t.Fatalf("%s - failed creating validator: %s", location, err)
+		}
+
+		for _, testCase := range []struct {
+			rawResource string
+			expect      Status
+		}{
+			{"apiVersion: example.com/v1\nkind: Widget\nspec:\n  replicas: 2\n", Valid},
+			{"apiVersion: example.com/v1\nkind: Widget\nspec:\n  replicas: two\n", Invalid},
+			{"apiVersion: example.com/v1\nkind: Widget\nspec: {}\n", Invalid},
+		} {
+			if got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)}); got.Status != testCase.expect {
+				t.Errorf("%s - expected %d, got %d: %s", location, testCase.expect, got.Status, got.Err)
+			}
+		}
+	}
+
+	if requests["/schemas/widget.json"] != 1 {
+		t.Errorf("expected schema to be downloaded once, got %d requests", requests["/schemas/widget.json"])
+	}
+	if requests["/schemas/_definitions.json"] != 1 {
+		t.Errorf("expected referenced document to be downloaded once, got %d requests", requests["/schemas/_definitions.json"])
+	}
+}
+
+func TestAsReferenceLoader(t *testing.T) {
+	reg, _ := registry.New("./schemas/{{ .ResourceKind }}.json", "", false, false)
+	if _, ok := registry.AsReferenceLoader(tracedRegistry{registry.NewCircuitBreaker(reg, 1, 0), "./schemas"}); !ok {
+		t.Errorf("expected reference loader to be found behind wrapping registries")
+	}
+}
@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// SchemaLock pins the sha256 checksum of the schema of each kind, by apiVersion/Kind - followed by
+// " (strict)" for strict schemas - and of the documents schemas reference, by URL, so that validation
+// only uses schemas that were reviewed. When updating, the checksums of the schemas used are recorded
+// instead of being checked.
+type SchemaLock struct {
+	sync.Mutex
+	checksums map[string]string
+	update    bool
+}
+
+// NewSchemaLock returns an empty schema lock, recording the checksums of the schemas used
+func NewSchemaLock() *SchemaLock {
+	return &SchemaLock{
+		checksums: map[string]string{},
+		update:    true,
+	}
+}
+
+// ReadSchemaLock reads a schema lock written with WriteJSON, to check schemas against
+func ReadSchemaLock(r io.Reader) (*SchemaLock, error) {
+	checksums := map[string]string{}
+	if err := json.NewDecoder(r).Decode(&checksums); err != nil {
+		return nil, err
+	}
+
+	return &SchemaLock{
+		checksums: checksums,
+	}, nil
+}
+
+// schemaLockKey returns the key of the schema of kind in a schema lock. Strict and non-strict schemas
+// differ, and are both used with StrictDiff.
+func schemaLockKey(apiVersion, kind string, strict bool) string {
+	key := apiVersion + "/" + kind
+	if strict {
+		key += " (strict)"
+	}
+	return key
+}
+
+// check checks the schema at key, and the documents it references, against the lock
+func (l *SchemaLock) check(key string, schema []byte, references map[string][]byte) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if err := l.checkDocument(key, schema); err != nil {
+		return err
+	}
+
+	urls := make([]string, 0, len(references))
+	for url := range references {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		if err := l.checkDocument(url, references[url]); err != nil {
+			return fmt.Errorf("%s, referenced by the schema for %s", err, key)
+		}
+	}
+
+	return nil
+}
+
+func (l *SchemaLock) checkDocument(key string, b []byte) error {
+	sum := sha256.Sum256(b)
+	checksum := hex.EncodeToString(sum[:])
+
+	if l.update {
+		l.checksums[key] = checksum
+		return nil
+	}
+
+	expected, ok := l.checksums[key]
+	if !ok {
+		return fmt.Errorf("no checksum for the schema of %s in schema lock", key)
+	}
+	if expected != checksum {
+		return fmt.Errorf("schema for %s has checksum %s, expected %s by schema lock", key, checksum, expected)
+	}
+
+	return nil
+}
+
+// WriteJSON writes the checksums of the lock to w, as a JSON object with sorted keys
+func (l *SchemaLock) WriteJSON(w io.Writer) error {
+	l.Lock()
+	defer l.Unlock()
+
+	b, err := json.MarshalIndent(l.checksums, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
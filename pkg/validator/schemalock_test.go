@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/cache"
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestSchemaLock(t *testing.T) {
+	schema := []byte(`{"type": "object"}`)
+	sum := "ff419ebbeba438f66900abe77818ce940702bdfe70fa173cb94beecee8d3f112"
+	rawResource := []byte("kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: foo\n")
+
+	newValidator := func(lock *SchemaLock) v {
+		return v{
+			opts: Opts{
+				SkipKinds:              map[string]struct{}{},
+				RejectKinds:            map[string]struct{}{},
+				SchemaLock:             lock,
+				TolerateDownloadErrors: true,
+			},
+			schemaCache:    cache.NewInMemoryCache(),
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{newMockRegistry(func() ([]byte, error) {
+				return schema, nil
+			})},
+		}
+	}
+
+	update := NewSchemaLock()
+	val := newValidator(update)
+	if got := val.ValidateResource(resource.Resource{Bytes: rawResource}); got.Status != Valid {
+		t.Errorf("expected resource to be valid when updating the lock, got %d: %s", got.Status, got.Err)
+	}
+	var buf bytes.Buffer
+	if err := update.WriteJSON(&buf); err != nil {
+		t.Errorf("failed writing schema lock: %s", err)
+	}
+	if expect := "{\n  \"apps/v1/Deployment\": \"" + sum + "\"\n}\n"; buf.String() != expect {
+		t.Errorf("expected lock %s, got %s", expect, buf.String())
+	}
+
+	for _, testCase := range []struct {
+		lock   string
+		expect Status
+	}{
+		{`{"apps/v1/Deployment": "` + sum + `"}`, Valid},
+		{`{"apps/v1/Deployment": "0000"}`, Error},
+		{`{"v1/Service": "` + sum + `"}`, Error},
+	} {
+		lock, err := ReadSchemaLock(strings.NewReader(testCase.lock))
+		if err != nil {
+			t.Errorf("failed reading lock %s: %s", testCase.lock, err)
+			continue
+		}
+		val := newValidator(lock)
+		for i := 0; i < 2; i++ { // the second validation uses the cached schema
+			if got := val.ValidateResource(resource.Resource{Bytes: rawResource}); got.Status != testCase.expect {
+				t.Errorf("lock %s: expected status %d, got %d: %s", testCase.lock, testCase.expect, got.Status, got.Err)
+			}
+		}
+	}
+}
+
+func TestSchemaLockStrictDiffAndReferences(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "widget.json"), []byte(widgetSchemaWithRefs), 0644)
+	os.WriteFile(filepath.Join(dir, "widget-strict.json"), []byte(`{"type": "object", "properties": {"apiVersion": {}, "kind": {}, "spec": {"$ref": "_definitions.json#/definitions/spec"}}, "additionalProperties": false}`), 0644)
+	os.WriteFile(filepath.Join(dir, "_definitions.json"), []byte(definitions), 0644)
+	// The strict schema rejects status, so that the non-strict schema is looked up as well
+	rawResource := []byte("apiVersion: example.com/v1\nkind: Widget\nspec:\n  replicas: 2\nstatus: {}\n")
+
+	validate := func(lock *SchemaLock) Result {
+		val, err := New([]string{filepath.Join(dir, "{{ .ResourceKind }}{{ .StrictSuffix }}.json")}, Opts{Strict: true, StrictDiff: true, SchemaLock: lock})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return val.ValidateResource(resource.Resource{Bytes: rawResource})
+	}
+
+	update := NewSchemaLock()
+	if got := validate(update); got.Status != Invalid {
+		t.Errorf("expected resource to be invalid when updating the lock, got %d: %s", got.Status, got.Err)
+	}
+	keys := []string{}
+	for key := range update.checksums {
+		keys = append(keys, strings.TrimPrefix(key, "file://"+filepath.ToSlash(dir)+"/"))
+	}
+	sort.Strings(keys)
+	if expect := []string{"_definitions.json", "example.com/v1/Widget", "example.com/v1/Widget (strict)"}; !reflect.DeepEqual(keys, expect) {
+		t.Errorf("expected lock keys %v, got %v", expect, keys)
+	}
+	var buf bytes.Buffer
+	update.WriteJSON(&buf)
+
+	for i, testCase := range []struct {
+		remove    string
+		change    string
+		expect    Status
+		expectErr string
+	}{
+		{"", "", Invalid, "Additional property status is not allowed"},
+		{"example.com/v1/Widget", "", Error, "no checksum for the schema of example.com/v1/Widget in schema lock"},
+		{"example.com/v1/Widget (strict)", "", Error, "no checksum for the schema of example.com/v1/Widget (strict) in schema lock"},
+		{"", `{"definitions": {"spec": {"type": "object"}}}`, Error, "referenced by the schema for example.com/v1/Widget (strict)"},
+	} {
+		lock, err := ReadSchemaLock(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		delete(lock.checksums, testCase.remove)
+		if testCase.change != "" {
+			os.WriteFile(filepath.Join(dir, "_definitions.json"), []byte(testCase.change), 0644)
+		}
+
+		got := validate(lock)
+		if got.Status != testCase.expect || got.Err == nil || !strings.Contains(got.Err.Error(), testCase.expectErr) {
+			t.Errorf("test %d: expected status %d and error containing %s, got %d: %s", i+1, testCase.expect, testCase.expectErr, got.Status, got.Err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package validator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxSchemaRefDepth bounds the number of $refs followed when walking a schema, so that recursive
+// schemas do not loop
+const maxSchemaRefDepth = 32
+
+// walkSchema calls fn with each value of a resource and each subschema describing it, after walking
+// the values it contains. Subschemas are looked up in properties, additionalProperties, items and
+// allOf; anyOf and oneOf are ignored, as which subschema applies can not be known before validation.
+// $refs are followed when local to root, the document schema is part of. path is the path of value
+// in the resource, in the format of validation errors, e.g. spec.containers.0.image.
+func walkSchema(root, schema, value interface{}, path string, depth int, fn func(s map[string]interface{}, value interface{}, path string)) {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxSchemaRefDepth || !strings.HasPrefix(ref, "#") {
+			return
+		}
+		if target, ok := resolveLocalRef(root, ref[1:]); ok {
+			walkSchema(root, target, value, path, depth+1, fn)
+		}
+		return
+	}
+
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			walkSchema(root, sub, value, path, depth, fn)
+		}
+	}
+
+	switch t := value.(type) {
+	case map[string]interface{}:
+		properties, _ := s["properties"].(map[string]interface{})
+		additional, _ := s["additionalProperties"].(map[string]interface{})
+		for name, v := range t {
+			if propertySchema, ok := properties[name]; ok {
+				walkSchema(root, propertySchema, v, childPath(path, name), depth, fn)
+			} else if additional != nil {
+				walkSchema(root, additional, v, childPath(path, name), depth, fn)
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, v := range t {
+				walkSchema(root, items, v, childPath(path, strconv.Itoa(i)), depth, fn)
+			}
+		}
+	}
+
+	fn(s, value, path)
+}
+
+func childPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// resolveLocalRef returns the subschema of root at the JSON pointer, e.g. /definitions/io.k8s.api.core.v1.Pod
+func resolveLocalRef(root interface{}, pointer string) (interface{}, bool) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, false
+	}
+
+	current := root
+	for _, token := range tokens {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretKeyRegexp is IsConfigMapKey from k8s.io/apimachinery/pkg/util/validation, see labels.go
+var secretKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// validateSecretKey checks a key of the data or stringData of a Secret, which becomes a file name when mounted
+func validateSecretKey(key string) error {
+	switch {
+	case len(key) > dns1123SubdomainMaxLen:
+		return fmt.Errorf("must be no more than %d characters", dns1123SubdomainMaxLen)
+	case !secretKeyRegexp.MatchString(key):
+		return fmt.Errorf("must consist of alphanumeric characters, '-', '_' or '.'")
+	case key == ".", key == "..":
+		return fmt.Errorf("must not be '%s'", key)
+	case strings.HasPrefix(key, ".."):
+		return fmt.Errorf("must not start with '..'")
+	}
+
+	return nil
+}
+
+// validateSecret checks that the keys of the data and stringData of a Secret are valid,
+// and that data values are base64-encoded
+func validateSecret(r map[string]interface{}) error {
+	for _, field := range []string{"data", "stringData"} {
+		entries, ok := r[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if err := validateSecretKey(k); err != nil {
+				return fmt.Errorf("invalid key %q in %s: %s", k, field, err)
+			}
+			if field != "data" {
+				continue
+			}
+			value, ok := entries[k].(string)
+			if !ok {
+				continue // type errors are reported by the schema validation
+			}
+			if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+				return fmt.Errorf("invalid value for key %q in data: not valid base64", k)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateSecretKey(t *testing.T) {
+	for i, testCase := range []struct {
+		key       string
+		expectErr string
+	}{
+		{"password", ""},
+		{"tls.crt", ""},
+		{".dockerconfigjson", ""},
+		{"SOME_ENV-var", ""},
+		{"", "must consist of alphanumeric characters, '-', '_' or '.'"},
+		{"my key", "must consist of alphanumeric characters, '-', '_' or '.'"},
+		{"config/app.yaml", "must consist of alphanumeric characters, '-', '_' or '.'"},
+		{".", "must not be '.'"},
+		{"..", "must not be '..'"},
+		{"..data", "must not start with '..'"},
+		{strings.Repeat("a", 254), "must be no more than 253 characters"},
+	} {
+		err := validateSecretKey(testCase.key)
+		if testCase.expectErr == "" && err != nil {
+			t.Errorf("%d - expected %q to be valid, got %s", i, testCase.key, err)
+		}
+		if testCase.expectErr != "" && (err == nil || err.Error() != testCase.expectErr) {
+			t.Errorf("%d - expected error %q for %q, got %v", i, testCase.expectErr, testCase.key, err)
+		}
+	}
+}
+
+func TestValidateSecret(t *testing.T) {
+	for i, testCase := range []struct {
+		name        string
+		rawResource string
+		expectErr   string
+	}{
+		{
+			"valid secret",
+			`
+kind: Secret
+data:
+  username: YWRtaW4=
+  tls.crt: ""
+stringData:
+  password: not base64!
+`,
+			"",
+		},
+		{
+			"invalid base64 in data",
+			`
+kind: Secret
+data:
+  username: YWRtaW4=
+  password: not base64!
+`,
+			`invalid value for key "password" in data: not valid base64`,
+		},
+		{
+			"invalid key in stringData",
+			`
+kind: Secret
+stringData:
+  config/app.yaml: foo
+`,
+			`invalid key "config/app.yaml" in stringData: must consist of alphanumeric characters, '-', '_' or '.'`,
+		},
+		{
+			"type errors are left to the schema validation",
+			`
+kind: Secret
+data:
+  count: 1
+stringData: []
+`,
+			"",
+		},
+	} {
+		r := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(testCase.rawResource), &r); err != nil {
+			t.Fatalf("%d - failed parsing test resource: %s", i, err)
+		}
+		err := validateSecret(r)
+		if testCase.expectErr == "" && err != nil {
+			t.Errorf("%d - %s: expected no error, got %s", i, testCase.name, err)
+		}
+		if testCase.expectErr != "" && (err == nil || err.Error() != testCase.expectErr) {
+			t.Errorf("%d - %s: expected error %s, got %v", i, testCase.name, testCase.expectErr, err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultSizeLimit is the maximum size in bytes of a request to etcd, that objects stored by the
+// API server must fit in
+const DefaultSizeLimit = 1572864
+
+// validateSize returns an error if the resource is bigger than limit bytes, serialised as JSON as it
+// is sent to the API server
+func validateSize(r map[string]interface{}, limit int64) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if size := int64(len(b)); size > limit {
+		return fmt.Errorf("resource is %d bytes serialised as JSON, over the size limit of %d bytes", size, limit)
+	}
+
+	return nil
+}
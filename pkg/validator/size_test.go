@@ -0,0 +1,35 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestValidateSizeLimit(t *testing.T) {
+	for i, testCase := range []struct {
+		data      string
+		sizeLimit int64
+		expect    Status
+	}{
+		{"small", 0, Valid},
+		{strings.Repeat("a", 2*1024*1024), 0, Error},
+		{strings.Repeat("a", 1024), 1024, Error},
+		{strings.Repeat("a", 1024), 2048, Valid},
+	} {
+		val, err := New(nil, Opts{SyntaxOnly: true, CheckSizeLimit: true, SizeLimit: testCase.sizeLimit})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rawResource := "kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: big\ndata:\n  key: " + testCase.data + "\n"
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected status %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if got.Status == Error && got.Code != ConstraintViolation {
+			t.Errorf("%d - expected code %q, got %q", i, ConstraintViolation, got.Code)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// liveObjects are objects as stored by the API server, by apiVersion, kind, namespace and name. Manifests
+// are merged into them as server-side apply would before being validated.
+type liveObjects map[resource.Signature]map[string]interface{}
+
+// loadLiveObjects reads live objects from the files and folders at path, e.g. the output of kubectl get -o yaml
+func loadLiveObjects(path string) (liveObjects, error) {
+	resources, errs := resource.FromFiles(context.Background(), []string{path}, nil)
+
+	var readErr error
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		for err := range errs {
+			if de, ok := err.(resource.DiscoveryError); (!ok || !de.Duplicate()) && readErr == nil {
+				readErr = err
+			}
+		}
+		wg.Done()
+	}()
+
+	objects := liveObjects{}
+	for res := range resources {
+		if len(res.Bytes) == 0 {
+			continue
+		}
+		sig, err := res.Signature()
+		if err != nil {
+			if readErr == nil {
+				readErr = fmt.Errorf("%s: %s", res.Path, err)
+			}
+			continue
+		}
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal(res.Bytes, &obj); err != nil || obj == nil {
+			continue
+		}
+		objects[resource.Signature{Kind: sig.Kind, Version: sig.Version, Namespace: sig.Namespace, Name: sig.Name}] = obj
+	}
+	wg.Wait()
+
+	if readErr != nil {
+		return nil, fmt.Errorf("failed reading live objects: %s", readErr)
+	}
+
+	return objects, nil
+}
+
+// find returns the live object for a resource. Resources without a namespace are looked up in the
+// default namespace if they are not found without one.
+func (l liveObjects) find(r map[string]interface{}, sig *resource.Signature) (map[string]interface{}, bool) {
+	namespace := ""
+	if metadata, ok := r["metadata"].(map[string]interface{}); ok {
+		namespace, _ = metadata["namespace"].(string)
+	}
+
+	key := resource.Signature{Kind: sig.Kind, Version: sig.Version, Namespace: namespace, Name: sig.Name}
+	if obj, ok := l[key]; ok {
+		return obj, true
+	}
+	if namespace == "" {
+		key.Namespace = "default"
+		obj, ok := l[key]
+		return obj, ok
+	}
+
+	return nil, false
+}
+
+// applyTo returns the object server-side apply would store when applying r to the live object: maps are
+// merged, lists of objects with a name are merged by name, other values and lists are replaced, and
+// fields set to null are removed. The live object is not modified.
+func applyTo(live, r map[string]interface{}) map[string]interface{} {
+	merged, _ := deepCopy(live).(map[string]interface{})
+	return mergeMaps(merged, r)
+}
+
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		dst[k] = mergeValues(dst[k], v)
+	}
+	return dst
+}
+
+func mergeValues(dst, src interface{}) interface{} {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		if d, ok := dst.(map[string]interface{}); ok {
+			return mergeMaps(d, s)
+		}
+	case []interface{}:
+		if d, ok := dst.([]interface{}); ok && namedItems(d) && namedItems(s) {
+			return mergeNamedLists(d, s)
+		}
+	}
+	return deepCopy(src)
+}
+
+// namedItems returns true for lists of objects with a name, such as containers or env, which
+// Kubernetes merges by name
+func namedItems(l []interface{}) bool {
+	for _, item := range l {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeNamedLists(dst, src []interface{}) []interface{} {
+	index := map[string]int{}
+	for i, item := range dst {
+		index[item.(map[string]interface{})["name"].(string)] = i
+	}
+
+	for _, item := range src {
+		m := item.(map[string]interface{})
+		if i, ok := index[m["name"].(string)]; ok {
+			dst[i] = mergeMaps(dst[i].(map[string]interface{}), m)
+			continue
+		}
+		dst = append(dst, deepCopy(m))
+	}
+
+	return dst
+}
@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplyTo(t *testing.T) {
+	for i, testCase := range []struct {
+		live, applied, expect string
+	}{
+		{
+			"spec: {replicas: 3, paused: true}",
+			"spec: {replicas: 1}",
+			"spec: {replicas: 1, paused: true}",
+		},
+		{
+			"spec: {containers: [{name: app, image: app:1, ports: [{containerPort: 80}]}, {name: sidecar, image: proxy}]}",
+			"spec: {containers: [{name: app, image: app:2}, {name: init, image: busybox}]}",
+			"spec: {containers: [{name: app, image: app:2, ports: [{containerPort: 80}]}, {name: sidecar, image: proxy}, {name: init, image: busybox}]}",
+		},
+		{
+			"spec: {args: [a, b]}",
+			"spec: {args: [c]}",
+			"spec: {args: [c]}",
+		},
+		{
+			"spec: {replicas: 3, paused: true}",
+			"spec: {paused: null}",
+			"spec: {replicas: 3}",
+		},
+		{
+			"spec: {selector: {app: a}}",
+			"spec: {selector: [a]}",
+			"spec: {selector: [a]}",
+		},
+	} {
+		var live, applied, expect map[string]interface{}
+		yaml.Unmarshal([]byte(testCase.live), &live)
+		yaml.Unmarshal([]byte(testCase.applied), &applied)
+		yaml.Unmarshal([]byte(testCase.expect), &expect)
+
+		liveCopy := deepCopy(live)
+		if got := applyTo(live, applied); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%d - expected %+v, got %+v", i, expect, got)
+		}
+		if !reflect.DeepEqual(live, liveCopy) {
+			t.Errorf("%d - expected the live object not to be modified, got %+v", i, live)
+		}
+	}
+}
+
+func TestValidateSSABase(t *testing.T) {
+	dir := t.TempDir()
+	live := `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata: {name: web, namespace: default}
+  spec: {replicas: 2, strategy: {type: Recreate}}
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata: {name: api, namespace: prod}
+  spec: {replicas: 2, strategy: {type: Recreate}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "live.yaml"), []byte(live), 0644); err != nil {
+		t.Fatalf("failed writing live objects: %s", err)
+	}
+
+	// A Recreate strategy can not have rollingUpdate parameters
+	schema := `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "properties": {"replicas": {"type": "integer"}},
+      "not": {"required": ["strategy"], "properties": {"strategy": {"required": ["type", "rollingUpdate"], "properties": {"type": {"const": "Recreate"}}}}}
+    }
+  }
+}`
+
+	for i, testCase := range []struct {
+		rawResource string
+		expect      Status
+	}{
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\nspec: {strategy: {rollingUpdate: {maxSurge: 1}}}\n", Invalid},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\nspec: {strategy: {type: RollingUpdate, rollingUpdate: {maxSurge: 1}}}\n", Valid},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: api, namespace: prod}\nspec: {strategy: {rollingUpdate: {maxSurge: 1}}}\n", Invalid},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: api}\nspec: {strategy: {rollingUpdate: {maxSurge: 1}}}\n", Valid},
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: new}\nspec: {strategy: {rollingUpdate: {maxSurge: 1}}}\n", Valid},
+	} {
+		objects, err := loadLiveObjects(filepath.Join(dir, "live.yaml"))
+		if err != nil {
+			t.Fatalf("%d - failed loading live objects: %s", i, err)
+		}
+		val := v{
+			opts:           Opts{SkipKinds: map[string]struct{}{}, RejectKinds: map[string]struct{}{}},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{newMockRegistry(func() ([]byte, error) {
+				return []byte(schema), nil
+			})},
+			live: objects,
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)}); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+
+	if _, err := New(nil, Opts{SSABase: filepath.Join(dir, "does-not-exist.yaml")}); err == nil {
+		t.Errorf("expected an error for missing live objects")
+	}
+}
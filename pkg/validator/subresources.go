@@ -0,0 +1,23 @@
+package validator
+
+import (
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+// subresourceKinds are kinds used to interact with subresources of other resources, such as
+// deployments/scale or pods/eviction. They have no schema in most schema registries, so resources
+// of these kinds are skipped instead of failing when their schema can not be found.
+var subresourceKinds = map[string]struct{}{
+	"autoscaling/v1/Scale":                  {},
+	"apps/v1beta1/Scale":                    {},
+	"apps/v1beta2/Scale":                    {},
+	"extensions/v1beta1/Scale":              {},
+	"policy/v1/Eviction":                    {},
+	"policy/v1beta1/Eviction":               {},
+	"authentication.k8s.io/v1/TokenRequest": {},
+}
+
+func isSubresource(sig *resource.Signature) bool {
+	_, ok := subresourceKinds[sig.Version+"/"+sig.Kind]
+	return ok
+}
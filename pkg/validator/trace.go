@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"context"
+	"log"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+)
+
+// debugf logs a step of the validation of a resource, if Debug is set
+func (val *v) debugf(format string, a ...interface{}) {
+	if val.opts.Debug {
+		log.Printf("debug: "+format, a...)
+	}
+}
+
+// tracedRegistry logs the outcome of every schema download from a registry
+type tracedRegistry struct {
+	registry.Registry
+	location string
+}
+
+func (r tracedRegistry) DownloadSchemaWithContext(ctx context.Context, resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	b, err := registry.DownloadSchemaWithContext(ctx, r.Registry, resourceKind, resourceAPIVersion, k8sVersion)
+	switch err.(type) {
+	case nil:
+		log.Printf("debug: %s/%s - schema found in %s", resourceAPIVersion, resourceKind, r.location)
+	case *registry.NotFoundError:
+		log.Printf("debug: %s/%s - schema not found in %s", resourceAPIVersion, resourceKind, r.location)
+	case *registry.UnavailableError:
+		log.Printf("debug: %s/%s - skipped unavailable schema location %s", resourceAPIVersion, resourceKind, r.location)
+	default:
+		log.Printf("debug: %s/%s - failed querying %s: %s", resourceAPIVersion, resourceKind, r.location, err)
+	}
+
+	return b, err
+}
+
+func (r tracedRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersion string) ([]byte, error) {
+	return r.DownloadSchemaWithContext(context.Background(), resourceKind, resourceAPIVersion, k8sVersion)
+}
+
+// Unwrap returns the traced registry
+func (r tracedRegistry) Unwrap() registry.Registry {
+	return r.Registry
+}
@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/cache"
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestValidateDebug(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	val := v{
+		opts: Opts{
+			SkipKinds:   map[string]struct{}{},
+			RejectKinds: map[string]struct{}{},
+			Debug:       true,
+		},
+		schemaCache:    cache.NewInMemoryCache(),
+		schemaDownload: newSchemaCompiler().downloadSchema,
+		regs: []registry.Registry{
+			tracedRegistry{newMockRegistry(func() ([]byte, error) { return nil, fmt.Errorf("connection refused") }), "https://unreachable.example.com"},
+		},
+	}
+	res := resource.Resource{Path: "deployment.yaml", Bytes: []byte("kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: web\n")}
+	val.ValidateResource(res)
+
+	for _, expected := range []string{
+		"debug: deployment.yaml - document 0: found Deployment web, apiVersion apps/v1",
+		"debug: apps/v1/Deployment - schema not in memory cache, querying schema locations",
+		"debug: apps/v1/Deployment - failed querying https://unreachable.example.com: connection refused",
+		"debug: deployment.yaml - document 0: error: connection refused",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected log to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	val.opts.Debug = false
+	val.regs = []registry.Registry{newMockRegistry(func() ([]byte, error) { return nil, fmt.Errorf("connection refused") })}
+	val.ValidateResource(res)
+	if strings.Contains(buf.String(), "debug:") {
+		t.Errorf("expected no debug logs, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// UnknownKind is an API version and kind for which no schema could be found
+type UnknownKind struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// UnknownKinds records the distinct kinds for which no schema could be found during validation,
+// e.g. to track the schemas that still need to be added to a registry
+type UnknownKinds struct {
+	sync.Mutex
+	kinds map[UnknownKind]struct{}
+}
+
+// NewUnknownKinds returns an empty record of unknown kinds
+func NewUnknownKinds() *UnknownKinds {
+	return &UnknownKinds{
+		kinds: map[UnknownKind]struct{}{},
+	}
+}
+
+func (u *UnknownKinds) add(apiVersion, kind string) {
+	u.Lock()
+	defer u.Unlock()
+	u.kinds[UnknownKind{APIVersion: apiVersion, Kind: kind}] = struct{}{}
+}
+
+// Kinds returns the kinds recorded, sorted by API version and kind
+func (u *UnknownKinds) Kinds() []UnknownKind {
+	u.Lock()
+	defer u.Unlock()
+
+	kinds := []UnknownKind{}
+	for k := range u.kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].APIVersion != kinds[j].APIVersion {
+			return kinds[i].APIVersion < kinds[j].APIVersion
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+
+	return kinds
+}
+
+// WriteJSON writes the kinds recorded to w as a JSON array
+func (u *UnknownKinds) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(u.Kinds(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// WriteYAML writes the kinds recorded to w as a YAML list
+func (u *UnknownKinds) WriteYAML(w io.Writer) error {
+	b, err := yaml.Marshal(u.Kinds())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
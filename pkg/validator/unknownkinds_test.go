@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestUnknownKinds(t *testing.T) {
+	missing, _ := registry.New("/does-not-exist/{{ .ResourceKind }}.json", "", false, false)
+	unknownKinds := NewUnknownKinds()
+	val := v{
+		opts: Opts{
+			SkipKinds:            map[string]struct{}{},
+			RejectKinds:          map[string]struct{}{},
+			IgnoreMissingSchemas: true,
+			UnknownKinds:         unknownKinds,
+		},
+		schemaDownload: newSchemaCompiler().downloadSchema,
+		regs:           []registry.Registry{missing},
+	}
+
+	for _, r := range []string{
+		"kind: Widget\napiVersion: example.com/v1\nmetadata:\n  name: a\n",
+		"kind: Widget\napiVersion: example.com/v1\nmetadata:\n  name: b\n",
+		"kind: Gadget\napiVersion: example.com/v1\nmetadata:\n  name: c\n",
+		"kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: d\n",
+		"kind: Scale\napiVersion: autoscaling/v1\nmetadata:\n  name: e\n",
+	} {
+		if got := val.ValidateResource(resource.Resource{Bytes: []byte(r)}); got.Status != Skipped {
+			t.Errorf("expected resource to be skipped, got %d: %s", got.Status, got.Err)
+		}
+	}
+
+	expect := []UnknownKind{
+		{APIVersion: "apps/v1", Kind: "Deployment"},
+		{APIVersion: "example.com/v1", Kind: "Gadget"},
+		{APIVersion: "example.com/v1", Kind: "Widget"},
+	}
+	if got := unknownKinds.Kinds(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %+v, got %+v", expect, got)
+	}
+
+	var buf bytes.Buffer
+	if err := unknownKinds.WriteYAML(&buf); err != nil {
+		t.Errorf("failed writing unknown kinds: %s", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("- apiVersion: apps/v1\n  kind: Deployment\n")) {
+		t.Errorf("unexpected YAML output: %s", buf.String())
+	}
+}
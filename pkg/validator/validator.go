@@ -5,6 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/yannh/kubeconform/pkg/cache"
 	"github.com/yannh/kubeconform/pkg/registry"
@@ -23,14 +28,56 @@ const (
 	Skipped        // resource has been skipped, for example if its Kind was part of the kinds to skip
 	Valid          // resource is valid
 	Invalid        // resource is invalid
-	Empty          // resource is empty. Note: is triggered for files starting with a --- separator.
+	Empty          // resource is empty. Note: is triggered for files only containing blank documents.
+)
+
+// String returns a lowercase name for a validation status, e.g. for machine-readable formats
+func (s Status) String() string {
+	switch s {
+	case Valid:
+		return "valid"
+	case Invalid:
+		return "invalid"
+	case Error:
+		return "error"
+	case Skipped:
+		return "skipped"
+	case Empty:
+		return "empty"
+	}
+
+	return ""
+}
+
+// ErrorCode categorises the reason a resource failed validation
+type ErrorCode string
+
+const (
+	ParseError           ErrorCode = "parse-error"           // the resource could not be parsed, or is missing its kind or apiVersion
+	SchemaNotFound       ErrorCode = "schema-not-found"      // no schema could be found for the resource
+	SchemaDownloadError  ErrorCode = "schema-download-error" // a schema registry failed to return a schema
+	SchemaInvalid        ErrorCode = "schema-invalid"        // the schema found for the resource could not be compiled
+	ConstraintViolation  ErrorCode = "constraint-violation"  // the resource does not conform to its schema, or to a built-in check
+	RejectedKind         ErrorCode = "rejected-kind"         // the resource kind is part of the kinds to reject
+	PatchError           ErrorCode = "patch-error"           // a JSON Patch could not be applied to the resource
+	PrereleaseAPI        ErrorCode = "prerelease-api"        // the resource uses an alpha or beta apiVersion, with NoPrereleaseAPIs
+	DisallowedAPIVersion ErrorCode = "disallowed-apiversion" // the resource uses an apiVersion not allowed for its kind by AllowedAPIVersions
+	BrokenReference      ErrorCode = "broken-reference"      // the resource references resources that were not found, with CrossResourceChecks
+	ServerRejected       ErrorCode = "server-rejected"       // the cluster rejected the resource submitted with ServerDryRun, e.g. an admission webhook denied it
+	DryRunError          ErrorCode = "dry-run-error"         // the resource could not be submitted to the cluster with ServerDryRun
 )
 
 // Result contains the details of the result of a resource validation
 type Result struct {
-	Resource resource.Resource
-	Err      error
-	Status   Status
+	Resource       resource.Resource
+	Err            error
+	Status         Status
+	DownloadTime   time.Duration // time spent retrieving and compiling the schema, near 0 if it was cached
+	ValidationTime time.Duration // time spent validating the resource against its schema
+	Code           ErrorCode     // category of the failure for Error and Invalid results
+
+	// KubernetesVersion is the version of Kubernetes of the schema the resource was validated against
+	KubernetesVersion string
 }
 
 // Validator exposes multiple methods to validate your Kubernetes resources.
@@ -40,15 +87,67 @@ type Validator interface {
 	ValidateWithContext(ctx context.Context, filename string, r io.ReadCloser) []Result
 }
 
+// ContextValidator is a Validator that can also validate a single resource with a context, to
+// abort schema downloads when it is cancelled. Validators returned by New implement it.
+type ContextValidator interface {
+	Validator
+	ValidateResourceWithContext(ctx context.Context, res resource.Resource) Result
+}
+
 // Opts contains a set of options for the validator.
 type Opts struct {
-	Cache                string              // Cache schemas downloaded via HTTP to this folder
-	SkipTLS              bool                // skip TLS validation when downloading from an HTTP Schema Registry
-	SkipKinds            map[string]struct{} // List of resource Kinds to ignore
-	RejectKinds          map[string]struct{} // List of resource Kinds to reject
-	KubernetesVersion    string              // Kubernetes Version - has to match one in https://github.com/instrumenta/kubernetes-json-schema
-	Strict               bool                // thros an error if resources contain undocumented fields
-	IgnoreMissingSchemas bool                // skip a resource if no schema for that resource can be found
+	Cache                  string                       // Cache schemas downloaded via HTTP to this folder
+	SkipTLS                bool                         // skip TLS validation when downloading from an HTTP Schema Registry
+	NoProxy                string                       // hosts, domains and IP ranges to connect to without the proxy, overriding NO_PROXY
+	SkipKinds              map[string]struct{}          // List of resource Kinds to ignore
+	RejectKinds            map[string]struct{}          // List of resource Kinds to reject
+	OnlyKinds              map[string]struct{}          // resource Kinds to validate, if not empty - resources of other kinds are skipped
+	ListMode               string                       // how resources of kind List are handled: resource.ListExpand (if empty), ListValidate or ListSkip
+	RevisionAnnotation     string                       // annotation of resources whose value is set as {{ .SchemaRevision }} in schema locations
+	NoPrereleaseAPIs       bool                         // fail resources using an alpha or beta apiVersion, such as batch/v1beta1
+	AllowedPrereleaseAPIs  []string                     // apiVersions or apiVersion/Kinds allowed despite NoPrereleaseAPIs
+	AllowedAPIVersions     map[string][]string          // apiVersions resources can use, by kind - kinds not listed can use any
+	KubernetesVersion      string                       // Kubernetes Version - has to match one in https://github.com/instrumenta/kubernetes-json-schema
+	VersionResolution      string                       // VersionResolutionExact (the default, if empty) or VersionResolutionNearest
+	FallbackVersion        string                       // Kubernetes version schemas are looked up for last, with VersionResolutionNearest
+	Strict                 bool                         // thros an error if resources contain undocumented fields
+	IgnoreMissingSchemas   bool                         // skip a resource if no schema for that resource can be found
+	ValidateLabelSyntax    bool                         // check label and annotation keys and values against Kubernetes' syntax rules
+	RequiredLabels         []string                     // label keys every resource must set
+	ValidateImages         bool                         // check that container images are valid image references
+	RequireImageRegistry   bool                         // with ValidateImages, container images must name the registry they are pulled from
+	ValidateSecrets        bool                         // check that Secret data is base64-encoded and that Secret keys are valid
+	AggregatedAPIs         []string                     // apiVersions or apiVersion/Kinds of aggregated APIs, "default" for DefaultAggregatedAPIs - DefaultAggregatedAPIs if nil
+	StrictAggregated       bool                         // fail resources of aggregated APIs without a schema, instead of skipping them
+	SSABase                string                       // file or folder of live objects resources are merged into before validation, as server-side apply would
+	IgnoreKeys             []string                     // dot-separated paths of fields to remove before validation, * matches all list elements
+	CheckSizeLimit         bool                         // fail resources bigger than SizeLimit, serialised as JSON
+	SizeLimit              int64                        // maximum size of resources in bytes with CheckSizeLimit, DefaultSizeLimit if 0
+	SyntaxOnly             bool                         // only check resources can be parsed and have a kind and apiVersion, without schema validation
+	RegistryMaxFailures    int                          // stop querying a registry after this many consecutive failures - 0 to disable
+	RegistryCooldown       time.Duration                // time after which a registry that was disabled gets queried again
+	RegistryTimeouts       map[string]time.Duration     // timeouts of the requests to schema locations, by schema location
+	StrictDiff             bool                         // validate in strict and non-strict mode, only reporting errors specific to strict mode
+	Patches                map[string][]string          // paths to RFC 6902 JSON Patch files to apply to resources before validation, by Kind
+	IgnoreErrorPatterns    []string                     // regular expressions matching schema validation errors to ignore
+	SchemaCacheURL         string                       // URL of an HTTP cache shared between runs, queried before remote schema locations
+	WarnSchemaOverrides    bool                         // log a warning when a schema can be retrieved from more than one schema location
+	DefaultNamespace       string                       // namespace set on namespaced resources that do not have one
+	Provenance             *Provenance                  // if set, records the URL and checksum of every schema used, and of the documents they reference
+	UnknownKinds           *UnknownKinds                // if set, records the kinds for which no schema could be found
+	CrossResourceChecks    *CrossResourceChecks         // if set, records the resources validated to check them against each other
+	ServerDryRun           DryRunner                    // if set, valid resources are then submitted to a cluster with dry-run, e.g. a *registry.DryRunner
+	SchemaLock             *SchemaLock                  // if set, schemas must match the checksums of the lock, or are recorded in it when updating
+	OnlyFields             map[string]map[string]string // schema files to validate fields against instead of the schema of the resource, by kind and path
+	FailOnMissingFields    bool                         // resources missing a field set in OnlyFields are invalid
+	Aliases                map[string]string            // apiVersion/Kind to look up schemas under when none is found, by apiVersion/Kind
+	KindShortnames         map[string]string            // kinds read for short names, e.g. of custom resources, in addition to the ones of Kubernetes kinds
+	SkipOwned              bool                         // skip resources that have ownerReferences, as they are generated by controllers
+	TolerateDownloadErrors bool                         // skip resources whose schema could not be downloaded, other than because it does not exist
+	WarnDeprecatedFields   bool                         // warn about fields set in resources whose schema is marked as deprecated
+	ApplyDefaults          bool                         // set the default values declared in schemas on missing fields before validation
+	Warnings               func(Warning)                // if set, called with each warning instead of logging it - possibly from several goroutines
+	Debug                  bool                         // log each step of the validation of every resource: signature, schema lookups and outcome
 }
 
 // New returns a new Validator
@@ -57,21 +156,41 @@ func New(schemaLocations []string, opts Opts) (Validator, error) {
 	// raw.githubusercontent.com is frontend by Fastly and very fast
 	if len(schemaLocations) == 0 {
 		schemaLocations = []string{"https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/{{ .NormalizedKubernetesVersion }}-standalone{{ .StrictSuffix }}/{{ .ResourceKind }}{{ .KindSuffix }}.json"}
+		// Schemas compiled into the binary are looked up first, so that core resources validate offline
+		if registry.HasEmbeddedSchemas() {
+			schemaLocations = append([]string{"embedded"}, schemaLocations...)
+		}
 	}
 
-	registries := []registry.Registry{}
-	for _, schemaLocation := range schemaLocations {
-		reg, err := registry.New(schemaLocation, opts.Cache, opts.Strict, opts.SkipTLS)
-		if err != nil {
+	if opts.StrictDiff {
+		opts.Strict = true
+	}
+
+	registries, err := newRegistries(schemaLocations, opts, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	var lenientRegistries []registry.Registry
+	if opts.StrictDiff {
+		if lenientRegistries, err = newRegistries(schemaLocations, opts, false); err != nil {
 			return nil, err
 		}
-		registries = append(registries, reg)
 	}
 
 	if opts.KubernetesVersion == "" {
 		opts.KubernetesVersion = "master"
 	}
 
+	k8sVersions, err := kubernetesVersions(opts.KubernetesVersion, opts.VersionResolution, opts.FallbackVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SizeLimit == 0 {
+		opts.SizeLimit = DefaultSizeLimit
+	}
+
 	if opts.SkipKinds == nil {
 		opts.SkipKinds = map[string]struct{}{}
 	}
@@ -79,24 +198,146 @@ func New(schemaLocations []string, opts Opts) (Validator, error) {
 		opts.RejectKinds = map[string]struct{}{}
 	}
 
+	patches := map[string][]jsonPatch{}
+	for kind, patchFiles := range opts.Patches {
+		for _, patchFile := range patchFiles {
+			b, err := ioutil.ReadFile(patchFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed reading patch %s: %s", patchFile, err)
+			}
+			p, err := parseJSONPatch(b)
+			if err != nil {
+				return nil, fmt.Errorf("failed parsing patch %s: %s", patchFile, err)
+			}
+			patches[kind] = append(patches[kind], p)
+		}
+	}
+
+	var live liveObjects
+	if opts.SSABase != "" {
+		if live, err = loadLiveObjects(opts.SSABase); err != nil {
+			return nil, err
+		}
+	}
+
+	fieldSchemas, err := loadFieldSchemas(opts.OnlyFields)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := map[string]resource.Signature{}
+	for from, to := range opts.Aliases {
+		i := strings.LastIndex(to, "/")
+		if i <= 0 || i == len(to)-1 {
+			return nil, fmt.Errorf("invalid alias %s for %s, must be apiVersion/Kind", to, from)
+		}
+		aliases[from] = resource.Signature{Version: to[:i], Kind: to[i+1:]}
+	}
+
+	ignoreErrorPatterns := []*regexp.Regexp{}
+	for _, p := range opts.IgnoreErrorPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error pattern %s: %s", p, err)
+		}
+		ignoreErrorPatterns = append(ignoreErrorPatterns, re)
+	}
+
+	compiler := newSchemaCompiler()
 	return &v{
-		opts:           opts,
-		schemaDownload: downloadSchema,
-		schemaCache:    cache.NewInMemoryCache(),
-		regs:           registries,
+		opts:                opts,
+		patches:             patches,
+		ignoreErrorPatterns: ignoreErrorPatterns,
+		fieldSchemas:        fieldSchemas,
+		aliases:             aliases,
+		aggregatedAPIs:      aggregatedAPIs(opts.AggregatedAPIs),
+		live:                live,
+		schemaDownload:      compiler.downloadSchema,
+		compiler:            compiler,
+		schemaCache:         cache.NewInMemoryCache(),
+		regs:                registries,
+		k8sVersions:         k8sVersions,
+		locations:           schemaLocations,
+		lenientCache:        cache.NewInMemoryCache(),
+		lenientRegistries:   lenientRegistries,
 	}, nil
 }
 
+func newRegistries(schemaLocations []string, opts Opts, strict bool) ([]registry.Registry, error) {
+	registries := []registry.Registry{}
+	for _, schemaLocation := range schemaLocations {
+		timeout := opts.RegistryTimeouts[schemaLocation]
+		draft, schemaLocation := splitDraft(schemaLocation)
+		reg, err := registry.NewWithOpts(schemaLocation, registry.Opts{Cache: opts.Cache, Strict: strict, SkipTLS: opts.SkipTLS, NoProxy: opts.NoProxy, Timeout: timeout})
+		if err != nil {
+			return nil, err
+		}
+		if opts.RegistryMaxFailures > 0 {
+			reg = registry.NewCircuitBreaker(reg, opts.RegistryMaxFailures, opts.RegistryCooldown)
+		}
+		if opts.SchemaCacheURL != "" {
+			reg = registry.NewSharedCache(reg, schemaLocation, opts.SchemaCacheURL, strict, opts.SkipTLS, opts.NoProxy)
+		}
+		if opts.Debug {
+			reg = tracedRegistry{reg, schemaLocation}
+		}
+		if draft != "" {
+			reg = draftRegistry{reg, draft}
+		}
+		registries = append(registries, reg)
+	}
+
+	return registries, nil
+}
+
 type v struct {
 	opts           Opts
 	schemaCache    cache.Cache
-	schemaDownload func(registries []registry.Registry, kind, version, k8sVersion string) (*gojsonschema.Schema, error)
+	schemaDownload func(ctx context.Context, registries []registry.Registry, kind, version, k8sVersion string, found func(i int, schema []byte, references map[string][]byte)) (*gojsonschema.Schema, error)
+	compiler       *schemaCompiler // compiler of schemaDownload, keeping the documents schemas were compiled from
 	regs           []registry.Registry
+	locations      []string // schema locations of regs, for diagnostics
+	k8sVersions    []string // Kubernetes versions schemas are looked up for, in order
+	patches        map[string][]jsonPatch
+	fieldSchemas   map[string][]fieldSchema      // schemas for OnlyFields, by kind
+	aliases        map[string]resource.Signature // signatures to look up schemas under, by apiVersion/Kind
+	aggregatedAPIs map[string]struct{}           // apiVersions and apiVersion/Kinds served by aggregated API servers
+	live           liveObjects                   // objects resources are applied to, for SSABase
+
+	ignoreErrorPatterns []*regexp.Regexp
+	overridesChecked    sync.Map // kinds checked for WarnSchemaOverrides
+	fileRegistries      sync.Map // *fileRegistries, by schema locations set in files
+
+	// Non-strict schemas, used to compare against strict validation with StrictDiff
+	lenientCache      cache.Cache
+	lenientRegistries []registry.Registry
 }
 
 // ValidateResource validates a single resource. This allows to validate
 // large resource streams using multiple Go Routines.
 func (val *v) ValidateResource(res resource.Resource) Result {
+	return val.ValidateResourceWithContext(context.Background(), res)
+}
+
+// ValidateResourceWithContext validates a single resource. Schema downloads are aborted
+// when ctx is cancelled, and the resource is reported as an error.
+func (val *v) ValidateResourceWithContext(ctx context.Context, res resource.Resource) Result {
+	result := val.validateResource(ctx, res)
+	if result.Status == Valid && val.opts.ServerDryRun != nil {
+		result = val.serverDryRun(ctx, result)
+	}
+	if result.Status != Empty {
+		if result.Err != nil {
+			val.debugf("%s - document %d: %s: %s", res.Path, res.DocumentIndex, result.Status, result.Err)
+		} else {
+			val.debugf("%s - document %d: %s", res.Path, res.DocumentIndex, result.Status)
+		}
+	}
+
+	return result
+}
+
+func (val *v) validateResource(ctx context.Context, res resource.Resource) Result {
 	skip := func(signature resource.Signature) bool {
 		_, ok := val.opts.SkipKinds[signature.Kind]
 		return ok
@@ -113,7 +354,7 @@ func (val *v) ValidateResource(res resource.Resource) Result {
 
 	var r map[string]interface{}
 	if err := yaml.Unmarshal(res.Bytes, &r); err != nil {
-		return Result{Resource: res, Status: Error, Err: fmt.Errorf("error unmarshalling resource: %s", err)}
+		return Result{Resource: res, Status: Error, Err: fmt.Errorf("error unmarshalling resource: %s", err), Code: ParseError}
 	}
 
 	if r == nil { // Resource is empty
@@ -122,89 +363,393 @@ func (val *v) ValidateResource(res resource.Resource) Result {
 
 	sig, err := res.SignatureFromMap(r)
 	if err != nil {
-		return Result{Resource: res, Err: fmt.Errorf("error while parsing: %s", err), Status: Error}
+		return Result{Resource: res, Err: fmt.Errorf("error while parsing: %s", err), Status: Error, Code: ParseError}
+	}
+
+	// Short names and plurals of kinds are validated as the kind, against schemas that often only
+	// allow the kind itself
+	sig.Kind = resource.NormalizeKind(sig.Kind, val.opts.KindShortnames)
+	if kind, _ := r["kind"].(string); kind != sig.Kind {
+		r["kind"] = sig.Kind
+	}
+	val.debugf("%s - document %d: found %s %s, apiVersion %s", res.Path, res.DocumentIndex, sig.Kind, sig.Name, sig.Version)
+
+	_, onlyKind := val.opts.OnlyKinds[sig.Kind]
+	skipped := (len(val.opts.OnlyKinds) > 0 && !onlyKind) || skip(*sig) || (val.opts.SkipOwned && sig.Owned) ||
+		(val.opts.ListMode == resource.ListSkip && strings.ToLower(sig.Kind) == "list")
+
+	// Skipped resources can still be referenced by the resources checked against each other
+	if val.opts.CrossResourceChecks != nil {
+		val.opts.CrossResourceChecks.add(res, *sig, !skipped)
 	}
 
-	if skip(*sig) {
+	if skipped {
 		return Result{Resource: res, Err: nil, Status: Skipped}
 	}
 
 	if reject(*sig) {
-		return Result{Resource: res, Err: fmt.Errorf("prohibited resource kind %s", sig.Kind), Status: Error}
+		return Result{Resource: res, Err: fmt.Errorf("prohibited resource kind %s", sig.Kind), Status: Error, Code: RejectedKind}
 	}
 
-	cached := false
-	var schema *gojsonschema.Schema
+	if val.opts.NoPrereleaseAPIs && isPrerelease(sig.Version) && !val.prereleaseAllowed(sig) {
+		return Result{Resource: res, Err: fmt.Errorf("prohibited prerelease apiVersion %s", sig.Version), Status: Error, Code: PrereleaseAPI}
+	}
 
-	if val.schemaCache != nil {
-		s, err := val.schemaCache.Get(sig.Kind, sig.Version, val.opts.KubernetesVersion)
-		if err == nil {
-			cached = true
-			schema = s.(*gojsonschema.Schema)
+	if allowed, ok := val.opts.AllowedAPIVersions[sig.Kind]; ok && !apiVersionAllowed(sig.Version, allowed) {
+		return Result{Resource: res, Err: fmt.Errorf("apiVersion %s is not allowed for %s, allowed: %s", sig.Version, sig.Kind, strings.Join(allowed, ", ")), Status: Error, Code: DisallowedAPIVersion}
+	}
+
+	if len(val.opts.IgnoreKeys) > 0 {
+		removeKeys(r, val.opts.IgnoreKeys)
+	}
+
+	if val.opts.DefaultNamespace != "" {
+		setDefaultNamespace(r, sig.Version, sig.Kind, val.opts.DefaultNamespace)
+	}
+
+	for _, p := range val.patches[sig.Kind] {
+		if r, err = p.apply(r); err != nil {
+			return Result{Resource: res, Err: err, Status: Error, Code: PatchError}
+		}
+	}
+
+	if live, ok := val.live.find(r, sig); ok {
+		val.debugf("%s/%s - merging %s into its live object", sig.Version, sig.Kind, sig.Name)
+		r = applyTo(live, r)
+	}
+
+	if val.opts.ValidateLabelSyntax {
+		if err := validateLabelSyntax(r); err != nil {
+			return Result{Resource: res, Err: err, Status: Error, Code: ConstraintViolation}
 		}
 	}
 
-	if !cached {
-		if schema, err = val.schemaDownload(val.regs, sig.Kind, sig.Version, val.opts.KubernetesVersion); err != nil {
-			return Result{Resource: res, Err: err, Status: Error}
+	if len(val.opts.RequiredLabels) > 0 {
+		if missing := missingLabels(r, val.opts.RequiredLabels); len(missing) > 0 {
+			return Result{Resource: res, Err: fmt.Errorf("missing required labels: %s", strings.Join(missing, ", ")), Status: Error, Code: ConstraintViolation}
 		}
+	}
 
-		if val.schemaCache != nil {
-			val.schemaCache.Set(sig.Kind, sig.Version, val.opts.KubernetesVersion, schema)
+	if val.opts.ValidateImages {
+		if err := validateImages(sig.Version, sig.Kind, r, val.opts.RequireImageRegistry); err != nil {
+			return Result{Resource: res, Err: err, Status: Error, Code: ConstraintViolation}
 		}
 	}
 
+	if val.opts.ValidateSecrets && sig.Kind == "Secret" && sig.Version == "v1" {
+		if err := validateSecret(r); err != nil {
+			return Result{Resource: res, Err: err, Status: Error, Code: ConstraintViolation}
+		}
+	}
+
+	if val.opts.CheckSizeLimit {
+		if err := validateSize(r, val.opts.SizeLimit); err != nil {
+			return Result{Resource: res, Err: err, Status: Error, Code: ConstraintViolation}
+		}
+	}
+
+	if val.opts.SyntaxOnly {
+		return Result{Resource: res, Err: nil, Status: Valid}
+	}
+
+	if fields, ok := val.fieldSchemas[sig.Kind]; ok {
+		start := time.Now()
+		errs, err := fieldErrors(fields, r, val.opts.FailOnMissingFields)
+		if err == nil && len(val.ignoreErrorPatterns) > 0 {
+			errs = val.withoutIgnoredErrors(res.Path, errs)
+		}
+		validationTime := time.Since(start)
+		if err != nil {
+			return Result{Resource: res, Status: Error, Err: fmt.Errorf("problem validating schema. Check JSON formatting: %s", err), ValidationTime: validationTime, Code: ParseError}
+		}
+
+		if len(errs) == 0 {
+			return Result{Resource: res, Status: Valid, ValidationTime: validationTime}
+		}
+
+		return Result{Resource: res, Status: Invalid, Err: fmt.Errorf("%s", strings.Join(errs, " - ")), ValidationTime: validationTime, Code: ConstraintViolation}
+	}
+
+	revision, err := val.schemaRevision(r)
+	if err != nil {
+		return Result{Resource: res, Err: err, Status: Error, Code: ParseError}
+	}
+
+	regs, locations, schemaCache, err := val.registriesFor(res, revision)
+	if err != nil {
+		return Result{Resource: res, Err: err, Status: Error, Code: ParseError}
+	}
+
+	start := time.Now()
+	schema, k8sVersion, invalidErr, err := val.schemaFor(ctx, schemaCache, regs, locations, val.opts.Strict, sig)
+	downloadTime := time.Since(start)
+	if err != nil {
+		// Interrupted runs are still reported as errors
+		if val.opts.TolerateDownloadErrors && ctx.Err() == nil {
+			val.warnf(DownloadErrorTolerated, res.Path, "%s %s skipped: %s", sig.Name, sig.Kind, err)
+			return Result{Resource: res, Err: err, Status: Skipped, DownloadTime: downloadTime}
+		}
+		return Result{Resource: res, Err: err, Status: Error, DownloadTime: downloadTime, Code: SchemaDownloadError}
+	}
+
+	if schema != nil && val.opts.WarnSchemaOverrides {
+		val.warnSchemaOverrides(ctx, sig)
+	}
+
+	if schema == nil && invalidErr == nil && val.opts.UnknownKinds != nil && !isSubresource(sig) {
+		val.opts.UnknownKinds.add(sig.Version, sig.Kind)
+	}
+
 	if schema == nil {
-		if val.opts.IgnoreMissingSchemas {
-			return Result{Resource: res, Err: nil, Status: Skipped}
+		if val.opts.IgnoreMissingSchemas || (invalidErr == nil && isSubresource(sig)) {
+			return Result{Resource: res, Err: nil, Status: Skipped, DownloadTime: downloadTime}
+		}
+
+		if invalidErr == nil && !val.opts.StrictAggregated && val.isAggregated(sig) {
+			return Result{Resource: res, Err: fmt.Errorf("no schema found for %s, served by an aggregated API server", sig.Version), Status: Skipped, DownloadTime: downloadTime}
 		}
 
-		return Result{Resource: res, Err: fmt.Errorf("could not find schema for %s", sig.Kind), Status: Error}
+		if invalidErr != nil {
+			return Result{Resource: res, Err: invalidErr, Status: Error, DownloadTime: downloadTime, Code: SchemaInvalid}
+		}
+
+		return Result{Resource: res, Err: fmt.Errorf("could not find schema for %s", sig.Kind), Status: Error, DownloadTime: downloadTime, Code: SchemaNotFound}
 	}
 
-	resourceLoader := gojsonschema.NewGoLoader(r)
+	if (val.opts.WarnDeprecatedFields || val.opts.ApplyDefaults) && val.compiler != nil {
+		if document, ok := val.compiler.document(schema); ok {
+			// Fields are checked before defaults are applied, as only the fields set in the resource are reported
+			if val.opts.WarnDeprecatedFields {
+				for _, field := range deprecatedFields(document, document, r) {
+					val.warnf(DeprecatedField, res.Path, "%s %s: field %s is deprecated", sig.Kind, sig.Name, field)
+				}
+			}
+			if val.opts.ApplyDefaults {
+				applyDefaults(document, document, r)
+			}
+		}
+	}
 
-	results, err := schema.Validate(resourceLoader)
+	start = time.Now()
+	errs, err := validationErrors(schema, r)
+	if err == nil && val.opts.StrictDiff {
+		errs, err = val.strictOnlyErrors(ctx, sig, r, errs)
+	}
+	if err == nil && len(val.ignoreErrorPatterns) > 0 {
+		errs = val.withoutIgnoredErrors(res.Path, errs)
+	}
+	validationTime := time.Since(start)
+	if e, ok := err.(*invalidSchemaError); ok {
+		return Result{Resource: res, Err: e, Status: Error, DownloadTime: downloadTime, ValidationTime: validationTime, Code: SchemaInvalid, KubernetesVersion: k8sVersion}
+	}
 	if err != nil {
 		// This error can only happen if the Object to validate is poorly formed. There's no hope of saving this one
-		return Result{Resource: res, Status: Error, Err: fmt.Errorf("problem validating schema. Check JSON formatting: %s", err)}
+		return Result{Resource: res, Status: Error, Err: fmt.Errorf("problem validating schema. Check JSON formatting: %s", err), DownloadTime: downloadTime, ValidationTime: validationTime, Code: ParseError, KubernetesVersion: k8sVersion}
 	}
 
-	if results.Valid() {
-		return Result{Resource: res, Status: Valid}
+	if len(errs) == 0 {
+		return Result{Resource: res, Status: Valid, DownloadTime: downloadTime, ValidationTime: validationTime, KubernetesVersion: k8sVersion}
 	}
 
-	msg := ""
-	for _, errMsg := range results.Errors() {
-		if msg != "" {
-			msg += " - "
+	return Result{Resource: res, Status: Invalid, Err: fmt.Errorf("%s", strings.Join(errs, " - ")), DownloadTime: downloadTime, ValidationTime: validationTime, Code: ConstraintViolation, KubernetesVersion: k8sVersion}
+}
+
+// foundSchema is a schema kept in the memory cache, with the Kubernetes version it was found for
+type foundSchema struct {
+	schema     *gojsonschema.Schema
+	k8sVersion string
+}
+
+// schemaFor retrieves the schema for a resource from the cache, or from the registries, and the
+// Kubernetes version it was found for - one of the versions schemas are looked up for, see
+// kubernetesVersions. A nil schema means none was found - invalidErr is set if schemas were found
+// but could not be compiled.
+func (val *v) schemaFor(ctx context.Context, c cache.Cache, regs []registry.Registry, locations []string, strict bool, sig *resource.Signature) (*gojsonschema.Schema, string, *invalidSchemaError, error) {
+	if c != nil {
+		if s, err := c.Get(sig.Kind, sig.Version, val.opts.KubernetesVersion); err == nil {
+			val.debugf("%s/%s - schema found in memory cache", sig.Version, sig.Kind)
+			if e, ok := s.(*invalidSchemaError); ok {
+				return nil, "", e, nil
+			}
+			found := s.(foundSchema)
+			return found.schema, found.k8sVersion, nil, nil
+		}
+		val.debugf("%s/%s - schema not in memory cache, querying schema locations", sig.Version, sig.Kind)
+	}
+
+	// Resources of renamed kinds are looked up under their alias when they have no schema of their own
+	lookups := []resource.Signature{*sig}
+	if alias, ok := val.aliases[sig.Version+"/"+sig.Kind]; ok {
+		lookups = append(lookups, alias)
+	}
+
+	var schema *gojsonschema.Schema
+	var schemaK8sVersion string
+	var invalidErr *invalidSchemaError // schemas that can not be compiled are handled like missing ones
+	k8sVersions := val.k8sVersions
+	if len(k8sVersions) == 0 {
+		k8sVersions = []string{val.opts.KubernetesVersion}
+	}
+LOOKUPS:
+	for _, lookup := range lookups {
+		lookup := lookup
+		for j, k8sVersion := range k8sVersions {
+			k8sVersion := k8sVersion
+			if j > 0 {
+				val.debugf("%s/%s - no schema for Kubernetes version %s, trying %s", lookup.Version, lookup.Kind, k8sVersions[j-1], k8sVersion)
+			}
+
+			var found func(int, []byte, map[string][]byte)
+			var lockErr error
+			if val.opts.Provenance != nil || val.opts.SchemaLock != nil {
+				found = func(i int, b []byte, references map[string][]byte) {
+					if val.opts.Provenance != nil {
+						_, location := splitDraft(locations[i])
+						url := registry.SchemaURL(location, lookup.Kind, lookup.Version, k8sVersion, strict)
+						val.opts.Provenance.add(url, lookup.Version, lookup.Kind, b, references)
+					}
+					if val.opts.SchemaLock != nil {
+						lockErr = val.opts.SchemaLock.check(schemaLockKey(lookup.Version, lookup.Kind, strict), b, references)
+					}
+				}
+			}
+
+			s, err := val.schemaDownload(ctx, regs, lookup.Kind, lookup.Version, k8sVersion, found)
+			if err == nil && lockErr != nil {
+				// Schemas that do not match the lock are never used, even with TolerateDownloadErrors
+				err = &invalidSchemaError{err: lockErr, locked: true}
+			}
+			if e, ok := err.(*invalidSchemaError); ok {
+				if invalidErr == nil {
+					invalidErr = e
+				}
+				continue
+			}
+			if err != nil {
+				return nil, "", nil, err
+			}
+			if s != nil {
+				val.debugf("%s/%s - compiled schema for %s/%s, Kubernetes version %s", sig.Version, sig.Kind, lookup.Version, lookup.Kind, k8sVersion)
+				schema, schemaK8sVersion, invalidErr = s, k8sVersion, nil
+				break LOOKUPS
+			}
+		}
+	}
+
+	switch {
+	case invalidErr != nil:
+		val.debugf("%s/%s - %s", sig.Version, sig.Kind, invalidErr)
+	case schema == nil:
+		val.debugf("%s/%s - no schema found", sig.Version, sig.Kind)
+	}
+
+	if c != nil {
+		if invalidErr != nil {
+			c.Set(sig.Kind, sig.Version, val.opts.KubernetesVersion, invalidErr)
+		} else {
+			c.Set(sig.Kind, sig.Version, val.opts.KubernetesVersion, foundSchema{schema, schemaK8sVersion})
+		}
+	}
+
+	return schema, schemaK8sVersion, invalidErr, nil
+}
+
+// strictOnlyErrors returns the errors from strictErrs that do not occur when validating
+// the resource against its non-strict schema. Non-strict schemas that do not match the schema lock
+// are returned as an error, other invalid ones are ignored.
+func (val *v) strictOnlyErrors(ctx context.Context, sig *resource.Signature, r map[string]interface{}, strictErrs []string) ([]string, error) {
+	if len(strictErrs) == 0 {
+		return strictErrs, nil
+	}
+
+	schema, _, invalidErr, err := val.schemaFor(ctx, val.lenientCache, val.lenientRegistries, val.locations, false, sig)
+	if invalidErr != nil && invalidErr.locked {
+		return nil, invalidErr
+	}
+	if err != nil || schema == nil {
+		return strictErrs, err
+	}
+
+	lenientErrs, err := validationErrors(schema, r)
+	if err != nil {
+		return nil, err
+	}
+
+	lenient := map[string]struct{}{}
+	for _, e := range lenientErrs {
+		lenient[e] = struct{}{}
+	}
+
+	errs := []string{}
+	for _, e := range strictErrs {
+		if _, ok := lenient[e]; !ok {
+			errs = append(errs, e)
 		}
+	}
+
+	return errs, nil
+}
+
+// withoutIgnoredErrors removes the errors matching one of the patterns to ignore
+func (val *v) withoutIgnoredErrors(path string, errs []string) []string {
+	filtered := []string{}
+	for _, e := range errs {
+		ignored := false
+		for _, re := range val.ignoreErrorPatterns {
+			if re.MatchString(e) {
+				val.warnf(ErrorIgnored, path, "ignoring error matching %s: %s", re, e)
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// validationErrors validates a resource against a schema, and returns a description of each violation
+func validationErrors(schema *gojsonschema.Schema, r map[string]interface{}) ([]string, error) {
+	results, err := schema.Validate(gojsonschema.NewGoLoader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	errs := []string{}
+	for _, errMsg := range results.Errors() {
 		details := errMsg.Details()
-		msg += fmt.Sprintf("For field %s: %s", details["field"].(string), errMsg.Description())
+		errs = append(errs, fmt.Sprintf("For field %s: %s", details["field"].(string), errMsg.Description()))
 	}
 
-	return Result{Resource: res, Status: Invalid, Err: fmt.Errorf("%s", msg)}
+	return errs, nil
 }
 
 // ValidateWithContext validates resources found in r
 // filename should be a name for the stream, such as a filename or stdin
 func (val *v) ValidateWithContext(ctx context.Context, filename string, r io.ReadCloser) []Result {
 	validationResults := []Result{}
-	resourcesChan, _ := resource.FromStream(ctx, filename, r)
-	for {
+	resourcesChan, errorsChan := resource.FromStreamWithOpts(ctx, filename, r, resource.StreamOpts{ListMode: val.opts.ListMode})
+	// Both channels are read until closed, the stream blocks until its errors are received
+	for resourcesChan != nil || errorsChan != nil {
 		select {
 		case res, ok := <-resourcesChan:
-			validationResults = append(validationResults, val.ValidateResource(res))
+			validationResults = append(validationResults, val.ValidateResourceWithContext(ctx, res))
 			if !ok {
 				resourcesChan = nil
 			}
 
-		case <-ctx.Done():
-			break
-		}
-
-		if resourcesChan == nil {
-			break
+		case err, ok := <-errorsChan:
+			if !ok {
+				errorsChan = nil
+				continue
+			}
+			path := filename
+			if de, ok := err.(resource.DiscoveryError); ok {
+				path, err = de.Path, de.Err
+			}
+			validationResults = append(validationResults, Result{Resource: resource.Resource{Path: path}, Err: err, Status: Error})
 		}
 	}
 
@@ -218,19 +763,45 @@ func (val *v) Validate(filename string, r io.ReadCloser) []Result {
 	return val.ValidateWithContext(context.Background(), filename, r)
 }
 
-func downloadSchema(registries []registry.Registry, kind, version, k8sVersion string) (*gojsonschema.Schema, error) {
-	var err error
+// invalidSchemaError is returned when a schema was found, but could not be compiled
+type invalidSchemaError struct {
+	err    error
+	locked bool // the schema does not match the schema lock
+}
+
+func (e *invalidSchemaError) Error() string { return e.err.Error() }
+
+// downloadSchema retrieves the schema for a resource from the first registry serving a valid one.
+// If found is not nil, it is called with the index of that registry, the schema and the documents it
+// references, by URL. Schemas are compiled with c, so that identical schemas served for several kinds
+// are compiled once.
+func (c *schemaCompiler) downloadSchema(ctx context.Context, registries []registry.Registry, kind, version, k8sVersion string, found func(i int, schema []byte, references map[string][]byte)) (*gojsonschema.Schema, error) {
+	var err, unavailableErr, invalidErr error
 	var schemaBytes []byte
 
-	for _, reg := range registries {
-		schemaBytes, err = reg.DownloadSchema(kind, version, k8sVersion)
+	for _, i := range queryOrder(ctx, registries, kind, version) {
+		reg := registries[i]
+		schemaBytes, err = registry.DownloadSchemaWithContext(ctx, reg, kind, version, k8sVersion)
 		if err == nil {
-			schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaBytes))
+			// Schemas may $ref other documents, retrieved from the same registry
+			var base string
+			refs, ok := registry.AsReferenceLoader(reg)
+			if ok {
+				if base, err = refs.SchemaURL(kind, version, k8sVersion); err != nil {
+					refs = nil
+				}
+			}
+
+			schema, err := c.compile(ctx, schemaBytes, base, refs, declaredDraft(reg))
 
 			// If we got a non-parseable response, we try the next registry
 			if err != nil {
+				invalidErr = &invalidSchemaError{err: fmt.Errorf("failed compiling schema for %s: %s", kind, err)}
 				continue
 			}
+			if found != nil {
+				found(i, schemaBytes, c.referencedDocuments(schema))
+			}
 			return schema, err
 		}
 
@@ -239,12 +810,42 @@ func downloadSchema(registries []registry.Registry, kind, version, k8sVersion st
 			continue
 		}
 
+		// Registries that have been failing repeatedly are skipped
+		if _, unavailable := err.(*registry.UnavailableError); unavailable {
+			unavailableErr = err
+			continue
+		}
+
 		return nil, err
 	}
 
+	if unavailableErr != nil {
+		return nil, unavailableErr // the schema might exist in a registry we could not query
+	}
+
+	if invalidErr != nil {
+		return nil, invalidErr
+	}
+
 	return nil, nil // No schema found - we don't consider it an error, resource will be skipped
 }
 
+// queryOrder returns the indexes of registries in the order they are queried for a kind: registries
+// serving the kind, such as clusters, are authoritative for it and queried first, followed by the others
+// in order. Other registries are not queried for kinds an authoritative registry has a schema for.
+func queryOrder(ctx context.Context, registries []registry.Registry, kind, version string) []int {
+	first, others := []int{}, []int{}
+	for i, reg := range registries {
+		if s, ok := registry.AsKindServer(reg); ok && s.ServesKind(ctx, kind, version) {
+			first = append(first, i)
+			continue
+		}
+		others = append(others, i)
+	}
+
+	return append(first, others...)
+}
+
 // From kubeval - let's see if absolutely necessary
 // func init () {
 // 	gojsonschema.FormatCheckers.Add("int64", ValidFormat{})
@@ -1,10 +1,23 @@
 package validator
 
 import (
-	"github.com/yannh/kubeconform/pkg/registry"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
+	"github.com/yannh/kubeconform/pkg/registry"
 	"github.com/yannh/kubeconform/pkg/resource"
+
+	"github.com/xeipuuv/gojsonschema"
 )
 
 type mockRegistry struct {
@@ -21,6 +34,16 @@ func (m mockRegistry) DownloadSchema(resourceKind, resourceAPIVersion, k8sVersio
 	return m.SchemaDownloader()
 }
 
+// mockKindServer is a registry serving a fixed list of kinds, as clusters do
+type mockKindServer struct {
+	mockRegistry
+	kinds map[string]bool
+}
+
+func (m mockKindServer) ServesKind(ctx context.Context, resourceKind, resourceAPIVersion string) bool {
+	return m.kinds[resourceAPIVersion+"/"+resourceKind]
+}
+
 func TestValidate(t *testing.T) {
 	for i, testCase := range []struct {
 		name                         string
@@ -295,7 +318,7 @@ lastName: bar
 				IgnoreMissingSchemas: testCase.ignoreMissingSchema,
 			},
 			schemaCache:    nil,
-			schemaDownload: downloadSchema,
+			schemaDownload: newSchemaCompiler().downloadSchema,
 			regs: []registry.Registry{
 				newMockRegistry(func() ([]byte, error) {
 					return testCase.schemaRegistry1, nil
@@ -310,3 +333,552 @@ lastName: bar
 		}
 	}
 }
+
+func TestDownloadSchemaSkipsUnavailableRegistries(t *testing.T) {
+	failing := registry.NewCircuitBreaker(newMockRegistry(func() ([]byte, error) {
+		return nil, fmt.Errorf("connection refused")
+	}), 1, time.Hour)
+	mirror := newMockRegistry(func() ([]byte, error) {
+		return []byte(`{"type": "object"}`), nil
+	})
+	c := newSchemaCompiler()
+
+	if _, err := c.downloadSchema(context.Background(), []registry.Registry{failing, mirror}, "Deployment", "apps/v1", "1.18.0", nil); err == nil {
+		t.Errorf("expected an error before the circuit opens")
+	}
+
+	schema, err := c.downloadSchema(context.Background(), []registry.Registry{failing, mirror}, "Deployment", "apps/v1", "1.18.0", nil)
+	if err != nil || schema == nil {
+		t.Errorf("expected schema to be retrieved from the mirror, got error %v", err)
+	}
+
+	if _, err := c.downloadSchema(context.Background(), []registry.Registry{failing}, "Deployment", "apps/v1", "1.18.0", nil); err == nil {
+		t.Errorf("expected an error when all registries are unavailable")
+	}
+}
+
+func TestValidateResourceCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type": "object"}`))
+	}))
+	defer server.Close()
+
+	val, err := New([]string{server.URL + "/{{ .ResourceKind }}.json"}, Opts{})
+	if err != nil {
+		t.Fatalf("failed creating validator: %s", err)
+	}
+
+	res := resource.Resource{Bytes: []byte("kind: Deployment\napiVersion: apps/v1\n")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := val.(ContextValidator).ValidateResourceWithContext(ctx, res); got.Status != Error || got.Code != SchemaDownloadError {
+		t.Errorf("expected a schema download error once cancelled, got %d: %s", got.Status, got.Err)
+	}
+
+	// Failed downloads are not cached
+	if got := val.ValidateResource(res); got.Status != Valid {
+		t.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+	}
+}
+
+func TestValidateSyntaxOnly(t *testing.T) {
+	for i, testCase := range []struct {
+		rawResource []byte
+		expect      Status
+	}{
+		{[]byte("kind: name\napiVersion: v1\nfirstName: foo\n"), Valid},
+		{[]byte("apiVersion: v1\nfirstName: foo\n"), Error},
+		{[]byte("kind: [name\n"), Error},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:   map[string]struct{}{},
+				RejectKinds: map[string]struct{}{},
+				SyntaxOnly:  true,
+			},
+			schemaDownload: func(_ context.Context, _ []registry.Registry, _, _, _ string, _ func(int, []byte, map[string][]byte)) (*gojsonschema.Schema, error) {
+				t.Errorf("%d - schemas should not be downloaded in syntax-only mode", i)
+				return nil, nil
+			},
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource}); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d", i, testCase.expect, got.Status)
+		}
+	}
+}
+
+func TestValidateErrorCodes(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"firstName": {"type": "string"}}}`)
+	for i, testCase := range []struct {
+		rawResource []byte
+		schema      []byte
+		downloadErr error
+		expect      ErrorCode
+	}{
+		{[]byte("kind: name\napiVersion: v1\nfirstName: foo\n"), schema, nil, ""},
+		{[]byte("kind: [name\n"), schema, nil, ParseError},
+		{[]byte("apiVersion: v1\nfirstName: foo\n"), schema, nil, ParseError},
+		{[]byte("kind: rejected\napiVersion: v1\n"), schema, nil, RejectedKind},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\n"), schema, nil, ConstraintViolation},
+		{[]byte("kind: name\napiVersion: v1\n"), []byte("<html>error page</html>"), nil, SchemaInvalid},
+		{[]byte("kind: name\napiVersion: v1\n"), nil, fmt.Errorf("connection refused"), SchemaDownloadError},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:   map[string]struct{}{},
+				RejectKinds: map[string]struct{}{"rejected": {}},
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) {
+					return testCase.schema, testCase.downloadErr
+				}),
+			},
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource}); got.Code != testCase.expect {
+			t.Errorf("%d - expected code %q, got %q: %s", i, testCase.expect, got.Code, got.Err)
+		}
+	}
+
+	missing, _ := registry.New("/does-not-exist/{{ .ResourceKind }}.json", "", false, false)
+	val := v{
+		opts:           Opts{SkipKinds: map[string]struct{}{}, RejectKinds: map[string]struct{}{}},
+		schemaDownload: newSchemaCompiler().downloadSchema,
+		regs:           []registry.Registry{missing},
+	}
+	if got := val.ValidateResource(resource.Resource{Bytes: []byte("kind: name\napiVersion: v1\n")}); got.Code != SchemaNotFound {
+		t.Errorf("expected code %q, got %q", SchemaNotFound, got.Code)
+	}
+}
+
+func TestValidateStrictDiff(t *testing.T) {
+	lenientSchema := []byte(`{"type": "object", "properties": {"firstName": {"type": "string"}}}`)
+	strictSchema := []byte(`{"type": "object", "properties": {"firstName": {"type": "string"}, "kind": {}, "apiVersion": {}}, "additionalProperties": false}`)
+
+	for i, testCase := range []struct {
+		rawResource []byte
+		expect      Status
+		expectErr   string
+	}{
+		{[]byte("kind: name\napiVersion: v1\nfirstName: foo\n"), Valid, ""},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: foo\nlastName: bar\n"), Invalid, "For field (root): Additional property lastName is not allowed"},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\n"), Valid, ""}, // fails in both modes
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\nlastName: bar\n"), Invalid, "For field (root): Additional property lastName is not allowed"},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:   map[string]struct{}{},
+				RejectKinds: map[string]struct{}{},
+				Strict:      true,
+				StrictDiff:  true,
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return strictSchema, nil }),
+			},
+			lenientRegistries: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return lenientSchema, nil }),
+			},
+		}
+
+		got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if testCase.expectErr != "" && (got.Err == nil || got.Err.Error() != testCase.expectErr) {
+			t.Errorf("%d - expected error %s, got %s", i, testCase.expectErr, got.Err)
+		}
+	}
+}
+
+func TestValidateIgnoreErrorPatterns(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"firstName": {"type": "string"}, "age": {"type": "integer"}}}`)
+	for i, testCase := range []struct {
+		rawResource []byte
+		patterns    []string
+		expect      Status
+		expectErr   string
+	}{
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\n"), []string{"^For field firstName"}, Valid, ""},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\nage: foo\n"), []string{"^For field firstName"}, Invalid, "For field age: Invalid type. Expected: integer, given: string"},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\nage: foo\n"), []string{"firstName", "Expected: integer"}, Valid, ""},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\n"), []string{"lastName"}, Invalid, "For field firstName: Invalid type. Expected: string, given: integer"},
+	} {
+		val, err := New(nil, Opts{IgnoreErrorPatterns: testCase.patterns})
+		if err != nil {
+			t.Fatalf("%d - failed creating validator: %s", i, err)
+		}
+		val.(*v).regs = []registry.Registry{
+			newMockRegistry(func() ([]byte, error) { return schema, nil }),
+		}
+
+		got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if testCase.expectErr != "" && (got.Err == nil || got.Err.Error() != testCase.expectErr) {
+			t.Errorf("%d - expected error %s, got %s", i, testCase.expectErr, got.Err)
+		}
+	}
+
+	if _, err := New(nil, Opts{IgnoreErrorPatterns: []string{"["}}); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+}
+
+func TestValidateKubernetesVersion(t *testing.T) {
+	schema := []byte(`{"type": "object", "properties": {"firstName": {"type": "string"}}}`)
+	for i, testCase := range []struct {
+		rawResource []byte
+		schema      []byte
+		expect      string
+	}{
+		{[]byte("kind: name\napiVersion: v1\nfirstName: foo\n"), schema, "1.18.0"},
+		{[]byte("kind: name\napiVersion: v1\nfirstName: 1\n"), schema, "1.18.0"},
+		{[]byte("kind: name\napiVersion: v1\n"), nil, ""},
+		{[]byte("kind: [name\n"), schema, ""},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:         map[string]struct{}{},
+				RejectKinds:       map[string]struct{}{},
+				KubernetesVersion: "1.18.0",
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return testCase.schema, nil }),
+			},
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource}); got.KubernetesVersion != testCase.expect {
+			t.Errorf("%d - expected kubernetes version %q, got %q", i, testCase.expect, got.KubernetesVersion)
+		}
+	}
+}
+
+func TestValidateSkipOwned(t *testing.T) {
+	owned := []byte("kind: ReplicaSet\napiVersion: apps/v1\nmetadata:\n  name: web-5d8f\n  ownerReferences:\n  - kind: Deployment\n    name: web\n")
+	for i, testCase := range []struct {
+		rawResource []byte
+		skipOwned   bool
+		expect      Status
+	}{
+		{owned, false, Valid},
+		{owned, true, Skipped},
+		{[]byte("kind: ReplicaSet\napiVersion: apps/v1\nmetadata:\n  name: web\n"), true, Valid},
+		{[]byte("kind: ReplicaSet\napiVersion: apps/v1\nmetadata:\n  name: web\n  ownerReferences: []\n"), true, Valid},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:   map[string]struct{}{},
+				RejectKinds: map[string]struct{}{},
+				SkipOwned:   testCase.skipOwned,
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return []byte(`{"type": "object"}`), nil }),
+			},
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource}); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+}
+
+func TestValidateOnlyKinds(t *testing.T) {
+	deployment := []byte("kind: Deployment\napiVersion: apps/v1\nmetadata:\n  name: web\n")
+	service := []byte("kind: Service\napiVersion: v1\nmetadata:\n  name: web\n")
+	secret := []byte("kind: Secret\napiVersion: v1\nmetadata:\n  name: web\n")
+	for i, testCase := range []struct {
+		rawResource []byte
+		onlyKinds   map[string]struct{}
+		skipKinds   map[string]struct{}
+		rejectKinds map[string]struct{}
+		expect      Status
+	}{
+		{deployment, nil, map[string]struct{}{}, map[string]struct{}{}, Valid},
+		{deployment, map[string]struct{}{"Deployment": {}, "Service": {}}, map[string]struct{}{}, map[string]struct{}{}, Valid},
+		{secret, map[string]struct{}{"Deployment": {}, "Service": {}}, map[string]struct{}{}, map[string]struct{}{}, Skipped},
+		{service, map[string]struct{}{"Deployment": {}, "Service": {}}, map[string]struct{}{"Service": {}}, map[string]struct{}{}, Skipped},
+		{secret, map[string]struct{}{"Deployment": {}}, map[string]struct{}{}, map[string]struct{}{"Secret": {}}, Skipped},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:   testCase.skipKinds,
+				RejectKinds: testCase.rejectKinds,
+				OnlyKinds:   testCase.onlyKinds,
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return []byte(`{"type": "object"}`), nil }),
+			},
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource}); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+}
+
+func TestValidateTolerateDownloadErrors(t *testing.T) {
+	for i, testCase := range []struct {
+		schemaErr              error
+		tolerateDownloadErrors bool
+		expect                 Status
+	}{
+		{fmt.Errorf("connection refused"), false, Error},
+		{fmt.Errorf("connection refused"), true, Skipped},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:              map[string]struct{}{},
+				RejectKinds:            map[string]struct{}{},
+				TolerateDownloadErrors: testCase.tolerateDownloadErrors,
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return nil, testCase.schemaErr }),
+			},
+		}
+		got := val.ValidateResource(resource.Resource{Bytes: []byte("kind: Deployment\napiVersion: apps/v1\n")})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if got.Status == Skipped && got.Err == nil {
+			t.Errorf("%d - expected the reason for skipping the resource", i)
+		}
+	}
+}
+
+func TestValidateSubresources(t *testing.T) {
+	for i, testCase := range []struct {
+		rawResource []byte
+		expect      Status
+	}{
+		{[]byte("kind: Scale\napiVersion: autoscaling/v1\n"), Skipped},
+		{[]byte("kind: Eviction\napiVersion: policy/v1\n"), Skipped},
+		{[]byte("kind: Scale\napiVersion: example.com/v1\n"), Error},
+		{[]byte("kind: Deployment\napiVersion: apps/v1\n"), Error},
+	} {
+		missing, _ := registry.New("/does-not-exist/{{ .ResourceKind }}.json", "", false, false)
+		val := v{
+			opts:           Opts{SkipKinds: map[string]struct{}{}, RejectKinds: map[string]struct{}{}},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs:           []registry.Registry{missing},
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: testCase.rawResource}); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+}
+
+func TestValidateAggregatedAPIs(t *testing.T) {
+	for i, testCase := range []struct {
+		aggregatedAPIs   []string
+		strictAggregated bool
+		rawResource      string
+		expect           Status
+	}{
+		{nil, false, "apiVersion: metrics.k8s.io/v1beta1\nkind: PodMetrics\n", Skipped},
+		{nil, true, "apiVersion: metrics.k8s.io/v1beta1\nkind: PodMetrics\n", Error},
+		{nil, false, "apiVersion: example.com/v1\nkind: Widget\n", Error},
+		{[]string{"example.com/v1/Widget"}, false, "apiVersion: example.com/v1\nkind: Widget\n", Skipped},
+		{[]string{"example.com/v1/Widget"}, false, "apiVersion: example.com/v1\nkind: Gadget\n", Error},
+		{[]string{"example.com/v1"}, false, "apiVersion: example.com/v1\nkind: Gadget\n", Skipped},
+		{[]string{"example.com/v1"}, false, "apiVersion: metrics.k8s.io/v1beta1\nkind: PodMetrics\n", Error},
+		{[]string{"default", "example.com/v1"}, false, "apiVersion: metrics.k8s.io/v1beta1\nkind: PodMetrics\n", Skipped},
+	} {
+		val, err := New([]string{"/does-not-exist/{{ .ResourceKind }}.json"}, Opts{AggregatedAPIs: testCase.aggregatedAPIs, StrictAggregated: testCase.strictAggregated})
+		if err != nil {
+			t.Fatalf("%d - failed creating validator: %s", i, err)
+		}
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if got.Status == Skipped && got.Err == nil {
+			t.Errorf("%d - expected a reason for skipping the resource", i)
+		}
+	}
+}
+
+func TestValidateAliases(t *testing.T) {
+	dir := t.TempDir()
+	schema := []byte(`{"type": "object", "required": ["spec"]}`)
+	if err := os.WriteFile(filepath.Join(dir, "widget-new-v1.json"), schema, 0644); err != nil {
+		t.Fatalf("failed writing schema: %s", err)
+	}
+	schemaLocation := filepath.Join(dir, "{{ .ResourceKind }}{{ .KindSuffix }}.json")
+
+	for i, testCase := range []struct {
+		aliases     map[string]string
+		rawResource string
+		expect      Status
+	}{
+		{nil, "apiVersion: old.example.com/v1\nkind: Widget\n", Error},
+		{map[string]string{"old.example.com/v1/Widget": "new.example.com/v1/Widget"}, "apiVersion: old.example.com/v1\nkind: Widget\n", Invalid},
+		{map[string]string{"old.example.com/v1/Widget": "new.example.com/v1/Widget"}, "apiVersion: old.example.com/v1\nkind: Widget\nspec: {}\n", Valid},
+		{map[string]string{"old.example.com/v1/Widget": "new.example.com/v1/Gadget"}, "apiVersion: old.example.com/v1\nkind: Widget\nspec: {}\n", Error},
+	} {
+		val, err := New([]string{schemaLocation}, Opts{Aliases: testCase.aliases})
+		if err != nil {
+			t.Fatalf("%d - failed creating validator: %s", i, err)
+		}
+		if got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)}); got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+	}
+
+	if _, err := New([]string{schemaLocation}, Opts{Aliases: map[string]string{"v1/Foo": "Bar"}}); err == nil {
+		t.Errorf("expected an error for an invalid alias")
+	}
+}
+
+func TestValidateKindShortnames(t *testing.T) {
+	for i, testCase := range []struct {
+		rawResource string
+		shortnames  map[string]string
+		expect      Status
+		expectKind  string
+	}{
+		{"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n", nil, Valid, "Deployment"},
+		{"apiVersion: apps/v1\nkind: deploy\nmetadata:\n  name: web\n", nil, Valid, "Deployment"},
+		{"apiVersion: apps/v1\nkind: deployments\nmetadata:\n  name: web\n", nil, Valid, "Deployment"},
+		{"apiVersion: apps/v1\nkind: dep\nmetadata:\n  name: web\n", nil, Invalid, "dep"},
+		{"apiVersion: apps/v1\nkind: dep\nmetadata:\n  name: web\n", map[string]string{"dep": "Deployment"}, Valid, "Deployment"},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:      map[string]struct{}{},
+				RejectKinds:    map[string]struct{}{},
+				KindShortnames: testCase.shortnames,
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) {
+					return []byte(`{"type": "object", "properties": {"kind": {"type": "string", "enum": ["Deployment"]}}}`), nil
+				}),
+			},
+		}
+		got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+		if got.Status != testCase.expect {
+			t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+		}
+		if sig, _ := got.Resource.Signature(); sig.Kind != testCase.expectKind {
+			t.Errorf("%d - expected the resource to be read as a %s, got %s", i, testCase.expectKind, sig.Kind)
+		}
+	}
+}
+
+func TestDownloadSchemaAuthoritativeRegistries(t *testing.T) {
+	queried := []string{}
+	mock := func(name, schema string) mockRegistry {
+		return mockRegistry{SchemaDownloader: func() ([]byte, error) {
+			queried = append(queried, name)
+			return []byte(schema), nil
+		}}
+	}
+	cluster := mockKindServer{mock("cluster", `{"type": "object", "required": ["spec"]}`), map[string]bool{"example.com/v1/Widget": true}}
+	registries := []registry.Registry{mock("static", `{"type": "object"}`), cluster}
+
+	for i, testCase := range []struct {
+		kind          string
+		expectFound   int
+		expectQueried []string
+	}{
+		{"Widget", 1, []string{"cluster"}},
+		{"Gadget", 0, []string{"static"}},
+	} {
+		queried = []string{}
+		found := -1
+		if _, err := newSchemaCompiler().downloadSchema(context.Background(), registries, testCase.kind, "example.com/v1", "master", func(i int, _ []byte, _ map[string][]byte) { found = i }); err != nil {
+			t.Fatalf("%d - %s", i, err)
+		}
+		if found != testCase.expectFound || !reflect.DeepEqual(queried, testCase.expectQueried) {
+			t.Errorf("%d - expected schema from registry %d after querying %v, got %d after %v", i, testCase.expectFound, testCase.expectQueried, found, queried)
+		}
+	}
+
+	queried = []string{}
+	if got := schemaLocationsFor(context.Background(), registries, []string{"static", "cluster"}, "Widget", "example.com/v1", "master"); !reflect.DeepEqual(got, []string{"cluster"}) || !reflect.DeepEqual(queried, []string{"cluster"}) {
+		t.Errorf("expected only the cluster to be queried for a kind it serves, got %v after querying %v", got, queried)
+	}
+}
+
+func TestValidateListMode(t *testing.T) {
+	list := "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: a\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: b\n"
+	for _, testCase := range []struct {
+		listMode string
+		expect   []Status
+	}{
+		{"", []Status{Valid, Valid}},
+		{resource.ListExpand, []Status{Valid, Valid}},
+		{resource.ListValidate, []Status{Valid}},
+		{resource.ListSkip, []Status{Skipped}},
+	} {
+		val := v{
+			opts: Opts{
+				SkipKinds:   map[string]struct{}{},
+				RejectKinds: map[string]struct{}{},
+				ListMode:    testCase.listMode,
+			},
+			schemaDownload: newSchemaCompiler().downloadSchema,
+			regs: []registry.Registry{
+				newMockRegistry(func() ([]byte, error) { return []byte(`{"type": "object"}`), nil }),
+			},
+		}
+
+		got := []Status{}
+		for _, res := range val.Validate("lists.yaml", io.NopCloser(strings.NewReader(list))) {
+			if res.Status != Empty {
+				got = append(got, res.Status)
+			}
+		}
+		if !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("list mode %q - expected %v, got %v", testCase.listMode, testCase.expect, got)
+		}
+	}
+}
+
+func TestValidateStreamErrors(t *testing.T) {
+	duplicates := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+`
+	for i, testCase := range []struct {
+		name string
+		r    io.Reader
+	}{
+		{"duplicate resource", strings.NewReader(duplicates)},
+		{"read error", iotest.ErrReader(fmt.Errorf("connection reset"))},
+	} {
+		val, err := New(nil, Opts{SyntaxOnly: true})
+		if err != nil {
+			t.Fatalf("test %d: failed initialising validator: %s", i+1, err)
+		}
+
+		done := make(chan []Result)
+		go func() { done <- val.Validate("f.yaml", io.NopCloser(testCase.r)) }()
+		select {
+		case results := <-done:
+			nErrors := 0
+			for _, res := range results {
+				if res.Status == Error {
+					nErrors++
+					if res.Resource.Path != "f.yaml" {
+						t.Errorf("test %d - %s: expected the error for f.yaml, got %s", i+1, testCase.name, res.Resource.Path)
+					}
+				}
+			}
+			if nErrors != 1 {
+				t.Errorf("test %d - %s: expected 1 error, got %d: %+v", i+1, testCase.name, nErrors, results)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("test %d - %s: validation did not complete", i+1, testCase.name)
+		}
+	}
+}
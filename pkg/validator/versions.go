@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategies to find a schema when there is none for the Kubernetes version validated against
+const (
+	VersionResolutionExact   = "exact"   // only look up schemas of the Kubernetes version
+	VersionResolutionNearest = "nearest" // also look up schemas of the minor, then major version, e.g. 1.28 then 1 for 1.28.3
+)
+
+// kubernetesVersions returns the Kubernetes versions schemas are looked up for, in order, with the
+// version resolution strategy: the Kubernetes version, followed with nearest by its minor and major
+// versions, then by the fallback version if set
+func kubernetesVersions(k8sVersion, resolution, fallback string) ([]string, error) {
+	switch resolution {
+	case "", VersionResolutionExact:
+		return []string{k8sVersion}, nil
+	case VersionResolutionNearest:
+	default:
+		return nil, fmt.Errorf("invalid version resolution %s, must be %s or %s", resolution, VersionResolutionExact, VersionResolutionNearest)
+	}
+
+	versions := []string{k8sVersion}
+	for v := k8sVersion; strings.Contains(v, "."); {
+		v = v[:strings.LastIndex(v, ".")]
+		versions = append(versions, v)
+	}
+	if fallback != "" && fallback != versions[len(versions)-1] {
+		versions = append(versions, fallback)
+	}
+
+	return versions, nil
+}
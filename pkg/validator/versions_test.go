@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestKubernetesVersions(t *testing.T) {
+	for _, testCase := range []struct {
+		k8sVersion, resolution, fallback string
+		expect                           []string
+		expectErr                        bool
+	}{
+		{"1.28.3", "", "", []string{"1.28.3"}, false},
+		{"1.28.3", "exact", "", []string{"1.28.3"}, false},
+		{"1.28.3", "nearest", "", []string{"1.28.3", "1.28", "1"}, false},
+		{"1.28.3", "nearest", "master", []string{"1.28.3", "1.28", "1", "master"}, false},
+		{"v1.28", "nearest", "", []string{"v1.28", "v1"}, false},
+		{"master", "nearest", "master", []string{"master"}, false},
+		{"1.28.3", "closest", "", nil, true},
+	} {
+		got, err := kubernetesVersions(testCase.k8sVersion, testCase.resolution, testCase.fallback)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("%s, %s - expected error %t, got %v", testCase.k8sVersion, testCase.resolution, testCase.expectErr, err)
+		}
+		if !reflect.DeepEqual(got, testCase.expect) {
+			t.Errorf("%s, %s - expected %v, got %v", testCase.k8sVersion, testCase.resolution, testCase.expect, got)
+		}
+	}
+}
+
+func TestValidateVersionResolution(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"v1.28", "master"} {
+		os.MkdirAll(filepath.Join(dir, version), 0755)
+	}
+	os.WriteFile(filepath.Join(dir, "v1.28", "configmap.json"), []byte(`{"type": "object", "required": ["data"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "master", "secret.json"), []byte(`{"type": "object", "required": ["data"]}`), 0644)
+	location := filepath.Join(dir, "{{ .NormalizedKubernetesVersion }}", "{{ .ResourceKind }}.json")
+
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"
+	secret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: a\n"
+	for i, testCase := range []struct {
+		rawResource          string
+		resolution, fallback string
+		expect               Status
+		expectVersion        string
+	}{
+		{configMap, "exact", "", Skipped, ""},
+		{configMap, "nearest", "", Invalid, "1.28"},
+		{secret, "nearest", "", Skipped, ""},
+		{secret, "nearest", "master", Invalid, "master"},
+	} {
+		val, err := New([]string{location}, Opts{KubernetesVersion: "1.28.3", VersionResolution: testCase.resolution, FallbackVersion: testCase.fallback, IgnoreMissingSchemas: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The second time, the schema and the version it was found for come from the memory cache
+		for j := 0; j < 2; j++ {
+			got := val.ValidateResource(resource.Resource{Bytes: []byte(testCase.rawResource)})
+			if got.Status != testCase.expect {
+				t.Errorf("%d - expected %d, got %d: %s", i, testCase.expect, got.Status, got.Err)
+			}
+			if got.KubernetesVersion != testCase.expectVersion {
+				t.Errorf("%d - expected the schema to be found for Kubernetes version %q, got %q", i, testCase.expectVersion, got.KubernetesVersion)
+			}
+		}
+	}
+
+	if _, err := New([]string{location}, Opts{VersionResolution: "closest"}); err == nil {
+		t.Errorf("expected an error for an unknown version resolution")
+	}
+}
@@ -0,0 +1,42 @@
+package validator
+
+import (
+	"fmt"
+	"log"
+)
+
+// WarningCode categorises warnings
+type WarningCode string
+
+const (
+	DownloadErrorTolerated WarningCode = "download-error-tolerated" // a resource was skipped as its schema could not be downloaded, with TolerateDownloadErrors
+	SchemaOverridden       WarningCode = "schema-overridden"        // the schema of a kind is served by several schema locations, with WarnSchemaOverrides
+	ErrorIgnored           WarningCode = "error-ignored"            // a validation error matched one of IgnoreErrorPatterns, and was not reported
+	DeprecatedField        WarningCode = "deprecated-field"         // a field set in a resource is marked as deprecated by its schema, with WarnDeprecatedFields
+)
+
+// Warning is a problem found during validation that is not reported in a Result, as it does not
+// make a resource fail validation
+type Warning struct {
+	Code    WarningCode
+	Path    string // file of the resource the warning is about, empty for warnings about a kind
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Path == "" {
+		return w.Message
+	}
+	return w.Path + " - " + w.Message
+}
+
+// warnf sends a warning to the Warnings function of the options, or logs it if not set
+func (val *v) warnf(code WarningCode, path string, format string, a ...interface{}) {
+	w := Warning{Code: code, Path: path, Message: fmt.Sprintf(format, a...)}
+	if val.opts.Warnings != nil {
+		val.opts.Warnings(w)
+		return
+	}
+
+	log.Printf("warning: %s", w)
+}
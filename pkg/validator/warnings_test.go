@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/yannh/kubeconform/pkg/registry"
+	"github.com/yannh/kubeconform/pkg/resource"
+)
+
+func TestWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var mu sync.Mutex
+	warnings := []Warning{}
+	sink := func(w Warning) {
+		mu.Lock()
+		defer mu.Unlock()
+		warnings = append(warnings, w)
+	}
+
+	schema := []byte(`{"type": "object", "properties": {"spec": {"type": "object"}}}`)
+	val := v{
+		opts: Opts{
+			SkipKinds:              map[string]struct{}{},
+			RejectKinds:            map[string]struct{}{},
+			TolerateDownloadErrors: true,
+			Warnings:               sink,
+		},
+		schemaDownload: newSchemaCompiler().downloadSchema,
+		regs: []registry.Registry{newMockRegistry(func() ([]byte, error) {
+			return schema, nil
+		})},
+		ignoreErrorPatterns: []*regexp.Regexp{regexp.MustCompile("spec")},
+	}
+	if got := val.ValidateResource(resource.Resource{Path: "a.yaml", Bytes: []byte("kind: Widget\napiVersion: v1\nspec: 1\n")}); got.Status != Valid {
+		t.Errorf("expected resource to be valid, got %d: %s", got.Status, got.Err)
+	}
+
+	val.regs = []registry.Registry{newMockRegistry(func() ([]byte, error) {
+		return nil, fmt.Errorf("connection refused")
+	})}
+	val.ignoreErrorPatterns = nil
+	if got := val.ValidateResource(resource.Resource{Path: "b.yaml", Bytes: []byte("kind: Gadget\napiVersion: v1\nmetadata:\n  name: g\n")}); got.Status != Skipped {
+		t.Errorf("expected resource to be skipped, got %d: %s", got.Status, got.Err)
+	}
+
+	expect := []Warning{
+		{Code: ErrorIgnored, Path: "a.yaml", Message: "ignoring error matching spec: For field spec: Invalid type. Expected: object, given: integer"},
+		{Code: DownloadErrorTolerated, Path: "b.yaml", Message: "g Gadget skipped: connection refused"},
+	}
+	if !reflect.DeepEqual(warnings, expect) {
+		t.Errorf("expected warnings %+v, got %+v", expect, warnings)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected warnings not to be logged, got %s", buf.String())
+	}
+}